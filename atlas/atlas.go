@@ -0,0 +1,196 @@
+package atlas
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/purelazy/GopenGL/texture"
+)
+
+// Options control how Build lays out an atlas.
+type Options struct {
+	// Padding is the number of transparent pixels between images.
+	Padding int
+
+	// Extrude repeats each image's edge pixels this many times around it,
+	// so that bilinear filtering at the edge samples the image itself rather
+	// than its neighbour or the padding.
+	Extrude int
+
+	// MaxSize is the largest width or height Build may use. 0 means 4096.
+	MaxSize int
+}
+
+// Region is where an image was placed in an atlas.
+type Region struct {
+	Name string
+	// Rect holds the image's own pixels, without padding or extrusion.
+	Rect image.Rectangle
+	// UV holds the texture coordinates of Rect as u0, v0, u1, v1, with
+	// (u0, v0) at the image's top-left corner, for an atlas uploaded without
+	// FlipY.
+	UV [4]float32
+}
+
+// Size returns the width and height of the image in pixels.
+func (r Region) Size() (width, height int) {
+	return r.Rect.Dx(), r.Rect.Dy()
+}
+
+// Atlas is a set of images packed into one.
+type Atlas struct {
+	Image   *image.NRGBA
+	Regions map[string]Region
+}
+
+// Build packs images into the smallest power of two sized atlas, starting
+// from a square and doubling the shorter side, that they fit in.
+func Build(images map[string]image.Image, opts Options) (*Atlas, error) {
+	maxSize := opts.MaxSize
+	if maxSize == 0 {
+		maxSize = 4096
+	}
+	border := 2*opts.Extrude + opts.Padding
+
+	// Tall images first packs tightest. Sorting by name as well keeps the
+	// layout the same from run to run.
+	names := make([]string, 0, len(images))
+	area, widest, tallest := 0, 0, 0
+	for name, img := range images {
+		b := img.Bounds()
+		if b.Empty() {
+			return nil, fmt.Errorf("atlas: image %q is empty", name)
+		}
+		names = append(names, name)
+		w, h := b.Dx()+border, b.Dy()+border
+		area += w * h
+		if w > widest {
+			widest = w
+		}
+		if h > tallest {
+			tallest = h
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := images[names[i]].Bounds(), images[names[j]].Bounds()
+		if a.Dy() != b.Dy() {
+			return a.Dy() > b.Dy()
+		}
+		if a.Dx() != b.Dx() {
+			return a.Dx() > b.Dx()
+		}
+		return names[i] < names[j]
+	})
+
+	side := nextPowerOfTwo(int(math.Ceil(math.Sqrt(float64(area)))))
+	width, height := side, side
+	for width < widest {
+		width *= 2
+	}
+	for height < tallest {
+		height *= 2
+	}
+
+	for {
+		if width > maxSize || height > maxSize {
+			return nil, fmt.Errorf("atlas: %d images do not fit in %dx%d", len(images), maxSize, maxSize)
+		}
+		if places, ok := pack(names, images, width, height, border); ok {
+			return paint(names, images, places, width, height, opts.Extrude), nil
+		}
+		if width <= height {
+			width *= 2
+		} else {
+			height *= 2
+		}
+	}
+}
+
+// pack places every image, with its border, in a width x height bin.
+func pack(names []string, images map[string]image.Image, width, height, border int) ([]image.Point, bool) {
+	p := NewPacker(width, height)
+	places := make([]image.Point, len(names))
+	for i, name := range names {
+		b := images[name].Bounds()
+		x, y, ok := p.Insert(b.Dx()+border, b.Dy()+border)
+		if !ok {
+			return nil, false
+		}
+		places[i] = image.Point{x, y}
+	}
+	return places, true
+}
+
+// paint copies each image to its place, after extrude pixels of its border,
+// and repeats its edges over them.
+func paint(names []string, images map[string]image.Image, places []image.Point, width, height, extrude int) *Atlas {
+	a := &Atlas{
+		Image:   image.NewNRGBA(image.Rect(0, 0, width, height)),
+		Regions: make(map[string]Region, len(names)),
+	}
+
+	for i, name := range names {
+		src := images[name]
+		b := src.Bounds()
+		origin := places[i].Add(image.Point{extrude, extrude})
+		rect := image.Rectangle{origin, origin.Add(b.Size())}
+		draw.Draw(a.Image, rect, src, b.Min, draw.Src)
+		a.extrude(rect, extrude)
+
+		a.Regions[name] = Region{
+			Name: name,
+			Rect: rect,
+			UV: [4]float32{
+				float32(rect.Min.X) / float32(width),
+				float32(rect.Min.Y) / float32(height),
+				float32(rect.Max.X) / float32(width),
+				float32(rect.Max.Y) / float32(height),
+			},
+		}
+	}
+	return a
+}
+
+// extrude copies the edge pixels of rect outwards n times, corners
+// included.
+func (a *Atlas) extrude(rect image.Rectangle, n int) {
+	if n == 0 {
+		return
+	}
+	clamp := func(v, lo, hi int) int {
+		if v < lo {
+			return lo
+		}
+		if v >= hi {
+			return hi - 1
+		}
+		return v
+	}
+	outer := rect.Inset(-n)
+	for y := outer.Min.Y; y < outer.Max.Y; y++ {
+		for x := outer.Min.X; x < outer.Max.X; x++ {
+			if image.Pt(x, y).In(rect) {
+				continue
+			}
+			sx, sy := clamp(x, rect.Min.X, rect.Max.X), clamp(y, rect.Min.Y, rect.Max.Y)
+			a.Image.SetNRGBA(x, y, a.Image.NRGBAAt(sx, sy))
+		}
+	}
+}
+
+// Upload creates a texture from the atlas image. Leave opts.FlipY unset so
+// that the regions' UVs hold.
+func (a *Atlas) Upload(opts texture.Options) (*texture.Texture2D, error) {
+	return texture.NewTexture2D(a.Image, opts)
+}
+
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
@@ -0,0 +1,141 @@
+package atlas
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// testImages returns n images of random sizes, each filled with its own
+// opaque colour.
+func testImages(n int) map[string]image.Image {
+	r := rand.New(rand.NewSource(1))
+	images := make(map[string]image.Image, n)
+	for i := 0; i < n; i++ {
+		img := image.NewNRGBA(image.Rect(0, 0, 1+r.Intn(30), 1+r.Intn(30)))
+		c := color.NRGBA{uint8(i), uint8(i * 7), uint8(i * 13), 255}
+		for y := 0; y < img.Rect.Dy(); y++ {
+			for x := 0; x < img.Rect.Dx(); x++ {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+		images[fmt.Sprint("image", i)] = img
+	}
+	return images
+}
+
+func TestBuild(t *testing.T) {
+	images := testImages(40)
+	for _, opts := range []Options{{}, {Padding: 2}, {Extrude: 1}, {Padding: 1, Extrude: 2}} {
+		a, err := Build(images, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bounds := a.Image.Bounds()
+		if len(a.Regions) != len(images) {
+			t.Fatalf("%+v: %d regions for %d images", opts, len(a.Regions), len(images))
+		}
+
+		// Each region's cell is its image, the extrusion around it and the
+		// padding after it. Cells stay in the atlas and apart.
+		cells := make(map[string]image.Rectangle)
+		for name, r := range a.Regions {
+			src := images[name]
+			if r.Name != name || r.Rect.Size() != src.Bounds().Size() {
+				t.Fatalf("%+v: region %q is %v for a %v image", opts, r.Name, r.Rect, src.Bounds())
+			}
+			cell := r.Rect.Inset(-opts.Extrude)
+			cell.Max = cell.Max.Add(image.Pt(opts.Padding, opts.Padding))
+			if !cell.In(bounds) {
+				t.Errorf("%+v: %s at %v is outside the %v atlas", opts, name, cell, bounds)
+			}
+			for other, c := range cells {
+				if cell.Overlaps(c) {
+					t.Errorf("%+v: %s at %v overlaps %s at %v", opts, name, cell, other, c)
+				}
+			}
+			cells[name] = cell
+
+			want := [4]float32{
+				float32(r.Rect.Min.X) / float32(bounds.Dx()),
+				float32(r.Rect.Min.Y) / float32(bounds.Dy()),
+				float32(r.Rect.Max.X) / float32(bounds.Dx()),
+				float32(r.Rect.Max.Y) / float32(bounds.Dy()),
+			}
+			if r.UV != want {
+				t.Errorf("%+v: %s has UV %v, want %v", opts, name, r.UV, want)
+			}
+		}
+
+		// The image and its extrusion are its colour, and the padding and
+		// everything else is transparent.
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				want := color.NRGBA{}
+				for name, r := range a.Regions {
+					if image.Pt(x, y).In(r.Rect.Inset(-opts.Extrude)) {
+						want = color.NRGBAModel.Convert(images[name].At(0, 0)).(color.NRGBA)
+					}
+				}
+				if got := a.Image.NRGBAAt(x, y); got != want {
+					t.Fatalf("%+v: pixel (%d, %d) is %v, want %v", opts, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestBuildPowerOfTwo(t *testing.T) {
+	a, err := Build(testImages(40), Options{Padding: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, h := a.Image.Bounds().Dx(), a.Image.Bounds().Dy()
+	if w&(w-1) != 0 || h&(h-1) != 0 {
+		t.Errorf("atlas is %dx%d, want powers of two", w, h)
+	}
+
+	one, err := Build(map[string]image.Image{"one": image.NewNRGBA(image.Rect(0, 0, 5, 3))}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := one.Image.Bounds(); b.Dx() != 8 || b.Dy() != 4 {
+		t.Errorf("a 5x3 image packs into %v, want 8x4", b)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		images map[string]image.Image
+		opts   Options
+		err    string
+	}{
+		{
+			"too many",
+			testImages(40),
+			Options{MaxSize: 32},
+			"atlas: 40 images do not fit in 32x32",
+		},
+		{
+			"too wide with padding",
+			map[string]image.Image{"wide": image.NewNRGBA(image.Rect(0, 0, 64, 1))},
+			Options{Padding: 1, MaxSize: 64},
+			"atlas: 1 images do not fit in 64x64",
+		},
+		{
+			"empty",
+			map[string]image.Image{"empty": image.NewNRGBA(image.Rect(0, 0, 0, 4))},
+			Options{},
+			`atlas: image "empty" is empty`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := Build(tt.images, tt.opts)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package atlas packs many small images into one texture, so that they can
+// all be drawn without switching textures.
+//
+// Packer places rectangles in a fixed size bin with the skyline bottom-left
+// heuristic. Build uses it to lay out named images, with padding between
+// them and their edges extruded so that filtering and mipmapping do not
+// bleed one image into the next, and records where each one went.
+package atlas
+
+// Packer places rectangles in a Width x Height bin, with y increasing
+// downwards as in an image. It keeps the skyline: the lower edge of
+// everything placed so far, as a list of horizontal segments from left to
+// right.
+type Packer struct {
+	Width, Height int
+
+	skyline []segment
+}
+
+type segment struct {
+	x, y, width int
+}
+
+// NewPacker returns an empty width x height bin.
+func NewPacker(width, height int) *Packer {
+	return &Packer{
+		Width:   width,
+		Height:  height,
+		skyline: []segment{{0, 0, width}},
+	}
+}
+
+// Insert finds room for a width x height rectangle and returns its top-left
+// corner. Of the places it could go, the one whose lower edge has the least
+// y is taken, then the one on the narrowest segment. ok is false if it does
+// not fit.
+func (p *Packer) Insert(width, height int) (x, y int, ok bool) {
+	if width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+
+	best, bestBottom, bestWidth := -1, 0, 0
+	for i := range p.skyline {
+		top, fits := p.fit(i, width, height)
+		if !fits {
+			continue
+		}
+		bottom := top + height
+		if best < 0 || bottom < bestBottom || (bottom == bestBottom && p.skyline[i].width < bestWidth) {
+			best, bestBottom, bestWidth = i, bottom, p.skyline[i].width
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+
+	x, y = p.skyline[best].x, bestBottom-height
+	p.add(best, x, bestBottom, width)
+	return x, y, true
+}
+
+// fit returns the y at which a rectangle starting at segment i would rest:
+// the greatest y of the segments it spans.
+func (p *Packer) fit(i, width, height int) (y int, ok bool) {
+	x := p.skyline[i].x
+	if x+width > p.Width {
+		return 0, false
+	}
+	for left := width; left > 0; i++ {
+		if p.skyline[i].y > y {
+			y = p.skyline[i].y
+		}
+		if y+height > p.Height {
+			return 0, false
+		}
+		left -= p.skyline[i].width
+	}
+	return y, true
+}
+
+// add moves the skyline down to y across width from x, starting at
+// segment i.
+func (p *Packer) add(i, x, y, width int) {
+	p.skyline = append(p.skyline, segment{})
+	copy(p.skyline[i+1:], p.skyline[i:])
+	p.skyline[i] = segment{x, y, width}
+
+	// Trim or drop the segments the new one covers.
+	for j := i + 1; j < len(p.skyline); {
+		s := &p.skyline[j]
+		end := x + width
+		if s.x >= end {
+			break
+		}
+		shrink := end - s.x
+		if shrink < s.width {
+			s.x += shrink
+			s.width -= shrink
+			break
+		}
+		p.skyline = append(p.skyline[:j], p.skyline[j+1:]...)
+	}
+
+	// Merge neighbours at the same height.
+	for j := 0; j < len(p.skyline)-1; {
+		if p.skyline[j].y == p.skyline[j+1].y {
+			p.skyline[j].width += p.skyline[j+1].width
+			p.skyline = append(p.skyline[:j+1], p.skyline[j+2:]...)
+		} else {
+			j++
+		}
+	}
+}
+
+// Occupancy returns the fraction of the bin under the skyline, which is an
+// upper bound on how much of it is used.
+func (p *Packer) Occupancy() float32 {
+	area := 0
+	for _, s := range p.skyline {
+		area += s.y * s.width
+	}
+	return float32(area) / float32(p.Width*p.Height)
+}
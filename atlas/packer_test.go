@@ -0,0 +1,66 @@
+package atlas
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestPacker(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	p := NewPacker(256, 256)
+	bin := image.Rect(0, 0, p.Width, p.Height)
+	var placed []image.Rectangle
+	for i := 0; i < 500; i++ {
+		w, h := 1+r.Intn(40), 1+r.Intn(40)
+		x, y, ok := p.Insert(w, h)
+		if !ok {
+			continue
+		}
+		rect := image.Rect(x, y, x+w, y+h)
+		if !rect.In(bin) {
+			t.Fatalf("%v is outside the %v bin", rect, bin)
+		}
+		for _, q := range placed {
+			if rect.Overlaps(q) {
+				t.Fatalf("%v overlaps %v", rect, q)
+			}
+		}
+		placed = append(placed, rect)
+	}
+	if len(placed) < 50 {
+		t.Errorf("placed only %d rectangles", len(placed))
+	}
+
+	area := 0
+	for _, q := range placed {
+		area += q.Dx() * q.Dy()
+	}
+	if used := float32(area) / float32(p.Width*p.Height); p.Occupancy() < used {
+		t.Errorf("occupancy %v is less than the %v used", p.Occupancy(), used)
+	}
+}
+
+func TestPackerFull(t *testing.T) {
+	p := NewPacker(64, 32)
+	tests := []struct {
+		name          string
+		width, height int
+		ok            bool
+	}{
+		{"too wide", 65, 1, false},
+		{"too tall", 1, 33, false},
+		{"empty", 0, 10, false},
+		{"left half", 32, 32, true},
+		{"right half", 32, 32, true},
+		{"when full", 1, 1, false},
+	}
+	for _, tt := range tests {
+		if _, _, ok := p.Insert(tt.width, tt.height); ok != tt.ok {
+			t.Errorf("%s: Insert(%d, %d) gave ok %v", tt.name, tt.width, tt.height, ok)
+		}
+	}
+	if p.Occupancy() != 1 {
+		t.Errorf("full bin has occupancy %v", p.Occupancy())
+	}
+}
@@ -0,0 +1,163 @@
+// Package camera builds view and projection matrices and answers the
+// questions that come with them: what is inside the view (Frustum), where a
+// point lands on screen (Project) and which world-space ray sits under a pixel
+// (RayFromPixel).
+//
+// Everything here is plain math on mgl32 types, with no GL calls;
+// glstate.ApplyDepth sets up the depth test to match the projection.
+package camera
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Kind selects the type of projection a Camera uses.
+type Kind int
+
+const (
+	// PerspectiveKind is a symmetric perspective projection set by FovY and
+	// Aspect.
+	PerspectiveKind Kind = iota
+	// OrthographicKind is a parallel projection through Left, Right, Bottom,
+	// Top.
+	OrthographicKind
+	// OffAxisKind is an asymmetric perspective projection through Left,
+	// Right, Bottom, Top on the near plane.
+	OffAxisKind
+)
+
+// Camera describes a lens (the projection) and where it is placed (the view).
+type Camera struct {
+	// Eye is where the camera is positioned.
+	Eye mgl32.Vec3
+	// Target is the point at which the camera is looking.
+	Target mgl32.Vec3
+	// Up is the direction that is up, usually +Y.
+	Up mgl32.Vec3
+
+	Kind Kind
+
+	// FovY is the field of view along the Y axis, in radians (Perspective).
+	FovY float32
+	// Aspect is width / height of the viewport (Perspective).
+	Aspect float32
+
+	// Left, Right, Bottom and Top bound the view volume (Orthographic) or the
+	// window on the near plane (OffAxis).
+	Left, Right, Bottom, Top float32
+
+	// Near and Far are the clipping distances. Far may be Infinite for the
+	// perspective kinds.
+	Near, Far float32
+
+	// ReverseZ maps the near plane to depth 1 and the far plane to depth 0.
+	// See glstate.ApplyDepth.
+	ReverseZ bool
+}
+
+// NewPerspective returns a perspective camera at (0, 0, 1) looking at the
+// origin.
+func NewPerspective(fovy, aspect, near, far float32) *Camera {
+	return &Camera{
+		Eye:    mgl32.Vec3{0, 0, 1},
+		Up:     mgl32.Vec3{0, 1, 0},
+		Kind:   PerspectiveKind,
+		FovY:   fovy,
+		Aspect: aspect,
+		Near:   near,
+		Far:    far,
+	}
+}
+
+// NewOrthographic returns an orthographic camera at (0, 0, 1) looking at the
+// origin.
+func NewOrthographic(left, right, bottom, top, near, far float32) *Camera {
+	return &Camera{
+		Eye:    mgl32.Vec3{0, 0, 1},
+		Up:     mgl32.Vec3{0, 1, 0},
+		Kind:   OrthographicKind,
+		Left:   left,
+		Right:  right,
+		Bottom: bottom,
+		Top:    top,
+		Near:   near,
+		Far:    far,
+	}
+}
+
+// NewOffAxis returns an off-axis perspective camera at (0, 0, 1) looking at
+// the origin.
+func NewOffAxis(left, right, bottom, top, near, far float32) *Camera {
+	c := NewOrthographic(left, right, bottom, top, near, far)
+	c.Kind = OffAxisKind
+
+	return c
+}
+
+// LookAt positions the camera, as mgl32.LookAtV does.
+func (c *Camera) LookAt(eye, target, up mgl32.Vec3) {
+	c.Eye, c.Target, c.Up = eye, target, up
+}
+
+// SetViewport updates the aspect ratio after the window has been resized.
+// Orthographic and off-axis cameras keep their height and have their width
+// rescaled about the centre.
+func (c *Camera) SetViewport(width, height int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	aspect := float32(width) / float32(height)
+
+	if c.Kind != PerspectiveKind {
+		cx := (c.Left + c.Right) / 2
+		halfWidth := (c.Top - c.Bottom) / 2 * aspect
+		c.Left, c.Right = cx-halfWidth, cx+halfWidth
+	}
+	c.Aspect = aspect
+}
+
+// View returns the view (a.k.a. camera) matrix.
+func (c *Camera) View() mgl32.Mat4 {
+	return mgl32.LookAtV(c.Eye, c.Target, c.Up)
+}
+
+// Projection returns the projection matrix for the camera's Kind.
+func (c *Camera) Projection() mgl32.Mat4 {
+	switch c.Kind {
+	case OrthographicKind:
+		if c.ReverseZ {
+			return OrthographicReverseZ(c.Left, c.Right, c.Bottom, c.Top, c.Near, c.Far)
+		}
+		return Orthographic(c.Left, c.Right, c.Bottom, c.Top, c.Near, c.Far)
+	case OffAxisKind:
+		if c.ReverseZ {
+			return OffAxisReverseZ(c.Left, c.Right, c.Bottom, c.Top, c.Near, c.Far)
+		}
+		return OffAxis(c.Left, c.Right, c.Bottom, c.Top, c.Near, c.Far)
+	default:
+		if c.ReverseZ {
+			return PerspectiveReverseZ(c.FovY, c.Aspect, c.Near, c.Far)
+		}
+		return Perspective(c.FovY, c.Aspect, c.Near, c.Far)
+	}
+}
+
+// ViewProjection returns Projection * View.
+func (c *Camera) ViewProjection() mgl32.Mat4 {
+	return c.Projection().Mul4(c.View())
+}
+
+// Forward returns the unit vector from Eye towards Target.
+func (c *Camera) Forward() mgl32.Vec3 {
+	return c.Target.Sub(c.Eye).Normalize()
+}
+
+// depthRange returns the normalized device depth of the near plane and of a
+// point part way into the scene. The second value is never the far plane, so
+// it stays finite when Far is Infinite.
+func (c *Camera) depthRange() (near, inside float32) {
+	if c.ReverseZ {
+		return 1, 0.5
+	}
+	return -1, 0
+}
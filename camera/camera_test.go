@@ -0,0 +1,213 @@
+package camera
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const epsilon = 1e-4
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= epsilon*math.Max(1, math.Abs(float64(b)))
+}
+
+// depth returns the normalized device depth of the eye-space point at
+// distance d in front of the camera.
+func depth(m mgl32.Mat4, d float32) float32 {
+	clip := m.Mul4x1(mgl32.Vec4{0, 0, -d, 1})
+	return clip[2] / clip[3]
+}
+
+func TestDepthMapping(t *testing.T) {
+	fovy := mgl32.DegToRad(60)
+	tests := []struct {
+		name              string
+		m                 mgl32.Mat4
+		near, far         float32
+		nearZ, farZ       float32
+		infinite, reverse bool
+	}{
+		{"Perspective", Perspective(fovy, 1.5, 0.1, 100), 0.1, 100, -1, 1, false, false},
+		{"PerspectiveReverseZ", PerspectiveReverseZ(fovy, 1.5, 0.1, 100), 0.1, 100, 1, 0, false, true},
+		{"PerspectiveInfinite", Perspective(fovy, 1.5, 0.1, Infinite), 0.1, Infinite, -1, 1, true, false},
+		{"PerspectiveReverseZInfinite", PerspectiveReverseZ(fovy, 1.5, 0.1, Infinite), 0.1, Infinite, 1, 0, true, true},
+		{"OffAxis", OffAxis(-1, 2, -1, 1, 0.5, 50), 0.5, 50, -1, 1, false, false},
+		{"OffAxisReverseZ", OffAxisReverseZ(-1, 2, -1, 1, 0.5, 50), 0.5, 50, 1, 0, false, true},
+		{"OffAxisInfinite", OffAxis(-1, 2, -1, 1, 0.5, Infinite), 0.5, Infinite, -1, 1, true, false},
+		{"Orthographic", Orthographic(-2, 2, -1, 1, 1, 20), 1, 20, -1, 1, false, false},
+		{"OrthographicReverseZ", OrthographicReverseZ(-2, 2, -1, 1, 1, 20), 1, 20, 1, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if z := depth(tt.m, tt.near); !near(z, tt.nearZ) {
+				t.Errorf("depth at near = %v, want %v", z, tt.nearZ)
+			}
+			if tt.infinite {
+				// The far plane is never reached, only approached.
+				z := depth(tt.m, 1e6)
+				if !near(z, tt.farZ) || z == tt.farZ {
+					t.Errorf("depth far away = %v, want just short of %v", z, tt.farZ)
+				}
+			} else if z := depth(tt.m, tt.far); !near(z, tt.farZ) {
+				t.Errorf("depth at far = %v, want %v", z, tt.farZ)
+			}
+
+			// Depth moves monotonically from near to far.
+			prev := depth(tt.m, tt.near)
+			for d := tt.near * 1.5; d < 50 && d < tt.far; d *= 1.5 {
+				z := depth(tt.m, d)
+				if tt.reverse && z >= prev || !tt.reverse && z <= prev {
+					t.Fatalf("depth %v at %v does not move on from %v", z, d, prev)
+				}
+				prev = z
+			}
+		})
+	}
+}
+
+func TestOrthographicInfinite(t *testing.T) {
+	for _, m := range []mgl32.Mat4{
+		Orthographic(-1, 1, -1, 1, 0.1, Infinite),
+		OrthographicReverseZ(-1, 1, -1, 1, 0.1, Infinite),
+	} {
+		for i, v := range m {
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+				t.Fatalf("element %d is %v", i, v)
+			}
+		}
+	}
+}
+
+func TestFrustum(t *testing.T) {
+	type point struct {
+		p      mgl32.Vec3
+		inside bool
+	}
+	// Every camera sits at (0, 0, 5) looking down -Z at the origin.
+	common := []point{
+		{mgl32.Vec3{0, 0, 0}, true},
+		{mgl32.Vec3{0, 0, 6}, false},    // behind the eye
+		{mgl32.Vec3{0, 0, 4.95}, false}, // between the eye and the near plane
+		{mgl32.Vec3{50, 0, 0}, false},   // off to the right
+		{mgl32.Vec3{-50, 0, 0}, false},  // off to the left
+		{mgl32.Vec3{0, 50, 0}, false},   // above
+		{mgl32.Vec3{0, -50, 0}, false},  // below
+	}
+	tests := []struct {
+		name   string
+		camera *Camera
+		reverse,
+		infinite bool
+	}{
+		{"perspective", NewPerspective(mgl32.DegToRad(60), 1, 0.1, 20), false, false},
+		{"perspective reverse-Z", NewPerspective(mgl32.DegToRad(60), 1, 0.1, 20), true, false},
+		{"perspective infinite", NewPerspective(mgl32.DegToRad(60), 1, 0.1, Infinite), false, true},
+		{"perspective reverse-Z infinite", NewPerspective(mgl32.DegToRad(60), 1, 0.1, Infinite), true, true},
+		{"orthographic", NewOrthographic(-3, 3, -3, 3, 0.1, 20), false, false},
+		{"orthographic reverse-Z", NewOrthographic(-3, 3, -3, 3, 0.1, 20), true, false},
+		{"off-axis", NewOffAxis(-0.05, 0.05, -0.05, 0.05, 0.1, 20), false, false},
+		{"off-axis reverse-Z", NewOffAxis(-0.05, 0.05, -0.05, 0.05, 0.1, 20), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.camera
+			c.LookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+			c.ReverseZ = tt.reverse
+			f := c.Frustum()
+
+			points := append([]point{
+				{mgl32.Vec3{0, 0, -14}, true}, // just short of the far plane
+				// Past the far plane, unless there is none.
+				{mgl32.Vec3{0, 0, -16}, tt.infinite},
+				{mgl32.Vec3{0, 0, -1000}, tt.infinite},
+			}, common...)
+			for _, p := range points {
+				if got := f.ContainsPoint(p.p); got != p.inside {
+					t.Errorf("ContainsPoint(%v) = %v, want %v", p.p, got, p.inside)
+				}
+			}
+			if !f.IntersectsSphere(mgl32.Vec3{0, 0, 6}, 1.5) || f.IntersectsSphere(mgl32.Vec3{0, 0, 7}, 1.5) {
+				t.Errorf("IntersectsSphere is wrong about spheres at the near plane")
+			}
+			if !f.IntersectsAABB(mgl32.Vec3{-100, -100, -1}, mgl32.Vec3{100, 100, 1}) ||
+				f.IntersectsAABB(mgl32.Vec3{10, 10, 6}, mgl32.Vec3{11, 11, 7}) {
+				t.Errorf("IntersectsAABB is wrong")
+			}
+		})
+	}
+}
+
+func TestProjectUnproject(t *testing.T) {
+	cameras := map[string]*Camera{
+		"perspective":          NewPerspective(mgl32.DegToRad(45), 4.0/3, 0.1, 100),
+		"perspective infinite": NewPerspective(mgl32.DegToRad(45), 4.0/3, 0.1, Infinite),
+		"orthographic":         NewOrthographic(-4, 4, -3, 3, 0.1, 100),
+		"off-axis":             NewOffAxis(-0.04, 0.06, -0.03, 0.03, 0.1, 100),
+	}
+	points := []mgl32.Vec3{{0, 0, 0}, {1, 0.5, -2}, {-1.5, 1, 1}, {0.3, -0.7, -10}}
+	for name, c := range cameras {
+		for _, reverse := range []bool{false, true} {
+			c.LookAt(mgl32.Vec3{1, 2, 5}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0})
+			c.ReverseZ = reverse
+			for _, p := range points {
+				w := c.Project(p, 800, 600)
+				back := c.Unproject(w[0], w[1], w[2], 800, 600)
+				if back.Sub(p).Len() > 1e-3*float32(math.Max(1, float64(p.Len()))) {
+					t.Errorf("%s (reverse-Z %v): %v projects to %v and back to %v", name, reverse, p, w, back)
+				}
+			}
+		}
+	}
+
+	// The target lands in the middle of the window, and window Y points down.
+	c := NewPerspective(mgl32.DegToRad(45), 1, 0.1, 100)
+	c.LookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+	if w := c.Project(mgl32.Vec3{}, 400, 400); !near(w[0], 200) || !near(w[1], 200) {
+		t.Errorf("target projects to %v, want the centre", w)
+	}
+	if w := c.Project(mgl32.Vec3{0, 1, 0}, 400, 400); w[1] >= 200 {
+		t.Errorf("a point above the target projects to y = %v, below the centre", w[1])
+	}
+}
+
+func TestRayFromPixel(t *testing.T) {
+	const width, height = 640, 480
+	for _, reverse := range []bool{false, true} {
+		p := NewPerspective(mgl32.DegToRad(60), float32(width)/height, 0.5, Infinite)
+		p.LookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		p.ReverseZ = reverse
+
+		// The middle pixel looks straight ahead from the near plane.
+		r := p.RayFromPixel(width/2, height/2, width, height)
+		if !r.Dir.ApproxEqualThreshold(p.Forward(), epsilon) || !r.Origin.ApproxEqualThreshold(mgl32.Vec3{0, 0, 4.5}, epsilon) {
+			t.Errorf("reverse-Z %v: centre ray %+v", reverse, r)
+		}
+		// Every ray passes through the pixel it was made for.
+		for _, px := range [][2]float64{{0, 0}, {100, 400}, {639, 1}} {
+			r := p.RayFromPixel(px[0], px[1], width, height)
+			if l := r.Dir.Len(); !near(l, 1) {
+				t.Errorf("ray direction %v has length %v", r.Dir, l)
+			}
+			w := p.Project(r.At(10), width, height)
+			if !near(w[0], float32(px[0])) || !near(w[1], float32(px[1])) {
+				t.Errorf("reverse-Z %v: ray through %v passes through %v", reverse, px, w)
+			}
+			// The top left of the window is up and to the left.
+			if px == [2]float64{0, 0} && (r.Dir[0] >= 0 || r.Dir[1] <= 0) {
+				t.Errorf("ray through the top left corner points %v", r.Dir)
+			}
+		}
+
+		// Orthographic rays are all parallel to Forward, starting where the
+		// pixel is on the near plane.
+		o := NewOrthographic(-4, 4, -3, 3, 1, 50)
+		o.LookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		o.ReverseZ = reverse
+		r = o.RayFromPixel(0, 0, width, height)
+		if !r.Dir.ApproxEqualThreshold(mgl32.Vec3{0, 0, -1}, epsilon) || !r.Origin.ApproxEqualThreshold(mgl32.Vec3{-4, 3, 4}, epsilon) {
+			t.Errorf("reverse-Z %v: orthographic corner ray %+v", reverse, r)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package camera
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Plane is the set of points p where Normal.Dot(p) + D == 0. Points with a
+// positive Distance are on the side the normal points to.
+type Plane struct {
+	Normal mgl32.Vec3
+	D      float32
+}
+
+// Distance returns the signed distance from the plane to p.
+func (pl Plane) Distance(p mgl32.Vec3) float32 {
+	return pl.Normal.Dot(p) + pl.D
+}
+
+// normalize scales the plane so that Normal has unit length.
+func (pl Plane) normalize() Plane {
+	l := pl.Normal.Len()
+	if l == 0 {
+		return pl
+	}
+	return Plane{pl.Normal.Mul(1 / l), pl.D / l}
+}
+
+// Indices of the planes in a Frustum.
+const (
+	Left = iota
+	Right
+	Bottom
+	Top
+	Near
+	Far
+)
+
+// Frustum is the six planes bounding a view volume, with normals pointing
+// inwards.
+type Frustum [6]Plane
+
+// Frustum returns the camera's world-space view volume.
+func (c *Camera) Frustum() Frustum {
+	return FrustumFromMatrix(c.ViewProjection(), c.ReverseZ)
+}
+
+// FrustumFromMatrix extracts the planes from a combined projection * view
+// matrix (Gribb & Hartmann). reverseZ must match how m was built.
+//
+// With an Infinite far plane the Far plane degenerates to one that contains
+// every point.
+func FrustumFromMatrix(m mgl32.Mat4, reverseZ bool) Frustum {
+	row := func(i int) mgl32.Vec4 { return m.Row(i) }
+	plane := func(v mgl32.Vec4) Plane {
+		return Plane{mgl32.Vec3{v[0], v[1], v[2]}, v[3]}.normalize()
+	}
+
+	r0, r1, r2, r3 := row(0), row(1), row(2), row(3)
+
+	var f Frustum
+	f[Left] = plane(r3.Add(r0))
+	f[Right] = plane(r3.Sub(r0))
+	f[Bottom] = plane(r3.Add(r1))
+	f[Top] = plane(r3.Sub(r1))
+
+	if reverseZ {
+		// Depth runs from 1 at the near plane to 0 at the far plane.
+		f[Near] = plane(r3.Sub(r2))
+		f[Far] = plane(r2)
+	} else {
+		f[Near] = plane(r3.Add(r2))
+		f[Far] = plane(r3.Sub(r2))
+	}
+
+	return f
+}
+
+// ContainsPoint reports whether p is inside the frustum.
+func (f *Frustum) ContainsPoint(p mgl32.Vec3) bool {
+	for _, pl := range f {
+		if pl.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsSphere reports whether a sphere is at least partly inside the
+// frustum.
+func (f *Frustum) IntersectsSphere(centre mgl32.Vec3, radius float32) bool {
+	for _, pl := range f {
+		if pl.Distance(centre) < -radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsAABB reports whether the axis-aligned box min, max is at least
+// partly inside the frustum. It is conservative: a box near a frustum corner
+// may be reported as intersecting when it is not.
+func (f *Frustum) IntersectsAABB(min, max mgl32.Vec3) bool {
+	for _, pl := range f {
+		// The corner furthest along the normal.
+		p := min
+		for i := 0; i < 3; i++ {
+			if pl.Normal[i] >= 0 {
+				p[i] = max[i]
+			}
+		}
+		if pl.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The projections below follow the usual OpenGL conventions: the camera looks
+// down -Z in eye space and clip-space depth runs from -1 (near) to +1 (far).
+//
+// The ReverseZ variants instead map the near plane to a depth of 1 and the far
+// plane to 0. They are meant to be used with glClipControl(GL_LOWER_LEFT,
+// GL_ZERO_TO_ONE), a GREATER depth test and a floating-point depth buffer,
+// which together spread depth precision evenly over the whole scene.
+
+// Infinite is a far clipping distance that puts the far plane at infinity.
+var Infinite = float32(math.Inf(1))
+
+// IsInfinite reports whether far is the Infinite far clipping distance.
+func IsInfinite(far float32) bool {
+	return math.IsInf(float64(far), 1)
+}
+
+// Perspective is mgl32.Perspective with support for an Infinite far plane.
+func Perspective(fovy, aspect, near, far float32) mgl32.Mat4 {
+	if !IsInfinite(far) {
+		return mgl32.Perspective(fovy, aspect, near, far)
+	}
+
+	f := float32(1 / math.Tan(float64(fovy)/2))
+
+	return mgl32.Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, -1, -1,
+		0, 0, -2 * near, 0,
+	}
+}
+
+// PerspectiveReverseZ is a reverse-Z perspective projection. far may be
+// Infinite.
+func PerspectiveReverseZ(fovy, aspect, near, far float32) mgl32.Mat4 {
+	f := float32(1 / math.Tan(float64(fovy)/2))
+	a, b := reverseZ(near, far)
+
+	return mgl32.Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, a, -1,
+		0, 0, b, 0,
+	}
+}
+
+// OffAxis is a perspective projection through the window left, right, bottom,
+// top on the near plane, as used for stereo pairs and tiled displays. far may
+// be Infinite.
+func OffAxis(left, right, bottom, top, near, far float32) mgl32.Mat4 {
+	if !IsInfinite(far) {
+		return mgl32.Frustum(left, right, bottom, top, near, far)
+	}
+
+	m := offAxis(left, right, bottom, top, near)
+	m[10] = -1
+	m[14] = -2 * near
+
+	return m
+}
+
+// OffAxisReverseZ is the reverse-Z form of OffAxis. far may be Infinite.
+func OffAxisReverseZ(left, right, bottom, top, near, far float32) mgl32.Mat4 {
+	m := offAxis(left, right, bottom, top, near)
+	m[10], m[14] = reverseZ(near, far)
+
+	return m
+}
+
+// Orthographic is mgl32.Ortho. It is here so that every projection the Camera
+// builds can be found in one place. An orthographic far plane cannot be at
+// infinity, so an Infinite far is replaced by MaxOrthographicFar.
+func Orthographic(left, right, bottom, top, near, far float32) mgl32.Mat4 {
+	return mgl32.Ortho(left, right, bottom, top, near, orthographicFar(far))
+}
+
+// OrthographicReverseZ is an orthographic projection mapping near to a depth
+// of 1 and far to 0. Like Orthographic, it replaces an Infinite far by
+// MaxOrthographicFar.
+func OrthographicReverseZ(left, right, bottom, top, near, far float32) mgl32.Mat4 {
+	far = orthographicFar(far)
+	m := mgl32.Ortho(left, right, bottom, top, near, far)
+	m[10] = 1 / (far - near)
+	m[14] = far / (far - near)
+
+	return m
+}
+
+// MaxOrthographicFar is the far clipping distance the orthographic
+// projections use when asked for an Infinite one. The depth of everything
+// in front of it is then nearly the same, but finite.
+const MaxOrthographicFar = 1e30
+
+// orthographicFar returns far, or MaxOrthographicFar if far is not finite.
+func orthographicFar(far float32) float32 {
+	if IsInfinite(far) || math.IsNaN(float64(far)) {
+		return MaxOrthographicFar
+	}
+	return far
+}
+
+// offAxis fills in the X, Y and W rows of an off-axis perspective projection.
+func offAxis(left, right, bottom, top, near float32) mgl32.Mat4 {
+	return mgl32.Mat4{
+		2 * near / (right - left), 0, 0, 0,
+		0, 2 * near / (top - bottom), 0, 0,
+		(right + left) / (right - left), (top + bottom) / (top - bottom), 0, -1,
+		0, 0, 0, 0,
+	}
+}
+
+// reverseZ returns the Z row entries that take eye-space -near to a depth of
+// 1 and -far to 0.
+func reverseZ(near, far float32) (a, b float32) {
+	if IsInfinite(far) {
+		return 0, near
+	}
+
+	return near / (far - near), far * near / (far - near)
+}
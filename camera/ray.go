@@ -0,0 +1,58 @@
+package camera
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Ray is a half-line starting at Origin. Dir has unit length.
+type Ray struct {
+	Origin mgl32.Vec3
+	Dir    mgl32.Vec3
+}
+
+// At returns the point at distance t along the ray.
+func (r Ray) At(t float32) mgl32.Vec3 {
+	return r.Origin.Add(r.Dir.Mul(t))
+}
+
+// Window coordinates in this package are in pixels with the origin at the top
+// left, which is what glfw reports for the cursor position.
+
+// Project returns the window coordinates of the world-space point p for a
+// width x height viewport. The Z component is the normalized device depth.
+func (c *Camera) Project(p mgl32.Vec3, width, height int) mgl32.Vec3 {
+	clip := c.ViewProjection().Mul4x1(p.Vec4(1))
+	ndc := clip.Vec3().Mul(1 / clip[3])
+
+	return mgl32.Vec3{
+		(ndc[0] + 1) / 2 * float32(width),
+		(1 - ndc[1]) / 2 * float32(height),
+		ndc[2],
+	}
+}
+
+// Unproject is the inverse of Project: it returns the world-space point at
+// window position x, y and normalized device depth z.
+func (c *Camera) Unproject(x, y, z float32, width, height int) mgl32.Vec3 {
+	ndc := mgl32.Vec4{
+		2*x/float32(width) - 1,
+		1 - 2*y/float32(height),
+		z,
+		1,
+	}
+	p := c.ViewProjection().Inv().Mul4x1(ndc)
+
+	return p.Vec3().Mul(1 / p[3])
+}
+
+// RayFromPixel returns the world-space ray through the pixel at x, y. For the
+// perspective kinds it starts on the near plane and points away from the eye;
+// for orthographic cameras all rays are parallel to Forward.
+func (c *Camera) RayFromPixel(x, y float64, width, height int) Ray {
+	nearZ, insideZ := c.depthRange()
+
+	a := c.Unproject(float32(x), float32(y), nearZ, width, height)
+	b := c.Unproject(float32(x), float32(y), insideZ, width, height)
+
+	return Ray{Origin: a, Dir: b.Sub(a).Normalize()}
+}
@@ -6,12 +6,14 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
-	"strings"
 	"unsafe"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/pick"
+	"github.com/purelazy/GopenGL/shader"
 )
 
 func createWindow(title string, width, height int) *glfw.Window {
@@ -48,73 +50,6 @@ func createWindow(title string, width, height int) *glfw.Window {
 	return win
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	// Check for errors
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		// How many bytes to allocate
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		fmt.Println("compileShader log")
-		fmt.Println(log)
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func createShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		fmt.Println("Vertex shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		fmt.Println("Fragment shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
 func main() {
 
 	// The thread running this, stays with this and only this.
@@ -179,11 +114,11 @@ func main() {
 
 	` + "\x00"
 
-	shader, err := createShader(vertexShader, fragmentShader)
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
-	defer gl.DeleteProgram(shader)
+	defer gl.DeleteProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -193,7 +128,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	gl.UseProgram(shader)
+	gl.UseProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -213,7 +148,9 @@ func main() {
 	// // Perspective generates a Perspective Matrix.
 	// projection := mgl32.Perspective(fovy, aspectRatio, nearClip, farClip)
 
-	projection := mgl32.Ortho(-1, 1, -1, 1, 0, 2)
+	// An orthographic lens looking at the cube [-1,1]^3
+	cam := camera.NewOrthographic(-1, 1, -1, 1, 0, 2)
+	projection := cam.Projection()
 
 	//              |
 	// +-------------------------+
@@ -224,7 +161,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	projectionUniform := gl.GetUniformLocation(shader, gl.Str("projection\x00"))
+	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
 	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
 
 	//              |
@@ -241,8 +178,9 @@ func main() {
 	lookingAt := mgl32.Vec3{0, 0, 0}
 	// Up is in the positive Y direction
 	thisWayIsUp := mgl32.Vec3{0, 1, 0}
-	// LookAtV positions the camera based on these 3 things
-	camera := mgl32.LookAtV(eye, lookingAt, thisWayIsUp)
+	// LookAt positions the camera based on these 3 things
+	cam.LookAt(eye, lookingAt, thisWayIsUp)
+	view := cam.View()
 
 	//              |
 	// +-------------------------+
@@ -253,8 +191,8 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	cameraUniform := gl.GetUniformLocation(shader, gl.Str("camera\x00"))
-	gl.UniformMatrix4fv(cameraUniform, 1, false, &camera[0])
+	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
+	gl.UniformMatrix4fv(cameraUniform, 1, false, &view[0])
 
 	//              |
 	// +-------------------------+
@@ -265,7 +203,7 @@ func main() {
 	//              |
 
 	model := mgl32.Ident4()
-	modelUniform := gl.GetUniformLocation(shader, gl.Str("model\x00"))
+	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
 	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
 	//              |
@@ -346,6 +284,31 @@ func main() {
 
 	gl.BufferData(gl.ARRAY_BUFFER, int(unsafe.Sizeof(samplePoints)), unsafe.Pointer(&samplePoints), gl.STATIC_DRAW)
 
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Click on a star to see  |
+	// | its index and position  |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	// Only the stars the shader draws (0.7 <= length < 1) can be picked.
+	// visible maps a kd-tree point back to its index in samplePoints.
+	var stars []mgl32.Vec3
+	var visible []int
+	for i, p := range samplePoints {
+		v := mgl32.Vec3{p.x, p.y, p.z}
+		if v.Len() >= 0.7 && v.Len() < 1 {
+			stars = append(stars, v)
+			visible = append(visible, i)
+		}
+	}
+
+	picker := pick.NewPicker(cam)
+	starField := picker.Add(pick.NewKDTree(stars, 0.01), mgl32.Ident4())
+	picker.Attach(win)
+
 	//              |
 	// +-------------------------+
 	// |                         |
@@ -450,6 +413,9 @@ func main() {
 
 		gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
+		// The picker needs to know where the stars are now
+		starField.Model = model
+
 		//              |
 		// +-------------------------+
 		// |                         |
@@ -491,5 +457,13 @@ func main() {
 		// Without this, clicking the close window button would not be detected
 		// and you would need to use "Control-C" to stop the program.
 		glfw.PollEvents()
+
+		// Report any stars that were clicked on
+		for len(picker.Events) > 0 {
+			e := <-picker.Events
+			i := visible[e.Index]
+			p := samplePoints[i]
+			fmt.Printf("Star %d at (%.3f, %.3f, %.3f)\n", i, p.x, p.y, p.z)
+		}
 	}
 }
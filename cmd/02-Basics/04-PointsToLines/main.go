@@ -6,12 +6,13 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
-	"strings"
 	"unsafe"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/reveal"
+	"github.com/purelazy/GopenGL/shader"
 )
 
 //go:generate echo createWindow
@@ -49,84 +50,6 @@ func createWindow(title string, width, height int) *glfw.Window {
 	return win
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	// Check for errors
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		// How many bytes to allocate
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		fmt.Println("compileShader log")
-		fmt.Println(log)
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func createShader(vertexShaderSource, gss, fragmentShaderSource string) (uint32, error) {
-	//func createShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		fmt.Println("Vertex shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	gs, err := compileShader(gss, gl.GEOMETRY_SHADER)
-	if err != nil {
-		fmt.Println("Geometry shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-	fmt.Println("MAX_GEOMETRY_OUTPUT_VERTICES: ", gl.MAX_GEOMETRY_OUTPUT_VERTICES)
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		fmt.Println("Fragment shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, gs)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(gs)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
 func main() {
 
 	// The thread running this, stays with this and only this.
@@ -153,9 +76,10 @@ func main() {
 	// +-------------------------+
 	//              |
 
+	// reveal.GLSL fades the points by how long ago they appeared.
 	var vertexShader = `
 		#version 430
-
+` + reveal.GLSL + `
 		uniform mat4 projection;
 		uniform mat4 camera;
 		uniform mat4 model;
@@ -168,7 +92,7 @@ func main() {
 			if (length(vert) < 1) {
 				gl_Position = projection * camera * model * vec4(vert, 1);
 				if (length(vert) < 0.7) gl_Position = vec4(doNotDraw, 1);
-				colour = vec3((-gl_Position.z+1.0)/2.0, 0.0, 0.0);
+				colour = vec3((-gl_Position.z+1.0)/2.0, 0.0, 0.0) * revealAlpha(float(gl_VertexID));
 			}
 			else {
 				//colour = vec3(0.0, 0.0, 0.9);
@@ -212,12 +136,11 @@ func main() {
 		}
 	` + "\x00"
 
-	shader, err := createShader(vertexShader, geometryShader, fragmentShader)
-	// shader, err := createShader(vertexShader, fragmentShader)
+	program, err := shader.NewGeometryProgram(vertexShader, geometryShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
-	defer gl.DeleteProgram(shader)
+	defer gl.DeleteProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -227,7 +150,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	gl.UseProgram(shader)
+	gl.UseProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -258,7 +181,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	projectionUniform := gl.GetUniformLocation(shader, gl.Str("projection\x00"))
+	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
 	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
 
 	//              |
@@ -288,7 +211,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	cameraUniform := gl.GetUniformLocation(shader, gl.Str("camera\x00"))
+	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
 	gl.UniformMatrix4fv(cameraUniform, 1, false, &camera[0])
 
 	//              |
@@ -300,7 +223,7 @@ func main() {
 	//              |
 
 	model := mgl32.Ident4()
-	modelUniform := gl.GetUniformLocation(shader, gl.Str("model\x00"))
+	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
 	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
 	//              |
@@ -442,7 +365,13 @@ func main() {
 
 	gl.PointSize(2)
 
-	var primitivesToDraw int32 = 1
+	// Reveal the points over 30 seconds and start again, whatever the
+	// frame rate. The keyboard can pause, scrub and speed it up.
+	points := reveal.New(count, 30, gl.POINTS)
+	points.Mode = reveal.Loop
+	points.Fade = 400
+	points.Floor = 0.3
+	points.Attach(win)
 
 	gl.Enable(gl.DEPTH_TEST)
 	gl.ClearColor(0, 0, 0, 1)
@@ -481,6 +410,8 @@ func main() {
 		dt := time - previousTime
 		previousTime = time
 
+		points.Update(dt)
+
 		//              |
 		// +-------------------------+
 		// |                         |
@@ -501,10 +432,8 @@ func main() {
 		// +-------------------------+
 		//              |
 
-		//gl.DrawArrays(gl.POINTS, 0, int32(len(samplePoints)))
-		gl.DrawArrays(gl.POINTS, 0, int32(primitivesToDraw%int32(count)))
-
-		primitivesToDraw++
+		points.Apply(program)
+		points.Draw()
 
 		//              |
 		// +-------------------------+
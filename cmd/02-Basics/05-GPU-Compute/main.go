@@ -1,24 +1,26 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-
+	"math"
 	"runtime"
-	"strings"
-	"unsafe"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/purelazy/GopenGL/compute"
 )
 
 func init() {
 	runtime.LockOSThread()
 }
 
-func createWindow(title string, width, height int) *glfw.Window {
+// createWindow opens a window for its GL context, or returns an error if
+// there is no display or driver to open one with.
+func createWindow(title string, width, height int) (*glfw.Window, error) {
 
 	if err := glfw.Init(); err != nil {
-		panic(fmt.Errorf("could not initialize glfw: %v", err))
+		return nil, fmt.Errorf("could not initialize glfw: %v", err)
 	}
 
 	glfw.WindowHint(glfw.ContextVersionMajor, 4)
@@ -30,85 +32,136 @@ func createWindow(title string, width, height int) *glfw.Window {
 	win, err := glfw.CreateWindow(width, height, title, nil, nil)
 
 	if err != nil {
-		panic(fmt.Errorf("could not create opengl renderer: %v", err))
+		glfw.Terminate()
+		return nil, fmt.Errorf("could not create opengl renderer: %v", err)
 	}
 
 	win.MakeContextCurrent()
 
 	if err := gl.Init(); err != nil {
-		panic(err)
-	}
-
-	return win
-}
-
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	// Check for errors
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		// How many bytes to allocate
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		fmt.Println("compileShader log")
-		fmt.Println(log)
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+		win.Destroy()
+		glfw.Terminate()
+		return nil, err
 	}
 
-	return shader, nil
-}
-
-func createShader(vertexShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		fmt.Println("Vertex shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-
-	return program, nil
+	return win, nil
 }
 
 func main() {
+	backend := flag.String("backend", "auto", "auto, gpu or cpu to run the sqrt kernel there, or feedback for a vertex shader with transform feedback")
+	check := flag.Bool("check", false, "run the sqrt kernel on both the GPU and the CPU and compare the results")
+	flag.Parse()
+
+	data := []float32{16.0, 2.0, 3.0, 4.0, 5.0}
 
 	//              |
 	// +-------------------------+
 	// |                         |
-	// |   Create a Window       |
+	// |  sqrt as a kernel, with |
+	// |  a compute shader and   |
+	// |  a Go function          |
 	// |                         |
 	// +-------------------------+
 	//              |
 
-	const windowWidth int = 800
-	const windowHeight int = 600
-	win := createWindow("Hello OpenGL in Go", windowWidth, windowHeight)
-	defer win.Destroy()
+	sqrt := &compute.Kernel[float32, float32]{
+		Source: sqrtShader,
+		Func: func(in, out []float32, i int) {
+			out[i] = float32(math.Sqrt(float64(in[i])))
+		},
+	}
 
 	//              |
 	// +-------------------------+
 	// |                         |
-	// |   Here's the Shader     |
+	// |   Create a Window       |
+	// |   (the CPU needs none)  |
 	// |                         |
 	// +-------------------------+
 	//              |
 
-	var vertexShader = `
+	if *backend == "cpu" && !*check {
+		fmt.Println(sqrt.RunCPU(data))
+		return
+	}
+
+	const windowWidth int = 800
+	const windowHeight int = 600
+	win, err := createWindow("Hello OpenGL in Go", windowWidth, windowHeight)
+	haveContext := err == nil
+	if haveContext {
+		defer glfw.Terminate()
+		defer win.Destroy()
+		// Let auto choose the GPU, now GL can be asked about it.
+		compute.SetContext(true)
+	} else if *backend != "auto" && *backend != "cpu" {
+		panic(err)
+	} else {
+		// No GL: auto falls back to the CPU.
+		fmt.Println(err)
+	}
+	defer sqrt.Delete()
+
+	switch {
+	case *check:
+		if !haveContext {
+			fmt.Println("-check compares the GPU with the CPU, and there is no OpenGL context for the GPU")
+			return
+		}
+		if !compute.Supported() {
+			fmt.Println("compute shaders need OpenGL 4.3")
+			return
+		}
+		if err := sqrt.Check(data, compute.Within(1e-6)); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("the GPU and the CPU agree")
+	case *backend == "feedback":
+		fmt.Println(feedbackSqrt(data))
+	default:
+		if err := sqrt.Backend.Set(*backend); err != nil {
+			panic(err)
+		}
+		if haveContext && sqrt.Backend == compute.GPU && !compute.Supported() {
+			fmt.Println("compute shaders need OpenGL 4.3; try -backend feedback or -backend cpu")
+			return
+		}
+		out, err := sqrt.Run(data)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(sqrt.Backend.Resolve(), out)
+	}
+}
+
+var sqrtShader = `
+    #version 430 core
+
+    layout(local_size_x = 64) in;
+    layout(std430) readonly buffer Input { float inValues[]; };
+    layout(std430) writeonly buffer Output { float outValues[]; };
+    uniform uvec3 invocations;
+
+    void main()
+    {
+        uint i = gl_GlobalInvocationID.x;
+        if (i >= invocations.x) return;
+        outValues[i] = sqrt(inValues[i]);
+    }
+` + "\x00"
+
+//              |
+// +-------------------------+
+// |                         |
+// |  sqrt with a vertex     |
+// |  shader and transform   |
+// |  feedback               |
+// |                         |
+// +-------------------------+
+//              |
+
+var sqrtVertexShader = `
     #version 430 core
 
     in float inValue;
@@ -120,96 +173,18 @@ func main() {
     }
 ` + "\x00"
 
-	shader, err := createShader(vertexShader)
+func feedbackSqrt(data []float32) []float32 {
+	feedback, err := compute.NewTransformFeedback[float32](sqrtVertexShader, "", []string{"outValue"}, gl.INTERLEAVED_ATTRIBS)
 	if err != nil {
 		panic(err)
 	}
-	defer gl.DeleteProgram(shader)
-
-	// Str takes a null-terminated Go string and returns its GL-compatible address.
-	// This function reaches into Go string storage in an unsafe way so the caller
-	// must ensure the string is not garbage collected.
-
-	// Specify values to record in transform feedback buffers
-	names := "outValue\x00"
-	uint8Name := gl.Str(names)
-	gl.TransformFeedbackVaryings(shader, 1, &uint8Name, gl.INTERLEAVED_ATTRIBS)
+	defer feedback.Delete()
 
-	// Link the program
-	gl.LinkProgram(shader)
-
-	var status int32
-	gl.GetProgramiv(shader, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(shader, logLength, nil, gl.Str(log))
-
-		err = fmt.Errorf("failed to link program: %v", log)
-		fmt.Println(err)
+	input, err := compute.NewInput(feedback.Program, data, "inValue")
+	if err != nil {
+		panic(err)
 	}
+	defer input.Delete()
 
-	defer gl.DeleteShader(shader)
-	gl.UseProgram(shader)
-
-	var vao uint32
-	// Generate vertex array name
-	gl.GenVertexArrays(1, &vao)
-	// Bind it
-	gl.BindVertexArray(vao)
-
-	// Here is the
-	data := [...]float32{16.0, 2.0, 3.0, 4.0, 5.0}
-
-	var vbo uint32
-	// Get a buffer, call it fred
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, int(unsafe.Sizeof(data)), unsafe.Pointer(&data), gl.STATIC_DRAW)
-
-	inputAttrib := gl.GetAttribLocation(shader, gl.Str("inValue\x00"))
-	gl.EnableVertexAttribArray(uint32(inputAttrib))
-	gl.VertexAttribPointer(uint32(inputAttrib), 1, gl.FLOAT, false, 0, unsafe.Pointer(nil))
-
-	// Create transform feedback buffer
-	var tbo uint32
-	gl.GenBuffers(1, &tbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, tbo)
-	gl.BufferData(gl.ARRAY_BUFFER, int(unsafe.Sizeof(data)), unsafe.Pointer(nil), gl.STATIC_READ)
-
-	fmt.Println(int(unsafe.Sizeof(data)))
-	fmt.Println(len(data))
-
-	// Perform feedback transform
-
-	gl.Enable(gl.RASTERIZER_DISCARD)
-
-	gl.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, 0, tbo)
-
-	gl.BeginTransformFeedback(gl.POINTS)
-
-	gl.DrawArrays(gl.POINTS, 0, int32(len(data)))
-
-	gl.EndTransformFeedback()
-
-	gl.Disable(gl.RASTERIZER_DISCARD)
-
-	gl.Flush()
-
-	// Fetch and print results
-	var feedback [5]float32
-	gl.GetBufferSubData(gl.TRANSFORM_FEEDBACK_BUFFER, 0, int(unsafe.Sizeof(feedback)), unsafe.Pointer(&feedback))
-
-	fmt.Println(feedback[0], feedback[1], feedback[2], feedback[3], feedback[4])
-
-	gl.DeleteProgram(shader)
-	gl.DeleteShader(shader)
-
-	gl.DeleteBuffers(1, &tbo)
-	gl.DeleteBuffers(1, &vbo)
-
-	gl.DeleteVertexArrays(1, &vao)
-
+	return feedback.Run(input.VAO, 0, input.Len)
 }
@@ -6,14 +6,52 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
-	"strings"
 	"unsafe"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/geometry"
+	"github.com/purelazy/GopenGL/lines"
+	"github.com/purelazy/GopenGL/obj"
+	"github.com/purelazy/GopenGL/paths"
+	"github.com/purelazy/GopenGL/reveal"
+	"github.com/purelazy/GopenGL/shader"
+	"github.com/purelazy/GopenGL/stl"
 )
 
+// saveWalk thickens the walk into a tube and writes it as walk.stl and
+// walk.obj.
+func saveWalk(walk []mgl32.Vec3) error {
+	tube := geometry.Tube(walk, 0.003, 8)
+
+	if err := stl.Save("walk.stl", tube); err != nil {
+		return err
+	}
+	if err := obj.Save("walk.obj", &obj.Mesh{Object: "walk", Mesh: *tube}); err != nil {
+		return err
+	}
+
+	fmt.Println("Saved", tube.Triangles(), "triangles to walk.stl and walk.obj")
+	return nil
+}
+
+// walkVertices colours the walk from blue at its start to orange at its
+// end, for the thick line renderer.
+func walkVertices(walk paths.Polyline) []lines.Vertex {
+	start, end := mgl32.Vec4{0.2, 0.5, 1, 1}, mgl32.Vec4{1, 0.6, 0.2, 1}
+	vertices := make([]lines.Vertex, len(walk))
+	for i, p := range walk {
+		t := float32(i) / float32(len(walk))
+		vertices[i] = lines.Vertex{
+			Position: p,
+			Width:    2.5,
+			Color:    start.Mul(1 - t).Add(end.Mul(t)),
+		}
+	}
+	return vertices
+}
+
 //go:generate echo createWindow
 func createWindow(title string, width, height int) *glfw.Window {
 
@@ -49,74 +87,6 @@ func createWindow(title string, width, height int) *glfw.Window {
 	return win
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	// Check for errors
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		// How many bytes to allocate
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		fmt.Println("compileShader log")
-		fmt.Println(log)
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func createShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	//func createShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		fmt.Println("Vertex shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		fmt.Println("Fragment shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
 func main() {
 
 	// The thread running this, stays with this and only this.
@@ -143,9 +113,10 @@ func main() {
 	// +-------------------------+
 	//              |
 
+	// reveal.GLSL fades the walk behind its head.
 	var vertexShader = `
 		#version 430
-
+` + reveal.GLSL + `
 		uniform mat4 projection;
 		uniform mat4 camera;
 		uniform mat4 model;
@@ -158,7 +129,7 @@ func main() {
 
 				gl_Position = projection * camera * model * vec4(vert, 1);
 				// z range is [-1,1]
-				colour = vec3((-gl_Position.z+1.0)/4.0, abs(model[0][0]/4.0), model[0][0]);
+				colour = vec3((-gl_Position.z+1.0)/4.0, abs(model[0][0]/4.0), model[0][0]) * revealAlpha(float(gl_VertexID));
 
 
 		}
@@ -175,12 +146,11 @@ func main() {
 		}
 	` + "\x00"
 
-	shader, err := createShader(vertexShader, fragmentShader)
-	// shader, err := createShader(vertexShader, fragmentShader)
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
-	defer gl.DeleteProgram(shader)
+	defer gl.DeleteProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -190,7 +160,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	gl.UseProgram(shader)
+	gl.UseProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -221,7 +191,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	projectionUniform := gl.GetUniformLocation(shader, gl.Str("projection\x00"))
+	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
 	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
 
 	//              |
@@ -251,7 +221,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	cameraUniform := gl.GetUniformLocation(shader, gl.Str("camera\x00"))
+	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
 	gl.UniformMatrix4fv(cameraUniform, 1, false, &camera[0])
 
 	//              |
@@ -263,7 +233,7 @@ func main() {
 	//              |
 
 	model := mgl32.Ident4()
-	modelUniform := gl.GetUniformLocation(shader, gl.Str("model\x00"))
+	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
 	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
 	//              |
@@ -319,50 +289,10 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	type vec3 struct {
-		x float32
-		y float32
-		z float32
-	}
-
+	// A new random walk is made each time the last one has been drawn.
 	const count int = 20000
-	var samplePoints [count]vec3
-
-	// pos := vec3{0, 0, 0}
-	// samplePoints[0] = pos
-	// move := vec3{}
-
-	// for i := 1; i < count; i++ {
-	// 	rnd := rand.Intn(6)
-	// 	switch rnd {
-	// 	case 0:
-	// 		move = vec3{-0.01, 0, 0}
-	// 	case 1:
-	// 		move = vec3{0.01, 0, 0}
-	// 	case 2:
-	// 		move = vec3{0, -0.01, 0}
-	// 	case 3:
-	// 		move = vec3{0, 0.01, 0}
-	// 	case 4:
-	// 		move = vec3{0, 0, -0.01}
-	// 	case 5:
-	// 		move = vec3{0, 0, 0.01}
-	// 	default:
-	// 		panic("unrecognized escape character")
-	// 	}
-	// 	//fmt.Println(move, start)
-	// 	pos.x += move.x
-	// 	pos.y += move.y
-	// 	pos.z += move.z
-	// 	samplePoints[i] = pos
-	// 	// if i%2000 == 0 {
-	// 	// 	pos = vec3{0, 0, 0}
-	// 	// 	samplePoints[i] = pos
-	// 	// } else {
-	// 	// 	samplePoints[i] = pos
-	// 	// }
-	// 	//samplePoints[i] = vec3{rand.Float32()*2 - 1, rand.Float32()*2 - 1, rand.Float32()*2 - 1}
-	// }
+	random := rand.New(rand.NewSource(1))
+	walk := paths.LatticeWalk(random, count-1, 0.01, 3)
 
 	//              |
 	// +-------------------------+
@@ -374,7 +304,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	gl.BufferData(gl.ARRAY_BUFFER, int(unsafe.Sizeof(samplePoints)), unsafe.Pointer(&samplePoints), gl.STATIC_DRAW)
+	gl.BufferData(gl.ARRAY_BUFFER, len(walk)*int(unsafe.Sizeof(walk[0])), gl.Ptr(walk), gl.STATIC_DRAW)
 
 	//              |
 	// +-------------------------+
@@ -387,7 +317,7 @@ func main() {
 	//              |
 
 	var vPosition uint32 = 0
-	coordinatesPerVertex := int32(unsafe.Sizeof(vec3{})) / int32(unsafe.Sizeof(float32(0)))
+	coordinatesPerVertex := int32(unsafe.Sizeof(walk[0])) / int32(unsafe.Sizeof(float32(0)))
 	gl.VertexAttribPointer(vPosition, coordinatesPerVertex, gl.FLOAT, false, 0, gl.PtrOffset(0))
 
 	//              |
@@ -414,7 +344,60 @@ func main() {
 	gl.Enable(gl.DEPTH_TEST)
 	gl.ClearColor(0, 0, 0, 1)
 
-	var primitivesToDraw int32 = 0
+	// Draw the walk over 20 seconds, whatever the frame rate. The keyboard
+	// can pause, scrub and speed it up.
+	drawing := reveal.New(count, 20, gl.LINE_STRIP)
+	drawing.Fade = 4000
+	drawing.Floor = 0.25
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | gl.LINE_STRIP is only   |
+	// | ever one pixel wide, so |
+	// | draw thick, smooth      |
+	// | lines instead. Press L  |
+	// | to compare them         |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	thick, err := lines.New()
+	if err != nil {
+		panic(err)
+	}
+	defer thick.Delete()
+	thick.Join = lines.JoinRound
+	thick.Cap = lines.CapRound
+	thick.AddStrip(walkVertices(walk))
+	thickLines := true
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Press S to save the     |
+	// | walk drawn so far as a  |
+	// | tube, for 3D printing   |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	win.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		if key == glfw.KeyL {
+			thickLines = !thickLines
+		}
+		if key != glfw.KeyS {
+			return
+		}
+		_, drawn := drawing.Range()
+		if err := saveWalk(walk[:drawn]); err != nil {
+			fmt.Println(err)
+		}
+	})
+	drawing.Attach(win)
 
 	//              |
 	// +-------------------------+
@@ -431,43 +414,10 @@ func main() {
 
 	for !win.ShouldClose() {
 
-		if primitivesToDraw%int32(count) == 0 {
-			fmt.Println("Hello")
-			pos := vec3{0, 0, 0}
-			samplePoints[0] = pos
-			move := vec3{}
-
-			for i := 1; i < count; i++ {
-				rnd := rand.Intn(6)
-				switch rnd {
-				case 0:
-					move = vec3{-0.01, 0, 0}
-				case 1:
-					move = vec3{0.01, 0, 0}
-				case 2:
-					move = vec3{0, -0.01, 0}
-				case 3:
-					move = vec3{0, 0.01, 0}
-				case 4:
-					move = vec3{0, 0, -0.01}
-				case 5:
-					move = vec3{0, 0, 0.01}
-				default:
-					panic("unrecognized escape character")
-				}
-				//fmt.Println(move, start)
-				pos.x += move.x
-				pos.y += move.y
-				pos.z += move.z
-				samplePoints[i] = pos
-				// if i%2000 == 0 {
-				// 	pos = vec3{0, 0, 0}
-				// 	samplePoints[i] = pos
-				// } else {
-				// 	samplePoints[i] = pos
-				// }
-				//samplePoints[i] = vec3{rand.Float32()*2 - 1, rand.Float32()*2 - 1, rand.Float32()*2 - 1}
-			}
+		// Once the walk is drawn, start again with a new one.
+		if drawing.Time() >= drawing.Duration {
+			drawing.Seek(0)
+			walk = paths.LatticeWalk(random, count-1, 0.01, 3)
 
 			//              |
 			// +-------------------------+
@@ -479,7 +429,9 @@ func main() {
 			// +-------------------------+
 			//              |
 
-			gl.BufferData(gl.ARRAY_BUFFER, int(unsafe.Sizeof(samplePoints)), unsafe.Pointer(&samplePoints), gl.STATIC_DRAW)
+			gl.BufferData(gl.ARRAY_BUFFER, len(walk)*int(unsafe.Sizeof(walk[0])), gl.Ptr(walk), gl.STATIC_DRAW)
+			thick.Clear()
+			thick.AddStrip(walkVertices(walk))
 
 		}
 
@@ -506,6 +458,8 @@ func main() {
 		dt := time - previousTime
 		previousTime = time
 
+		drawing.Update(dt)
+
 		//              |
 		// +-------------------------+
 		// |                         |
@@ -526,14 +480,13 @@ func main() {
 		// +-------------------------+
 		//              |
 
-		//gl.DrawArrays(gl.POINTS, 0, int32(len(samplePoints)))
-		//gl.DrawArrays(gl.POINTS, 0, int32(primitivesToDraw%int32(count)))
-		//gl.DrawArrays(gl.LINES, 0, int32(primitivesToDraw%int32(count)))
-		gl.DrawArrays(gl.LINE_STRIP, 0, int32(primitivesToDraw%int32(count)))
-		//gl.DrawArrays(gl.TRIANGLES, 0, int32(primitivesToDraw%int32(count)))
-		//gl.DrawArrays(gl.TRIANGLE_FAN, 0, int32(primitivesToDraw%int32(count)))
-
-		primitivesToDraw += 2
+		if thickLines {
+			first, n := drawing.Range()
+			thick.DrawRange(projection.Mul4(camera).Mul4(model), first, n)
+		} else {
+			drawing.Apply(program)
+			drawing.Draw()
+		}
 
 		//              |
 		// +-------------------------+
@@ -8,16 +8,19 @@ package main
 import (
 	"fmt"
 	"image"
-	"image/draw"
-	_ "image/png"
+	"image/color"
 	"log"
-	"os"
 	"runtime"
-	"strings"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/geometry"
+	"github.com/purelazy/GopenGL/glstate"
+	"github.com/purelazy/GopenGL/shader"
+	"github.com/purelazy/GopenGL/skybox"
+	"github.com/purelazy/GopenGL/texture"
 )
 
 const windowWidth = 800
@@ -27,59 +30,6 @@ func init() {
 	// GLFW event handling must run on the main OS thread
 	runtime.LockOSThread()
 }
-func init() {
-	// GLFW event handling must run on the main OS thread
-	fmt.Println("Init")
-}
-
-func loadTexture(file string) (uint32, error) {
-	imgFile, err := os.Open(file)
-	if err != nil {
-		return 0, fmt.Errorf("texture %q not found on disk: %v", file, err)
-	}
-	img, formatName, err := image.Decode(imgFile)
-	if err != nil {
-		return 0, err
-	}
-
-	fmt.Println("Image format is", formatName)
-	fmt.Println("Image Bounds is", img.Bounds())
-
-	//              |
-	// +-------------------------+
-	// |                         |
-	// |    Convert the Image    |
-	// |                         |
-	// +-------------------------+
-	//              |
-
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return 0, fmt.Errorf("unsupported stride")
-	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
-
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexImage2D(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		int32(rgba.Rect.Size().X),
-		int32(rgba.Rect.Size().Y),
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(rgba.Pix))
-
-	return texture, nil
-}
 
 func main() {
 	if err := glfw.Init(); err != nil {
@@ -107,27 +57,30 @@ func main() {
 	fmt.Println("OpenGL version", version)
 
 	// Configure the vertex and fragment shaders
-	program, err := newProgram(vertexShader, fragmentShader)
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
 
 	gl.UseProgram(program)
 
-	projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(windowWidth)/windowHeight, 0.1, 10.0)
+	cam := camera.NewPerspective(mgl32.DegToRad(45.0), float32(windowWidth)/windowHeight, 0.1, 10.0)
+	cam.LookAt(mgl32.Vec3{3, 3, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+
+	projection := cam.Projection()
 	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
 	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
 
-	camera := mgl32.LookAtV(mgl32.Vec3{3, 3, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+	view := cam.View()
 	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
-	gl.UniformMatrix4fv(cameraUniform, 1, false, &camera[0])
+	gl.UniformMatrix4fv(cameraUniform, 1, false, &view[0])
 
 	model := mgl32.Ident4()
 	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
 	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
-	textureUniform := gl.GetUniformLocation(program, gl.Str("tex\x00"))
-	gl.Uniform1i(textureUniform, 0)
+	// Each sampler gets its own texture unit the first time it is bound
+	units := texture.NewUnits(program)
 
 	//gl.BindFragDataLocation(program, 0, gl.Str("outputColor\x00"))
 
@@ -139,11 +92,46 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	texture, err := loadTexture("square.png")
+	// Trilinear and anisotropic filtering keep the texture sharp on faces
+	// seen edge on, without shimmering as the cube turns away.
+	tex, err := texture.Load("square.png", texture.Options{
+		Mipmaps:    true,
+		Anisotropy: 8,
+		WrapS:      gl.CLAMP_TO_EDGE,
+		WrapT:      gl.CLAMP_TO_EDGE,
+	})
 	if err != nil {
 		log.Fatalln(err)
 	}
 
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |     Build a Skybox      |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	// A panorama painted in code, turned into a cube map on the GPU. A
+	// photographed sky would come from texture.LoadTextureCubeEquirect.
+	pano, err := texture.NewTexture2D(skyPanorama(512, 256), texture.Options{WrapT: gl.CLAMP_TO_EDGE})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	sky, err := texture.NewTextureCubeFromEquirect(pano, 256, texture.Options{})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	pano.Delete()
+
+	box, err := skybox.New(sky)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Generate a 2x2x2 cube: 6 faces of 2 triangles each
+	cube := geometry.Cube(2, 1)
+
 	// Configure the vertex data
 	var vao uint32
 	gl.GenVertexArrays(1, &vao)
@@ -152,19 +140,24 @@ func main() {
 	var vbo uint32
 	gl.GenBuffers(1, &vbo)
 	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(cubeVertices)*4, gl.Ptr(cubeVertices), gl.STATIC_DRAW)
+	gl.BufferData(gl.ARRAY_BUFFER, len(cube.Vertices)*int(geometry.VertexSize), gl.Ptr(cube.Vertices), gl.STATIC_DRAW)
+
+	var ebo uint32
+	gl.GenBuffers(1, &ebo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(cube.Indices)*4, gl.Ptr(cube.Indices), gl.STATIC_DRAW)
 
 	vertAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vert\x00")))
 	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointer(vertAttrib, 3, gl.FLOAT, false, 5*4, gl.PtrOffset(0))
+	gl.VertexAttribPointer(vertAttrib, 3, gl.FLOAT, false, geometry.VertexSize, gl.PtrOffset(geometry.PositionOffset))
 
 	texCoordAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vertTexCoord\x00")))
 	gl.EnableVertexAttribArray(texCoordAttrib)
-	gl.VertexAttribPointer(texCoordAttrib, 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
+	gl.VertexAttribPointer(texCoordAttrib, 2, gl.FLOAT, false, geometry.VertexSize, gl.PtrOffset(geometry.UVOffset))
 
 	// Configure global settings
 	gl.Enable(gl.DEPTH_TEST)
-	gl.DepthFunc(gl.LESS)
+	glstate.ApplyDepth(cam.ReverseZ)
 	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
 
 	angle := 0.0
@@ -187,10 +180,14 @@ func main() {
 
 		gl.BindVertexArray(vao)
 
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, texture)
+		if err := units.Bind("tex", tex); err != nil {
+			log.Fatalln(err)
+		}
 
-		gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
+		gl.DrawElements(gl.TRIANGLES, int32(len(cube.Indices)), gl.UNSIGNED_INT, gl.PtrOffset(0))
+
+		// The sky fills whatever the cube left empty
+		box.Draw(cam)
 
 		// Maintenance
 		window.SwapBuffers()
@@ -198,62 +195,32 @@ func main() {
 	}
 }
 
-func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		return 0, err
-	}
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return 0, err
+// skyPanorama paints an equirectangular sky: deep blue overhead fading to a
+// pale horizon, over dark ground.
+func skyPanorama(width, height int) image.Image {
+	zenith := mgl32.Vec3{0.15, 0.35, 0.75}
+	horizon := mgl32.Vec3{0.85, 0.9, 0.95}
+	ground := mgl32.Vec3{0.25, 0.22, 0.2}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		// Elevation runs from 1 (straight up) at the top row to -1 at the
+		// bottom.
+		elevation := 1 - 2*(float32(y)+0.5)/float32(height)
+
+		var c mgl32.Vec3
+		if elevation >= 0 {
+			c = horizon.Add(zenith.Sub(horizon).Mul(mgl32.Clamp(elevation*2, 0, 1)))
+		} else {
+			c = horizon.Add(ground.Sub(horizon).Mul(mgl32.Clamp(-elevation*8, 0, 1)))
+		}
+
+		rgba := color.NRGBA{uint8(c[0] * 255), uint8(c[1] * 255), uint8(c[2] * 255), 255}
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, rgba)
+		}
 	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
+	return img
 }
 
 var vertexShader = `
@@ -288,57 +255,6 @@ void main() {
 }
 ` + "\x00"
 
-var cubeVertices = []float32{
-	//  X, Y, Z, U, V
-	// Bottom
-	-1.0, -1.0, -1.0, 0.0, 0.0,
-	1.0, -1.0, -1.0, 1.0, 0.0,
-	-1.0, -1.0, 1.0, 0.0, 1.0,
-	1.0, -1.0, -1.0, 1.0, 0.0,
-	1.0, -1.0, 1.0, 1.0, 1.0,
-	-1.0, -1.0, 1.0, 0.0, 1.0,
-
-	// Top
-	-1.0, 1.0, -1.0, 0.0, 0.0,
-	-1.0, 1.0, 1.0, 0.0, 1.0,
-	1.0, 1.0, -1.0, 1.0, 0.0,
-	1.0, 1.0, -1.0, 1.0, 0.0,
-	-1.0, 1.0, 1.0, 0.0, 1.0,
-	1.0, 1.0, 1.0, 1.0, 1.0,
-
-	// Front
-	-1.0, -1.0, 1.0, 1.0, 0.0,
-	1.0, -1.0, 1.0, 0.0, 0.0,
-	-1.0, 1.0, 1.0, 1.0, 1.0,
-	1.0, -1.0, 1.0, 0.0, 0.0,
-	1.0, 1.0, 1.0, 0.0, 1.0,
-	-1.0, 1.0, 1.0, 1.0, 1.0,
-
-	// Back
-	-1.0, -1.0, -1.0, 0.0, 0.0,
-	-1.0, 1.0, -1.0, 0.0, 1.0,
-	1.0, -1.0, -1.0, 1.0, 0.0,
-	1.0, -1.0, -1.0, 1.0, 0.0,
-	-1.0, 1.0, -1.0, 0.0, 1.0,
-	1.0, 1.0, -1.0, 1.0, 1.0,
-
-	// Left
-	-1.0, -1.0, 1.0, 0.0, 1.0,
-	-1.0, 1.0, -1.0, 1.0, 0.0,
-	-1.0, -1.0, -1.0, 0.0, 0.0,
-	-1.0, -1.0, 1.0, 0.0, 1.0,
-	-1.0, 1.0, 1.0, 1.0, 1.0,
-	-1.0, 1.0, -1.0, 1.0, 0.0,
-
-	// Right
-	1.0, -1.0, 1.0, 1.0, 1.0,
-	1.0, -1.0, -1.0, 1.0, 0.0,
-	1.0, 1.0, -1.0, 0.0, 0.0,
-	1.0, -1.0, 1.0, 1.0, 1.0,
-	1.0, 1.0, -1.0, 0.0, 0.0,
-	1.0, 1.0, 1.0, 0.0, 1.0,
-}
-
 // // Set the working directory to the root of Go package, so that its assets can be accessed.
 // func init() {
 // 	dir, err := importPathToDir("github.com/go-gl/example/gl41core-cube")
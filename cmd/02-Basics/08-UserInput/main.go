@@ -7,12 +7,14 @@ import (
 	//"math/rand"
 	"os"
 	"runtime"
-	"strings"
 	"unsafe"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/pick"
+	"github.com/purelazy/GopenGL/shader"
 )
 
 // Returns a clojure which gets the next prime each call
@@ -70,73 +72,6 @@ func createWindow(title string, width, height int) *glfw.Window {
 	return win
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	// Check for errors
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		// How many bytes to allocate
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		fmt.Println("compileShader log")
-		fmt.Println(log)
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func createShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		fmt.Println("Vertex shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		fmt.Println("Fragment shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
 // Used to move the model along the z-axis
 var zoom float32 = 0
 
@@ -217,11 +152,11 @@ func main() {
 
 	` + "\x00"
 
-	shader, err := createShader(vertexShader, fragmentShader)
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
-	defer gl.DeleteProgram(shader)
+	defer gl.DeleteProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -231,7 +166,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	gl.UseProgram(shader)
+	gl.UseProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -248,8 +183,10 @@ func main() {
 	// The near and far clipping distances
 	var nearClip float32 = 0.01
 	var farClip float32 = 12
-	// Perspective generates a Perspective Matrix.
-	projection := mgl32.Perspective(fovy, aspectRatio, nearClip, farClip)
+	// The camera describes the lens (projection) and where it is (view).
+	cam := camera.NewPerspective(fovy, aspectRatio, nearClip, farClip)
+	// Projection generates a Perspective Matrix.
+	projection := cam.Projection()
 
 	//              |
 	// +-------------------------+
@@ -260,7 +197,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	projectionUniform := gl.GetUniformLocation(shader, gl.Str("projection\x00"))
+	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
 	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
 
 	//              |
@@ -277,8 +214,9 @@ func main() {
 	lookingAt := mgl32.Vec3{0, 0, 0}
 	// Up is in the positive Y direction
 	thisWayIsUp := mgl32.Vec3{0, 1, 0}
-	// LookAtV positions the camera
-	view := mgl32.LookAtV(eye, lookingAt, thisWayIsUp)
+	// LookAt positions the camera
+	cam.LookAt(eye, lookingAt, thisWayIsUp)
+	view := cam.View()
 
 	//              |
 	// +-------------------------+
@@ -289,7 +227,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	viewUniform := gl.GetUniformLocation(shader, gl.Str("view\x00"))
+	viewUniform := gl.GetUniformLocation(program, gl.Str("view\x00"))
 	gl.UniformMatrix4fv(viewUniform, 1, false, &view[0])
 
 	//              |
@@ -305,7 +243,7 @@ func main() {
 	model := mgl32.Ident4()
 
 	// Returns the location of a uniform variable
-	modelUniform := gl.GetUniformLocation(shader, gl.Str("model\x00"))
+	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
 
 	// Specify the value of a uniform variable for the current program object
 	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
@@ -394,6 +332,24 @@ func main() {
 
 	gl.BufferData(gl.ARRAY_BUFFER, int(unsafe.Sizeof(samplePoints)), unsafe.Pointer(&samplePoints), gl.STATIC_DRAW)
 
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Click on a prime to see |
+	// | its index and position  |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	primePoints := make([]mgl32.Vec3, count)
+	for i, p := range samplePoints {
+		primePoints[i] = mgl32.Vec3{p.x, p.y, p.z}
+	}
+
+	picker := pick.NewPicker(cam)
+	spiral := picker.Add(pick.NewKDTree(primePoints, 0.002), mgl32.Ident4())
+	picker.Attach(win)
+
 	//              |
 	// +-------------------------+
 	// |                         |
@@ -475,6 +431,9 @@ func main() {
 
 		gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
+		// The picker needs to know where the spiral is now
+		spiral.Model = model
+
 		//              |
 		// +-------------------------+
 		// |                         |
@@ -516,5 +475,12 @@ func main() {
 		// Without this, clicking the close window button would not be detected
 		// and you would need to use "Control-C" to stop the program.
 		glfw.PollEvents()
+
+		// Report any primes that were clicked on
+		for len(picker.Events) > 0 {
+			e := <-picker.Events
+			p := samplePoints[e.Index]
+			fmt.Printf("Prime %d at (%.5f, %.5f)\n", e.Index, p.x, p.y)
+		}
 	}
 }
@@ -6,13 +6,15 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
-	"strings"
 	"time"
 	"unsafe"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/framebuffer"
+	"github.com/purelazy/GopenGL/post"
+	"github.com/purelazy/GopenGL/shader"
 )
 
 //go:generate echo createWindow
@@ -57,87 +59,6 @@ func createWindow(title string, width, height int) *glfw.Window {
 	return win
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	// Check for errors
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		// How many bytes to allocate
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		fmt.Println("compileShader log")
-		fmt.Println(log)
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func createShader(vertexShaderSource, gss, fragmentShaderSource string) (uint32, error) {
-	//func createShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		fmt.Println("Vertex shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	gs, err := compileShader(gss, gl.GEOMETRY_SHADER)
-	if err != nil {
-		fmt.Println("Geometry shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	var maxOutVert int32
-	gl.GetIntegerv(gl.MAX_GEOMETRY_OUTPUT_VERTICES, &maxOutVert)
-	fmt.Println("MAX_GEOMETRY_OUTPUT_VERTICES: ", maxOutVert)
-
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		fmt.Println("Fragment shader did not compile")
-		fmt.Println(err)
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, gs)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(gs)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
 func main() {
 
 	// The thread running this, stays with this and only this.
@@ -237,16 +158,16 @@ func main() {
 		out vec4 outputColor;
 
 		void main() {
-			outputColor = vec4(colourFS, 1.0);
+			// Brighter than white, so that bloom makes the lines glow.
+			outputColor = vec4(colourFS * 3.0, 1.0);
 		}
 	` + "\x00"
 
-	shader, err := createShader(vertexShader, geometryShader, fragmentShader)
-	// shader, err := createShader(vertexShader, fragmentShader)
+	program, err := shader.NewGeometryProgram(vertexShader, geometryShader, fragmentShader)
 	if err != nil {
 		panic(err)
 	}
-	defer gl.DeleteProgram(shader)
+	defer gl.DeleteProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -256,7 +177,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	gl.UseProgram(shader)
+	gl.UseProgram(program)
 
 	//              |
 	// +-------------------------+
@@ -287,7 +208,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	projectionUniform := gl.GetUniformLocation(shader, gl.Str("projection\x00"))
+	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
 	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
 
 	//              |
@@ -317,7 +238,7 @@ func main() {
 	// +-------------------------+
 	//              |
 
-	cameraUniform := gl.GetUniformLocation(shader, gl.Str("camera\x00"))
+	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
 	gl.UniformMatrix4fv(cameraUniform, 1, false, &camera[0])
 
 	//              |
@@ -329,7 +250,7 @@ func main() {
 	//              |
 
 	model := mgl32.Ident4()
-	modelUniform := gl.GetUniformLocation(shader, gl.Str("model\x00"))
+	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
 	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
 	//              |
@@ -477,6 +398,92 @@ func main() {
 	gl.Enable(gl.DEPTH_TEST)
 	gl.ClearColor(0, 0, 0, 1)
 
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Render the lines into   |
+	// | an HDR framebuffer      |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	fbWidth, fbHeight := win.GetFramebufferSize()
+	scene, err := framebuffer.New(framebuffer.Spec{
+		Width:  fbWidth,
+		Height: fbHeight,
+		Colors: []framebuffer.Attachment{{Name: "outputColor", Format: gl.RGBA16F}},
+		Depth:  gl.DEPTH_COMPONENT24,
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer scene.Delete()
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Post-processing: glow,  |
+	// | tone map, smooth edges, |
+	// | darken the corners      |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	chain, err := post.NewChain(fbWidth, fbHeight)
+	if err != nil {
+		panic(err)
+	}
+	defer chain.Delete()
+
+	bloom, err := post.NewBloom()
+	if err != nil {
+		panic(err)
+	}
+	chain.Add(bloom)
+	toneMap, err := post.NewToneMap(post.ACES)
+	if err != nil {
+		panic(err)
+	}
+	chain.Add(toneMap)
+	fxaa, err := post.NewFXAA()
+	if err != nil {
+		panic(err)
+	}
+	chain.Add(fxaa)
+	vignette, err := post.NewVignette()
+	if err != nil {
+		panic(err)
+	}
+	chain.Add(vignette)
+
+	// Keep the framebuffer and chain the size of the window.
+	win.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		if width == 0 || height == 0 {
+			return
+		}
+		if err := chain.Resize(width, height); err != nil {
+			fmt.Println(err)
+		}
+	})
+	scene.ResizeWithWindow(win, func(err error) { fmt.Println(err) })
+
+	// B turns bloom on and off; up and down change its intensity.
+	win.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action == glfw.Release {
+			return
+		}
+		switch key {
+		case glfw.KeyB:
+			if action == glfw.Press {
+				chain.SetEnabled("bloom", !chain.Enabled("bloom"))
+			}
+		case glfw.KeyUp:
+			bloom.Intensity += 0.1
+		case glfw.KeyDown:
+			bloom.Intensity = float32(math.Max(float64(bloom.Intensity)-0.1, 0))
+		}
+	})
+
 	//              |
 	// +-------------------------+
 	// |                         |
@@ -496,7 +503,8 @@ func main() {
 		// +-------------------------+
 		//              |
 
-		// Clear these buffers
+		// Draw into the HDR framebuffer, and clear its buffers
+		scene.Bind()
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		//              |
@@ -521,6 +529,9 @@ func main() {
 
 		angle += omega * dt
 		model = mgl32.HomogRotate3D(float32(angle), mgl32.Vec3{0, 1, 0})
+		// The post-processing chain uses programs and a VAO of its own
+		gl.UseProgram(program)
+		gl.BindVertexArray(theVAO)
 		gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
 
 		//              |
@@ -535,6 +546,20 @@ func main() {
 
 		primitivesToDraw++
 
+		//              |
+		// +-------------------------+
+		// |                         |
+		// |  Post-process onto the  |
+		// |  window                 |
+		// |                         |
+		// +-------------------------+
+		//              |
+
+		chain.Time = float32(time)
+		if err := chain.Run(scene.Textures[0], nil); err != nil {
+			fmt.Println(err)
+		}
+
 		//              |
 		// +-------------------------+
 		// |                         |
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/pointcloud"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+func createWindow(title string, width, height int) *glfw.Window {
+
+	// Make sure width and height are not zero.
+	if !(width != 0 && height != 0) {
+		fmt.Println("Width and Height cannot be zero.")
+		os.Exit(0)
+	}
+
+	if err := glfw.Init(); err != nil {
+		panic(fmt.Errorf("could not initialize glfw: %v", err))
+	}
+
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 6)
+	glfw.WindowHint(glfw.Resizable, glfw.True)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	// Create a window
+	win, err := glfw.CreateWindow(width, height, title, nil, nil)
+
+	if err != nil {
+		panic(fmt.Errorf("could not create opengl renderer: %v", err))
+	}
+
+	win.MakeContextCurrent()
+
+	if err := gl.Init(); err != nil {
+		panic(err)
+	}
+
+	return win
+}
+
+// writeSampleCloud saves a shell of coloured stars, like 03-Stars, so there
+// is something to load when no file is given.
+func writeSampleCloud(path string, count int) error {
+	c := &pointcloud.Cloud{}
+	for c.Len() < count {
+		p := mgl32.Vec3{rand.Float32()*2 - 1, rand.Float32()*2 - 1, rand.Float32()*2 - 1}
+		if p.Len() < 0.7 || p.Len() >= 1 {
+			continue
+		}
+		c.Positions = append(c.Positions, p)
+		c.Colors = append(c.Colors, mgl32.Vec4{(p[0] + 1) / 2, (p[1] + 1) / 2, (p[2] + 1) / 2, 1})
+	}
+	return pointcloud.Save(path, c)
+}
+
+func main() {
+
+	// The thread running this, stays with this and only this.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Pick a file to load    |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	// Usage: go run . [points.ply | points.xyz | points.csv]
+	file := filepath.Join(os.TempDir(), "stars.ply")
+	if len(os.Args) > 1 {
+		file = os.Args[1]
+	} else if err := writeSampleCloud(file, 1000000); err != nil {
+		panic(err)
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |   Create a Window       |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	var windowWidth, windowHeight int = 1600, 1200
+	win := createWindow("Hello OpenGL in Go", windowWidth, windowHeight)
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |   Create the Shader     |
+	// |   (Compile & Link)      |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	var vertexShader = `
+		#version 430
+
+		uniform mat4 projection;
+		uniform mat4 camera;
+		uniform mat4 model;
+
+		layout (location = 0) in vec3 vert;
+		layout (location = 1) in vec4 vertColour;
+		out vec4 colour;
+
+		void main() {
+			gl_Position = projection * camera * model * vec4(vert, 1);
+			colour = vertColour;
+		}
+
+` + "\x00"
+
+	var fragmentShader = `
+		#version 430
+
+		in vec4 colour;
+		out vec4 outputColor;
+
+		void main() {
+			outputColor = colour;
+		}
+
+	` + "\x00"
+
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
+	if err != nil {
+		panic(err)
+	}
+	defer gl.DeleteProgram(program)
+
+	gl.UseProgram(program)
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Describe camera lens   |
+	// |  and position it        |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	cam := camera.NewPerspective(mgl32.DegToRad(45), float32(windowWidth)/float32(windowHeight), 0.01, 10)
+	cam.LookAt(mgl32.Vec3{0, 0, 3}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+	projection := cam.Projection()
+	view := cam.View()
+
+	projectionUniform := gl.GetUniformLocation(program, gl.Str("projection\x00"))
+	gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
+	cameraUniform := gl.GetUniformLocation(program, gl.Str("camera\x00"))
+	gl.UniformMatrix4fv(cameraUniform, 1, false, &view[0])
+	modelUniform := gl.GetUniformLocation(program, gl.Str("model\x00"))
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Start loading the file  |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	// The file is read on another goroutine. Each frame, Poll uploads
+	// whatever has been read so far, so the points appear as they load.
+	loader, err := pointcloud.StartLoading(file, pointcloud.ChunkSize)
+	if err != nil {
+		panic(err)
+	}
+	points := loader.NewBuffer()
+	defer points.Delete()
+	loading := true
+	fmt.Println("Loading", file)
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Let's rotate the model  |
+	// | by omega (in radians)   |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	angle := 0.0
+	omega := 0.05 * math.Pi
+	previousTime := glfw.GetTime()
+
+	gl.Enable(gl.DEPTH_TEST)
+	gl.PointSize(2)
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// | Loop until the window   |
+	// | is closed               |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	for !win.ShouldClose() {
+
+		gl.ClearColor(0, 0, 0, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		if loading {
+			done, err := loader.Poll(points)
+			if err != nil {
+				panic(err)
+			}
+			if done {
+				loading = false
+				fmt.Println("Loaded", points.Count, "points")
+			}
+		}
+
+		time := glfw.GetTime()
+		angle += omega * (time - previousTime)
+		previousTime = time
+
+		// Centre the points and scale them to fit in the unit sphere.
+		centre := points.Min.Add(points.Max).Mul(0.5)
+		radius := points.Max.Sub(points.Min).Len() / 2
+		if radius == 0 {
+			radius = 1
+		}
+		model := mgl32.HomogRotate3D(float32(angle), mgl32.Vec3{0, 1, 0}).
+			Mul4(mgl32.Scale3D(1/radius, 1/radius, 1/radius)).
+			Mul4(mgl32.Translate3D(-centre[0], -centre[1], -centre[2]))
+		gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
+
+		points.Draw()
+
+		win.SwapBuffers()
+		glfw.PollEvents()
+	}
+}
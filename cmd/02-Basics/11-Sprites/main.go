@@ -0,0 +1,189 @@
+// Draws thousands of spinning, tinted sprites from one atlas with a single
+// draw call per frame.
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"math/rand"
+	"runtime"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/atlas"
+	"github.com/purelazy/GopenGL/sprite"
+	"github.com/purelazy/GopenGL/texture"
+)
+
+const windowWidth = 1024
+const windowHeight = 768
+const spriteCount = 5000
+
+func init() {
+	// GLFW event handling must run on the main OS thread
+	runtime.LockOSThread()
+}
+
+// shape paints a size x size white shape on a transparent background, to be
+// tinted by the sprite batch. inside decides which pixels, in [-1, 1]
+// coordinates, belong to the shape.
+func shape(size int, inside func(x, y float64) bool) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			fx := 2*(float64(x)+0.5)/float64(size) - 1
+			fy := 2*(float64(y)+0.5)/float64(size) - 1
+			if inside(fx, fy) {
+				img.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+// mover is a sprite and how it moves.
+type mover struct {
+	sprite.Sprite
+	velocity mgl32.Vec2
+	spin     float32
+}
+
+func main() {
+	if err := glfw.Init(); err != nil {
+		log.Fatalln("failed to initialize glfw:", err)
+	}
+	defer glfw.Terminate()
+
+	glfw.WindowHint(glfw.Resizable, glfw.False)
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 6)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Sprites", nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	window.MakeContextCurrent()
+
+	if err := gl.Init(); err != nil {
+		panic(err)
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |     Build an Atlas      |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	// A handful of shapes in a few sizes, packed into one texture.
+	images := map[string]image.Image{}
+	for _, size := range []int{16, 32, 48} {
+		images[fmt.Sprint("circle", size)] = shape(size, func(x, y float64) bool {
+			return x*x+y*y <= 1
+		})
+		images[fmt.Sprint("diamond", size)] = shape(size, func(x, y float64) bool {
+			return math.Abs(x)+math.Abs(y) <= 1
+		})
+		images[fmt.Sprint("ring", size)] = shape(size, func(x, y float64) bool {
+			r := x*x + y*y
+			return r <= 1 && r >= 0.4
+		})
+		images[fmt.Sprint("cross", size)] = shape(size, func(x, y float64) bool {
+			return math.Abs(x) < 0.25 || math.Abs(y) < 0.25
+		})
+	}
+
+	a, err := atlas.Build(images, atlas.Options{Padding: 1, Extrude: 1})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	tex, err := a.Upload(texture.Options{Mipmaps: true})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println("Atlas", a.Image.Bounds().Size(), "holds", len(a.Regions), "images")
+
+	batch, err := sprite.NewBatch(tex)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |     Scatter Sprites     |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	names := make([]string, 0, len(a.Regions))
+	for name := range a.Regions {
+		names = append(names, name)
+	}
+
+	movers := make([]mover, spriteCount)
+	for i := range movers {
+		m := &movers[i]
+		m.Sprite = sprite.FromRegion(a.Regions[names[rand.Intn(len(names))]])
+		m.Position = mgl32.Vec2{rand.Float32() * windowWidth, rand.Float32() * windowHeight}
+		m.Depth = rand.Float32()
+		m.Tint = mgl32.Vec4{0.3 + 0.7*rand.Float32(), 0.3 + 0.7*rand.Float32(), 0.3 + 0.7*rand.Float32(), 0.8}
+		angle := rand.Float64() * 2 * math.Pi
+		speed := 20 + 80*rand.Float32()
+		m.velocity = mgl32.Vec2{float32(math.Cos(angle)) * speed, float32(math.Sin(angle)) * speed}
+		m.spin = 2*rand.Float32() - 1
+	}
+
+	// Pixel coordinates with y down, like the atlas and the window. Sprites
+	// with greater depth are nearer.
+	projection := mgl32.Ortho(0, windowWidth, windowHeight, 0, -1, 0)
+
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthFunc(gl.LEQUAL)
+	gl.ClearColor(0.1, 0.1, 0.15, 1.0)
+
+	previousTime := glfw.GetTime()
+
+	for !window.ShouldClose() {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		// Update
+		time := glfw.GetTime()
+		elapsed := float32(time - previousTime)
+		previousTime = time
+
+		batch.Begin()
+		for i := range movers {
+			m := &movers[i]
+			m.Position = m.Position.Add(m.velocity.Mul(elapsed))
+			m.Rotation += m.spin * elapsed
+
+			// Wrap around the window's edges.
+			if m.Position[0] < 0 {
+				m.Position[0] += windowWidth
+			} else if m.Position[0] > windowWidth {
+				m.Position[0] -= windowWidth
+			}
+			if m.Position[1] < 0 {
+				m.Position[1] += windowHeight
+			} else if m.Position[1] > windowHeight {
+				m.Position[1] -= windowHeight
+			}
+
+			batch.Add(m.Sprite)
+		}
+
+		// Render: one draw call for every sprite
+		batch.End(projection)
+
+		// Maintenance
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+}
@@ -0,0 +1,248 @@
+// Runs each of the parallel package's GPU primitives on random data and
+// checks the result against its CPU reference implementation.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/purelazy/GopenGL/compute"
+	"github.com/purelazy/GopenGL/parallel"
+)
+
+func init() {
+	// GLFW event handling must run on the main OS thread
+	runtime.LockOSThread()
+}
+
+// failed is set when a primitive disagrees with its CPU reference.
+var failed bool
+
+// check prints how a primitive did, and remembers a failure.
+func check(name string, start time.Time, ok bool, detail string) {
+	status := "ok"
+	if !ok {
+		status = "FAIL"
+		failed = true
+	}
+	fmt.Printf("%-24s %-4s %10v  %s\n", name, status, time.Since(start).Round(time.Microsecond), detail)
+}
+
+// near reports whether a and b are within tolerance of each other.
+func near(a, b float32, tolerance float64) bool {
+	return math.Abs(float64(a)-float64(b)) <= tolerance
+}
+
+func equal[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func main() {
+	n := flag.Int("n", 1<<20, "number of elements")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  A hidden window, for   |
+	// |  its GL context         |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	if err := glfw.Init(); err != nil {
+		log.Fatalln("failed to initialize glfw:", err)
+	}
+	defer glfw.Terminate()
+
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 6)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	win, err := glfw.CreateWindow(64, 64, "Parallel", nil, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer win.Destroy()
+	win.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		log.Fatalln(err)
+	}
+	if !compute.Supported() {
+		log.Fatalln("compute shaders need OpenGL 4.3")
+	}
+
+	k := parallel.NewKernels()
+	defer k.Delete()
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Random data            |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	r := rand.New(rand.NewSource(*seed))
+	keys := make([]uint32, *n)
+	values := make([]uint32, *n)
+	floats := make([]float32, *n)
+	flags := make([]uint32, *n)
+	for i := range keys {
+		keys[i] = r.Uint32()
+		values[i] = uint32(i)
+		floats[i] = r.Float32()*2 - 1
+		flags[i] = uint32(r.Intn(3) % 2)
+	}
+	small := make([]uint32, *n)
+	for i := range small {
+		small[i] = uint32(r.Intn(1000))
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Reduce                 |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	floatBuffer := compute.NewBuffer(floats)
+	defer floatBuffer.Delete()
+	smallBuffer := compute.NewBuffer(small)
+	defer smallBuffer.Delete()
+
+	for _, op := range []struct {
+		name string
+		op   parallel.Op
+	}{{"sum", parallel.Sum}, {"min", parallel.Min}, {"max", parallel.Max}} {
+		start := time.Now()
+		got, err := parallel.Reduce(k, op.op, floatBuffer)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		want := parallel.ReduceCPU(op.op, floats)
+		// The GPU adds in a different order, and rounding errors grow with
+		// the number of elements.
+		check("reduce "+op.name+" float", start, near(got, want, 1e-6*float64(*n)), fmt.Sprint(got, " want ", want))
+
+		start = time.Now()
+		gotUint, err := parallel.Reduce(k, op.op, smallBuffer)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		wantUint := parallel.ReduceCPU(op.op, small)
+		check("reduce "+op.name+" uint", start, gotUint == wantUint, fmt.Sprint(gotUint, " want ", wantUint))
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Scan                   |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	scanned := compute.NewBufferLen[uint32](*n)
+	defer scanned.Delete()
+	for _, inclusive := range []bool{false, true} {
+		start := time.Now()
+		if err := parallel.Scan(k, smallBuffer, scanned, inclusive); err != nil {
+			log.Fatalln(err)
+		}
+		got := scanned.Read()
+		check(fmt.Sprintf("scan inclusive=%v", inclusive), start, equal(got, parallel.ScanCPU(small, inclusive)), "")
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Sort                   |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	keyBuffer := compute.NewBuffer(keys)
+	defer keyBuffer.Delete()
+	start := time.Now()
+	if err := parallel.Sort(k, keyBuffer); err != nil {
+		log.Fatalln(err)
+	}
+	got := keyBuffer.Read()
+	want := append([]uint32(nil), keys...)
+	parallel.SortCPU(want)
+	check("sort", start, equal(got, want), "")
+
+	// Small keys, so that there are plenty of equal ones for stability.
+	pairKeys := compute.NewBuffer(small)
+	defer pairKeys.Delete()
+	pairValues := compute.NewBuffer(values)
+	defer pairValues.Delete()
+	start = time.Now()
+	if err := parallel.SortPairs(k, pairKeys, pairValues); err != nil {
+		log.Fatalln(err)
+	}
+	gotKeys, gotValues := pairKeys.Read(), pairValues.Read()
+	wantKeys, wantValues := append([]uint32(nil), small...), append([]uint32(nil), values...)
+	parallel.SortPairsCPU(wantKeys, wantValues)
+	check("sort pairs", start, equal(gotKeys, wantKeys) && equal(gotValues, wantValues), "")
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Compact                |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	flagBuffer := compute.NewBuffer(flags)
+	defer flagBuffer.Delete()
+	compacted := compute.NewBufferLen[float32](*n)
+	defer compacted.Delete()
+	start = time.Now()
+	kept, err := parallel.Compact(k, floatBuffer, flagBuffer, compacted)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	gotCompacted := make([]float32, kept)
+	compacted.ReadInto(0, gotCompacted)
+	check("compact", start, equal(gotCompacted, parallel.CompactCPU(floats, flags)), fmt.Sprint(kept, " kept"))
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Histogram              |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	bins := compute.NewBufferLen[uint32](64)
+	defer bins.Delete()
+	start = time.Now()
+	if err := parallel.Histogram(k, floatBuffer, -1, 1, bins); err != nil {
+		log.Fatalln(err)
+	}
+	check("histogram", start, equal(bins.Read(), parallel.HistogramCPU(floats, -1, 1, bins.Len)), "")
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,166 @@
+// Runs a particle system from a JSON config on the GPU: a fountain falling
+// onto the ground through curl noise turbulence, and a cloud of embers.
+//
+//	13-Particles [config.json]
+//
+// The camera orbits the origin. Space pulls the particles towards the
+// attractor above the fountain while it is held, R restarts the system and
+// Q switches between point sprites and quads.
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"runtime"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/particles"
+)
+
+const windowWidth = 1280
+const windowHeight = 720
+
+func init() {
+	// GLFW event handling must run on the main OS thread
+	runtime.LockOSThread()
+}
+
+func main() {
+	file := "fountain.json"
+	if len(os.Args) > 1 {
+		file = os.Args[1]
+	}
+	config, err := particles.LoadConfig(file)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := glfw.Init(); err != nil {
+		log.Fatalln("failed to initialize glfw:", err)
+	}
+	defer glfw.Terminate()
+
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 6)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Particles", nil, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	window.MakeContextCurrent()
+	glfw.SwapInterval(1)
+
+	if err := gl.Init(); err != nil {
+		log.Fatalln(err)
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  The particle system    |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	system, err := particles.New(config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer func() { system.Delete() }()
+
+	cam := camera.NewPerspective(mgl32.DegToRad(50), float32(windowWidth)/windowHeight, 0.1, 200)
+	width, height := window.GetFramebufferSize()
+	cam.SetViewport(width, height)
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		gl.Viewport(0, 0, int32(width), int32(height))
+		cam.SetViewport(width, height)
+	})
+
+	// The attractor is only switched on while space is held.
+	var strength float32
+	if len(config.Attractors) > 0 {
+		strength = config.Attractors[0].Strength
+		if strength == 0 {
+			strength = 60
+		}
+	}
+
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+		case glfw.KeyR:
+			system.Reset()
+		case glfw.KeyQ:
+			// The render mode is compiled in, so switch by starting again.
+			c := system.Config
+			if c.Render == "points" {
+				c.Render = "quads"
+			} else {
+				c.Render = "points"
+			}
+			next, err := particles.New(c)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			system.Delete()
+			system = next
+			fmt.Println("Drawing", c.Render)
+		}
+	})
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Render loop            |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	gl.Enable(gl.DEPTH_TEST)
+	gl.ClearColor(0.02, 0.02, 0.04, 1.0)
+
+	previousTime := glfw.GetTime()
+	lastReport := previousTime
+	for !window.ShouldClose() {
+		time := glfw.GetTime()
+		// Long frames, e.g. while the window is dragged, are cut short so
+		// the simulation stays stable.
+		dt := float32(math.Min(time-previousTime, 1.0/30))
+		previousTime = time
+
+		if len(system.Config.Attractors) > 0 {
+			system.Config.Attractors[0].Strength = 0
+			if window.GetKey(glfw.KeySpace) == glfw.Press {
+				system.Config.Attractors[0].Strength = strength
+			}
+		}
+		system.Update(dt)
+
+		angle := float32(time) * 0.2
+		cam.LookAt(mgl32.Vec3{18 * float32(math.Sin(float64(angle))), 7, 18 * float32(math.Cos(float64(angle)))},
+			mgl32.Vec3{0, 4, 0}, mgl32.Vec3{0, 1, 0})
+
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		_, height := window.GetFramebufferSize()
+		system.Draw(cam, height)
+
+		if time-lastReport >= 2 {
+			fmt.Println(system.Alive(), "particles alive")
+			lastReport = time
+		}
+
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+}
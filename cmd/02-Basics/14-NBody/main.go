@@ -0,0 +1,326 @@
+// Simulates a star field under its own gravity with the nbody package, and
+// draws it through pointcloud.Buffer like the stars of 03-Stars.
+//
+//	14-NBody -ic galaxies -n 20000
+//	14-NBody -ic plummer -solver barneshut -headless -steps 1000 -dump plummer-%04d.ply -every 100
+//
+// Headless runs print the energy drift and write the bodies to files,
+// without a window unless the GPU solver needs one. -ic may also name a
+// file a headless run dumped, to carry on from there. In the window, space
+// pauses and the scroll wheel zooms.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"runtime"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/compute"
+	"github.com/purelazy/GopenGL/nbody"
+	"github.com/purelazy/GopenGL/pointcloud"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+func init() {
+	// GLFW event handling must run on the main OS thread
+	runtime.LockOSThread()
+}
+
+// createWindow opens a window with a GL 4.6 context, hidden for headless
+// runs that only want the context.
+func createWindow(width, height int, visible bool) *glfw.Window {
+	if err := glfw.Init(); err != nil {
+		log.Fatalln("failed to initialize glfw:", err)
+	}
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 6)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	if !visible {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+	win, err := glfw.CreateWindow(width, height, "N-Body", nil, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	win.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		log.Fatalln(err)
+	}
+	return win
+}
+
+// initialConditions makes the bodies to start from.
+func initialConditions(ic string, n int, seed int64) ([]nbody.Body, error) {
+	r := rand.New(rand.NewSource(seed))
+	switch ic {
+	case "plummer":
+		return nbody.Plummer(n, 1, 1, r), nil
+	case "disk":
+		return nbody.Disk(n, 0.5, 2, 1, r), nil
+	case "galaxies":
+		return nbody.Galaxies(n, 1, 1.5, 8, r), nil
+	}
+	return nbody.Load(ic)
+}
+
+func main() {
+	ic := flag.String("ic", "galaxies", "initial conditions: plummer, disk, galaxies or a file a run dumped")
+	n := flag.Int("n", 16384, "number of bodies")
+	seed := flag.Int64("seed", 1, "random seed")
+	solverName := flag.String("solver", "auto", "direct, gpu, barneshut or auto")
+	theta := flag.Float64("theta", 0.5, "Barnes-Hut opening angle")
+	var integrator nbody.Integrator
+	flag.Var(&integrator, "integrator", "leapfrog, verlet or euler")
+	dt := flag.Float64("dt", 0.005, "time step")
+	softening := flag.Float64("softening", 0.02, "softening length")
+	headless := flag.Bool("headless", false, "run without a window")
+	steps := flag.Int("steps", 1000, "number of steps for a headless run")
+	dump := flag.String("dump", "", "file to write the bodies to, with a %d verb for the step in a headless run")
+	every := flag.Int("every", 100, "steps between dumps and energy reports; 0 for none")
+	flag.Parse()
+
+	bodies, err := initialConditions(*ic, *n, *seed)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Pick a solver          |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	name := *solverName
+	if name == "auto" {
+		// The GPU is fastest for all pairs up to a point, past which
+		// Barnes-Hut's N log N wins.
+		switch {
+		case len(bodies) > 1<<17:
+			name = "barneshut"
+		case *headless:
+			name = "direct"
+		default:
+			name = "gpu"
+		}
+	}
+
+	var win *glfw.Window
+	if !*headless || name == "gpu" {
+		win = createWindow(1200, 900, !*headless)
+		defer glfw.Terminate()
+		defer win.Destroy()
+		if *solverName == "auto" && !compute.Supported() {
+			name = "barneshut"
+		}
+	}
+
+	var solver nbody.Solver
+	switch name {
+	case "direct":
+		solver = nbody.Direct{}
+	case "barneshut":
+		solver = &nbody.BarnesHut{Theta: *theta}
+	case "gpu":
+		gpu, err := nbody.NewGPU()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer gpu.Delete()
+		solver = gpu
+	default:
+		log.Fatalf("unknown solver %q", name)
+	}
+
+	sys := nbody.NewSystem(bodies, solver)
+	sys.Integrator = integrator
+	sys.Softening = float32(*softening)
+	fmt.Printf("%d bodies, %s solver, %v integrator, dt %v\n", len(bodies), name, integrator, *dt)
+
+	report := func() {
+		fmt.Printf("t %8.3f  step %6d  energy drift %+.3e\n", sys.Time, sys.Steps, sys.Drift())
+	}
+	save := func() {
+		path := *dump
+		if strings.Contains(path, "%") {
+			path = fmt.Sprintf(path, sys.Steps)
+		}
+		if err := nbody.Save(path, sys.Bodies); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Headless run           |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	if *headless {
+		report()
+		for sys.Steps < *steps {
+			if err := sys.Step(float32(*dt)); err != nil {
+				log.Fatalln(err)
+			}
+			if *every > 0 && sys.Steps%*every == 0 {
+				report()
+				if *dump != "" {
+					save()
+				}
+			}
+		}
+		if *dump != "" && (*every <= 0 || sys.Steps%*every != 0) {
+			save()
+		}
+		return
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  The point pipeline     |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	var vertexShader = `
+		#version 430
+
+		uniform mat4 projection;
+		uniform mat4 camera;
+		uniform mat4 model;
+
+		layout (location = 0) in vec3 vert;
+		layout (location = 1) in vec4 vertColour;
+		out vec4 colour;
+
+		void main() {
+			gl_Position = projection * camera * model * vec4(vert, 1);
+			colour = vertColour;
+		}
+
+` + "\x00"
+
+	var fragmentShader = `
+		#version 430
+
+		in vec4 colour;
+		out vec4 outputColor;
+
+		void main() {
+			outputColor = vec4(colour.rgb * 0.6, 1.0);
+		}
+
+	` + "\x00"
+
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer gl.DeleteProgram(program)
+	gl.UseProgram(program)
+
+	points := pointcloud.NewBuffer(len(bodies))
+	defer points.Delete()
+	points.Append(nbody.Cloud(sys.Bodies))
+
+	// Far enough back to see most of the bodies.
+	min, max := points.Min, points.Max
+	distance := 1.5 * max.Sub(min).Len()
+	if distance > 40 || distance == 0 {
+		distance = 40
+	}
+
+	cam := camera.NewPerspective(mgl32.DegToRad(45), 4.0/3, 0.01, 1000)
+	width, height := win.GetFramebufferSize()
+	cam.SetViewport(width, height)
+	win.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		gl.Viewport(0, 0, int32(width), int32(height))
+		cam.SetViewport(width, height)
+	})
+
+	paused := false
+	win.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+		case glfw.KeySpace:
+			paused = !paused
+		}
+	})
+	win.SetScrollCallback(func(w *glfw.Window, x, y float64) {
+		distance *= float32(math.Pow(0.9, y))
+	})
+
+	projectionUniform := shader.Uniform(program, "projection")
+	cameraUniform := shader.Uniform(program, "camera")
+	modelUniform := shader.Uniform(program, "model")
+	model := mgl32.Ident4()
+	gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
+
+	// The stars add up where they bunch together.
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE)
+	gl.PointSize(2)
+	gl.ClearColor(0, 0, 0, 1)
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Render loop            |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	angle := 0.0
+	previousTime := glfw.GetTime()
+	for !win.ShouldClose() {
+		time := glfw.GetTime()
+		angle += 0.05 * (time - previousTime)
+		previousTime = time
+
+		if !paused {
+			if err := sys.Step(float32(*dt)); err != nil {
+				log.Fatalln(err)
+			}
+			if *every > 0 && sys.Steps%*every == 0 {
+				report()
+			}
+			points.Clear()
+			points.Append(nbody.Cloud(sys.Bodies))
+		}
+
+		eye := mgl32.Vec3{distance * float32(math.Sin(angle)), distance * 0.4, distance * float32(math.Cos(angle))}
+		cam.LookAt(eye, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		projection, view := cam.Projection(), cam.View()
+		gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
+		gl.UniformMatrix4fv(cameraUniform, 1, false, &view[0])
+
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		// Stepping may have left the GPU solver's program current.
+		gl.UseProgram(program)
+		points.Draw()
+
+		win.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	if *dump != "" {
+		save()
+	}
+}
@@ -0,0 +1,243 @@
+// Draws the number patterns of the numbers package: keys 1 to 5 switch
+// between the Ulam spiral, the Sacks spiral, the polar prime plot of
+// 08-UserInput, a sunflower and the Gaussian primes. The scroll wheel
+// zooms.
+//
+// With -bench it draws nothing, and instead times the segmented sieve
+// against 08-UserInput's trial division, newP.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/numbers"
+	"github.com/purelazy/GopenGL/pointcloud"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+func init() {
+	// GLFW event handling must run on the main OS thread
+	runtime.LockOSThread()
+}
+
+// newP is 08-UserInput's prime generator, copied here to be timed: it
+// returns a closure which gets the next prime each call, by trial
+// division as naïvely as possible.
+func newP() func() int {
+	n := 1
+	return func() int {
+		for {
+			n++
+			for f := 2; ; f++ {
+				if f == n {
+					return n
+				}
+				if n%f == 0 {
+					break
+				}
+			}
+		}
+	}
+}
+
+// bench times newP and the sieve finding the first n primes, checks they
+// agree, and then times the sieve and the patterns at sizes trial division
+// could not reach.
+func bench(n int) {
+	start := time.Now()
+	prime := newP()
+	naive := make([]int, n)
+	for i := range naive {
+		naive[i] = prime()
+	}
+	naiveTime := time.Since(start)
+
+	start = time.Now()
+	sieved := numbers.Primes(n)
+	sieveTime := time.Since(start)
+
+	for i := range naive {
+		if naive[i] != sieved[i] {
+			log.Fatalf("prime %d: newP says %d, the sieve %d", i, naive[i], sieved[i])
+		}
+	}
+	fmt.Printf("first %d primes: newP %v, sieve %v, %.0f times faster\n",
+		n, naiveTime.Round(time.Microsecond), sieveTime.Round(time.Microsecond),
+		float64(naiveTime)/float64(sieveTime))
+
+	for _, limit := range []int{1e6, 1e7, 1e8} {
+		start = time.Now()
+		primes := numbers.PrimesBelow(limit)
+		fmt.Printf("primes below %-9d %8d in %v\n", limit, len(primes), time.Since(start).Round(time.Microsecond))
+	}
+
+	for _, p := range []struct {
+		name string
+		make func() *pointcloud.Cloud
+	}{
+		{"ulam 4000²", func() *pointcloud.Cloud { return numbers.Ulam(4000) }},
+		{"sacks 1e8", func() *pointcloud.Cloud { return numbers.Sacks(1e8) }},
+		{"polar 1e8", func() *pointcloud.Cloud { return numbers.Polar(1e8) }},
+		{"phyllotaxis 4e6", func() *pointcloud.Cloud { return numbers.Phyllotaxis(4e6, numbers.GoldenAngle, 34) }},
+		{"gaussian 3000", func() *pointcloud.Cloud { return numbers.GaussianPrimes(3000) }},
+	} {
+		start = time.Now()
+		c := p.make()
+		fmt.Printf("%-16s %8d points in %v\n", p.name, c.Len(), time.Since(start).Round(time.Microsecond))
+	}
+}
+
+func main() {
+	benchmark := flag.Bool("bench", false, "time the sieve against newP instead of drawing")
+	benchN := flag.Int("n", 10000, "number of primes for newP to find in the benchmark")
+	flag.Parse()
+
+	if *benchmark {
+		bench(*benchN)
+		return
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |   Create a Window       |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	if err := glfw.Init(); err != nil {
+		log.Fatalln("failed to initialize glfw:", err)
+	}
+	defer glfw.Terminate()
+
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 6)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	win, err := glfw.CreateWindow(1000, 1000, "Number Patterns", nil, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	win.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		log.Fatalln(err)
+	}
+
+	var vertexShader = `
+		#version 430
+
+		uniform mat4 projection;
+
+		layout (location = 0) in vec3 vert;
+		layout (location = 1) in vec4 vertColour;
+		out vec4 colour;
+
+		void main() {
+			gl_Position = projection * vec4(vert, 1);
+			colour = vertColour;
+		}
+
+` + "\x00"
+
+	var fragmentShader = `
+		#version 430
+
+		in vec4 colour;
+		out vec4 outputColor;
+
+		void main() {
+			outputColor = colour;
+		}
+
+	` + "\x00"
+
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer gl.DeleteProgram(program)
+	gl.UseProgram(program)
+	projectionUniform := shader.Uniform(program, "projection")
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  The patterns           |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	patterns := []struct {
+		name string
+		make func() *pointcloud.Cloud
+	}{
+		{"Ulam spiral", func() *pointcloud.Cloud { return numbers.Ulam(2000) }},
+		{"Sacks spiral", func() *pointcloud.Cloud { return numbers.Sacks(4e6) }},
+		{"Polar primes", func() *pointcloud.Cloud { return numbers.Polar(4e6) }},
+		{"Sunflower", func() *pointcloud.Cloud { return numbers.Phyllotaxis(1e6, numbers.GoldenAngle, 34) }},
+		{"Gaussian primes", func() *pointcloud.Cloud { return numbers.GaussianPrimes(1500) }},
+	}
+
+	points := pointcloud.NewBuffer(1 << 20)
+	defer points.Delete()
+	show := func(i int) {
+		start := time.Now()
+		c := patterns[i].make()
+		points.Clear()
+		points.Append(c)
+		fmt.Printf("%s: %d points in %v\n", patterns[i].name, c.Len(), time.Since(start).Round(time.Millisecond))
+	}
+	show(0)
+
+	zoom := float32(1)
+	win.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		if key == glfw.KeyEscape {
+			w.SetShouldClose(true)
+		}
+		if i := int(key - glfw.Key1); i >= 0 && i < len(patterns) {
+			show(i)
+		}
+	})
+	win.SetScrollCallback(func(w *glfw.Window, x, y float64) {
+		zoom *= float32(math.Pow(1.1, y))
+	})
+	win.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	gl.ClearColor(0, 0, 0, 1)
+	gl.PointSize(1)
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Render loop            |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	for !win.ShouldClose() {
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+
+		// Keep the pattern square whatever the window's shape.
+		width, height := win.GetFramebufferSize()
+		aspect := float32(width) / float32(math.Max(1, float64(height)))
+		projection := mgl32.Ortho2D(-aspect/zoom, aspect/zoom, -1/zoom, 1/zoom)
+		gl.UniformMatrix4fv(projectionUniform, 1, false, &projection[0])
+		points.Draw()
+
+		win.SwapBuffers()
+		glfw.PollEvents()
+	}
+}
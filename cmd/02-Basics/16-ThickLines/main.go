@@ -0,0 +1,230 @@
+// Draws thick, anti-aliased lines with the lines package. Key 1 shows
+// zigzags, a spiral that widens and changes colour as it goes, and a
+// dashed circle whose dashes march; key 2 shows a Lorenz attractor of
+// -n segments, turning in 3D, and prints the frame time.
+//
+// J cycles the joins between miter, round and bevel, C the caps between
+// butt, round and square, and D dashes the zigzags.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"runtime"
+	"time"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/lines"
+	"github.com/purelazy/GopenGL/paths"
+)
+
+func init() {
+	// GLFW event handling must run on the main OS thread
+	runtime.LockOSThread()
+}
+
+// zigzag returns a zigzag from x0 to x1 at height y, its corners turning
+// more sharply from left to right.
+func zigzag(x0, x1, y float32) []mgl32.Vec3 {
+	var p []mgl32.Vec3
+	const n = 10
+	for i := 0; i <= n; i++ {
+		x := x0 + (x1-x0)*float32(i)/n
+		h := float32(10 + 8*i)
+		if i%2 == 1 {
+			h = -h
+		}
+		p = append(p, mgl32.Vec3{x, y + h, 0})
+	}
+	return p
+}
+
+// spiral returns a spiral about centre that widens from 1 to 24 pixels and
+// turns from blue to red as it winds out.
+func spiral(centre mgl32.Vec3) []lines.Vertex {
+	const n = 400
+	v := make([]lines.Vertex, n)
+	for i := range v {
+		t := float32(i) / n
+		a := float64(t) * 6 * math.Pi
+		r := 10 + 150*t
+		v[i] = lines.Vertex{
+			Position: centre.Add(mgl32.Vec3{r * float32(math.Cos(a)), r * float32(math.Sin(a)), 0}),
+			Width:    1 + 23*t,
+			Color:    mgl32.Vec4{t, 0.3, 1 - t, 1},
+		}
+	}
+	return v
+}
+
+// circle returns a closed circle of radius r about centre.
+func circle(centre mgl32.Vec3, r float32) []mgl32.Vec3 {
+	const n = 128
+	p := make([]mgl32.Vec3, n+1)
+	for i := range p {
+		a := 2 * math.Pi * float64(i) / n
+		p[i] = centre.Add(mgl32.Vec3{r * float32(math.Cos(a)), r * float32(math.Sin(a)), 0})
+	}
+	return p
+}
+
+// attractor returns a Lorenz attractor of n segments, fitted into a cube of
+// side 2 and coloured along its length.
+func attractor(n int) []lines.Vertex {
+	walk := paths.Lorenz(rand.New(rand.NewSource(1)), n, 0.002)
+	walk.Fit(2)
+	v := make([]lines.Vertex, len(walk))
+	for i, p := range walk {
+		t := float32(i) / float32(len(walk))
+		v[i] = lines.Vertex{
+			Position: p,
+			Width:    1.5,
+			Color:    mgl32.Vec4{0.3 + 0.7*t, 0.6, 1 - 0.7*t, 0.8},
+		}
+	}
+	return v
+}
+
+func main() {
+	segments := flag.Int("n", 500000, "number of segments in the attractor")
+	flag.Parse()
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |   Create a Window       |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	if err := glfw.Init(); err != nil {
+		log.Fatalln("failed to initialize glfw:", err)
+	}
+	defer glfw.Terminate()
+
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 6)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	win, err := glfw.CreateWindow(1000, 800, "Thick Lines", nil, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	win.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		log.Fatalln(err)
+	}
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  The lines              |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	// The 2D shapes are laid out in pixels, from the bottom-left corner.
+	shapes, err := lines.New()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer shapes.Delete()
+	white := mgl32.Vec4{1, 1, 1, 1}
+	shapes.AddLine(zigzag(60, 940, 700), 4, white)
+	shapes.AddLine(zigzag(60, 940, 580), 12, white)
+	shapes.AddLine(zigzag(60, 940, 420), 28, mgl32.Vec4{1, 0.8, 0.3, 0.7})
+	shapes.AddStrip(spiral(mgl32.Vec3{260, 180, 0}))
+
+	dashed, err := lines.New()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer dashed.Delete()
+	dashed.Cap = lines.CapRound
+	dashed.Dash = []float32{30, 15, 5, 15}
+	dashed.AddLine(circle(mgl32.Vec3{700, 180, 0}, 140), 6, mgl32.Vec4{0.4, 1, 0.5, 1})
+
+	start := time.Now()
+	lorenz, err := lines.New()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer lorenz.Delete()
+	lorenz.AddStrip(attractor(*segments))
+	fmt.Printf("attractor: %d segments in %v\n", *segments, time.Since(start).Round(time.Millisecond))
+
+	scene := 1
+	win.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+		case glfw.Key1, glfw.Key2:
+			scene = int(key-glfw.Key1) + 1
+		case glfw.KeyJ:
+			shapes.Join = (shapes.Join + 1) % 3
+			lorenz.Join = shapes.Join
+			fmt.Println("join:", shapes.Join)
+		case glfw.KeyC:
+			shapes.Cap = (shapes.Cap + 1) % 3
+			fmt.Println("cap:", shapes.Cap)
+		case glfw.KeyD:
+			if shapes.Dash == nil {
+				shapes.Dash = []float32{20, 10}
+			} else {
+				shapes.Dash = nil
+			}
+		}
+	})
+	win.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	gl.ClearColor(0.05, 0.05, 0.08, 1)
+
+	//              |
+	// +-------------------------+
+	// |                         |
+	// |  Render loop            |
+	// |                         |
+	// +-------------------------+
+	//              |
+
+	frames, second := 0, time.Now()
+	for !win.ShouldClose() {
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		width, height := win.GetFramebufferSize()
+		t := float32(glfw.GetTime())
+
+		switch scene {
+		case 1:
+			pixels := mgl32.Ortho2D(0, float32(width), 0, float32(height))
+			shapes.Draw(pixels)
+			dashed.DashOffset = -20 * t
+			dashed.Draw(pixels)
+		case 2:
+			aspect := float32(width) / float32(math.Max(1, float64(height)))
+			projection := mgl32.Perspective(mgl32.DegToRad(45), aspect, 0.1, 10)
+			view := mgl32.LookAtV(mgl32.Vec3{0, 0, 3.5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+			model := mgl32.HomogRotate3DY(0.3 * t)
+			lorenz.Draw(projection.Mul4(view).Mul4(model))
+
+			frames++
+			if time.Since(second) >= time.Second {
+				gl.Finish()
+				fmt.Printf("%.2f ms a frame\n", 1000*time.Since(second).Seconds()/float64(frames))
+				frames, second = 0, time.Now()
+			}
+		}
+
+		win.SwapBuffers()
+		glfw.PollEvents()
+	}
+}
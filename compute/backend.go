@@ -0,0 +1,68 @@
+package compute
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Backend is where a Kernel runs.
+type Backend int
+
+// Backends. Auto picks GPU when it can, and CPU otherwise.
+const (
+	Auto Backend = iota
+	GPU
+	CPU
+)
+
+func (b Backend) String() string {
+	switch b {
+	case GPU:
+		return "gpu"
+	case CPU:
+		return "cpu"
+	}
+	return "auto"
+}
+
+// Set parses "auto", "gpu" or "cpu", so that a Backend can be a flag:
+//
+//	var backend compute.Backend
+//	flag.Var(&backend, "backend", "auto, gpu or cpu")
+func (b *Backend) Set(s string) error {
+	switch s {
+	case "auto":
+		*b = Auto
+	case "gpu":
+		*b = GPU
+	case "cpu":
+		*b = CPU
+	default:
+		return fmt.Errorf("compute: unknown backend %q, want auto, gpu or cpu", s)
+	}
+	return nil
+}
+
+// contextLoaded is set by SetContext.
+var contextLoaded atomic.Bool
+
+// SetContext records whether there is a current GL context whose functions
+// gl.Init has loaded. Auto needs to know, as it cannot ask GL whether
+// compute shaders are supported before then; until told otherwise it
+// picks CPU.
+func SetContext(loaded bool) {
+	contextLoaded.Store(loaded)
+}
+
+// Resolve returns the backend to run on: b itself, unless it is Auto, in
+// which case GPU if SetContext has recorded a context that can run compute
+// shaders, and CPU if not.
+func (b Backend) Resolve() Backend {
+	if b != Auto {
+		return b
+	}
+	if contextLoaded.Load() && Supported() {
+		return GPU
+	}
+	return CPU
+}
@@ -0,0 +1,42 @@
+package compute
+
+import "github.com/go-gl/gl/v4.6-core/gl"
+
+// Writes by a compute shader are only guaranteed to be visible to later
+// work after a MemoryBarrier naming how that work will read them. pending
+// holds the barrier bits still owed for the buffers and images dispatches
+// have bound since, so that each use waits once and only when it has to.
+var pending uint32
+
+// bufferUses are the ways a buffer written by a shader can be read later.
+const bufferUses = gl.SHADER_STORAGE_BARRIER_BIT |
+	gl.BUFFER_UPDATE_BARRIER_BIT |
+	gl.VERTEX_ATTRIB_ARRAY_BARRIER_BIT |
+	gl.ELEMENT_ARRAY_BARRIER_BIT |
+	gl.UNIFORM_BARRIER_BIT |
+	gl.COMMAND_BARRIER_BIT |
+	gl.PIXEL_BUFFER_BARRIER_BIT |
+	gl.TRANSFORM_FEEDBACK_BARRIER_BIT |
+	gl.ATOMIC_COUNTER_BARRIER_BIT
+
+// imageUses are the ways an image written by a shader can be read later.
+const imageUses = gl.SHADER_IMAGE_ACCESS_BARRIER_BIT |
+	gl.TEXTURE_FETCH_BARRIER_BIT |
+	gl.TEXTURE_UPDATE_BARRIER_BIT |
+	gl.FRAMEBUFFER_BARRIER_BIT
+
+// Barrier waits for earlier dispatches' writes before the given uses, a
+// combination of gl.*_BARRIER_BIT values. It issues a MemoryBarrier only
+// for the uses a dispatch has left pending.
+//
+// Dispatch, and Buffer's Read and Write, call it for their own uses. Call
+// it before using results any other way, e.g. with
+// gl.VERTEX_ATTRIB_ARRAY_BARRIER_BIT before drawing from a buffer a
+// dispatch filled, or gl.TEXTURE_FETCH_BARRIER_BIT before sampling an
+// image it wrote.
+func Barrier(uses uint32) {
+	if bits := uses & pending; bits != 0 {
+		gl.MemoryBarrier(bits)
+		pending &^= bits
+	}
+}
@@ -0,0 +1,93 @@
+package compute
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Buffer is a GL buffer holding Len values of type T, for compute shaders
+// to read and write as shader storage.
+//
+// T must match the std430 layout of the shader's block. Scalars, vec2 and
+// vec4 map directly onto float32, [2]float32 and [4]float32, but a vec3 is
+// aligned to 16 bytes, so use [4]float32 or pad structs to match.
+type Buffer[T any] struct {
+	ID  uint32
+	Len int
+}
+
+// NewBuffer creates a buffer holding a copy of data. It needs a current GL
+// context.
+func NewBuffer[T any](data []T) *Buffer[T] {
+	b := &Buffer[T]{Len: len(data)}
+	gl.CreateBuffers(1, &b.ID)
+	gl.NamedBufferData(b.ID, b.Size(), pointer(data), gl.DYNAMIC_COPY)
+	return b
+}
+
+// NewBufferLen creates a buffer of n zero values.
+func NewBufferLen[T any](n int) *Buffer[T] {
+	return NewBuffer(make([]T, n))
+}
+
+// pointer returns a pointer to the first element of data, or nil if there
+// is none.
+func pointer[T any](data []T) unsafe.Pointer {
+	if len(data) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&data[0])
+}
+
+// elementSize returns the size of T in bytes.
+func elementSize[T any]() int {
+	var zero T
+	return int(unsafe.Sizeof(zero))
+}
+
+// Size returns the size of the buffer in bytes.
+func (b *Buffer[T]) Size() int {
+	return b.Len * elementSize[T]()
+}
+
+// Write copies data into the buffer from element offset on. It waits for
+// dispatches that may have written the buffer.
+func (b *Buffer[T]) Write(offset int, data []T) {
+	if len(data) == 0 {
+		return
+	}
+	Barrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+	size := elementSize[T]()
+	gl.NamedBufferSubData(b.ID, offset*size, len(data)*size, pointer(data))
+}
+
+// Read returns the buffer's values. It waits for dispatches that may have
+// written them.
+func (b *Buffer[T]) Read() []T {
+	data := make([]T, b.Len)
+	b.ReadInto(0, data)
+	return data
+}
+
+// ReadInto copies len(dst) values, from element offset on, into dst.
+func (b *Buffer[T]) ReadInto(offset int, dst []T) {
+	if len(dst) == 0 {
+		return
+	}
+	Barrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+	size := elementSize[T]()
+	gl.GetNamedBufferSubData(b.ID, offset*size, len(dst)*size, pointer(dst))
+}
+
+// Resize reallocates the buffer to hold n zero values. Its contents are
+// lost.
+func (b *Buffer[T]) Resize(n int) {
+	b.Len = n
+	gl.NamedBufferData(b.ID, b.Size(), pointer(make([]T, n)), gl.DYNAMIC_COPY)
+}
+
+// Delete frees the buffer.
+func (b *Buffer[T]) Delete() {
+	gl.DeleteBuffers(1, &b.ID)
+}
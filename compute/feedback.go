@@ -0,0 +1,266 @@
+package compute
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+// Varying is a shader output recorded by a TransformFeedback.
+type Varying struct {
+	Name string
+	// Type is the GLSL type, such as gl.FLOAT_VEC3.
+	Type uint32
+	// Size is the array length, 1 if it is not an array.
+	Size int32
+	// Bytes is the space the varying takes in a record.
+	Bytes int
+}
+
+// TransformFeedback runs a vertex shader, and optionally a geometry shader,
+// over vertices and records their outputs as values of T, without drawing
+// anything. It works on contexts too old for compute shaders.
+//
+// T holds the varyings packed in the order they were named, so
+//
+//	out float root;
+//	out vec2 pair;
+//
+// are recorded as struct { Root float32; Pair [2]float32 }, whether the
+// mode is interleaved or separate.
+type TransformFeedback[T any] struct {
+	Program uint32
+	// Mode is gl.INTERLEAVED_ATTRIBS, with every varying in one buffer, or
+	// gl.SEPARATE_ATTRIBS, with a buffer for each.
+	Mode     uint32
+	Varyings []Varying
+	// Primitive is the kind of primitive the vertices are drawn as,
+	// gl.POINTS unless it is changed.
+	Primitive uint32
+
+	// Buffers holds what was recorded: one buffer, or one per varying, each
+	// with room for Capacity records.
+	Buffers  []uint32
+	Capacity int
+	// Written is the number of records the last run wrote.
+	Written int
+
+	geometry      bool
+	verticesOut   int32
+	geometryMode  uint32
+	query         uint32
+	varyingOffset []int
+}
+
+// NewTransformFeedback links a program from a vertex shader and, if
+// geometrySource is not empty, a geometry shader, that records the named
+// varyings. mode is gl.INTERLEAVED_ATTRIBS or gl.SEPARATE_ATTRIBS. It needs
+// a current GL context.
+func NewTransformFeedback[T any](vertexSource, geometrySource string, varyings []string, mode uint32) (*TransformFeedback[T], error) {
+	if len(varyings) == 0 {
+		return nil, fmt.Errorf("compute: transform feedback has no varyings")
+	}
+	vs, err := shader.Compile(vertexSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return nil, fmt.Errorf("compute: %v", err)
+	}
+	shaders := []uint32{vs}
+	if geometrySource != "" {
+		gs, err := shader.Compile(geometrySource, gl.GEOMETRY_SHADER)
+		if err != nil {
+			gl.DeleteShader(vs)
+			return nil, fmt.Errorf("compute: %v", err)
+		}
+		shaders = append(shaders, gs)
+	}
+	program, err := shader.LinkFeedback(varyings, mode, shaders...)
+	if err != nil {
+		return nil, fmt.Errorf("compute: %v", err)
+	}
+
+	tf := &TransformFeedback[T]{
+		Program:   program,
+		Mode:      mode,
+		Primitive: gl.POINTS,
+		geometry:  geometrySource != "",
+	}
+	if err := tf.reflect(); err != nil {
+		gl.DeleteProgram(program)
+		return nil, err
+	}
+	if tf.geometry {
+		var out, outputType int32
+		gl.GetProgramiv(program, gl.GEOMETRY_VERTICES_OUT, &out)
+		gl.GetProgramiv(program, gl.GEOMETRY_OUTPUT_TYPE, &outputType)
+		tf.verticesOut = out
+		tf.geometryMode = uint32(outputType)
+	}
+
+	count := 1
+	if mode == gl.SEPARATE_ATTRIBS {
+		count = len(tf.Varyings)
+	}
+	tf.Buffers = make([]uint32, count)
+	gl.CreateBuffers(int32(count), &tf.Buffers[0])
+	gl.GenQueries(1, &tf.query)
+	return tf, nil
+}
+
+// reflect asks the program for its varyings, and checks they fill T.
+func (tf *TransformFeedback[T]) reflect() error {
+	var count, maxLength int32
+	gl.GetProgramiv(tf.Program, gl.TRANSFORM_FEEDBACK_VARYINGS, &count)
+	gl.GetProgramiv(tf.Program, gl.TRANSFORM_FEEDBACK_VARYING_MAX_LENGTH, &maxLength)
+
+	name := make([]uint8, maxLength+1)
+	offset := 0
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetTransformFeedbackVarying(tf.Program, uint32(i), int32(len(name)), &length, &size, &xtype, &name[0])
+		v := Varying{Name: string(name[:length]), Type: xtype, Size: size}
+		t, ok := types[xtype]
+		if !ok {
+			return fmt.Errorf("compute: varying %q has type 0x%X, which cannot be recorded", v.Name, xtype)
+		}
+		v.Bytes = t.bytes() * int(size)
+		tf.Varyings = append(tf.Varyings, v)
+		tf.varyingOffset = append(tf.varyingOffset, offset)
+		offset += v.Bytes
+	}
+
+	if size := elementSize[T](); offset != size {
+		return fmt.Errorf("compute: varyings take %d bytes, but a record holds %d", offset, size)
+	}
+	return nil
+}
+
+// feedbackMode returns the primitive mode BeginTransformFeedback needs, and
+// the number of vertices recorded for each primitive.
+func (tf *TransformFeedback[T]) feedbackMode() (mode uint32, vertices int) {
+	primitive := tf.Primitive
+	if tf.geometry {
+		primitive = tf.geometryMode
+	}
+	switch primitive {
+	case gl.LINES, gl.LINE_STRIP, gl.LINE_LOOP:
+		return gl.LINES, 2
+	case gl.TRIANGLES, gl.TRIANGLE_STRIP, gl.TRIANGLE_FAN:
+		return gl.TRIANGLES, 3
+	}
+	return gl.POINTS, 1
+}
+
+// maxRecords returns the most records drawing count vertices can write.
+func (tf *TransformFeedback[T]) maxRecords(count int) int {
+	_, vertices := tf.feedbackMode()
+	if tf.geometry {
+		// Strips are recorded as separate primitives.
+		return count * int(tf.verticesOut) * vertices
+	}
+	return count * vertices
+}
+
+// Reserve makes room in Buffers for at least n records. Their contents are
+// lost if they grow.
+func (tf *TransformFeedback[T]) Reserve(n int) {
+	if n <= tf.Capacity {
+		return
+	}
+	tf.Capacity = n
+	if tf.Mode != gl.SEPARATE_ATTRIBS {
+		gl.NamedBufferData(tf.Buffers[0], n*elementSize[T](), nil, gl.DYNAMIC_COPY)
+		return
+	}
+	for i, v := range tf.Varyings {
+		gl.NamedBufferData(tf.Buffers[i], n*v.Bytes, nil, gl.DYNAMIC_COPY)
+	}
+}
+
+// Run draws count vertices from first on, from the vertex array vao such
+// as an Input's, and returns what was recorded.
+func (tf *TransformFeedback[T]) Run(vao uint32, first, count int) []T {
+	tf.Record(vao, first, count)
+	return tf.Read(tf.Written)
+}
+
+// Record is Run without reading the results back, for drawing them from
+// Buffers or feeding them to another pass. It sets Written.
+func (tf *TransformFeedback[T]) Record(vao uint32, first, count int) {
+	tf.Reserve(tf.maxRecords(count))
+	tf.Written = tf.record(vao, first, count, tf.Buffers)
+}
+
+// record draws count vertices from vao into buffers, and returns the
+// number of records written.
+func (tf *TransformFeedback[T]) record(vao uint32, first, count int, buffers []uint32) int {
+	if count <= 0 {
+		return 0
+	}
+	mode, vertices := tf.feedbackMode()
+
+	discard := gl.IsEnabled(gl.RASTERIZER_DISCARD)
+	gl.Enable(gl.RASTERIZER_DISCARD)
+	gl.UseProgram(tf.Program)
+	gl.BindVertexArray(vao)
+	for i, b := range buffers {
+		gl.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, uint32(i), b)
+	}
+
+	gl.BeginQuery(gl.TRANSFORM_FEEDBACK_PRIMITIVES_WRITTEN, tf.query)
+	gl.BeginTransformFeedback(mode)
+	gl.DrawArrays(tf.Primitive, int32(first), int32(count))
+	gl.EndTransformFeedback()
+	gl.EndQuery(gl.TRANSFORM_FEEDBACK_PRIMITIVES_WRITTEN)
+
+	for i := range buffers {
+		gl.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, uint32(i), 0)
+	}
+	gl.BindVertexArray(0)
+	if !discard {
+		gl.Disable(gl.RASTERIZER_DISCARD)
+	}
+
+	var primitives uint32
+	gl.GetQueryObjectuiv(tf.query, gl.QUERY_RESULT, &primitives)
+	return int(primitives) * vertices
+}
+
+// Read returns the first n records in Buffers.
+func (tf *TransformFeedback[T]) Read(n int) []T {
+	return tf.read(tf.Buffers, n)
+}
+
+// read returns the first n records in buffers, gathering the varyings
+// into each record in separate mode.
+func (tf *TransformFeedback[T]) read(buffers []uint32, n int) []T {
+	records := make([]T, n)
+	if n == 0 {
+		return records
+	}
+	if tf.Mode != gl.SEPARATE_ATTRIBS {
+		gl.GetNamedBufferSubData(buffers[0], 0, n*elementSize[T](), pointer(records))
+		return records
+	}
+
+	stride := elementSize[T]()
+	out := unsafe.Slice((*byte)(pointer(records)), n*stride)
+	for i, v := range tf.Varyings {
+		column := make([]byte, n*v.Bytes)
+		gl.GetNamedBufferSubData(buffers[i], 0, len(column), pointer(column))
+		offset := tf.varyingOffset[i]
+		for r := 0; r < n; r++ {
+			copy(out[r*stride+offset:r*stride+offset+v.Bytes], column[r*v.Bytes:(r+1)*v.Bytes])
+		}
+	}
+	return records
+}
+
+// Delete frees the program, buffers and query.
+func (tf *TransformFeedback[T]) Delete() {
+	gl.DeleteQueries(1, &tf.query)
+	gl.DeleteBuffers(int32(len(tf.Buffers)), &tf.Buffers[0])
+	gl.DeleteProgram(tf.Program)
+}
@@ -0,0 +1,82 @@
+package compute
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Input is vertex data for a vertex shader to read, such as the values a
+// TransformFeedback transforms: a buffer of Len vertices and a vertex
+// array that feeds it to the shader's inputs.
+type Input struct {
+	VAO, Buffer uint32
+	Len         int
+}
+
+// NewInput uploads data and points the named inputs of program at it. The
+// inputs are packed into I in the order given, with their types taken from
+// the program, so
+//
+//	in vec3 position;
+//	in float age;
+//
+// reads from a struct { Position [3]float32; Age float32 }. It needs a
+// current GL context.
+func NewInput[I any](program uint32, data []I, inputs ...string) (*Input, error) {
+	in := &Input{Len: len(data)}
+	gl.CreateBuffers(1, &in.Buffer)
+	gl.NamedBufferData(in.Buffer, len(data)*elementSize[I](), pointer(data), gl.STATIC_DRAW)
+	gl.GenVertexArrays(1, &in.VAO)
+	if err := setInputs(program, in.VAO, in.Buffer, elementSize[I](), inputs); err != nil {
+		in.Delete()
+		return nil, err
+	}
+	return in, nil
+}
+
+// setInputs points the named inputs of program at buffer, through vao,
+// packed in order into vertices of stride bytes.
+func setInputs(program, vao, buffer uint32, stride int, inputs []string) error {
+	gl.BindVertexArray(vao)
+	defer gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer)
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	offset := 0
+	for _, name := range inputs {
+		index := gl.GetProgramResourceIndex(program, gl.PROGRAM_INPUT, gl.Str(name+"\x00"))
+		if index == gl.INVALID_INDEX {
+			return fmt.Errorf("compute: input %q is not in program %d", name, program)
+		}
+		props := []uint32{gl.TYPE, gl.LOCATION}
+		values := make([]int32, len(props))
+		gl.GetProgramResourceiv(program, gl.PROGRAM_INPUT, index, int32(len(props)), &props[0], int32(len(values)), nil, &values[0])
+
+		t, ok := types[uint32(values[0])]
+		if !ok || t.components > 4 {
+			return fmt.Errorf("compute: input %q has type 0x%X, which is not a scalar or vector", name, values[0])
+		}
+		location := uint32(values[1])
+		gl.EnableVertexAttribArray(location)
+		switch t.base {
+		case gl.FLOAT:
+			gl.VertexAttribPointer(location, t.components, t.base, false, int32(stride), gl.PtrOffset(offset))
+		case gl.DOUBLE:
+			gl.VertexAttribLPointer(location, t.components, t.base, int32(stride), gl.PtrOffset(offset))
+		default:
+			gl.VertexAttribIPointer(location, t.components, t.base, int32(stride), gl.PtrOffset(offset))
+		}
+		offset += t.bytes()
+	}
+	if offset > stride {
+		return fmt.Errorf("compute: inputs %v take %d bytes, more than the %d of a vertex", inputs, offset, stride)
+	}
+	return nil
+}
+
+// Delete frees the buffer and vertex array.
+func (in *Input) Delete() {
+	gl.DeleteVertexArrays(1, &in.VAO)
+	gl.DeleteBuffers(1, &in.Buffer)
+}
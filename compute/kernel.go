@@ -0,0 +1,156 @@
+package compute
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Kernel is a computation with two implementations, a compute shader and a
+// Go function, that map an input slice to an output slice of the same
+// length. Run uses whichever Backend selects, so the same code works with
+// and without compute shader support.
+type Kernel[In, Out any] struct {
+	// Source is the compute shader. It reads the storage block Input and
+	// writes the storage block Output, with an invocation for each element,
+	// whose number it is given as
+	//
+	//	uniform uvec3 invocations;
+	Source string
+	// Func computes out[i]. It is called for every index of in, from as many
+	// goroutines as there are CPUs, so it must write nothing but out[i].
+	Func func(in []In, out []Out, i int)
+	// Setup, if it is not nil, sets the shader's other uniforms before each
+	// dispatch.
+	Setup func(p *Program)
+	// Backend is where Run runs the kernel.
+	Backend Backend
+
+	program *Program
+}
+
+// Run computes the output for in on the kernel's backend.
+func (k *Kernel[In, Out]) Run(in []In) ([]Out, error) {
+	if k.Backend.Resolve() == GPU {
+		return k.RunGPU(in)
+	}
+	return k.RunCPU(in), nil
+}
+
+// RunGPU computes the output for in with the compute shader, which is
+// compiled the first time. It needs a current GL context with compute
+// shaders.
+func (k *Kernel[In, Out]) RunGPU(in []In) ([]Out, error) {
+	if k.program == nil {
+		p, err := NewProgram(k.Source)
+		if err != nil {
+			return nil, err
+		}
+		k.program = p
+	}
+	if len(in) == 0 {
+		return []Out{}, nil
+	}
+
+	input := NewBuffer(in)
+	defer input.Delete()
+	output := NewBufferLen[Out](len(in))
+	defer output.Delete()
+	if err := k.program.BindBuffer("Input", input.ID); err != nil {
+		return nil, err
+	}
+	if err := k.program.BindBuffer("Output", output.ID); err != nil {
+		return nil, err
+	}
+	if k.Setup != nil {
+		k.Setup(k.program)
+	}
+	k.program.Dispatch(len(in), 1, 1)
+	return output.Read(), nil
+}
+
+// RunCPU computes the output for in with Func, across goroutines.
+func (k *Kernel[In, Out]) RunCPU(in []In) []Out {
+	out := make([]Out, len(in))
+	ParallelFor(len(in), func(i int) {
+		k.Func(in, out, i)
+	})
+	return out
+}
+
+// Check runs the kernel on both backends and returns an error naming the
+// first output where the two do not agree, as decided by equal. It needs
+// a current GL context with compute shaders.
+func (k *Kernel[In, Out]) Check(in []In, equal func(gpu, cpu Out) bool) error {
+	gpu, err := k.RunGPU(in)
+	if err != nil {
+		return err
+	}
+	cpu := k.RunCPU(in)
+	for i := range cpu {
+		if !equal(gpu[i], cpu[i]) {
+			return fmt.Errorf("compute: output %d is %v on the GPU but %v on the CPU", i, gpu[i], cpu[i])
+		}
+	}
+	return nil
+}
+
+// Delete frees the compute shader, if it was compiled.
+func (k *Kernel[In, Out]) Delete() {
+	if k.program != nil {
+		k.program.Delete()
+		k.program = nil
+	}
+}
+
+// ParallelFor calls f for every i in [0, n), splitting the range between
+// as many goroutines as there are CPUs, and returns when all are done.
+func ParallelFor(n int, f func(i int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				f(i)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// Within returns an equal function for Check that accepts float32 results
+// that differ by at most tolerance, relative to the CPU's when that is
+// larger than 1.
+func Within(tolerance float32) func(gpu, cpu float32) bool {
+	return func(gpu, cpu float32) bool {
+		d := gpu - cpu
+		if d < 0 {
+			d = -d
+		}
+		scale := cpu
+		if scale < 0 {
+			scale = -scale
+		}
+		if scale < 1 {
+			scale = 1
+		}
+		return d <= tolerance*scale
+	}
+}
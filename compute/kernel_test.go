@@ -0,0 +1,229 @@
+package compute
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/purelazy/GopenGL/internal/gltest"
+)
+
+// epsilon is how far, relative to the larger of 1 and the Go result, GPU
+// results may be from the Go ones. GLSL lets sqrt, inversesqrt and division
+// be a few ULP out, and a float32 ULP is about 1.2e-7.
+const epsilon = 1e-5
+
+func TestMain(m *testing.M) {
+	gltest.Main(m, "compute", nil)
+}
+
+// crossCheck runs k on in with the Go backend forced, and returns its
+// results after checking there is one for each input. If there is a GL
+// context, it also runs k with the compute shader and checks the results
+// agree by equal; if not, it says so and checks only the Go backend.
+func crossCheck[In, Out any](t *testing.T, k *Kernel[In, Out], in []In, equal func(gpu, cpu Out) bool) []Out {
+	t.Helper()
+	k.Backend = CPU
+	cpu, err := k.Run(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cpu) != len(in) {
+		t.Fatalf("Go backend gave %d results for %d inputs", len(cpu), len(in))
+	}
+
+	if !gltest.Available() {
+		t.Log("no OpenGL 4.3 context: the compute shader is not checked")
+		return cpu
+	}
+	err = gltest.Run(func() error {
+		defer k.Delete()
+		k.Backend = GPU
+		gpu, err := k.Run(in)
+		if err != nil {
+			return err
+		}
+		for i := range cpu {
+			if !equal(gpu[i], cpu[i]) {
+				return fmt.Errorf("output %d is %v on the GPU but %v in Go", i, gpu[i], cpu[i])
+			}
+		}
+		return k.Check(in, equal)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cpu
+}
+
+func TestSqrtKernel(t *testing.T) {
+	k := &Kernel[float32, float32]{
+		Source: `
+#version 430
+layout(local_size_x = 64) in;
+layout(std430) readonly buffer Input { float inValues[]; };
+layout(std430) writeonly buffer Output { float outValues[]; };
+uniform uvec3 invocations;
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i >= invocations.x) return;
+    outValues[i] = sqrt(inValues[i]);
+}
+` + "\x00",
+		Func: func(in, out []float32, i int) {
+			out[i] = float32(math.Sqrt(float64(in[i])))
+		},
+	}
+	in := make([]float32, 1000)
+	for i := range in {
+		in[i] = float32(i) * 0.37
+	}
+	out := crossCheck(t, k, in, Within(epsilon))
+	for _, i := range []int{0, 1, 100, 999} {
+		if want := math.Sqrt(float64(in[i])); math.Abs(float64(out[i])-want) > epsilon*math.Max(1, want) {
+			t.Errorf("sqrt(%v) is %v, want %v", in[i], out[i], want)
+		}
+	}
+
+	if out := crossCheck(t, k, nil, Within(epsilon)); len(out) != 0 {
+		t.Errorf("no inputs gave %d outputs", len(out))
+	}
+}
+
+func TestSetupKernel(t *testing.T) {
+	// a*x + y, with a set as a uniform.
+	const a = 2.5
+	k := &Kernel[[2]float32, float32]{
+		Source: `
+#version 430
+layout(local_size_x = 64) in;
+layout(std430) readonly buffer Input { vec2 xy[]; };
+layout(std430) writeonly buffer Output { float r[]; };
+uniform uvec3 invocations;
+uniform float a;
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i >= invocations.x) return;
+    r[i] = a * xy[i].x + xy[i].y;
+}
+` + "\x00",
+		Func: func(in [][2]float32, out []float32, i int) {
+			out[i] = a*in[i][0] + in[i][1]
+		},
+		Setup: func(p *Program) {
+			p.Set("a", a)
+		},
+	}
+	in := make([][2]float32, 777)
+	for i := range in {
+		in[i] = [2]float32{float32(i) - 300, float32(i%7) * 0.25}
+	}
+	out := crossCheck(t, k, in, Within(epsilon))
+	if out[300] != 1.5 {
+		t.Errorf("2.5*0 + 1.5 is %v", out[300])
+	}
+}
+
+func TestVectorKernel(t *testing.T) {
+	// Normalizes vec4s, which line up with [4]float32 in std430.
+	k := &Kernel[[4]float32, [4]float32]{
+		Source: `
+#version 430
+layout(local_size_x = 64) in;
+layout(std430) readonly buffer Input { vec4 v[]; };
+layout(std430) writeonly buffer Output { vec4 n[]; };
+uniform uvec3 invocations;
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i >= invocations.x) return;
+    n[i] = normalize(v[i]);
+}
+` + "\x00",
+		Func: func(in, out [][4]float32, i int) {
+			v := in[i]
+			l := float32(math.Sqrt(float64(v[0]*v[0] + v[1]*v[1] + v[2]*v[2] + v[3]*v[3])))
+			out[i] = [4]float32{v[0] / l, v[1] / l, v[2] / l, v[3] / l}
+		},
+	}
+	in := make([][4]float32, 500)
+	for i := range in {
+		f := float32(i)
+		in[i] = [4]float32{f + 1, -f, f * 0.5, 3}
+	}
+	within := Within(epsilon)
+	out := crossCheck(t, k, in, func(gpu, cpu [4]float32) bool {
+		for c := range gpu {
+			if !within(gpu[c], cpu[c]) {
+				return false
+			}
+		}
+		return true
+	})
+	if out[0] != [4]float32{1 / float32(math.Sqrt(10)), 0, 0, 3 / float32(math.Sqrt(10))} {
+		t.Errorf("normalize(1, 0, 0, 3) is %v", out[0])
+	}
+}
+
+func TestIntegerKernel(t *testing.T) {
+	// The number of Collatz steps to reach 1, which must match exactly.
+	k := &Kernel[uint32, uint32]{
+		Source: `
+#version 430
+layout(local_size_x = 64) in;
+layout(std430) readonly buffer Input { uint start[]; };
+layout(std430) writeonly buffer Output { uint steps[]; };
+uniform uvec3 invocations;
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i >= invocations.x) return;
+    uint x = start[i], s = 0u;
+    while (x > 1u) {
+        x = (x & 1u) == 0u ? x / 2u : 3u * x + 1u;
+        s++;
+    }
+    steps[i] = s;
+}
+` + "\x00",
+		Func: func(in, out []uint32, i int) {
+			x, s := in[i], uint32(0)
+			for x > 1 {
+				if x%2 == 0 {
+					x /= 2
+				} else {
+					x = 3*x + 1
+				}
+				s++
+			}
+			out[i] = s
+		},
+	}
+	in := make([]uint32, 2000)
+	for i := range in {
+		in[i] = uint32(i + 1)
+	}
+	out := crossCheck(t, k, in, func(gpu, cpu uint32) bool { return gpu == cpu })
+	if out[0] != 0 || out[26] != 111 {
+		t.Errorf("1 takes %d steps and 27 takes %d, want 0 and 111", out[0], out[26])
+	}
+}
+
+func TestBackend(t *testing.T) {
+	for _, b := range []Backend{Auto, GPU, CPU} {
+		var parsed Backend
+		if err := parsed.Set(b.String()); err != nil || parsed != b {
+			t.Errorf("Set(%q) gave %v, %v", b.String(), parsed, err)
+		}
+	}
+	var b Backend
+	if err := b.Set("tpu"); err == nil {
+		t.Error("Set(\"tpu\") gave no error")
+	}
+
+	// Nothing has called SetContext, so Auto falls back to the Go backend.
+	if r := Auto.Resolve(); r != CPU {
+		t.Errorf("Auto resolves to %v without a context", r)
+	}
+	if r := GPU.Resolve(); r != GPU {
+		t.Errorf("GPU resolves to %v", r)
+	}
+}
@@ -0,0 +1,88 @@
+package compute
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// PingPong runs an interleaved TransformFeedback over its own output, e.g.
+// to update particles: each Step reads the records of the last step through
+// the program's inputs and writes the next ones into the other of two
+// buffers. A vertex shader such as
+//
+//	in vec3 position;
+//	in vec3 velocity;
+//	out vec3 nextPosition;
+//	out vec3 nextVelocity;
+//
+// records struct { Position, Velocity [3]float32 } and reads it back
+// through the inputs "position" and "velocity". With a geometry shader the
+// number of records can change from step to step, up to the capacity.
+type PingPong[T any] struct {
+	Feedback *TransformFeedback[T]
+	// Len is the number of records in the current buffer.
+	Len int
+
+	buffers [2]uint32
+	vaos    [2]uint32
+	current int
+}
+
+// NewPingPong starts from the records initial, with room for capacity
+// records or len(initial) if that is more. inputs are the program's inputs
+// that read a record, in order. It needs a current GL context.
+func NewPingPong[T any](tf *TransformFeedback[T], initial []T, capacity int, inputs ...string) (*PingPong[T], error) {
+	if tf.Mode == gl.SEPARATE_ATTRIBS {
+		return nil, fmt.Errorf("compute: ping-pong needs interleaved transform feedback")
+	}
+	if capacity < len(initial) {
+		capacity = len(initial)
+	}
+
+	p := &PingPong[T]{Feedback: tf, Len: len(initial)}
+	gl.CreateBuffers(2, &p.buffers[0])
+	gl.GenVertexArrays(2, &p.vaos[0])
+	size := capacity * elementSize[T]()
+	for i, b := range p.buffers {
+		gl.NamedBufferData(b, size, nil, gl.DYNAMIC_COPY)
+		if err := setInputs(tf.Program, p.vaos[i], b, elementSize[T](), inputs); err != nil {
+			p.Delete()
+			return nil, err
+		}
+	}
+	if len(initial) > 0 {
+		gl.NamedBufferSubData(p.buffers[0], 0, len(initial)*elementSize[T](), pointer(initial))
+	}
+	return p, nil
+}
+
+// Step transforms the current records into the other buffer, which becomes
+// current.
+func (p *PingPong[T]) Step() {
+	next := 1 - p.current
+	p.Len = p.Feedback.record(p.vaos[p.current], 0, p.Len, p.buffers[next:next+1])
+	p.current = next
+}
+
+// Buffer returns the current buffer, to draw the records from.
+func (p *PingPong[T]) Buffer() uint32 {
+	return p.buffers[p.current]
+}
+
+// VAO returns a vertex array that feeds the current buffer to the
+// feedback program's inputs.
+func (p *PingPong[T]) VAO() uint32 {
+	return p.vaos[p.current]
+}
+
+// Read returns the current records.
+func (p *PingPong[T]) Read() []T {
+	return p.Feedback.read(p.buffers[p.current:p.current+1], p.Len)
+}
+
+// Delete frees the buffers and vertex arrays, but not the feedback.
+func (p *PingPong[T]) Delete() {
+	gl.DeleteVertexArrays(2, &p.vaos[0])
+	gl.DeleteBuffers(2, &p.buffers[0])
+}
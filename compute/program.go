@@ -0,0 +1,316 @@
+// Package compute runs compute shaders: it compiles them, binds shader
+// storage buffers and images to them by name, dispatches enough work groups
+// to cover a number of invocations, and reads results back into Go slices.
+//
+// A shader that squares some numbers:
+//
+//	#version 430
+//	layout(local_size_x = 64) in;
+//	layout(std430) buffer values { float v[]; };
+//	uniform uvec3 invocations;
+//	void main() {
+//	    uint i = gl_GlobalInvocationID.x;
+//	    if (i >= invocations.x) return;
+//	    v[i] *= v[i];
+//	}
+//
+// runs with
+//
+//	p, err := compute.NewProgram(source)
+//	values := compute.NewBuffer([]float32{1, 2, 3})
+//	p.BindBuffer("values", values.ID)
+//	p.Dispatch(values.Len, 1, 1)
+//	squares := values.Read()
+//
+// Compute shaders need OpenGL 4.3; see Supported. On older contexts,
+// TransformFeedback does the same kind of work with a vertex shader, and
+// PingPong repeats it over its own output. A Kernel pairs a compute shader
+// with a Go function that runs across goroutines, and picks one by
+// Backend.
+package compute
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+// Supported reports whether the current context can run compute shaders.
+func Supported() bool {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	return major > 4 || major == 4 && minor >= 3
+}
+
+// IndirectCommand is the layout DispatchIndirect reads from a buffer: the
+// number of work groups in each dimension.
+type IndirectCommand struct {
+	X, Y, Z uint32
+}
+
+// binding is a buffer or image bound to a program by name.
+type binding struct {
+	unit uint32 // binding point or image unit
+	id   uint32 // buffer or texture, 0 until bound
+
+	// Images only.
+	level  int32
+	access uint32
+	format uint32
+}
+
+// Program is a linked compute shader.
+type Program struct {
+	ID uint32
+	// LocalSize is the work group size the shader declares.
+	LocalSize [3]int
+
+	buffers   map[string]*binding
+	images    map[string]*binding
+	locations map[string]int32
+}
+
+// NewProgram compiles and links a compute shader. It needs a current GL
+// context.
+func NewProgram(source string) (*Program, error) {
+	cs, err := shader.Compile(source, gl.COMPUTE_SHADER)
+	if err != nil {
+		return nil, fmt.Errorf("compute: %v", err)
+	}
+	id, err := shader.Link(cs)
+	if err != nil {
+		return nil, fmt.Errorf("compute: %v", err)
+	}
+
+	p := &Program{
+		ID:        id,
+		buffers:   make(map[string]*binding),
+		images:    make(map[string]*binding),
+		locations: make(map[string]int32),
+	}
+	var size [3]int32
+	gl.GetProgramiv(id, gl.COMPUTE_WORK_GROUP_SIZE, &size[0])
+	for i := range size {
+		p.LocalSize[i] = int(size[i])
+	}
+	return p, nil
+}
+
+// LoadProgram compiles and links a compute shader from a file.
+func LoadProgram(path string) (*Program, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("compute: %v", err)
+	}
+	return NewProgram(string(source))
+}
+
+// BindBuffer binds the buffer id, such as a Buffer's ID, to the named
+// shader storage block. Each block gets a binding point of its own the
+// first time, replacing any layout(binding) in the shader, and its buffer
+// is bound to it at every Dispatch.
+func (p *Program) BindBuffer(block string, id uint32) error {
+	b, ok := p.buffers[block]
+	if !ok {
+		index := gl.GetProgramResourceIndex(p.ID, gl.SHADER_STORAGE_BLOCK, gl.Str(block+"\x00"))
+		if index == gl.INVALID_INDEX {
+			return fmt.Errorf("compute: storage block %q is not in program %d", block, p.ID)
+		}
+		var max int32
+		gl.GetIntegerv(gl.MAX_SHADER_STORAGE_BUFFER_BINDINGS, &max)
+		unit := uint32(len(p.buffers))
+		if unit >= uint32(max) {
+			return fmt.Errorf("compute: no binding point left for storage block %q (there are %d)", block, max)
+		}
+		gl.ShaderStorageBlockBinding(p.ID, index, unit)
+		b = &binding{unit: unit}
+		p.buffers[block] = b
+	}
+	b.id = id
+	return nil
+}
+
+// BindImage binds level of the texture id to the named image uniform, with
+// access gl.READ_ONLY, gl.WRITE_ONLY or gl.READ_WRITE and format the
+// image's layout qualifier, such as gl.RGBA32F or gl.R32UI. Each image gets
+// an image unit of its own the first time, and its texture is bound to it
+// at every Dispatch.
+func (p *Program) BindImage(image string, id uint32, level int32, access, format uint32) error {
+	b, ok := p.images[image]
+	if !ok {
+		location := p.location(image)
+		if location < 0 {
+			return fmt.Errorf("compute: image %q is not in program %d", image, p.ID)
+		}
+		var max int32
+		gl.GetIntegerv(gl.MAX_IMAGE_UNITS, &max)
+		unit := uint32(len(p.images))
+		if unit >= uint32(max) {
+			return fmt.Errorf("compute: no image unit left for image %q (there are %d)", image, max)
+		}
+		gl.ProgramUniform1i(p.ID, location, int32(unit))
+		b = &binding{unit: unit}
+		p.images[image] = b
+	}
+	b.id, b.level, b.access, b.format = id, level, access, format
+	return nil
+}
+
+// location returns the location of the named uniform, asking the program
+// only the first time.
+func (p *Program) location(name string) int32 {
+	location, ok := p.locations[name]
+	if !ok {
+		location = shader.Uniform(p.ID, name)
+		p.locations[name] = location
+	}
+	return location
+}
+
+// Set sets the named float, vec2, vec3 or vec4 uniform, according to the
+// number of values. Uniforms the shader does not use are ignored.
+func (p *Program) Set(name string, values ...float32) {
+	location := p.location(name)
+	if location < 0 {
+		return
+	}
+	switch len(values) {
+	case 1:
+		gl.ProgramUniform1f(p.ID, location, values[0])
+	case 2:
+		gl.ProgramUniform2f(p.ID, location, values[0], values[1])
+	case 3:
+		gl.ProgramUniform3f(p.ID, location, values[0], values[1], values[2])
+	case 4:
+		gl.ProgramUniform4f(p.ID, location, values[0], values[1], values[2], values[3])
+	}
+}
+
+// SetInt sets the named int or ivec uniform.
+func (p *Program) SetInt(name string, values ...int32) {
+	location := p.location(name)
+	if location < 0 {
+		return
+	}
+	switch len(values) {
+	case 1:
+		gl.ProgramUniform1i(p.ID, location, values[0])
+	case 2:
+		gl.ProgramUniform2i(p.ID, location, values[0], values[1])
+	case 3:
+		gl.ProgramUniform3i(p.ID, location, values[0], values[1], values[2])
+	case 4:
+		gl.ProgramUniform4i(p.ID, location, values[0], values[1], values[2], values[3])
+	}
+}
+
+// SetUint sets the named uint or uvec uniform.
+func (p *Program) SetUint(name string, values ...uint32) {
+	location := p.location(name)
+	if location < 0 {
+		return
+	}
+	switch len(values) {
+	case 1:
+		gl.ProgramUniform1ui(p.ID, location, values[0])
+	case 2:
+		gl.ProgramUniform2ui(p.ID, location, values[0], values[1])
+	case 3:
+		gl.ProgramUniform3ui(p.ID, location, values[0], values[1], values[2])
+	case 4:
+		gl.ProgramUniform4ui(p.ID, location, values[0], values[1], values[2], values[3])
+	}
+}
+
+// Groups returns the number of work groups needed for x * y * z
+// invocations, rounding up, e.g. to fill an IndirectCommand.
+func (p *Program) Groups(x, y, z int) IndirectCommand {
+	groups := func(n, size int) uint32 {
+		if size < 1 {
+			size = 1
+		}
+		return uint32((n + size - 1) / size)
+	}
+	return IndirectCommand{
+		X: groups(x, p.LocalSize[0]),
+		Y: groups(y, p.LocalSize[1]),
+		Z: groups(z, p.LocalSize[2]),
+	}
+}
+
+// Dispatch runs at least x * y * z invocations, in as many work groups as
+// it takes. The last groups may run past the counts, so the shader should
+// return early for invocations beyond them; if it declares
+//
+//	uniform uvec3 invocations;
+//
+// Dispatch sets it to (x, y, z) for that purpose.
+func (p *Program) Dispatch(x, y, z int) {
+	if x <= 0 || y <= 0 || z <= 0 {
+		return
+	}
+	p.SetUint("invocations", uint32(x), uint32(y), uint32(z))
+	g := p.Groups(x, y, z)
+	p.use(0)
+	gl.DispatchCompute(g.X, g.Y, g.Z)
+	p.written()
+}
+
+// DispatchGroups runs x * y * z work groups.
+func (p *Program) DispatchGroups(x, y, z uint32) {
+	p.use(0)
+	gl.DispatchCompute(x, y, z)
+	p.written()
+}
+
+// DispatchIndirect runs the number of work groups given by the
+// IndirectCommand at byte offset in the buffer id, which may have been
+// written by an earlier dispatch.
+func (p *Program) DispatchIndirect(id uint32, offset int) {
+	p.use(gl.COMMAND_BARRIER_BIT)
+	gl.BindBuffer(gl.DISPATCH_INDIRECT_BUFFER, id)
+	gl.DispatchComputeIndirect(offset)
+	gl.BindBuffer(gl.DISPATCH_INDIRECT_BUFFER, 0)
+	p.written()
+}
+
+// use waits for earlier dispatches' writes to be visible to this one, and
+// makes the program and its bindings current.
+func (p *Program) use(extra uint32) {
+	Barrier(gl.SHADER_STORAGE_BARRIER_BIT | gl.SHADER_IMAGE_ACCESS_BARRIER_BIT |
+		gl.TEXTURE_FETCH_BARRIER_BIT | gl.UNIFORM_BARRIER_BIT | gl.ATOMIC_COUNTER_BARRIER_BIT | extra)
+
+	gl.UseProgram(p.ID)
+	for _, b := range p.buffers {
+		if b.id != 0 {
+			gl.BindBufferBase(gl.SHADER_STORAGE_BUFFER, b.unit, b.id)
+		}
+	}
+	for _, b := range p.images {
+		if b.id != 0 {
+			gl.BindImageTexture(b.unit, b.id, b.level, false, 0, b.access, b.format)
+		}
+	}
+}
+
+// written records that the dispatch may have written its buffers and
+// images, so later uses of them must wait.
+func (p *Program) written() {
+	if len(p.buffers) > 0 {
+		pending |= bufferUses
+	}
+	for _, b := range p.images {
+		if b.access != gl.READ_ONLY {
+			pending |= imageUses
+			break
+		}
+	}
+}
+
+// Delete frees the program.
+func (p *Program) Delete() {
+	gl.DeleteProgram(p.ID)
+}
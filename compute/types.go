@@ -0,0 +1,43 @@
+package compute
+
+import "github.com/go-gl/gl/v4.6-core/gl"
+
+// glslType is a GLSL type as vertex data: a number of components of a
+// basic type.
+type glslType struct {
+	components int32
+	// base is gl.FLOAT, gl.INT, gl.UNSIGNED_INT or gl.DOUBLE.
+	base uint32
+}
+
+// bytes returns the size of the type.
+func (t glslType) bytes() int {
+	if t.base == gl.DOUBLE {
+		return int(t.components) * 8
+	}
+	return int(t.components) * 4
+}
+
+// types are the GLSL types vertex shaders can read and transform feedback
+// can record, by the enum reflection gives them.
+var types = map[uint32]glslType{
+	gl.FLOAT:             {1, gl.FLOAT},
+	gl.FLOAT_VEC2:        {2, gl.FLOAT},
+	gl.FLOAT_VEC3:        {3, gl.FLOAT},
+	gl.FLOAT_VEC4:        {4, gl.FLOAT},
+	gl.FLOAT_MAT2:        {4, gl.FLOAT},
+	gl.FLOAT_MAT3:        {9, gl.FLOAT},
+	gl.FLOAT_MAT4:        {16, gl.FLOAT},
+	gl.INT:               {1, gl.INT},
+	gl.INT_VEC2:          {2, gl.INT},
+	gl.INT_VEC3:          {3, gl.INT},
+	gl.INT_VEC4:          {4, gl.INT},
+	gl.UNSIGNED_INT:      {1, gl.UNSIGNED_INT},
+	gl.UNSIGNED_INT_VEC2: {2, gl.UNSIGNED_INT},
+	gl.UNSIGNED_INT_VEC3: {3, gl.UNSIGNED_INT},
+	gl.UNSIGNED_INT_VEC4: {4, gl.UNSIGNED_INT},
+	gl.DOUBLE:            {1, gl.DOUBLE},
+	gl.DOUBLE_VEC2:       {2, gl.DOUBLE},
+	gl.DOUBLE_VEC3:       {3, gl.DOUBLE},
+	gl.DOUBLE_VEC4:       {4, gl.DOUBLE},
+}
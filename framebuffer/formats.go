@@ -0,0 +1,41 @@
+package framebuffer
+
+import (
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// kind is how an attachment's values are read and written: as floats
+// (normalised or not), signed integers or unsigned integers.
+type kind int
+
+const (
+	floatKind kind = iota
+	intKind
+	uintKind
+)
+
+func kindOf(format uint32) kind {
+	switch format {
+	case gl.R8I, gl.R16I, gl.R32I, gl.RG8I, gl.RG16I, gl.RG32I,
+		gl.RGB8I, gl.RGB16I, gl.RGB32I, gl.RGBA8I, gl.RGBA16I, gl.RGBA32I:
+		return intKind
+	case gl.R8UI, gl.R16UI, gl.R32UI, gl.RG8UI, gl.RG16UI, gl.RG32UI,
+		gl.RGB8UI, gl.RGB16UI, gl.RGB32UI, gl.RGBA8UI, gl.RGBA16UI, gl.RGBA32UI,
+		gl.RGB10_A2UI:
+		return uintKind
+	}
+	return floatKind
+}
+
+func isDepth(format uint32) bool {
+	switch format {
+	case gl.DEPTH_COMPONENT16, gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT32, gl.DEPTH_COMPONENT32F,
+		gl.DEPTH24_STENCIL8, gl.DEPTH32F_STENCIL8:
+		return true
+	}
+	return false
+}
+
+func hasStencil(format uint32) bool {
+	return format == gl.DEPTH24_STENCIL8 || format == gl.DEPTH32F_STENCIL8
+}
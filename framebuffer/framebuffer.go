@@ -0,0 +1,402 @@
+// Package framebuffer renders off screen, into textures or renderbuffers.
+//
+// A Framebuffer has any number of colour attachments, each in its own
+// format, and an optional depth or depth-stencil attachment. With MSAA it
+// renders into multisampled renderbuffers and Resolve averages them into
+// textures that can be sampled. MapOutputs matches a program's fragment
+// outputs to attachments by name, for multiple render targets.
+package framebuffer
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Attachment describes a colour attachment.
+type Attachment struct {
+	// Name is the fragment shader output that writes to the attachment. See
+	// MapOutputs.
+	Name string
+	// Format is a sized internal format such as gl.RGBA8, gl.RGBA16F or
+	// gl.R32UI. 0 means gl.RGBA8.
+	Format uint32
+	// Renderbuffer stores the attachment in a renderbuffer, which cannot be
+	// sampled, rather than a texture.
+	Renderbuffer bool
+}
+
+// Spec describes a framebuffer.
+type Spec struct {
+	Width, Height int
+
+	Colors []Attachment
+
+	// Depth is the depth or depth-stencil format: gl.DEPTH_COMPONENT24,
+	// gl.DEPTH_COMPONENT32F, gl.DEPTH24_STENCIL8 or gl.DEPTH32F_STENCIL8. 0
+	// means no depth attachment.
+	Depth uint32
+	// DepthTexture stores depth in a texture, so that it can be sampled,
+	// rather than a renderbuffer.
+	DepthTexture bool
+
+	// Samples above 1 turns on MSAA with that many samples per pixel, clamped
+	// to what the driver supports.
+	Samples int
+}
+
+// Framebuffer is a framebuffer object and its attachments.
+type Framebuffer struct {
+	Spec
+	ID uint32
+
+	// Textures holds the texture of each colour attachment, or 0 for a
+	// renderbuffer. With MSAA they are the resolved textures.
+	Textures []uint32
+	// DepthTextureID is the depth texture, if Spec.DepthTexture is set.
+	DepthTextureID uint32
+
+	renderbuffers []uint32
+	drawBuffers   []uint32
+
+	// resolved is the single-sample framebuffer MSAA resolves into.
+	resolved *Framebuffer
+}
+
+// New creates a framebuffer and its attachments. It needs a current GL
+// context.
+func New(spec Spec) (*Framebuffer, error) {
+	if len(spec.Colors) == 0 && spec.Depth == 0 {
+		return nil, fmt.Errorf("framebuffer: no attachments")
+	}
+	for i := range spec.Colors {
+		if spec.Colors[i].Format == 0 {
+			spec.Colors[i].Format = gl.RGBA8
+		}
+	}
+	if spec.Samples > 1 {
+		var max int32
+		gl.GetIntegerv(gl.MAX_SAMPLES, &max)
+		if spec.Samples > int(max) {
+			spec.Samples = int(max)
+		}
+	}
+
+	f := &Framebuffer{Spec: spec}
+	gl.GenFramebuffers(1, &f.ID)
+	if err := f.allocate(); err != nil {
+		f.Delete()
+		return nil, err
+	}
+	return f, nil
+}
+
+// multisampled reports whether f renders with MSAA.
+func (f *Framebuffer) multisampled() bool {
+	return f.Samples > 1
+}
+
+// allocate creates the attachments at the current size.
+func (f *Framebuffer) allocate() error {
+	if f.Width <= 0 || f.Height <= 0 {
+		return fmt.Errorf("framebuffer: size %dx%d is not positive", f.Width, f.Height)
+	}
+	w, h := int32(f.Width), int32(f.Height)
+
+	// With MSAA everything here is a multisampled renderbuffer, and the
+	// textures belong to the resolve framebuffer.
+	if f.multisampled() {
+		spec := f.Spec
+		spec.Samples = 0
+		spec.Colors = append([]Attachment(nil), f.Colors...)
+		resolved := &Framebuffer{Spec: spec}
+		gl.GenFramebuffers(1, &resolved.ID)
+		if err := resolved.allocate(); err != nil {
+			resolved.Delete()
+			return err
+		}
+		f.resolved = resolved
+		f.Textures = resolved.Textures
+		f.DepthTextureID = resolved.DepthTextureID
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	f.drawBuffers = f.drawBuffers[:0]
+	for i, a := range f.Colors {
+		point := uint32(gl.COLOR_ATTACHMENT0 + i)
+		f.drawBuffers = append(f.drawBuffers, point)
+		if f.multisampled() || a.Renderbuffer {
+			rbo := f.renderbuffer(a.Format)
+			gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, point, gl.RENDERBUFFER, rbo)
+			if !f.multisampled() {
+				f.Textures = append(f.Textures, 0)
+			}
+			continue
+		}
+
+		tex := newTexture(a.Format, w, h)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, point, gl.TEXTURE_2D, tex, 0)
+		f.Textures = append(f.Textures, tex)
+	}
+
+	if f.Depth != 0 {
+		point := uint32(gl.DEPTH_ATTACHMENT)
+		if hasStencil(f.Depth) {
+			point = gl.DEPTH_STENCIL_ATTACHMENT
+		}
+		if f.multisampled() || !f.DepthTexture {
+			rbo := f.renderbuffer(f.Depth)
+			gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, point, gl.RENDERBUFFER, rbo)
+		} else {
+			f.DepthTextureID = newTexture(f.Depth, w, h)
+			gl.FramebufferTexture2D(gl.FRAMEBUFFER, point, gl.TEXTURE_2D, f.DepthTextureID, 0)
+		}
+	}
+
+	if len(f.drawBuffers) > 0 {
+		gl.DrawBuffers(int32(len(f.drawBuffers)), &f.drawBuffers[0])
+	} else {
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	}
+
+	err := check()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return err
+}
+
+// renderbuffer creates a renderbuffer the size of f, with f's samples.
+func (f *Framebuffer) renderbuffer(format uint32) uint32 {
+	var rbo uint32
+	gl.GenRenderbuffers(1, &rbo)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, rbo)
+	if f.multisampled() {
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, int32(f.Samples), format, int32(f.Width), int32(f.Height))
+	} else {
+		gl.RenderbufferStorage(gl.RENDERBUFFER, format, int32(f.Width), int32(f.Height))
+	}
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	f.renderbuffers = append(f.renderbuffers, rbo)
+	return rbo
+}
+
+// newTexture creates a single level texture to render into. Integer and
+// depth formats are sampled with NEAREST filtering, others with LINEAR.
+func newTexture(format uint32, width, height int32) uint32 {
+	var tex uint32
+	gl.GenTextures(1, &tex)
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	gl.TexStorage2D(gl.TEXTURE_2D, 1, format, width, height)
+
+	filter := int32(gl.LINEAR)
+	if kindOf(format) != floatKind || isDepth(format) {
+		filter = gl.NEAREST
+	}
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return tex
+}
+
+// release deletes the attachments, but not the framebuffer object.
+func (f *Framebuffer) release() {
+	if f.resolved != nil {
+		f.resolved.Delete()
+		f.resolved = nil
+	} else {
+		for _, tex := range f.Textures {
+			if tex != 0 {
+				gl.DeleteTextures(1, &tex)
+			}
+		}
+		if f.DepthTextureID != 0 {
+			gl.DeleteTextures(1, &f.DepthTextureID)
+		}
+	}
+	f.Textures = nil
+	f.DepthTextureID = 0
+
+	if len(f.renderbuffers) > 0 {
+		gl.DeleteRenderbuffers(int32(len(f.renderbuffers)), &f.renderbuffers[0])
+	}
+	f.renderbuffers = nil
+}
+
+// Resize reallocates the attachments at a new size. Their contents are
+// lost, and the framebuffer and texture IDs change. If it fails, f is left
+// as it was.
+func (f *Framebuffer) Resize(width, height int) error {
+	if width == f.Width && height == f.Height {
+		return nil
+	}
+
+	// Build the new attachments in a framebuffer of their own, so that
+	// nothing of f's is touched until they are complete.
+	next := &Framebuffer{Spec: f.Spec}
+	next.Width, next.Height = width, height
+	gl.GenFramebuffers(1, &next.ID)
+	if err := next.allocate(); err != nil {
+		next.Delete()
+		return err
+	}
+
+	// Keep any mapping MapOutputs made.
+	next.drawBuffers = f.drawBuffers
+	f.Delete()
+	*f = *next
+	return nil
+}
+
+// ResizeWithWindow resizes f whenever win's framebuffer is resized, after
+// calling any size callback win already had. Errors are passed to onError,
+// which may be nil to ignore them.
+func (f *Framebuffer) ResizeWithWindow(win *glfw.Window, onError func(error)) {
+	var previous glfw.FramebufferSizeCallback
+	previous = win.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		if previous != nil {
+			previous(w, width, height)
+		}
+		// A minimised window reports 0 x 0; keep the old size until it is
+		// back.
+		if width == 0 || height == 0 {
+			return
+		}
+		if err := f.Resize(width, height); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+// Bind makes f the target of drawing and sets the viewport to cover it.
+func (f *Framebuffer) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	gl.Viewport(0, 0, int32(f.Width), int32(f.Height))
+	if len(f.drawBuffers) > 0 {
+		gl.DrawBuffers(int32(len(f.drawBuffers)), &f.drawBuffers[0])
+	}
+}
+
+// BindDefault makes the window's framebuffer the target of drawing, with a
+// width x height viewport.
+func BindDefault(width, height int) {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(width), int32(height))
+}
+
+// Clear clears every colour attachment to color, and depth (and stencil to
+// 0) to depth. Integer attachments are cleared to color truncated to
+// integers. f must be bound.
+func (f *Framebuffer) Clear(color [4]float32, depth float32) {
+	// ClearBuffer addresses draw buffers, not attachments, so point draw
+	// buffer i at attachment i while clearing.
+	all := make([]uint32, len(f.Colors))
+	for i := range all {
+		all[i] = uint32(gl.COLOR_ATTACHMENT0 + i)
+	}
+	if len(all) > 0 {
+		gl.DrawBuffers(int32(len(all)), &all[0])
+	}
+
+	for i, a := range f.Colors {
+		switch kindOf(a.Format) {
+		case intKind:
+			v := [4]int32{int32(color[0]), int32(color[1]), int32(color[2]), int32(color[3])}
+			gl.ClearBufferiv(gl.COLOR, int32(i), &v[0])
+		case uintKind:
+			v := [4]uint32{uint32(color[0]), uint32(color[1]), uint32(color[2]), uint32(color[3])}
+			gl.ClearBufferuiv(gl.COLOR, int32(i), &v[0])
+		default:
+			gl.ClearBufferfv(gl.COLOR, int32(i), &color[0])
+		}
+	}
+
+	if f.Depth != 0 {
+		if hasStencil(f.Depth) {
+			gl.ClearBufferfi(gl.DEPTH_STENCIL, 0, depth, 0)
+		} else {
+			gl.ClearBufferfv(gl.DEPTH, 0, &depth)
+		}
+	}
+
+	if len(f.drawBuffers) > 0 {
+		gl.DrawBuffers(int32(len(f.drawBuffers)), &f.drawBuffers[0])
+	}
+}
+
+// Resolve averages the samples of an MSAA framebuffer into its textures.
+// Depth is copied from one sample. It does nothing without MSAA.
+func (f *Framebuffer) Resolve() {
+	if f.resolved == nil {
+		return
+	}
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.ID)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, f.resolved.ID)
+	w, h := int32(f.Width), int32(f.Height)
+
+	// Blit copies from one read buffer to every draw buffer, so go one
+	// attachment at a time.
+	for i := range f.Colors {
+		point := uint32(gl.COLOR_ATTACHMENT0 + i)
+		gl.ReadBuffer(point)
+		gl.DrawBuffers(1, &point)
+		gl.BlitFramebuffer(0, 0, w, h, 0, 0, w, h, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	}
+	if f.Depth != 0 {
+		mask := uint32(gl.DEPTH_BUFFER_BIT)
+		if hasStencil(f.Depth) {
+			mask |= gl.STENCIL_BUFFER_BIT
+		}
+		gl.BlitFramebuffer(0, 0, w, h, 0, 0, w, h, mask, gl.NEAREST)
+	}
+
+	if len(f.resolved.drawBuffers) > 0 {
+		gl.DrawBuffers(int32(len(f.resolved.drawBuffers)), &f.resolved.drawBuffers[0])
+	}
+	if len(f.Colors) > 0 {
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// BlitToDefault copies colour attachment i, resolved if f uses MSAA, to the
+// whole window's framebuffer of width x height, scaling with linear
+// filtering if the sizes differ.
+func (f *Framebuffer) BlitToDefault(i int, width, height int) {
+	src := f
+	if f.resolved != nil {
+		f.Resolve()
+		src = f.resolved
+	}
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, src.ID)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+	gl.ReadBuffer(uint32(gl.COLOR_ATTACHMENT0 + i))
+	filter := uint32(gl.NEAREST)
+	if width != f.Width || height != f.Height {
+		filter = gl.LINEAR
+	}
+	gl.BlitFramebuffer(0, 0, int32(f.Width), int32(f.Height), 0, 0, int32(width), int32(height), gl.COLOR_BUFFER_BIT, filter)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// Texture returns the texture of the colour attachment with the given name,
+// or 0 if there is none or it is a renderbuffer.
+func (f *Framebuffer) Texture(name string) uint32 {
+	for i, a := range f.Colors {
+		if a.Name == name {
+			return f.Textures[i]
+		}
+	}
+	return 0
+}
+
+// Delete frees the framebuffer and its attachments.
+func (f *Framebuffer) Delete() {
+	f.release()
+	gl.DeleteFramebuffers(1, &f.ID)
+	f.ID = 0
+}
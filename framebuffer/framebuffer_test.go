@@ -0,0 +1,215 @@
+package framebuffer
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/internal/gltest"
+)
+
+func TestMain(m *testing.M) {
+	gltest.Main(m, "framebuffer", nil)
+}
+
+// onGL runs f with the GL context current, or skips the test if there is
+// none. f reports failures by returning them, as t.Fatal cannot be called
+// from the main thread.
+func onGL(t *testing.T, f func() error) {
+	t.Helper()
+	if !gltest.Available() {
+		t.Skip("no OpenGL context")
+	}
+	if err := gltest.Run(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var testSpecs = []Spec{
+	{
+		Width: 32, Height: 16,
+		Colors: []Attachment{
+			{Name: "color"},
+			{Name: "id", Format: gl.R32UI},
+			{Name: "scratch", Format: gl.RGBA16F, Renderbuffer: true},
+		},
+		Depth:        gl.DEPTH24_STENCIL8,
+		DepthTexture: true,
+	},
+	{
+		Width: 32, Height: 16,
+		Colors:  []Attachment{{Name: "color", Format: gl.RGBA16F}},
+		Depth:   gl.DEPTH_COMPONENT32F,
+		Samples: 4,
+	},
+	{Width: 32, Height: 16, Depth: gl.DEPTH_COMPONENT24, DepthTexture: true},
+}
+
+// complete checks that f is bound without errors and complete.
+func complete(f *Framebuffer) error {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if !gl.IsFramebuffer(f.ID) {
+		return fmt.Errorf("framebuffer %d does not exist", f.ID)
+	}
+	for _, tex := range append(slices.Clone(f.Textures), f.DepthTextureID) {
+		if tex != 0 && !gl.IsTexture(tex) {
+			return fmt.Errorf("texture %d does not exist", tex)
+		}
+	}
+	if f.resolved != nil {
+		if err := complete(f.resolved); err != nil {
+			return fmt.Errorf("resolve framebuffer: %v", err)
+		}
+	}
+	return check()
+}
+
+// clearErrors throws away any GL errors a test caused on purpose.
+func clearErrors() {
+	for gl.GetError() != gl.NO_ERROR {
+	}
+}
+
+func TestResize(t *testing.T) {
+	onGL(t, func() error {
+		for i, spec := range testSpecs {
+			f, err := New(spec)
+			if err != nil {
+				return fmt.Errorf("spec %d: %v", i, err)
+			}
+			defer f.Delete()
+			oldTextures := slices.Clone(f.Textures)
+			oldDepth := f.DepthTextureID
+
+			// A mapping MapOutputs made survives.
+			f.drawBuffers = []uint32{gl.NONE, gl.COLOR_ATTACHMENT0}
+			if len(f.Colors) == 0 {
+				f.drawBuffers = nil
+			}
+			mapped := slices.Clone(f.drawBuffers)
+
+			if err := f.Resize(64, 48); err != nil {
+				return fmt.Errorf("spec %d: %v", i, err)
+			}
+			if f.Width != 64 || f.Height != 48 || len(f.Textures) != len(spec.Colors) {
+				return fmt.Errorf("spec %d: resized to %dx%d with %d textures", i, f.Width, f.Height, len(f.Textures))
+			}
+			if err := complete(f); err != nil {
+				return fmt.Errorf("spec %d: %v", i, err)
+			}
+			if !slices.Equal(f.drawBuffers, mapped) {
+				return fmt.Errorf("spec %d: draw buffers %v after resizing, want %v", i, f.drawBuffers, mapped)
+			}
+			for _, tex := range append(oldTextures, oldDepth) {
+				if tex != 0 && gl.IsTexture(tex) {
+					return fmt.Errorf("spec %d: old texture %d was not deleted", i, tex)
+				}
+			}
+			if f.resolved != nil && !slices.Equal(f.Textures, f.resolved.Textures) {
+				return fmt.Errorf("spec %d: textures %v are not the resolved ones %v", i, f.Textures, f.resolved.Textures)
+			}
+			var w int32
+			gl.BindTexture(gl.TEXTURE_2D, f.Textures[0])
+			if len(f.Colors) == 0 {
+				gl.BindTexture(gl.TEXTURE_2D, f.DepthTextureID)
+			}
+			gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &w)
+			gl.BindTexture(gl.TEXTURE_2D, 0)
+			if w != 64 {
+				return fmt.Errorf("spec %d: texture is %d wide, want 64", i, w)
+			}
+		}
+		return nil
+	})
+}
+
+func TestResizeFailure(t *testing.T) {
+	onGL(t, func() error {
+		// Beyond the largest texture or renderbuffer, allocation fails
+		// only once attachments have been made.
+		var maxTexture, maxRenderbuffer int32
+		gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &maxTexture)
+		gl.GetIntegerv(gl.MAX_RENDERBUFFER_SIZE, &maxRenderbuffer)
+		tooWide := int(max(maxTexture, maxRenderbuffer)) + 1
+
+		for i, spec := range testSpecs {
+			f, err := New(spec)
+			if err != nil {
+				return fmt.Errorf("spec %d: %v", i, err)
+			}
+			defer f.Delete()
+			before := *f
+			before.Textures = slices.Clone(f.Textures)
+
+			for _, size := range [][2]int{{0, 16}, {32, -1}, {tooWide, 1}} {
+				err := f.Resize(size[0], size[1])
+				clearErrors()
+				if err == nil {
+					return fmt.Errorf("spec %d: resizing to %dx%d gave no error", i, size[0], size[1])
+				}
+				if f.ID != before.ID || f.Width != before.Width || f.Height != before.Height ||
+					!slices.Equal(f.Textures, before.Textures) || f.DepthTextureID != before.DepthTextureID ||
+					f.resolved != before.resolved {
+					return fmt.Errorf("spec %d: failing to resize to %dx%d changed the framebuffer", i, size[0], size[1])
+				}
+				if err := complete(f); err != nil {
+					return fmt.Errorf("spec %d: after failing to resize to %dx%d: %v", i, size[0], size[1], err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New(Spec{Width: 1, Height: 1}); err == nil || err.Error() != "framebuffer: no attachments" {
+		t.Errorf("got error %v for no attachments", err)
+	}
+	onGL(t, func() error {
+		_, err := New(Spec{Width: 0, Height: 1, Colors: []Attachment{{}}})
+		if err == nil || err.Error() != "framebuffer: size 0x1 is not positive" {
+			return fmt.Errorf("got error %v for a 0x1 framebuffer", err)
+		}
+		return nil
+	})
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format  uint32
+		kind    kind
+		depth   bool
+		stencil bool
+	}{
+		{gl.RGBA8, floatKind, false, false},
+		{gl.RGBA16F, floatKind, false, false},
+		{gl.R32I, intKind, false, false},
+		{gl.RG16UI, uintKind, false, false},
+		{gl.RGB10_A2UI, uintKind, false, false},
+		{gl.DEPTH_COMPONENT24, floatKind, true, false},
+		{gl.DEPTH32F_STENCIL8, floatKind, true, true},
+	}
+	for _, tt := range tests {
+		if k, d, s := kindOf(tt.format), isDepth(tt.format), hasStencil(tt.format); k != tt.kind || d != tt.depth || s != tt.stencil {
+			t.Errorf("format 0x%X: kind %d, depth %v, stencil %v, want %d, %v, %v", tt.format, k, d, s, tt.kind, tt.depth, tt.stencil)
+		}
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status uint32
+		want   string
+	}{
+		{gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT, "framebuffer is incomplete: an attachment is incomplete or has a format that cannot be rendered to"},
+		{gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE, "framebuffer is incomplete: the attachments have different numbers of samples"},
+		{0x1234, "framebuffer is incomplete: status 0x1234"},
+	}
+	for _, tt := range tests {
+		if got := StatusError(tt.status).Error(); got != tt.want {
+			t.Errorf("status 0x%X: got %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
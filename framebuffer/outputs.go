@@ -0,0 +1,85 @@
+package framebuffer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Output is a fragment shader output, as found by Outputs.
+type Output struct {
+	Name     string
+	Location int32
+}
+
+// Outputs lists program's fragment shader outputs and their locations,
+// leaving out built-ins such as gl_FragDepth.
+func Outputs(program uint32) []Output {
+	var count, maxLength int32
+	gl.GetProgramInterfaceiv(program, gl.PROGRAM_OUTPUT, gl.ACTIVE_RESOURCES, &count)
+	gl.GetProgramInterfaceiv(program, gl.PROGRAM_OUTPUT, gl.MAX_NAME_LENGTH, &maxLength)
+
+	var outputs []Output
+	name := make([]uint8, maxLength+1)
+	for i := uint32(0); i < uint32(count); i++ {
+		var length int32
+		gl.GetProgramResourceName(program, gl.PROGRAM_OUTPUT, i, int32(len(name)), &length, &name[0])
+		n := string(name[:length])
+		if strings.HasPrefix(n, "gl_") {
+			continue
+		}
+
+		prop := uint32(gl.LOCATION)
+		var location int32
+		gl.GetProgramResourceiv(program, gl.PROGRAM_OUTPUT, i, 1, &prop, 1, nil, &location)
+		outputs = append(outputs, Output{Name: n, Location: location})
+	}
+	return outputs
+}
+
+// MapOutputs routes each of program's fragment outputs to the colour
+// attachment with the same name, whatever location the output has. It is
+// an error for an output to have no attachment; attachments with no output
+// are left alone. The mapping holds until MapOutputs is called again, so
+// call it after switching to a program with other outputs.
+func (f *Framebuffer) MapOutputs(program uint32) error {
+	attachments := make(map[string]int, len(f.Colors))
+	for i, a := range f.Colors {
+		if a.Name != "" {
+			attachments[a.Name] = i
+		}
+	}
+
+	// Draw buffer n receives the output at location n.
+	var drawBuffers []uint32
+	for _, o := range Outputs(program) {
+		i, ok := attachments[o.Name]
+		if !ok {
+			return fmt.Errorf("framebuffer: fragment output %q has no attachment", o.Name)
+		}
+		if o.Location < 0 {
+			continue
+		}
+		for int(o.Location) >= len(drawBuffers) {
+			drawBuffers = append(drawBuffers, gl.NONE)
+		}
+		drawBuffers[o.Location] = uint32(gl.COLOR_ATTACHMENT0 + i)
+	}
+	if len(drawBuffers) == 0 {
+		return fmt.Errorf("framebuffer: program %d has no fragment outputs", program)
+	}
+
+	var max int32
+	gl.GetIntegerv(gl.MAX_DRAW_BUFFERS, &max)
+	if len(drawBuffers) > int(max) {
+		return fmt.Errorf("framebuffer: output location %d is beyond the %d draw buffers", len(drawBuffers)-1, max)
+	}
+
+	f.drawBuffers = drawBuffers
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.ID)
+	gl.DrawBuffers(int32(len(drawBuffers)), &drawBuffers[0])
+	err := check()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return err
+}
@@ -0,0 +1,45 @@
+package framebuffer
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// StatusError is the status CheckFramebufferStatus gave for an incomplete
+// framebuffer.
+type StatusError uint32
+
+func (e StatusError) Error() string {
+	var reason string
+	switch uint32(e) {
+	case gl.FRAMEBUFFER_UNDEFINED:
+		reason = "the default framebuffer does not exist"
+	case gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:
+		reason = "an attachment is incomplete or has a format that cannot be rendered to"
+	case gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT:
+		reason = "it has no attachments"
+	case gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:
+		reason = "a draw buffer names a missing attachment"
+	case gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:
+		reason = "the read buffer names a missing attachment"
+	case gl.FRAMEBUFFER_UNSUPPORTED:
+		reason = "the driver does not support this combination of formats"
+	case gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:
+		reason = "the attachments have different numbers of samples"
+	case gl.FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS:
+		reason = "some attachments are layered and some are not"
+	default:
+		reason = fmt.Sprintf("status 0x%X", uint32(e))
+	}
+	return "framebuffer is incomplete: " + reason
+}
+
+// check returns a StatusError if the framebuffer bound to FRAMEBUFFER is
+// not complete.
+func check() error {
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return StatusError(status)
+	}
+	return nil
+}
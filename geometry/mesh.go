@@ -0,0 +1,213 @@
+// Package geometry holds the CPU-side Mesh type and generators for the
+// standard primitives: planes, grids, cubes, spheres, cylinders, cones, tori
+// and capsules, plus tubes around line strips.
+//
+// Every generator returns an indexed triangle list with counter-clockwise
+// front faces, outward unit normals, texture coordinates and tangents. Closed
+// shapes share positions along their UV seams, so OpenEdges reports none for
+// them.
+package geometry
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Vertex is the interleaved layout used for meshes. It is twelve tightly
+// packed float32s, so a []Vertex can be handed straight to gl.BufferData.
+type Vertex struct {
+	Position mgl32.Vec3
+	Normal   mgl32.Vec3
+	// Tangent.W is +1 or -1 and gives the handedness of the bitangent:
+	// bitangent = cross(Normal, Tangent.XYZ) * Tangent.W.
+	Tangent mgl32.Vec4
+	UV      mgl32.Vec2
+}
+
+// Sizes and offsets, in bytes, for gl.VertexAttribPointer.
+const (
+	VertexSize     = int32(unsafe.Sizeof(Vertex{}))
+	PositionOffset = int(unsafe.Offsetof(Vertex{}.Position))
+	NormalOffset   = int(unsafe.Offsetof(Vertex{}.Normal))
+	TangentOffset  = int(unsafe.Offsetof(Vertex{}.Tangent))
+	UVOffset       = int(unsafe.Offsetof(Vertex{}.UV))
+)
+
+// Mesh is an indexed triangle list.
+type Mesh struct {
+	Vertices []Vertex
+	// Indices holds three vertex numbers per triangle.
+	Indices []uint32
+}
+
+// Triangles returns the number of triangles.
+func (m *Mesh) Triangles() int {
+	return len(m.Indices) / 3
+}
+
+// Positions returns a copy of the vertex positions, e.g. for pick.NewBVH.
+func (m *Mesh) Positions() []mgl32.Vec3 {
+	p := make([]mgl32.Vec3, len(m.Vertices))
+	for i, v := range m.Vertices {
+		p[i] = v.Position
+	}
+	return p
+}
+
+// Transform applies t to positions, and its inverse transpose to normals and
+// tangents.
+func (m *Mesh) Transform(t mgl32.Mat4) {
+	n := t.Mat3().Inv().Transpose()
+	for i := range m.Vertices {
+		v := &m.Vertices[i]
+		v.Position = t.Mul4x1(v.Position.Vec4(1)).Vec3()
+		v.Normal = n.Mul3x1(v.Normal).Normalize()
+		v.Tangent = t.Mat3().Mul3x1(v.Tangent.Vec3()).Normalize().Vec4(v.Tangent[3])
+	}
+}
+
+// Append adds the triangles of o to m.
+func (m *Mesh) Append(o *Mesh) {
+	base := uint32(len(m.Vertices))
+	m.Vertices = append(m.Vertices, o.Vertices...)
+	for _, i := range o.Indices {
+		m.Indices = append(m.Indices, base+i)
+	}
+}
+
+// Bounds returns the axis-aligned box around the vertices.
+func (m *Mesh) Bounds() (min, max mgl32.Vec3) {
+	if len(m.Vertices) == 0 {
+		return
+	}
+	min, max = m.Vertices[0].Position, m.Vertices[0].Position
+	for _, v := range m.Vertices[1:] {
+		for i := 0; i < 3; i++ {
+			if v.Position[i] < min[i] {
+				min[i] = v.Position[i]
+			}
+			if v.Position[i] > max[i] {
+				max[i] = v.Position[i]
+			}
+		}
+	}
+	return min, max
+}
+
+// ComputeTangents fills in Tangent from the positions, normals and UVs
+// (Lengyel's method).
+func (m *Mesh) ComputeTangents() {
+	tan := make([]mgl32.Vec3, len(m.Vertices))
+	bitan := make([]mgl32.Vec3, len(m.Vertices))
+
+	for t := 0; t+2 < len(m.Indices); t += 3 {
+		i0, i1, i2 := m.Indices[t], m.Indices[t+1], m.Indices[t+2]
+		v0, v1, v2 := m.Vertices[i0], m.Vertices[i1], m.Vertices[i2]
+
+		e1 := v1.Position.Sub(v0.Position)
+		e2 := v2.Position.Sub(v0.Position)
+		d1 := v1.UV.Sub(v0.UV)
+		d2 := v2.UV.Sub(v0.UV)
+
+		det := d1[0]*d2[1] - d2[0]*d1[1]
+		if det == 0 {
+			continue
+		}
+		r := 1 / det
+		sdir := e1.Mul(d2[1]).Sub(e2.Mul(d1[1])).Mul(r)
+		tdir := e2.Mul(d1[0]).Sub(e1.Mul(d2[0])).Mul(r)
+
+		for _, i := range [3]uint32{i0, i1, i2} {
+			tan[i] = tan[i].Add(sdir)
+			bitan[i] = bitan[i].Add(tdir)
+		}
+	}
+
+	for i := range m.Vertices {
+		v := &m.Vertices[i]
+		n := v.Normal
+
+		// Gram-Schmidt orthogonalize against the normal.
+		t := tan[i].Sub(n.Mul(n.Dot(tan[i])))
+		if t.Len() < 1e-12 {
+			t = anyPerpendicular(n)
+		}
+		t = t.Normalize()
+
+		w := float32(1)
+		if n.Cross(t).Dot(bitan[i]) < 0 {
+			w = -1
+		}
+		v.Tangent = t.Vec4(w)
+	}
+}
+
+// Weld merges vertices that are identical in every attribute and drops the
+// ones no longer used.
+func (m *Mesh) Weld() {
+	seen := make(map[Vertex]uint32, len(m.Vertices))
+	remap := make([]uint32, len(m.Vertices))
+	var out []Vertex
+
+	for i, v := range m.Vertices {
+		j, ok := seen[v]
+		if !ok {
+			j = uint32(len(out))
+			seen[v] = j
+			out = append(out, v)
+		}
+		remap[i] = j
+	}
+
+	for i, idx := range m.Indices {
+		m.Indices[i] = remap[idx]
+	}
+	m.Vertices = out
+}
+
+// OpenEdges returns the number of edges, joining vertices by position alone,
+// that are not shared by exactly two triangles. It is 0 for a watertight
+// mesh.
+func (m *Mesh) OpenEdges() int {
+	id := make(map[mgl32.Vec3]uint32)
+	key := func(i uint32) uint32 {
+		p := m.Vertices[i].Position
+		k, ok := id[p]
+		if !ok {
+			k = uint32(len(id))
+			id[p] = k
+		}
+		return k
+	}
+
+	type edge struct{ a, b uint32 }
+	count := make(map[edge]int)
+	for t := 0; t+2 < len(m.Indices); t += 3 {
+		k := [3]uint32{key(m.Indices[t]), key(m.Indices[t+1]), key(m.Indices[t+2])}
+		for j := 0; j < 3; j++ {
+			a, b := k[j], k[(j+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			count[edge{a, b}]++
+		}
+	}
+
+	open := 0
+	for _, c := range count {
+		if c != 2 {
+			open++
+		}
+	}
+	return open
+}
+
+// anyPerpendicular returns some unit vector perpendicular to n.
+func anyPerpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	a := mgl32.Vec3{1, 0, 0}
+	if n[0] > 0.9 || n[0] < -0.9 {
+		a = mgl32.Vec3{0, 1, 0}
+	}
+	return n.Cross(a).Normalize()
+}
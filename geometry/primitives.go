@@ -0,0 +1,384 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// builder accumulates vertices and triangles for the generators.
+type builder struct {
+	m Mesh
+}
+
+func (b *builder) vertex(p, n mgl32.Vec3, u, v float32) uint32 {
+	b.m.Vertices = append(b.m.Vertices, Vertex{Position: p, Normal: n, UV: mgl32.Vec2{u, v}})
+	return uint32(len(b.m.Vertices) - 1)
+}
+
+// triangle adds a counter-clockwise triangle, skipping it when two of its
+// corners coincide (as they do next to the poles of a sphere).
+func (b *builder) triangle(i0, i1, i2 uint32) {
+	p0 := b.m.Vertices[i0].Position
+	p1 := b.m.Vertices[i1].Position
+	p2 := b.m.Vertices[i2].Position
+	if p0 == p1 || p1 == p2 || p2 == p0 {
+		return
+	}
+	b.m.Indices = append(b.m.Indices, i0, i1, i2)
+}
+
+// grid adds a (cols+1) x (rows+1) patch of vertices from surface, which maps
+// s, t in [0, 1] to a position and normal. Increasing s then t must turn
+// counter-clockwise about the normal. Texture coordinates are s, t.
+func (b *builder) grid(cols, rows int, surface func(i, j int) (p, n mgl32.Vec3)) {
+	base := uint32(len(b.m.Vertices))
+	for j := 0; j <= rows; j++ {
+		for i := 0; i <= cols; i++ {
+			p, n := surface(i, j)
+			b.vertex(p, n, float32(i)/float32(cols), float32(j)/float32(rows))
+		}
+	}
+
+	stride := uint32(cols + 1)
+	for j := 0; j < rows; j++ {
+		for i := 0; i < cols; i++ {
+			a := base + uint32(j)*stride + uint32(i)
+			c := a + stride + 1
+			b.triangle(a, a+1, c)
+			b.triangle(a, c, a+stride)
+		}
+	}
+}
+
+// fan adds a disc of radius r at height y, facing up (+Y) or down.
+func (b *builder) fan(segments int, r, y float32, up bool) {
+	n := mgl32.Vec3{0, -1, 0}
+	if up {
+		n = mgl32.Vec3{0, 1, 0}
+	}
+
+	centre := b.vertex(mgl32.Vec3{0, y, 0}, n, 0.5, 0.5)
+	first := uint32(len(b.m.Vertices))
+	for i := 0; i <= segments; i++ {
+		s, c := sinCos(i, segments)
+		b.vertex(mgl32.Vec3{r * s, y, r * c}, n, 0.5+s/2, 0.5+c/2)
+	}
+	for i := uint32(0); i < uint32(segments); i++ {
+		if up {
+			b.triangle(centre, first+i, first+i+1)
+		} else {
+			b.triangle(centre, first+i+1, first+i)
+		}
+	}
+}
+
+func (b *builder) mesh() *Mesh {
+	b.m.ComputeTangents()
+	return &b.m
+}
+
+// sinCos returns sin and cos of the angle i/segments of a full turn. The last
+// segment wraps exactly onto the first, so seams share positions.
+func sinCos(i, segments int) (float32, float32) {
+	if i == segments {
+		i = 0
+	}
+	s, c := math.Sincos(2 * math.Pi * float64(i) / float64(segments))
+	return float32(s), float32(c)
+}
+
+// Plane returns a width x depth square in the XZ plane, facing +Y, divided
+// into segments x segments quads.
+func Plane(width, depth float32, segments int) *Mesh {
+	return Grid(width, depth, segments, segments, nil)
+}
+
+// Grid returns a width x depth height field centred on the origin with
+// cols x rows quads. height gives Y at each X, Z; nil means flat.
+func Grid(width, depth float32, cols, rows int, height func(x, z float32) float32) *Mesh {
+	if height == nil {
+		height = func(x, z float32) float32 { return 0 }
+	}
+
+	dx := width / float32(cols)
+	dz := depth / float32(rows)
+
+	var b builder
+	b.grid(cols, rows, func(i, j int) (mgl32.Vec3, mgl32.Vec3) {
+		x := -width/2 + width*float32(i)/float32(cols)
+		z := depth/2 - depth*float32(j)/float32(rows)
+
+		// Central differences for the slope.
+		hx := (height(x+dx/2, z) - height(x-dx/2, z)) / dx
+		hz := (height(x, z+dz/2) - height(x, z-dz/2)) / dz
+		n := mgl32.Vec3{-hx, 1, -hz}.Normalize()
+
+		return mgl32.Vec3{x, height(x, z), z}, n
+	})
+
+	return b.mesh()
+}
+
+// Cube returns a cube with sides of length size centred on the origin. Each
+// face is divided into segments x segments quads and carries the whole
+// texture.
+func Cube(size float32, segments int) *Mesh {
+	faces := [6]struct{ n, u, v mgl32.Vec3 }{
+		{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{0, 1, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, -1}},
+		{mgl32.Vec3{0, -1, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, 1}},
+		{mgl32.Vec3{0, 0, 1}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}},
+		{mgl32.Vec3{0, 0, -1}, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 1, 0}},
+	}
+
+	h := size / 2
+	// coord(segments-i) is exactly -coord(i), so neighbouring faces, which
+	// may run along a shared edge in opposite directions, meet exactly.
+	coord := func(i int) float32 {
+		return h * (float32(2*i-segments) / float32(segments))
+	}
+
+	var b builder
+	for _, f := range faces {
+		f := f
+		b.grid(segments, segments, func(i, j int) (mgl32.Vec3, mgl32.Vec3) {
+			p := f.n.Mul(h).Add(f.u.Mul(coord(i))).Add(f.v.Mul(coord(j)))
+			return p, f.n
+		})
+	}
+
+	return b.mesh()
+}
+
+// UVSphere returns a sphere made of segments slices around the Y axis and
+// rings stacks from pole to pole.
+func UVSphere(radius float32, segments, rings int) *Mesh {
+	var b builder
+	b.grid(segments, rings, func(i, j int) (mgl32.Vec3, mgl32.Vec3) {
+		n := spherePoint(i, segments, j, rings)
+		return n.Mul(radius), n
+	})
+
+	return b.mesh()
+}
+
+// spherePoint returns the unit vector at longitude i/segments and latitude
+// j/rings, from the south pole (j == 0) to the north pole (j == rings).
+func spherePoint(i, segments, j, rings int) mgl32.Vec3 {
+	switch j {
+	case 0:
+		return mgl32.Vec3{0, -1, 0}
+	case rings:
+		return mgl32.Vec3{0, 1, 0}
+	}
+
+	theta := math.Pi * (float64(j)/float64(rings) - 0.5)
+	s, c := sinCos(i, segments)
+	cy := float32(math.Cos(theta))
+
+	return mgl32.Vec3{cy * s, float32(math.Sin(theta)), cy * c}
+}
+
+// Icosphere returns a sphere made by splitting each face of an icosahedron
+// into four, subdivisions times. Vertices are spread far more evenly than on
+// a UVSphere.
+func Icosphere(radius float32, subdivisions int) *Mesh {
+	const t = 1.618033988749895 // the golden ratio
+
+	points := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range points {
+		points[i] = points[i].Normalize()
+	}
+
+	faces := [][3]uint32{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	for s := 0; s < subdivisions; s++ {
+		midpoints := make(map[[2]uint32]uint32)
+		midpoint := func(a, b uint32) uint32 {
+			key := [2]uint32{a, b}
+			if a > b {
+				key = [2]uint32{b, a}
+			}
+			if m, ok := midpoints[key]; ok {
+				return m
+			}
+			points = append(points, points[a].Add(points[b]).Normalize())
+			m := uint32(len(points) - 1)
+			midpoints[key] = m
+			return m
+		}
+
+		next := make([][3]uint32, 0, 4*len(faces))
+		for _, f := range faces {
+			a := midpoint(f[0], f[1])
+			b := midpoint(f[1], f[2])
+			c := midpoint(f[2], f[0])
+			next = append(next,
+				[3]uint32{f[0], a, c},
+				[3]uint32{f[1], b, a},
+				[3]uint32{f[2], c, b},
+				[3]uint32{a, b, c})
+		}
+		faces = next
+	}
+
+	var b builder
+	for _, n := range points {
+		u, v := sphereUV(n)
+		b.vertex(n.Mul(radius), n, u, v)
+	}
+
+	// Triangles that straddle the u = 0/1 seam get copies of their
+	// low-u vertices with u + 1, and triangles touching a pole get a copy
+	// of the pole vertex at the average u of the other two.
+	for _, f := range faces {
+		var us [3]float32
+		for k, i := range f {
+			us[k] = b.m.Vertices[i].UV[0]
+		}
+		lo, hi := us[0], us[0]
+		for _, u := range us[1:] {
+			if u < lo {
+				lo = u
+			}
+			if u > hi {
+				hi = u
+			}
+		}
+		if hi-lo > 0.5 {
+			for k, i := range f {
+				if us[k] < 0.5 {
+					v := b.m.Vertices[i]
+					v.UV[0]++
+					us[k]++
+					b.m.Vertices = append(b.m.Vertices, v)
+					f[k] = uint32(len(b.m.Vertices) - 1)
+				}
+			}
+		}
+		for k, i := range f {
+			n := b.m.Vertices[i].Normal
+			if n[0] == 0 && n[2] == 0 {
+				v := b.m.Vertices[i]
+				v.UV[0] = (us[(k+1)%3] + us[(k+2)%3]) / 2
+				b.m.Vertices = append(b.m.Vertices, v)
+				f[k] = uint32(len(b.m.Vertices) - 1)
+			}
+		}
+		b.triangle(f[0], f[1], f[2])
+	}
+
+	return b.mesh()
+}
+
+// sphereUV is the equirectangular mapping of the unit vector n, matching
+// UVSphere.
+func sphereUV(n mgl32.Vec3) (u, v float32) {
+	u = float32(math.Atan2(float64(n[0]), float64(n[2])) / (2 * math.Pi))
+	if u < 0 {
+		u++
+	}
+	v = float32(0.5 + math.Asin(float64(mgl32.Clamp(n[1], -1, 1)))/math.Pi)
+	return u, v
+}
+
+// Cylinder returns a capped cylinder around the Y axis from y = -height/2 to
+// height/2, with segments slices and rings stacks along its side.
+func Cylinder(radius, height float32, segments, rings int) *Mesh {
+	var b builder
+	b.grid(segments, rings, func(i, j int) (mgl32.Vec3, mgl32.Vec3) {
+		s, c := sinCos(i, segments)
+		y := -height/2 + height*(float32(j)/float32(rings))
+		return mgl32.Vec3{radius * s, y, radius * c}, mgl32.Vec3{s, 0, c}
+	})
+	b.fan(segments, radius, -height/2, false)
+	b.fan(segments, radius, height/2, true)
+
+	return b.mesh()
+}
+
+// Cone returns a capped cone around the Y axis with its base at
+// y = -height/2 and its tip at height/2.
+func Cone(radius, height float32, segments, rings int) *Mesh {
+	var b builder
+	b.grid(segments, rings, func(i, j int) (mgl32.Vec3, mgl32.Vec3) {
+		s, c := sinCos(i, segments)
+		f := float32(j) / float32(rings)
+		y := -height/2 + height*f
+		r := radius * (1 - f)
+
+		n := mgl32.Vec3{height * s, radius, height * c}.Normalize()
+		if j == rings {
+			// The tip itself.
+			return mgl32.Vec3{0, y, 0}, n
+		}
+		return mgl32.Vec3{r * s, y, r * c}, n
+	})
+	b.fan(segments, radius, -height/2, false)
+
+	return b.mesh()
+}
+
+// Torus returns a ring around the Y axis. major is the distance from the
+// centre to the middle of the tube and minor is the radius of the tube.
+func Torus(major, minor float32, segments, sides int) *Mesh {
+	var b builder
+	b.grid(segments, sides, func(i, j int) (mgl32.Vec3, mgl32.Vec3) {
+		s, c := sinCos(i, segments)
+		ts, tc := sinCos(j, sides)
+
+		n := mgl32.Vec3{tc * s, ts, tc * c}
+		r := major + minor*tc
+		return mgl32.Vec3{r * s, minor * ts, r * c}, n
+	})
+
+	return b.mesh()
+}
+
+// Capsule returns a cylinder of the given height capped by two hemispheres,
+// so the total height is height + 2*radius. rings is the number of stacks in
+// each hemisphere.
+func Capsule(radius, height float32, segments, rings int) *Mesh {
+	// Rows 0..rings are the lower hemisphere, rings+1..2*rings+1 the upper.
+	rows := 2*rings + 1
+	total := height + 2*radius
+
+	var b builder
+	base := uint32(len(b.m.Vertices))
+	for j := 0; j <= rows; j++ {
+		lat := j
+		y := -height / 2
+		if j > rings {
+			lat = j - 1
+			y = height / 2
+		}
+		for i := 0; i <= segments; i++ {
+			n := spherePoint(i, segments, lat, 2*rings)
+			p := n.Mul(radius).Add(mgl32.Vec3{0, y, 0})
+			b.vertex(p, n, float32(i)/float32(segments), (p[1]+total/2)/total)
+		}
+	}
+
+	stride := uint32(segments + 1)
+	for j := 0; j < rows; j++ {
+		for i := 0; i < segments; i++ {
+			a := base + uint32(j)*stride + uint32(i)
+			c := a + stride + 1
+			b.triangle(a, a+1, c)
+			b.triangle(a, c, a+stride)
+		}
+	}
+
+	return b.mesh()
+}
@@ -0,0 +1,100 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// closed are the generators that make watertight meshes, each with the
+// point that its faces should face away from for a given face centre.
+var closed = []struct {
+	name   string
+	mesh   *Mesh
+	inside func(centre mgl32.Vec3) mgl32.Vec3
+}{
+	{"Cube", Cube(2, 3), origin},
+	{"UVSphere", UVSphere(1, 24, 12), origin},
+	{"Icosphere", Icosphere(1, 2), origin},
+	{"Cylinder", Cylinder(1, 2, 24, 3), axis},
+	{"Cone", Cone(1, 2, 24, 3), origin},
+	{"Torus", Torus(1, 0.3, 32, 16), tubeCentre(1)},
+	{"Capsule", Capsule(0.5, 1, 24, 6), axis},
+}
+
+func origin(mgl32.Vec3) mgl32.Vec3 { return mgl32.Vec3{} }
+
+// axis is the nearest point on the Y axis, for shapes that are round about
+// it but long along it.
+func axis(p mgl32.Vec3) mgl32.Vec3 {
+	// Caps are flat, so anything below their middle is inside.
+	return mgl32.Vec3{0, p[1] * 0.5, 0}
+}
+
+// tubeCentre returns the nearest point on the circle of radius major around
+// the Y axis, the middle of a torus's tube.
+func tubeCentre(major float32) func(mgl32.Vec3) mgl32.Vec3 {
+	return func(p mgl32.Vec3) mgl32.Vec3 {
+		return mgl32.Vec3{p[0], 0, p[2]}.Normalize().Mul(major)
+	}
+}
+
+func TestClosedMeshesAreWatertight(t *testing.T) {
+	for _, tt := range closed {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mesh.Triangles() == 0 {
+				t.Fatal("no triangles")
+			}
+			if n := tt.mesh.OpenEdges(); n != 0 {
+				t.Errorf("%d open edges", n)
+			}
+
+			// With consistent winding, every edge is used once in each
+			// direction by the two triangles that share it.
+			type edge struct{ a, b mgl32.Vec3 }
+			seen := make(map[edge]bool)
+			m := tt.mesh
+			for i := 0; i < len(m.Indices); i += 3 {
+				for j := 0; j < 3; j++ {
+					e := edge{m.Vertices[m.Indices[i+j]].Position, m.Vertices[m.Indices[i+(j+1)%3]].Position}
+					if seen[e] {
+						t.Fatalf("edge %v to %v is used twice in the same direction", e.a, e.b)
+					}
+					seen[e] = true
+				}
+			}
+		})
+	}
+}
+
+func TestClosedMeshesFaceOutwards(t *testing.T) {
+	for _, tt := range closed {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.mesh
+			for i := 0; i < len(m.Indices); i += 3 {
+				v0, v1, v2 := m.Vertices[m.Indices[i]], m.Vertices[m.Indices[i+1]], m.Vertices[m.Indices[i+2]]
+				face := v1.Position.Sub(v0.Position).Cross(v2.Position.Sub(v0.Position))
+				if face.Len() < 1e-9 {
+					t.Fatalf("triangle %d has no area", i/3)
+				}
+				face = face.Normalize()
+
+				centre := v0.Position.Add(v1.Position).Add(v2.Position).Mul(1.0 / 3)
+				if out := centre.Sub(tt.inside(centre)); face.Dot(out) <= 0 {
+					t.Fatalf("triangle %d at %v faces %v, inwards", i/3, centre, face)
+				}
+
+				for k, v := range [3]Vertex{v0, v1, v2} {
+					if l := v.Normal.Len(); l < 0.999 || l > 1.001 {
+						t.Fatalf("triangle %d corner %d: normal %v is not unit length", i/3, k, v.Normal)
+					}
+					// Smooth normals lean away from the face across curved
+					// or creased surfaces, but never by a right angle.
+					if d := v.Normal.Dot(face); d < 0.5 {
+						t.Fatalf("triangle %d corner %d: normal %v disagrees with face normal %v", i/3, k, v.Normal, face)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// maxMiter limits how far a ring is stretched at a sharp corner, as a
+// multiple of the radius.
+const maxMiter = 4
+
+// Tube thickens a line strip into a closed tube of the given radius with
+// sides faces around, capped at both ends. At each corner the ring of
+// vertices lies in the plane halfway between the two segments and is
+// stretched so the tube keeps its radius on both sides, as a mitred joint.
+// Repeated points are skipped; fewer than two distinct points give an empty
+// mesh. A path that crosses or retraces itself gives a self-intersecting
+// surface, which slicers treat as the union of the overlapping parts.
+//
+// This turns line drawings, such as the random walks of 06-MakingGeometry,
+// into solids that can be exported and 3D printed.
+func Tube(points []mgl32.Vec3, radius float32, sides int) *Mesh {
+	var path []mgl32.Vec3
+	for i, p := range points {
+		if i == 0 || p != path[len(path)-1] {
+			path = append(path, p)
+		}
+	}
+	if len(path) < 2 || sides < 3 {
+		return &Mesh{}
+	}
+
+	// One ring of sides+1 positions (the last repeats the first) and
+	// outward normals per point.
+	type ring struct {
+		dir  mgl32.Vec3
+		p, n []mgl32.Vec3
+	}
+	rings := make([]ring, len(path))
+
+	var normal mgl32.Vec3
+	for k, p := range path {
+		var in, out mgl32.Vec3
+		if k > 0 {
+			in = p.Sub(path[k-1]).Normalize()
+		}
+		if k+1 < len(path) {
+			out = path[k+1].Sub(p).Normalize()
+		}
+		switch k {
+		case 0:
+			in = out
+		case len(path) - 1:
+			out = in
+		}
+
+		dir := in.Add(out)
+		if dir.Len() < 1e-3 {
+			// The path doubles back on itself.
+			dir = in
+		}
+		dir = dir.Normalize()
+
+		// The ring is stretched along the direction the path bends.
+		bend := out.Sub(in)
+		stretch := float32(1)
+		if bend.Len() > 1e-6 {
+			bend = bend.Normalize()
+			if c := dir.Dot(out); c > 1/float32(maxMiter) {
+				stretch = 1 / c
+			} else {
+				// Sharper corners are held at the limit rather than left
+				// unstretched, which would pinch the tube.
+				stretch = maxMiter
+			}
+		}
+
+		// Carry the previous frame round the corner (parallel transport)
+		// so the tube does not twist.
+		if k == 0 {
+			normal = anyPerpendicular(dir)
+		} else {
+			normal = normal.Sub(dir.Mul(normal.Dot(dir)))
+			if normal.Len() < 1e-6 {
+				normal = anyPerpendicular(dir)
+			}
+			normal = normal.Normalize()
+		}
+		binormal := dir.Cross(normal)
+
+		r := ring{dir: dir, p: make([]mgl32.Vec3, sides+1), n: make([]mgl32.Vec3, sides+1)}
+		for i := 0; i <= sides; i++ {
+			s, c := sinCos(i, sides)
+			n := normal.Mul(c).Add(binormal.Mul(s))
+			o := n.Mul(radius)
+			if stretch != 1 {
+				o = o.Add(bend.Mul(o.Dot(bend) * (stretch - 1)))
+			}
+			r.p[i] = p.Add(o)
+			r.n[i] = n
+		}
+		rings[k] = r
+	}
+
+	var b builder
+	b.grid(sides, len(path)-1, func(i, j int) (mgl32.Vec3, mgl32.Vec3) {
+		return rings[j].p[i], rings[j].n[i]
+	})
+
+	// Caps, using the same ring positions so the mesh is watertight.
+	endCap := func(r ring, p mgl32.Vec3, n mgl32.Vec3, facing bool) {
+		centre := b.vertex(p, n, 0.5, 0.5)
+		first := uint32(len(b.m.Vertices))
+		for i := 0; i <= sides; i++ {
+			s, c := sinCos(i, sides)
+			b.vertex(r.p[i], n, 0.5+c/2, 0.5+s/2)
+		}
+		for i := uint32(0); i < uint32(sides); i++ {
+			if facing {
+				b.triangle(centre, first+i, first+i+1)
+			} else {
+				b.triangle(centre, first+i+1, first+i)
+			}
+		}
+	}
+	first, last := rings[0], rings[len(rings)-1]
+	endCap(first, path[0], first.dir.Mul(-1), false)
+	endCap(last, path[len(path)-1], last.dir, true)
+
+	return b.mesh()
+}
@@ -0,0 +1,45 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestTube(t *testing.T) {
+	const radius = 0.05
+	tests := []struct {
+		name   string
+		corner mgl32.Vec3 // the point after the corner at (1, 0, 0)
+		// reach is how far the corner's ring reaches from it, in radii.
+		reach float64
+	}{
+		{"straight", mgl32.Vec3{2, 0, 0}, 1},
+		{"right angle", mgl32.Vec3{1, 1, 0}, math.Sqrt2},
+		// Past the miter limit, the ring is held at the limit.
+		{"hairpin", mgl32.Vec3{0, 0.05, 0}, maxMiter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Tube([]mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 0, 0}, tt.corner}, radius, 16)
+			if n := m.OpenEdges(); n != 0 {
+				t.Errorf("%d open edges", n)
+			}
+
+			var reach float32
+			for _, v := range m.Vertices {
+				if d := v.Position.Sub(mgl32.Vec3{1, 0, 0}).Len(); d < 0.5 {
+					reach = max(reach, d)
+				}
+			}
+			if want := float32(tt.reach * radius); math.Abs(float64(reach-want)) > 1e-3*radius {
+				t.Errorf("corner ring reaches %v, want %v", reach, want)
+			}
+		})
+	}
+
+	if m := Tube([]mgl32.Vec3{{1, 2, 3}, {1, 2, 3}}, 1, 8); len(m.Indices) != 0 {
+		t.Errorf("a single repeated point gives %d indices, want none", len(m.Indices))
+	}
+}
@@ -0,0 +1,39 @@
+// Package glstate sets up OpenGL state that other packages only describe,
+// such as the depth test that a camera's projection expects, so that those
+// packages can stay free of GL calls.
+package glstate
+
+import (
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// ApplyDepth sets the depth range, depth test and clear depth to match a
+// camera.Camera's ReverseZ. Call it once after gl.Init, and again whenever
+// ReverseZ changes.
+//
+// Reverse-Z only pays off with a floating-point depth buffer; the default
+// framebuffer is usually 24-bit fixed point, so render into a framebuffer
+// with a DEPTH_COMPONENT32F attachment (see NewDepthRenderbuffer).
+func ApplyDepth(reverseZ bool) {
+	if reverseZ {
+		gl.ClipControl(gl.LOWER_LEFT, gl.ZERO_TO_ONE)
+		gl.ClearDepth(0)
+		gl.DepthFunc(gl.GREATER)
+	} else {
+		gl.ClipControl(gl.LOWER_LEFT, gl.NEGATIVE_ONE_TO_ONE)
+		gl.ClearDepth(1)
+		gl.DepthFunc(gl.LESS)
+	}
+}
+
+// NewDepthRenderbuffer allocates a width x height 32-bit floating-point depth
+// renderbuffer, ready to attach to a framebuffer's GL_DEPTH_ATTACHMENT.
+func NewDepthRenderbuffer(width, height int) uint32 {
+	var rbo uint32
+	gl.GenRenderbuffers(1, &rbo)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, rbo)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT32F, int32(width), int32(height))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	return rbo
+}
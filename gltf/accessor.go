@@ -0,0 +1,249 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Accessor component types.
+const (
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+)
+
+func componentSize(t int) int {
+	switch t {
+	case componentByte, componentUnsignedByte:
+		return 1
+	case componentShort, componentUnsignedShort:
+		return 2
+	case componentUnsignedInt, componentFloat:
+		return 4
+	}
+	return 0
+}
+
+// typeShape returns the number of columns and rows of an accessor type.
+func typeShape(t string) (cols, rows int) {
+	switch t {
+	case "SCALAR":
+		return 1, 1
+	case "VEC2":
+		return 1, 2
+	case "VEC3":
+		return 1, 3
+	case "VEC4":
+		return 1, 4
+	case "MAT2":
+		return 2, 2
+	case "MAT3":
+		return 3, 3
+	case "MAT4":
+		return 4, 4
+	}
+	return 0, 0
+}
+
+// component reads one component at b, converting it to a float. Normalized
+// integers are mapped to [0, 1] or [-1, 1].
+func component(b []byte, t int, normalized bool) float64 {
+	switch t {
+	case componentByte:
+		v := float64(int8(b[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case componentUnsignedByte:
+		v := float64(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case componentShort:
+		v := float64(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case componentUnsignedShort:
+		v := float64(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case componentUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(b))
+	case componentFloat:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	return 0
+}
+
+// bufferView returns the bytes of a buffer view.
+func (d *decoder) bufferView(i int) ([]byte, int, error) {
+	if i < 0 || i >= len(d.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %d out of range", i)
+	}
+	v := d.doc.BufferViews[i]
+	if v.Buffer < 0 || v.Buffer >= len(d.buffers) {
+		return nil, 0, fmt.Errorf("buffer view %d: buffer %d out of range", i, v.Buffer)
+	}
+	b := d.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset+v.ByteLength > len(b) {
+		return nil, 0, fmt.Errorf("buffer view %d runs past the end of buffer %d", i, v.Buffer)
+	}
+
+	return b[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+// read returns the components of every element of an accessor, along with
+// the number of components per element. Accessors with no buffer view read
+// as zeros, and sparse values are applied on top.
+func (d *decoder) read(i int) ([]float64, int, error) {
+	if i < 0 || i >= len(d.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d out of range", i)
+	}
+	a := d.doc.Accessors[i]
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("accessor %d: %s", i, fmt.Sprintf(format, args...))
+	}
+
+	size := componentSize(a.ComponentType)
+	cols, rows := typeShape(a.Type)
+	if size == 0 {
+		return nil, 0, errorf("unknown component type %d", a.ComponentType)
+	}
+	if cols == 0 {
+		return nil, 0, errorf("unknown type %q", a.Type)
+	}
+	n := cols * rows
+	if a.Count < 0 || a.Count > math.MaxInt32/n {
+		return nil, 0, errorf("bad count %d", a.Count)
+	}
+	if a.ByteOffset < 0 {
+		return nil, 0, errorf("negative byte offset %d", a.ByteOffset)
+	}
+
+	// Matrix columns start on 4-byte boundaries.
+	colStride := rows * size
+	if cols > 1 {
+		colStride = (colStride + 3) &^ 3
+	}
+	elemSize := cols * colStride
+
+	// Check count against the data before allocating for it.
+	var view []byte
+	stride := elemSize
+	if a.BufferView != nil {
+		b, viewStride, err := d.bufferView(*a.BufferView)
+		if err != nil {
+			return nil, 0, errorf("%v", err)
+		}
+		if viewStride != 0 {
+			stride = viewStride
+		}
+		if stride < elemSize {
+			return nil, 0, errorf("byte stride %d is less than the element size %d", stride, elemSize)
+		}
+		if a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+elemSize > len(b) {
+			return nil, 0, errorf("%d elements run past the end of buffer view %d", a.Count, *a.BufferView)
+		}
+		view = b
+	} else {
+		// Zeros, perhaps with sparse values, as for morph targets. They
+		// stand in for data of the same size elsewhere in the buffers.
+		total := 0
+		for _, b := range d.buffers {
+			total += len(b)
+		}
+		if a.Count*elemSize > total {
+			return nil, 0, errorf("%d elements with no buffer view are more than the buffers hold", a.Count)
+		}
+	}
+
+	values := make([]float64, a.Count*n)
+
+	element := func(b []byte, k int) {
+		for c := 0; c < cols; c++ {
+			for r := 0; r < rows; r++ {
+				values[k*n+c*rows+r] = component(b[c*colStride+r*size:], a.ComponentType, a.Normalized)
+			}
+		}
+	}
+
+	if view != nil {
+		for k := 0; k < a.Count; k++ {
+			element(view[a.ByteOffset+k*stride:], k)
+		}
+	}
+
+	if s := a.Sparse; s != nil {
+		isize := componentSize(s.Indices.ComponentType)
+		ib, _, err := d.bufferView(s.Indices.BufferView)
+		if err != nil {
+			return nil, 0, errorf("sparse indices: %v", err)
+		}
+		vb, _, err := d.bufferView(s.Values.BufferView)
+		if err != nil {
+			return nil, 0, errorf("sparse values: %v", err)
+		}
+		if isize == 0 || s.Count < 0 || s.Count > a.Count ||
+			s.Indices.ByteOffset < 0 || s.Values.ByteOffset < 0 ||
+			s.Indices.ByteOffset+s.Count*isize > len(ib) ||
+			s.Values.ByteOffset+s.Count*elemSize > len(vb) {
+			return nil, 0, errorf("bad sparse storage")
+		}
+		for j := 0; j < s.Count; j++ {
+			k := int(component(ib[s.Indices.ByteOffset+j*isize:], s.Indices.ComponentType, false))
+			if k >= a.Count {
+				return nil, 0, errorf("sparse index %d out of range", k)
+			}
+			element(vb[s.Values.ByteOffset+j*elemSize:], k)
+		}
+	}
+
+	return values, n, nil
+}
+
+// floats reads an accessor that must have n components per element.
+func (d *decoder) floats(i, n int) ([]float32, error) {
+	values, got, err := d.read(i)
+	if err != nil {
+		return nil, err
+	}
+	if got != n {
+		return nil, fmt.Errorf("accessor %d has %d components, expected %d", i, got, n)
+	}
+
+	f := make([]float32, len(values))
+	for k, v := range values {
+		f[k] = float32(v)
+	}
+	return f, nil
+}
+
+// uints reads an integer accessor that must have n components per element.
+func (d *decoder) uints(i, n int) ([]uint32, error) {
+	values, got, err := d.read(i)
+	if err != nil {
+		return nil, err
+	}
+	if got != n {
+		return nil, fmt.Errorf("accessor %d has %d components, expected %d", i, got, n)
+	}
+	if d.doc.Accessors[i].ComponentType == componentFloat {
+		return nil, fmt.Errorf("accessor %d holds floats, expected integers", i)
+	}
+
+	u := make([]uint32, len(values))
+	for k, v := range values {
+		u[k] = uint32(v)
+	}
+	return u, nil
+}
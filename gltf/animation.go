@@ -0,0 +1,229 @@
+package gltf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Path is the node property an animation channel drives.
+type Path string
+
+const (
+	TranslationPath Path = "translation"
+	RotationPath    Path = "rotation"
+	ScalePath       Path = "scale"
+	// WeightsPath drives morph target weights, which are not supported;
+	// such channels are loaded but Apply skips them.
+	WeightsPath Path = "weights"
+)
+
+// Interpolation says how a sampler fills in between keyframes.
+type Interpolation string
+
+const (
+	Linear      Interpolation = "LINEAR"
+	Step        Interpolation = "STEP"
+	CubicSpline Interpolation = "CUBICSPLINE"
+)
+
+// Animation is a set of channels played together.
+type Animation struct {
+	Name     string
+	Channels []*Channel
+	// Duration is the time of the last keyframe, in seconds.
+	Duration float32
+}
+
+// Channel animates one property of one node.
+type Channel struct {
+	Node    *Node
+	Path    Path
+	Sampler *Sampler
+}
+
+// Sampler holds keyframes. Output has one value per Input time, each of
+// Components floats; for CubicSpline each value is an in-tangent, the value
+// and an out-tangent, in that order.
+type Sampler struct {
+	Input         []float32
+	Output        []float32
+	Components    int
+	Interpolation Interpolation
+}
+
+// Apply poses the animated nodes at time t, in seconds. Times outside the
+// keyframes hold the first or last pose; to loop, pass t modulo Duration.
+func (a *Animation) Apply(t float32) {
+	for _, c := range a.Channels {
+		if c.Node == nil {
+			continue
+		}
+		switch c.Path {
+		case TranslationPath:
+			v := c.Sampler.sample(t, false)
+			c.Node.Translation = mgl32.Vec3{v[0], v[1], v[2]}
+		case RotationPath:
+			v := c.Sampler.sample(t, true)
+			c.Node.Rotation = mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}
+		case ScalePath:
+			v := c.Sampler.sample(t, false)
+			c.Node.Scale = mgl32.Vec3{v[0], v[1], v[2]}
+		}
+	}
+}
+
+// value returns keyframe k. For cubic splines part picks the in-tangent (0),
+// value (1) or out-tangent (2).
+func (s *Sampler) value(k, part int) []float32 {
+	n := s.Components
+	if s.Interpolation == CubicSpline {
+		k = 3*k + part
+	}
+	return s.Output[k*n : (k+1)*n]
+}
+
+// sample returns the value at time t. Rotations are quaternions (x, y, z, w)
+// and are interpolated along the shortest arc.
+func (s *Sampler) sample(t float32, rotation bool) []float32 {
+	last := len(s.Input) - 1
+	switch {
+	case t <= s.Input[0]:
+		return s.value(0, 1)
+	case t >= s.Input[last]:
+		return s.value(last, 1)
+	}
+
+	// Input[k] <= t < Input[k+1]
+	k := sort.Search(len(s.Input), func(i int) bool { return s.Input[i] > t }) - 1
+	dt := s.Input[k+1] - s.Input[k]
+	u := (t - s.Input[k]) / dt
+
+	out := make([]float32, s.Components)
+	switch s.Interpolation {
+	case Step:
+		copy(out, s.value(k, 1))
+		return out
+
+	case CubicSpline:
+		u2, u3 := u*u, u*u*u
+		v0, b0 := s.value(k, 1), s.value(k, 2)
+		a1, v1 := s.value(k+1, 0), s.value(k+1, 1)
+		for i := range out {
+			out[i] = (2*u3-3*u2+1)*v0[i] + (u3-2*u2+u)*dt*b0[i] +
+				(-2*u3+3*u2)*v1[i] + (u3-u2)*dt*a1[i]
+		}
+		if rotation {
+			q := mgl32.Quat{W: out[3], V: mgl32.Vec3{out[0], out[1], out[2]}}.Normalize()
+			out = []float32{q.V[0], q.V[1], q.V[2], q.W}
+		}
+		return out
+	}
+
+	v0, v1 := s.value(k, 1), s.value(k+1, 1)
+	if rotation {
+		q0 := mgl32.Quat{W: v0[3], V: mgl32.Vec3{v0[0], v0[1], v0[2]}}
+		q1 := mgl32.Quat{W: v1[3], V: mgl32.Vec3{v1[0], v1[1], v1[2]}}
+		if q0.Dot(q1) < 0 {
+			q1 = q1.Scale(-1)
+		}
+		q := mgl32.QuatSlerp(q0, q1, u).Normalize()
+		return []float32{q.V[0], q.V[1], q.V[2], q.W}
+	}
+	for i := range out {
+		out[i] = v0[i] + (v1[i]-v0[i])*u
+	}
+	return out
+}
+
+func (d *decoder) animations(nodes []*Node) ([]*Animation, error) {
+	animations := make([]*Animation, len(d.doc.Animations))
+	for i, aj := range d.doc.Animations {
+		a := &Animation{Name: aj.Name}
+		errorf := func(format string, args ...interface{}) error {
+			return fmt.Errorf("animation %d: %s", i, fmt.Sprintf(format, args...))
+		}
+
+		samplers := make([]*Sampler, len(aj.Samplers))
+		for j, sj := range aj.Samplers {
+			s := &Sampler{Interpolation: Linear}
+			if sj.Interpolation != "" {
+				s.Interpolation = Interpolation(sj.Interpolation)
+			}
+			switch s.Interpolation {
+			case Linear, Step, CubicSpline:
+			default:
+				return nil, errorf("sampler %d: unknown interpolation %q", j, sj.Interpolation)
+			}
+
+			var err error
+			if s.Input, err = d.floats(sj.Input, 1); err != nil {
+				return nil, errorf("sampler %d input: %v", j, err)
+			}
+			if len(s.Input) == 0 {
+				return nil, errorf("sampler %d has no keyframes", j)
+			}
+			values, n, err := d.read(sj.Output)
+			if err != nil {
+				return nil, errorf("sampler %d output: %v", j, err)
+			}
+			s.Output = make([]float32, len(values))
+			for k, v := range values {
+				s.Output[k] = float32(v)
+			}
+
+			// Weights outputs hold one scalar per morph target per
+			// keyframe; everything else has one value per keyframe.
+			keys := len(s.Input)
+			if s.Interpolation == CubicSpline {
+				keys *= 3
+			}
+			if n == 1 && len(s.Output)%keys == 0 {
+				n = len(s.Output) / keys
+			}
+			if len(s.Output) != keys*n {
+				return nil, errorf("sampler %d has %d outputs for %d keyframes", j, len(s.Output)/n, len(s.Input))
+			}
+			s.Components = n
+
+			if last := s.Input[len(s.Input)-1]; last > a.Duration {
+				a.Duration = last
+			}
+			samplers[j] = s
+		}
+
+		for j, cj := range aj.Channels {
+			if cj.Sampler < 0 || cj.Sampler >= len(samplers) {
+				return nil, errorf("channel %d: sampler %d out of range", j, cj.Sampler)
+			}
+			c := &Channel{Path: Path(cj.Target.Path), Sampler: samplers[cj.Sampler]}
+
+			want := 0
+			switch c.Path {
+			case TranslationPath, ScalePath:
+				want = 3
+			case RotationPath:
+				want = 4
+			case WeightsPath:
+			default:
+				return nil, errorf("channel %d: unknown path %q", j, cj.Target.Path)
+			}
+			if want != 0 && c.Sampler.Components != want {
+				return nil, errorf("channel %d: %s needs %d components, sampler has %d", j, c.Path, want, c.Sampler.Components)
+			}
+
+			if n := cj.Target.Node; n != nil {
+				if *n < 0 || *n >= len(nodes) {
+					return nil, errorf("channel %d: node %d out of range", j, *n)
+				}
+				c.Node = nodes[*n]
+			}
+			a.Channels = append(a.Channels, c)
+		}
+
+		animations[i] = a
+	}
+
+	return animations, nil
+}
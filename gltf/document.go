@@ -0,0 +1,181 @@
+package gltf
+
+// The types below mirror the JSON of a glTF 2.0 file. Only the properties
+// the importer uses are listed; optional ones are pointers so that a missing
+// value can be told apart from zero.
+
+type document struct {
+	Asset struct {
+		Version    string `json:"version"`
+		MinVersion string `json:"minVersion"`
+	} `json:"asset"`
+	ExtensionsRequired []string `json:"extensionsRequired"`
+
+	Scene       *int             `json:"scene"`
+	Scenes      []sceneJSON      `json:"scenes"`
+	Nodes       []nodeJSON       `json:"nodes"`
+	Meshes      []meshJSON       `json:"meshes"`
+	Accessors   []accessorJSON   `json:"accessors"`
+	BufferViews []bufferViewJSON `json:"bufferViews"`
+	Buffers     []bufferJSON     `json:"buffers"`
+	Materials   []materialJSON   `json:"materials"`
+	Textures    []textureJSON    `json:"textures"`
+	Images      []imageJSON      `json:"images"`
+	Samplers    []samplerJSON    `json:"samplers"`
+	Cameras     []cameraJSON     `json:"cameras"`
+	Skins       []skinJSON       `json:"skins"`
+	Animations  []animationJSON  `json:"animations"`
+}
+
+type sceneJSON struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type nodeJSON struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Camera      *int         `json:"camera"`
+	Skin        *int         `json:"skin"`
+	Matrix      *[16]float32 `json:"matrix"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"`
+	Scale       *[3]float32  `json:"scale"`
+}
+
+type meshJSON struct {
+	Name       string          `json:"name"`
+	Primitives []primitiveJSON `json:"primitives"`
+}
+
+type primitiveJSON struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type accessorJSON struct {
+	BufferView    *int    `json:"bufferView"`
+	ByteOffset    int     `json:"byteOffset"`
+	ComponentType int     `json:"componentType"`
+	Normalized    bool    `json:"normalized"`
+	Count         int     `json:"count"`
+	Type          string  `json:"type"`
+	Sparse        *sparse `json:"sparse"`
+}
+
+type sparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
+}
+
+type bufferViewJSON struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type bufferJSON struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type textureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord"`
+}
+
+type materialJSON struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          *[4]float32  `json:"baseColorFactor"`
+		BaseColorTexture         *textureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float32     `json:"metallicFactor"`
+		RoughnessFactor          *float32     `json:"roughnessFactor"`
+		MetallicRoughnessTexture *textureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture *struct {
+		textureInfo
+		Scale *float32 `json:"scale"`
+	} `json:"normalTexture"`
+	OcclusionTexture *struct {
+		textureInfo
+		Strength *float32 `json:"strength"`
+	} `json:"occlusionTexture"`
+	EmissiveTexture *textureInfo `json:"emissiveTexture"`
+	EmissiveFactor  [3]float32   `json:"emissiveFactor"`
+	AlphaMode       string       `json:"alphaMode"`
+	AlphaCutoff     *float32     `json:"alphaCutoff"`
+	DoubleSided     bool         `json:"doubleSided"`
+}
+
+type textureJSON struct {
+	Name    string `json:"name"`
+	Sampler *int   `json:"sampler"`
+	Source  *int   `json:"source"`
+}
+
+type imageJSON struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type samplerJSON struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type cameraJSON struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Perspective *struct {
+		AspectRatio float32  `json:"aspectRatio"`
+		YFov        float32  `json:"yfov"`
+		ZFar        *float32 `json:"zfar"`
+		ZNear       float32  `json:"znear"`
+	} `json:"perspective"`
+	Orthographic *struct {
+		XMag  float32 `json:"xmag"`
+		YMag  float32 `json:"ymag"`
+		ZFar  float32 `json:"zfar"`
+		ZNear float32 `json:"znear"`
+	} `json:"orthographic"`
+}
+
+type skinJSON struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Skeleton            *int   `json:"skeleton"`
+	Joints              []int  `json:"joints"`
+}
+
+type animationJSON struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
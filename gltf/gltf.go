@@ -0,0 +1,245 @@
+// Package gltf imports glTF 2.0 scenes, both .gltf files (with external,
+// embedded or data: URI buffers) and binary .glb files.
+//
+// A loaded Model holds the node hierarchy, meshes whose primitives are
+// geometry meshes, PBR metallic-roughness materials, textures with their
+// decoded PNG or JPEG images, cameras, skins and animations. Morph targets
+// and extensions are not supported; files that require an extension are
+// rejected.
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// Model is everything read from a glTF file.
+type Model struct {
+	Scenes []*Scene
+	// Scene is the scene to show by default. It is nil if the file has no
+	// scenes.
+	Scene *Scene
+
+	Nodes      []*Node
+	Meshes     []*Mesh
+	Materials  []*Material
+	Textures   []*Texture
+	Cameras    []*camera.Camera
+	Skins      []*Skin
+	Animations []*Animation
+}
+
+// Scene is a set of root nodes.
+type Scene struct {
+	Name  string
+	Nodes []*Node
+}
+
+// Load reads the .gltf or .glb file at path. External buffers and images are
+// resolved relative to its directory.
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Decode(f, path)
+}
+
+// Decode reads a glTF or GLB model from r. name is used in error messages and
+// to find external files, which are resolved relative to its directory.
+func Decode(r io.Reader, name string) (*Model, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	d := &decoder{name: name, dir: filepath.Dir(name)}
+	m, err := d.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return m, nil
+}
+
+type decoder struct {
+	name string
+	dir  string
+	doc  document
+	// bin is the BIN chunk of a .glb file.
+	bin     []byte
+	buffers [][]byte
+}
+
+const (
+	glbMagic     = 0x46546c67 // "glTF"
+	glbChunkJSON = 0x4e4f534a // "JSON"
+	glbChunkBIN  = 0x004e4942 // "BIN\0"
+)
+
+func (d *decoder) decode(data []byte) (*Model, error) {
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		if data, err = d.splitGLB(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(data, &d.doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(d.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("glTF version %q is not supported", d.doc.Asset.Version)
+	}
+	if len(d.doc.ExtensionsRequired) > 0 {
+		return nil, fmt.Errorf("required extensions %v are not supported", d.doc.ExtensionsRequired)
+	}
+
+	if err := d.loadBuffers(); err != nil {
+		return nil, err
+	}
+
+	return d.model()
+}
+
+// splitGLB returns the JSON chunk of a .glb file and keeps its BIN chunk.
+func (d *decoder) splitGLB(data []byte) ([]byte, error) {
+	if v := binary.LittleEndian.Uint32(data[4:]); v != 2 {
+		return nil, fmt.Errorf("GLB container version %d is not supported", v)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length < 12 {
+		return nil, fmt.Errorf("GLB header gives a length of %d bytes, less than the header", length)
+	}
+	if length > len(data) {
+		return nil, fmt.Errorf("GLB is truncated: %d of %d bytes", len(data), length)
+	}
+
+	var js []byte
+	for rest := data[12:length]; len(rest) >= 8; {
+		size := int(binary.LittleEndian.Uint32(rest))
+		kind := binary.LittleEndian.Uint32(rest[4:])
+		if 8+size > len(rest) {
+			return nil, fmt.Errorf("GLB chunk runs past the end of the file")
+		}
+		chunk := rest[8 : 8+size]
+		switch {
+		case kind == glbChunkJSON && js == nil:
+			js = chunk
+		case kind == glbChunkBIN && d.bin == nil:
+			d.bin = chunk
+		}
+		rest = rest[8+size:]
+	}
+	if js == nil {
+		return nil, fmt.Errorf("GLB has no JSON chunk")
+	}
+
+	return js, nil
+}
+
+func (d *decoder) loadBuffers() error {
+	d.buffers = make([][]byte, len(d.doc.Buffers))
+	for i, b := range d.doc.Buffers {
+		var data []byte
+		var err error
+		if b.URI == "" {
+			// The first buffer of a .glb with no URI is its BIN chunk.
+			if i != 0 || d.bin == nil {
+				return fmt.Errorf("buffer %d has no data", i)
+			}
+			data = d.bin
+		} else if data, err = d.readURI(b.URI); err != nil {
+			return fmt.Errorf("buffer %d: %v", i, err)
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("buffer %d has %d bytes, expected %d", i, len(data), b.ByteLength)
+		}
+		d.buffers[i] = data[:b.ByteLength]
+	}
+
+	return nil
+}
+
+// readURI returns the contents of a data: URI or a file next to the model.
+func (d *decoder) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("only base64 data URIs are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+
+	file, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(d.dir, filepath.FromSlash(file)))
+}
+
+// model converts the parsed document, resolving indices into pointers.
+func (d *decoder) model() (*Model, error) {
+	m := &Model{}
+
+	var err error
+	if m.Textures, err = d.textures(); err != nil {
+		return nil, err
+	}
+	if m.Materials, err = d.materials(m.Textures); err != nil {
+		return nil, err
+	}
+	if m.Meshes, err = d.meshes(m.Materials); err != nil {
+		return nil, err
+	}
+	if m.Cameras, err = d.cameras(); err != nil {
+		return nil, err
+	}
+	if m.Nodes, err = d.nodes(m); err != nil {
+		return nil, err
+	}
+	if m.Skins, err = d.skins(m.Nodes); err != nil {
+		return nil, err
+	}
+	for i, n := range d.doc.Nodes {
+		if n.Skin != nil {
+			if *n.Skin < 0 || *n.Skin >= len(m.Skins) {
+				return nil, fmt.Errorf("node %d: skin %d out of range", i, *n.Skin)
+			}
+			m.Nodes[i].Skin = m.Skins[*n.Skin]
+		}
+	}
+	if m.Animations, err = d.animations(m.Nodes); err != nil {
+		return nil, err
+	}
+
+	for i, s := range d.doc.Scenes {
+		scene := &Scene{Name: s.Name}
+		for _, n := range s.Nodes {
+			if n < 0 || n >= len(m.Nodes) {
+				return nil, fmt.Errorf("scene %d: node %d out of range", i, n)
+			}
+			scene.Nodes = append(scene.Nodes, m.Nodes[n])
+		}
+		m.Scenes = append(m.Scenes, scene)
+	}
+	switch {
+	case d.doc.Scene != nil && *d.doc.Scene >= 0 && *d.doc.Scene < len(m.Scenes):
+		m.Scene = m.Scenes[*d.doc.Scene]
+	case len(m.Scenes) > 0:
+		m.Scene = m.Scenes[0]
+	}
+
+	return m, nil
+}
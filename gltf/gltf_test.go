@@ -0,0 +1,249 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+func load(t *testing.T, path string) *Model {
+	t.Helper()
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestTriangle(t *testing.T) {
+	m := load(t, "testdata/triangle.gltf")
+
+	if len(m.Scenes) != 1 || m.Scene != m.Scenes[0] || m.Scene.Name != "Triangle scene" || len(m.Scene.Nodes) != 2 {
+		t.Fatalf("scenes: %+v", m.Scenes)
+	}
+	if len(m.Meshes) != 1 || len(m.Meshes[0].Primitives) != 1 {
+		t.Fatalf("got %d meshes, want 1 with 1 primitive", len(m.Meshes))
+	}
+	p := m.Meshes[0].Primitives[0]
+	want := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	if len(p.Vertices) != len(want) {
+		t.Fatalf("got %d vertices, want %d", len(p.Vertices), len(want))
+	}
+	for i, v := range p.Vertices {
+		if v.Position != want[i] {
+			t.Errorf("vertex %d at %v, want %v", i, v.Position, want[i])
+		}
+		// The file has no normals, so they are flat: +Z for this winding.
+		if !v.Normal.ApproxEqual(mgl32.Vec3{0, 0, 1}) {
+			t.Errorf("vertex %d normal %v, want +Z", i, v.Normal)
+		}
+	}
+	if len(p.Indices) != 3 || p.Indices[0] != 0 || p.Indices[1] != 1 || p.Indices[2] != 2 {
+		t.Errorf("indices %v, want [0 1 2]", p.Indices)
+	}
+	if p.Material != nil {
+		t.Errorf("material %v, want the default", p.Material)
+	}
+
+	if len(m.Cameras) != 1 {
+		t.Fatalf("got %d cameras, want 1", len(m.Cameras))
+	}
+	c := m.Cameras[0]
+	if c.Kind != camera.PerspectiveKind || c.FovY != 0.8 || c.Aspect != 1.5 || c.Near != 0.1 || c.Far != camera.Infinite {
+		t.Errorf("camera %+v", c)
+	}
+	if n := m.Nodes[1]; n.Camera != c || n.Translation != (mgl32.Vec3{0.5, 0.5, 3}) {
+		t.Errorf("camera node %+v", n)
+	}
+	if len(m.Skins) != 0 || len(m.Animations) != 0 {
+		t.Errorf("got %d skins and %d animations, want none", len(m.Skins), len(m.Animations))
+	}
+}
+
+func TestBox(t *testing.T) {
+	m := load(t, "testdata/box.glb")
+
+	if len(m.Meshes) != 1 || len(m.Meshes[0].Primitives) != 2 {
+		t.Fatalf("got %d meshes, want 1 with 2 primitives", len(m.Meshes))
+	}
+	for i, p := range m.Meshes[0].Primitives {
+		if len(p.Vertices) != 24 || p.Triangles() != 6 {
+			t.Errorf("primitive %d has %d vertices and %d triangles, want 24 and 6", i, len(p.Vertices), p.Triangles())
+		}
+		for _, v := range p.Vertices {
+			for k := 0; k < 3; k++ {
+				if math.Abs(float64(v.Position[k])) != 0.5 {
+					t.Fatalf("primitive %d: vertex at %v, not on the unit cube", i, v.Position)
+				}
+			}
+			if l := v.Normal.Len(); math.Abs(float64(l)-1) > 1e-5 {
+				t.Fatalf("primitive %d: normal %v is not unit length", i, v.Normal)
+			}
+		}
+	}
+
+	if len(m.Materials) != 2 || len(m.Textures) != 1 {
+		t.Fatalf("got %d materials and %d textures, want 2 and 1", len(m.Materials), len(m.Textures))
+	}
+	checker, red := m.Materials[0], m.Materials[1]
+	if checker.BaseColorTexture.Texture != m.Textures[0] || checker.Metallic != 0 || checker.Roughness != 0.5 {
+		t.Errorf("checker material %+v", checker)
+	}
+	if red.BaseColor != (mgl32.Vec4{0.8, 0.1, 0.1, 1}) || red.AlphaMode != Mask || red.AlphaCutoff != 0.25 || !red.DoubleSided {
+		t.Errorf("red material %+v", red)
+	}
+	if m.Meshes[0].Primitives[1].Material != red {
+		t.Errorf("second primitive does not use the red material")
+	}
+	if img := m.Textures[0].Image; img == nil || img.Bounds().Dx() == 0 {
+		t.Errorf("texture image was not decoded")
+	}
+	if !m.Textures[0].SRGB {
+		t.Errorf("base colour texture is not marked sRGB")
+	}
+
+	// The node's matrix is decomposed into translation, rotation and scale.
+	n := m.Nodes[0]
+	if n.Translation != (mgl32.Vec3{1, 2, 3}) || !n.Scale.ApproxEqual(mgl32.Vec3{2, 2, 2}) {
+		t.Errorf("node translation %v, scale %v", n.Translation, n.Scale)
+	}
+	if len(m.Cameras) != 0 || len(m.Skins) != 0 || len(m.Animations) != 0 {
+		t.Errorf("got %d cameras, %d skins, %d animations, want none", len(m.Cameras), len(m.Skins), len(m.Animations))
+	}
+}
+
+func TestSkin(t *testing.T) {
+	m := load(t, "testdata/skin.gltf")
+
+	if len(m.Meshes) != 1 || len(m.Meshes[0].Primitives) != 1 {
+		t.Fatalf("got %d meshes, want 1 with 1 primitive", len(m.Meshes))
+	}
+	p := m.Meshes[0].Primitives[0]
+	// The strip's 10 vertices have no normals, so each of its 8 triangles
+	// gets its own 3 for flat normals.
+	if len(p.Vertices) != 24 || p.Triangles() != 8 || len(p.Joints) != 24 || len(p.Weights) != 24 {
+		t.Fatalf("primitive has %d vertices, %d triangles, %d joints, %d weights",
+			len(p.Vertices), p.Triangles(), len(p.Joints), len(p.Weights))
+	}
+	for i, w := range p.Weights {
+		if s := w[0] + w[1] + w[2] + w[3]; math.Abs(float64(s)-1) > 1e-5 {
+			t.Errorf("vertex %d weights sum to %v", i, s)
+		}
+	}
+
+	if len(m.Skins) != 1 {
+		t.Fatalf("got %d skins, want 1", len(m.Skins))
+	}
+	s := m.Skins[0]
+	root, bend := m.Nodes[1], m.Nodes[2]
+	if s.Name != "Two bones" || s.Skeleton != root || len(s.Joints) != 2 || s.Joints[0] != root || s.Joints[1] != bend {
+		t.Errorf("skin %+v", s)
+	}
+	if len(s.InverseBindMatrices) != 2 {
+		t.Fatalf("got %d inverse bind matrices, want 2", len(s.InverseBindMatrices))
+	}
+	if m.Nodes[0].Skin != s {
+		t.Errorf("strip node does not use the skin")
+	}
+	if bend.Parent != root || bend.Translation != (mgl32.Vec3{0, 1, 0}) {
+		t.Errorf("bend node %+v", bend)
+	}
+
+	if len(m.Animations) != 1 {
+		t.Fatalf("got %d animations, want 1", len(m.Animations))
+	}
+	a := m.Animations[0]
+	if a.Name != "Wave" || len(a.Channels) != 3 || a.Duration != 2 {
+		t.Fatalf("animation %+v", a)
+	}
+	want := []struct {
+		node   *Node
+		path   Path
+		interp Interpolation
+		output int
+	}{
+		{bend, RotationPath, Linear, 3 * 4},
+		{root, TranslationPath, Step, 3 * 3},
+		{root, ScalePath, CubicSpline, 9 * 3},
+	}
+	for i, w := range want {
+		c := a.Channels[i]
+		if c.Node != w.node || c.Path != w.path || c.Sampler.Interpolation != w.interp ||
+			len(c.Sampler.Input) != 3 || len(c.Sampler.Output) != w.output {
+			t.Errorf("channel %d: %v %v %v, %d inputs, %d outputs", i, c.Node.Name, c.Path,
+				c.Sampler.Interpolation, len(c.Sampler.Input), len(c.Sampler.Output))
+		}
+	}
+}
+
+// glb builds a GLB header declaring length bytes, followed by rest.
+func glb(length uint32, rest []byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []uint32{glbMagic, 2, length})
+	b.Write(rest)
+	return b.Bytes()
+}
+
+// accessorDoc returns a glTF document with one triangle whose position
+// accessor has the given JSON fields, over a 36 byte buffer of zeros.
+func accessorDoc(fields string) string {
+	return `{
+		"asset": {"version": "2.0"},
+		"buffers": [{"byteLength": 36, "uri": "data:application/octet-stream;base64,` +
+		strings.Repeat("A", 48) + `"}],
+		"bufferViews": [{"buffer": 0, "byteLength": 36}],
+		"accessors": [{"bufferView": 0, "componentType": 5126, "type": "VEC3", ` + fields + `}],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}]
+	}`
+}
+
+// noView returns accessorDoc's document with the accessor's buffer view
+// taken away, so that it reads as zeros.
+func noView(fields string) string {
+	return strings.Replace(accessorDoc(fields), `"bufferView": 0, "componentType"`, `"componentType"`, 1)
+}
+
+func TestMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"GLB length less than the header", glb(4, make([]byte, 20))},
+		{"GLB length of zero", glb(0, nil)},
+		{"GLB truncated", glb(100, make([]byte, 20))},
+		{"GLB chunk past the end", glb(28, []byte{0xff, 0, 0, 0, 'J', 'S', 'O', 'N', '{', '}', ' ', ' ', ' ', ' ', ' ', ' '})},
+		{"negative count", []byte(accessorDoc(`"count": -1`))},
+		{"huge count", []byte(accessorDoc(`"count": 4000000000`))},
+		{"negative byte offset", []byte(accessorDoc(`"count": 1, "byteOffset": -12`))},
+		{"count past the buffer view", []byte(accessorDoc(`"count": 4`))},
+		{"negative buffer view offset", []byte(strings.Replace(accessorDoc(`"count": 3`),
+			`"byteLength": 36}]`, `"byteLength": 36, "byteOffset": -4}]`, 1))},
+		{"stride less than an element", []byte(strings.Replace(accessorDoc(`"count": 3`),
+			`"byteLength": 36}]`, `"byteLength": 36, "byteStride": 4}]`, 1))},
+		{"huge count with no buffer view", []byte(noView(`"count": 100000000`))},
+		{"negative sparse count", []byte(accessorDoc(`"count": 3, "sparse": {"count": -1,
+			"indices": {"bufferView": 0, "componentType": 5125}, "values": {"bufferView": 0}}`))},
+		{"negative sparse offset", []byte(accessorDoc(`"count": 3, "sparse": {"count": 1,
+			"indices": {"bufferView": 0, "componentType": 5125, "byteOffset": -4}, "values": {"bufferView": 0}}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(bytes.NewReader(tt.data), "bad.glb"); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+
+	// The same documents are fine with a sensible count.
+	if _, err := Decode(strings.NewReader(accessorDoc(`"count": 3`)), "good.gltf"); err != nil {
+		t.Errorf("good document: %v", err)
+	}
+	if _, err := Decode(strings.NewReader(noView(`"count": 3`)), "zeros.gltf"); err != nil {
+		t.Errorf("good document with no buffer view: %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package gltf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/texture"
+)
+
+// AlphaMode says how a material's alpha is used.
+type AlphaMode string
+
+const (
+	// Opaque ignores alpha.
+	Opaque AlphaMode = "OPAQUE"
+	// Mask discards fragments whose alpha is below AlphaCutoff.
+	Mask AlphaMode = "MASK"
+	// Blend blends the fragment over what is already drawn.
+	Blend AlphaMode = "BLEND"
+)
+
+// Material is a PBR metallic-roughness material. Each factor multiplies the
+// matching texture when there is one.
+type Material struct {
+	Name string
+
+	BaseColor        mgl32.Vec4
+	BaseColorTexture TextureRef // sRGB
+
+	// Metallic and Roughness are read from the blue and green channels of
+	// MetallicRoughnessTexture.
+	Metallic                 float32
+	Roughness                float32
+	MetallicRoughnessTexture TextureRef
+
+	NormalTexture TextureRef
+	NormalScale   float32
+
+	// OcclusionTexture is read from the red channel.
+	OcclusionTexture  TextureRef
+	OcclusionStrength float32
+
+	Emissive        mgl32.Vec3
+	EmissiveTexture TextureRef // sRGB
+
+	AlphaMode   AlphaMode
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+// DefaultMaterial returns the material glTF uses for primitives that have
+// none.
+func DefaultMaterial() *Material {
+	return &Material{
+		BaseColor:         mgl32.Vec4{1, 1, 1, 1},
+		Metallic:          1,
+		Roughness:         1,
+		NormalScale:       1,
+		OcclusionStrength: 1,
+		AlphaMode:         Opaque,
+		AlphaCutoff:       0.5,
+	}
+}
+
+// TextureRef points a material at a texture.
+type TextureRef struct {
+	// Texture is nil when the material has no texture in this slot.
+	Texture *Texture
+	// TexCoord selects the UV set. Only set 0 is imported, as Vertex.UV.
+	TexCoord int
+}
+
+// Texture is an image together with how it is sampled. The filter and wrap
+// values are GL enums, as they are in the file.
+type Texture struct {
+	Name  string
+	Image image.Image
+
+	MagFilter int32
+	MinFilter int32
+	WrapS     int32
+	WrapT     int32
+
+	// SRGB is set if a material uses the texture for colour, as base
+	// colour or emissive, which glTF stores sRGB encoded. Upload then
+	// gives it an sRGB format, so that shaders sample linear values.
+	SRGB bool
+
+	// ID is the GL texture name, set by Upload.
+	ID uint32
+}
+
+// Upload creates the GL texture for t, if it has not been already, and
+// returns its name. It needs a current GL context.
+func (t *Texture) Upload() (uint32, error) {
+	if t.ID != 0 {
+		return t.ID, nil
+	}
+
+	var mipmaps bool
+	switch t.MinFilter {
+	case gl.NEAREST, gl.LINEAR:
+	default:
+		mipmaps = true
+	}
+
+	// glTF puts the first row of an image at v = 0, as GL stores it, so the
+	// image is not flipped.
+	tex, err := texture.NewTexture2D(t.Image, texture.Options{
+		Mipmaps:   mipmaps,
+		MinFilter: t.MinFilter,
+		MagFilter: t.MagFilter,
+		WrapS:     t.WrapS,
+		WrapT:     t.WrapT,
+		SRGB:      t.SRGB,
+	})
+	if err != nil {
+		return 0, err
+	}
+	t.ID = tex.ID
+
+	return t.ID, nil
+}
+
+// LoadTextures uploads every texture in the model.
+func (m *Model) LoadTextures() error {
+	for i, t := range m.Textures {
+		if _, err := t.Upload(); err != nil {
+			return fmt.Errorf("texture %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) textures() ([]*Texture, error) {
+	images := make([]image.Image, len(d.doc.Images))
+	for i, im := range d.doc.Images {
+		var data []byte
+		var err error
+		switch {
+		case im.BufferView != nil:
+			data, _, err = d.bufferView(*im.BufferView)
+		case im.URI != "":
+			data, err = d.readURI(im.URI)
+		default:
+			err = fmt.Errorf("no data")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %v", i, err)
+		}
+
+		if images[i], _, err = image.Decode(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("image %d: %v", i, err)
+		}
+	}
+
+	textures := make([]*Texture, len(d.doc.Textures))
+	for i, tj := range d.doc.Textures {
+		t := &Texture{
+			Name:      tj.Name,
+			MagFilter: gl.LINEAR,
+			MinFilter: gl.LINEAR_MIPMAP_LINEAR,
+			WrapS:     gl.REPEAT,
+			WrapT:     gl.REPEAT,
+		}
+
+		if tj.Source == nil || *tj.Source < 0 || *tj.Source >= len(images) {
+			return nil, fmt.Errorf("texture %d has no image", i)
+		}
+		t.Image = images[*tj.Source]
+
+		if tj.Sampler != nil {
+			if *tj.Sampler < 0 || *tj.Sampler >= len(d.doc.Samplers) {
+				return nil, fmt.Errorf("texture %d: sampler %d out of range", i, *tj.Sampler)
+			}
+			s := d.doc.Samplers[*tj.Sampler]
+			if s.MagFilter != 0 {
+				t.MagFilter = int32(s.MagFilter)
+			}
+			if s.MinFilter != 0 {
+				t.MinFilter = int32(s.MinFilter)
+			}
+			if s.WrapS != 0 {
+				t.WrapS = int32(s.WrapS)
+			}
+			if s.WrapT != 0 {
+				t.WrapT = int32(s.WrapT)
+			}
+		}
+
+		textures[i] = t
+	}
+
+	return textures, nil
+}
+
+func (d *decoder) materials(textures []*Texture) ([]*Material, error) {
+	materials := make([]*Material, len(d.doc.Materials))
+	for i, mj := range d.doc.Materials {
+		m := DefaultMaterial()
+		m.Name = mj.Name
+
+		ref := func(info *textureInfo) (TextureRef, error) {
+			if info == nil {
+				return TextureRef{}, nil
+			}
+			if info.Index < 0 || info.Index >= len(textures) {
+				return TextureRef{}, fmt.Errorf("material %d: texture %d out of range", i, info.Index)
+			}
+			return TextureRef{Texture: textures[info.Index], TexCoord: info.TexCoord}, nil
+		}
+
+		var err error
+		if pbr := mj.PBRMetallicRoughness; pbr != nil {
+			if pbr.BaseColorFactor != nil {
+				m.BaseColor = *pbr.BaseColorFactor
+			}
+			if pbr.MetallicFactor != nil {
+				m.Metallic = *pbr.MetallicFactor
+			}
+			if pbr.RoughnessFactor != nil {
+				m.Roughness = *pbr.RoughnessFactor
+			}
+			if m.BaseColorTexture, err = ref(pbr.BaseColorTexture); err != nil {
+				return nil, err
+			}
+			if t := m.BaseColorTexture.Texture; t != nil {
+				t.SRGB = true
+			}
+			if m.MetallicRoughnessTexture, err = ref(pbr.MetallicRoughnessTexture); err != nil {
+				return nil, err
+			}
+		}
+		if nt := mj.NormalTexture; nt != nil {
+			if m.NormalTexture, err = ref(&nt.textureInfo); err != nil {
+				return nil, err
+			}
+			if nt.Scale != nil {
+				m.NormalScale = *nt.Scale
+			}
+		}
+		if ot := mj.OcclusionTexture; ot != nil {
+			if m.OcclusionTexture, err = ref(&ot.textureInfo); err != nil {
+				return nil, err
+			}
+			if ot.Strength != nil {
+				m.OcclusionStrength = *ot.Strength
+			}
+		}
+		if m.EmissiveTexture, err = ref(mj.EmissiveTexture); err != nil {
+			return nil, err
+		}
+		if t := m.EmissiveTexture.Texture; t != nil {
+			t.SRGB = true
+		}
+		m.Emissive = mj.EmissiveFactor
+
+		if mj.AlphaMode != "" {
+			m.AlphaMode = AlphaMode(mj.AlphaMode)
+		}
+		if mj.AlphaCutoff != nil {
+			m.AlphaCutoff = *mj.AlphaCutoff
+		}
+		m.DoubleSided = mj.DoubleSided
+
+		materials[i] = m
+	}
+
+	return materials, nil
+}
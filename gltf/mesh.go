@@ -0,0 +1,260 @@
+package gltf
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/geometry"
+)
+
+// Mesh is a set of primitives drawn together, usually one per material.
+type Mesh struct {
+	Name       string
+	Primitives []*Primitive
+}
+
+// Primitive is one draw call's worth of geometry.
+//
+// Triangle strips and fans are converted to triangle lists. Triangles that
+// come without normals get flat normals, and those without tangents get
+// tangents from ComputeTangents.
+type Primitive struct {
+	geometry.Mesh
+
+	// Mode is gl.TRIANGLES, gl.POINTS, gl.LINES, gl.LINE_LOOP or
+	// gl.LINE_STRIP. Indices are only triangles for gl.TRIANGLES.
+	Mode uint32
+	// Material is nil for the default material (see DefaultMaterial).
+	Material *Material
+
+	// Colors, Joints and Weights are COLOR_0, JOINTS_0 and WEIGHTS_0, one
+	// per vertex, or nil if the primitive does not have them.
+	Colors  []mgl32.Vec4
+	Joints  [][4]uint32
+	Weights []mgl32.Vec4
+}
+
+func (d *decoder) meshes(materials []*Material) ([]*Mesh, error) {
+	meshes := make([]*Mesh, len(d.doc.Meshes))
+	for i, mj := range d.doc.Meshes {
+		mesh := &Mesh{Name: mj.Name}
+		for j, pj := range mj.Primitives {
+			p, err := d.primitive(pj, materials)
+			if err != nil {
+				return nil, fmt.Errorf("mesh %d primitive %d: %v", i, j, err)
+			}
+			mesh.Primitives = append(mesh.Primitives, p)
+		}
+		meshes[i] = mesh
+	}
+
+	return meshes, nil
+}
+
+func (d *decoder) primitive(pj primitiveJSON, materials []*Material) (*Primitive, error) {
+	p := &Primitive{Mode: gl.TRIANGLES}
+
+	if pj.Material != nil {
+		if *pj.Material < 0 || *pj.Material >= len(materials) {
+			return nil, fmt.Errorf("material %d out of range", *pj.Material)
+		}
+		p.Material = materials[*pj.Material]
+	}
+
+	pos, ok := pj.Attributes["POSITION"]
+	if !ok {
+		return nil, fmt.Errorf("no POSITION attribute")
+	}
+	positions, err := d.floats(pos, 3)
+	if err != nil {
+		return nil, err
+	}
+	count := len(positions) / 3
+	p.Vertices = make([]geometry.Vertex, count)
+	for k := range p.Vertices {
+		p.Vertices[k].Position = mgl32.Vec3{positions[3*k], positions[3*k+1], positions[3*k+2]}
+	}
+
+	// attribute reads an optional per-vertex attribute with n components.
+	attribute := func(name string, n int) ([]float32, error) {
+		a, ok := pj.Attributes[name]
+		if !ok {
+			return nil, nil
+		}
+		f, err := d.floats(a, n)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if len(f) != count*n {
+			return nil, fmt.Errorf("%s has %d elements, POSITION has %d", name, len(f)/n, count)
+		}
+		return f, nil
+	}
+
+	normals, err := attribute("NORMAL", 3)
+	if err != nil {
+		return nil, err
+	}
+	for k := 0; k < count && normals != nil; k++ {
+		p.Vertices[k].Normal = mgl32.Vec3{normals[3*k], normals[3*k+1], normals[3*k+2]}
+	}
+
+	tangents, err := attribute("TANGENT", 4)
+	if err != nil {
+		return nil, err
+	}
+	for k := 0; k < count && tangents != nil; k++ {
+		p.Vertices[k].Tangent = mgl32.Vec4{tangents[4*k], tangents[4*k+1], tangents[4*k+2], tangents[4*k+3]}
+	}
+
+	uvs, err := attribute("TEXCOORD_0", 2)
+	if err != nil {
+		return nil, err
+	}
+	for k := 0; k < count && uvs != nil; k++ {
+		p.Vertices[k].UV = mgl32.Vec2{uvs[2*k], uvs[2*k+1]}
+	}
+
+	if a, ok := pj.Attributes["COLOR_0"]; ok {
+		values, n, err := d.read(a)
+		if err != nil {
+			return nil, fmt.Errorf("COLOR_0: %v", err)
+		}
+		if (n != 3 && n != 4) || len(values) != count*n {
+			return nil, fmt.Errorf("COLOR_0 must be VEC3 or VEC4 with one per vertex")
+		}
+		p.Colors = make([]mgl32.Vec4, count)
+		for k := range p.Colors {
+			p.Colors[k] = mgl32.Vec4{1, 1, 1, 1}
+			for c := 0; c < n; c++ {
+				p.Colors[k][c] = float32(values[k*n+c])
+			}
+		}
+	}
+
+	if a, ok := pj.Attributes["JOINTS_0"]; ok {
+		joints, err := d.uints(a, 4)
+		if err != nil {
+			return nil, fmt.Errorf("JOINTS_0: %v", err)
+		}
+		if len(joints) != count*4 {
+			return nil, fmt.Errorf("JOINTS_0 has %d elements, POSITION has %d", len(joints)/4, count)
+		}
+		p.Joints = make([][4]uint32, count)
+		for k := range p.Joints {
+			copy(p.Joints[k][:], joints[4*k:])
+		}
+	}
+
+	weights, err := attribute("WEIGHTS_0", 4)
+	if err != nil {
+		return nil, err
+	}
+	if weights != nil {
+		p.Weights = make([]mgl32.Vec4, count)
+		for k := range p.Weights {
+			p.Weights[k] = mgl32.Vec4{weights[4*k], weights[4*k+1], weights[4*k+2], weights[4*k+3]}
+		}
+	}
+
+	if pj.Indices != nil {
+		if p.Indices, err = d.uints(*pj.Indices, 1); err != nil {
+			return nil, fmt.Errorf("indices: %v", err)
+		}
+		for _, i := range p.Indices {
+			if int(i) >= count {
+				return nil, fmt.Errorf("index %d out of range (have %d vertices)", i, count)
+			}
+		}
+	} else {
+		p.Indices = make([]uint32, count)
+		for k := range p.Indices {
+			p.Indices[k] = uint32(k)
+		}
+	}
+
+	mode := gl.TRIANGLES
+	if pj.Mode != nil {
+		mode = *pj.Mode
+	}
+	switch mode {
+	case gl.POINTS, gl.LINES, gl.LINE_LOOP, gl.LINE_STRIP:
+		p.Mode = uint32(mode)
+		return p, nil
+	case gl.TRIANGLES:
+	case gl.TRIANGLE_STRIP:
+		p.Indices = stripToList(p.Indices)
+	case gl.TRIANGLE_FAN:
+		p.Indices = fanToList(p.Indices)
+	default:
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+
+	if normals == nil {
+		p.flatNormals()
+	}
+	if tangents == nil {
+		p.ComputeTangents()
+	}
+
+	return p, nil
+}
+
+// stripToList turns triangle strip indices into a triangle list, flipping
+// every other triangle to keep the winding.
+func stripToList(strip []uint32) []uint32 {
+	var list []uint32
+	for i := 0; i+2 < len(strip); i++ {
+		if i%2 == 0 {
+			list = append(list, strip[i], strip[i+1], strip[i+2])
+		} else {
+			list = append(list, strip[i+1], strip[i], strip[i+2])
+		}
+	}
+	return list
+}
+
+// fanToList turns triangle fan indices into a triangle list.
+func fanToList(fan []uint32) []uint32 {
+	var list []uint32
+	for i := 1; i+1 < len(fan); i++ {
+		list = append(list, fan[0], fan[i], fan[i+1])
+	}
+	return list
+}
+
+// flatNormals gives every triangle its own three vertices, each with the
+// triangle's normal.
+func (p *Primitive) flatNormals() {
+	vertices := make([]geometry.Vertex, len(p.Indices))
+	var colors, weights []mgl32.Vec4
+	var joints [][4]uint32
+
+	for k, i := range p.Indices {
+		vertices[k] = p.Vertices[i]
+		if p.Colors != nil {
+			colors = append(colors, p.Colors[i])
+		}
+		if p.Joints != nil {
+			joints = append(joints, p.Joints[i])
+		}
+		if p.Weights != nil {
+			weights = append(weights, p.Weights[i])
+		}
+		p.Indices[k] = uint32(k)
+	}
+
+	for t := 0; t+2 < len(vertices); t += 3 {
+		a, b, c := vertices[t].Position, vertices[t+1].Position, vertices[t+2].Position
+		n := b.Sub(a).Cross(c.Sub(a))
+		if n.Len() > 0 {
+			n = n.Normalize()
+		}
+		for k := t; k < t+3; k++ {
+			vertices[k].Normal = n
+		}
+	}
+
+	p.Vertices, p.Colors, p.Joints, p.Weights = vertices, colors, joints, weights
+}
@@ -0,0 +1,254 @@
+package gltf
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// Node is one entry in the scene hierarchy. Its transform is always held as
+// translation, rotation and scale; a matrix in the file is decomposed into
+// them, so that animations can change any node.
+type Node struct {
+	Name     string
+	Parent   *Node
+	Children []*Node
+
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+
+	// Mesh, Camera and Skin are nil when the node does not have one.
+	Mesh   *Mesh
+	Camera *camera.Camera
+	Skin   *Skin
+}
+
+// Local returns the node's transform relative to its parent.
+func (n *Node) Local() mgl32.Mat4 {
+	t := mgl32.Translate3D(n.Translation[0], n.Translation[1], n.Translation[2])
+	s := mgl32.Scale3D(n.Scale[0], n.Scale[1], n.Scale[2])
+	return t.Mul4(n.Rotation.Mat4()).Mul4(s)
+}
+
+// World returns the node's transform relative to the scene.
+func (n *Node) World() mgl32.Mat4 {
+	m := n.Local()
+	for p := n.Parent; p != nil; p = p.Parent {
+		m = p.Local().Mul4(m)
+	}
+	return m
+}
+
+// Walk calls fn for n and each of its descendants, parents first, with
+// their world transforms.
+func (n *Node) Walk(fn func(n *Node, world mgl32.Mat4)) {
+	var walk func(n *Node, parent mgl32.Mat4)
+	walk = func(n *Node, parent mgl32.Mat4) {
+		world := parent.Mul4(n.Local())
+		fn(n, world)
+		for _, c := range n.Children {
+			walk(c, world)
+		}
+	}
+
+	parent := mgl32.Ident4()
+	if n.Parent != nil {
+		parent = n.Parent.World()
+	}
+	walk(n, parent)
+}
+
+// PlaceCamera returns a copy of the node's camera positioned where the node
+// is. glTF cameras look down their local -Z axis with +Y up.
+func (n *Node) PlaceCamera() *camera.Camera {
+	if n.Camera == nil {
+		return nil
+	}
+
+	c := *n.Camera
+	w := n.World()
+	eye := w.Mul4x1(mgl32.Vec4{0, 0, 0, 1}).Vec3()
+	forward := w.Mul4x1(mgl32.Vec4{0, 0, -1, 0}).Vec3().Normalize()
+	up := w.Mul4x1(mgl32.Vec4{0, 1, 0, 0}).Vec3().Normalize()
+	c.LookAt(eye, eye.Add(forward), up)
+
+	return &c
+}
+
+// Skin binds a mesh to a skeleton of joint nodes.
+type Skin struct {
+	Name string
+	// Skeleton is the root of the joint hierarchy, if the file names one.
+	Skeleton *Node
+	Joints   []*Node
+	// InverseBindMatrices take the mesh into each joint's space. They are
+	// identity when the file leaves them out.
+	InverseBindMatrices []mgl32.Mat4
+}
+
+// JointMatrices returns, for each joint, the matrix that moves a vertex bound
+// to it from the bind pose to the joint's current pose, relative to the node
+// the skinned mesh is on. Vertex shaders blend them with Weights.
+func (s *Skin) JointMatrices(meshNode *Node) []mgl32.Mat4 {
+	inv := meshNode.World().Inv()
+	m := make([]mgl32.Mat4, len(s.Joints))
+	for i, j := range s.Joints {
+		m[i] = inv.Mul4(j.World()).Mul4(s.InverseBindMatrices[i])
+	}
+	return m
+}
+
+func (d *decoder) cameras() ([]*camera.Camera, error) {
+	cameras := make([]*camera.Camera, len(d.doc.Cameras))
+	for i, cj := range d.doc.Cameras {
+		switch {
+		case cj.Type == "perspective" && cj.Perspective != nil:
+			p := cj.Perspective
+			far := camera.Infinite
+			if p.ZFar != nil {
+				far = *p.ZFar
+			}
+			// An aspect ratio of 0 means "use the viewport's"; see
+			// Camera.SetViewport.
+			cameras[i] = camera.NewPerspective(p.YFov, p.AspectRatio, p.ZNear, far)
+
+		case cj.Type == "orthographic" && cj.Orthographic != nil:
+			o := cj.Orthographic
+			cameras[i] = camera.NewOrthographic(-o.XMag, o.XMag, -o.YMag, o.YMag, o.ZNear, o.ZFar)
+
+		default:
+			return nil, fmt.Errorf("camera %d: bad type %q", i, cj.Type)
+		}
+	}
+
+	return cameras, nil
+}
+
+func (d *decoder) nodes(m *Model) ([]*Node, error) {
+	nodes := make([]*Node, len(d.doc.Nodes))
+	for i := range nodes {
+		nodes[i] = &Node{}
+	}
+
+	for i, nj := range d.doc.Nodes {
+		n := nodes[i]
+		n.Name = nj.Name
+		n.Rotation = mgl32.QuatIdent()
+		n.Scale = mgl32.Vec3{1, 1, 1}
+
+		if nj.Matrix != nil {
+			n.Translation, n.Rotation, n.Scale = decompose(mgl32.Mat4(*nj.Matrix))
+		}
+		if nj.Translation != nil {
+			n.Translation = *nj.Translation
+		}
+		if r := nj.Rotation; r != nil {
+			n.Rotation = mgl32.Quat{W: r[3], V: mgl32.Vec3{r[0], r[1], r[2]}}
+		}
+		if nj.Scale != nil {
+			n.Scale = *nj.Scale
+		}
+
+		if nj.Mesh != nil {
+			if *nj.Mesh < 0 || *nj.Mesh >= len(m.Meshes) {
+				return nil, fmt.Errorf("node %d: mesh %d out of range", i, *nj.Mesh)
+			}
+			n.Mesh = m.Meshes[*nj.Mesh]
+		}
+		if nj.Camera != nil {
+			if *nj.Camera < 0 || *nj.Camera >= len(m.Cameras) {
+				return nil, fmt.Errorf("node %d: camera %d out of range", i, *nj.Camera)
+			}
+			n.Camera = m.Cameras[*nj.Camera]
+		}
+
+		for _, c := range nj.Children {
+			if c < 0 || c >= len(nodes) {
+				return nil, fmt.Errorf("node %d: child %d out of range", i, c)
+			}
+			if nodes[c].Parent != nil || c == i {
+				return nil, fmt.Errorf("node %d: child %d already has a parent", i, c)
+			}
+			nodes[c].Parent = n
+			n.Children = append(n.Children, nodes[c])
+		}
+	}
+
+	// A child can still be its own ancestor through a longer loop.
+	for i, n := range nodes {
+		steps := 0
+		for p := n.Parent; p != nil; p = p.Parent {
+			if steps++; steps > len(nodes) {
+				return nil, fmt.Errorf("node %d is part of a cycle", i)
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+// decompose splits a translation * rotation * scale matrix into its parts.
+func decompose(m mgl32.Mat4) (t mgl32.Vec3, r mgl32.Quat, s mgl32.Vec3) {
+	t = m.Col(3).Vec3()
+	s = mgl32.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+	if m.Mat3().Det() < 0 {
+		s[0] = -s[0]
+	}
+
+	var rot mgl32.Mat3
+	for c := 0; c < 3; c++ {
+		col := m.Col(c).Vec3()
+		if s[c] != 0 {
+			col = col.Mul(1 / s[c])
+		}
+		rot.SetCol(c, col)
+	}
+	r = mgl32.Mat4ToQuat(rot.Mat4()).Normalize()
+
+	return t, r, s
+}
+
+func (d *decoder) skins(nodes []*Node) ([]*Skin, error) {
+	skins := make([]*Skin, len(d.doc.Skins))
+	for i, sj := range d.doc.Skins {
+		s := &Skin{Name: sj.Name}
+
+		if sj.Skeleton != nil {
+			if *sj.Skeleton < 0 || *sj.Skeleton >= len(nodes) {
+				return nil, fmt.Errorf("skin %d: skeleton %d out of range", i, *sj.Skeleton)
+			}
+			s.Skeleton = nodes[*sj.Skeleton]
+		}
+
+		for _, j := range sj.Joints {
+			if j < 0 || j >= len(nodes) {
+				return nil, fmt.Errorf("skin %d: joint %d out of range", i, j)
+			}
+			s.Joints = append(s.Joints, nodes[j])
+		}
+
+		s.InverseBindMatrices = make([]mgl32.Mat4, len(s.Joints))
+		if sj.InverseBindMatrices != nil {
+			f, err := d.floats(*sj.InverseBindMatrices, 16)
+			if err != nil {
+				return nil, fmt.Errorf("skin %d: %v", i, err)
+			}
+			if len(f) < 16*len(s.Joints) {
+				return nil, fmt.Errorf("skin %d: %d inverse bind matrices for %d joints", i, len(f)/16, len(s.Joints))
+			}
+			for k := range s.InverseBindMatrices {
+				copy(s.InverseBindMatrices[k][:], f[16*k:])
+			}
+		} else {
+			for k := range s.InverseBindMatrices {
+				s.InverseBindMatrices[k] = mgl32.Ident4()
+			}
+		}
+
+		skins[i] = s
+	}
+
+	return skins, nil
+}
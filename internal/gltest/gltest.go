@@ -0,0 +1,92 @@
+// Package gltest runs tests that need an OpenGL context. GL calls must be
+// made from the thread that owns the context, so Main keeps the test
+// binary's main thread for it and runs the tests on another goroutine,
+// which hands GL work back with Run.
+package gltest
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+func init() {
+	// Keep main, and so TestMain, on the thread that opens the window.
+	runtime.LockOSThread()
+}
+
+var (
+	mainThread = make(chan func())
+	available  bool
+)
+
+// Main runs m's tests, and is meant to be called from TestMain. It first
+// opens a hidden window with an OpenGL 4.3 core context, which has compute
+// shaders, if there is a display and driver for one. If it can, setup, if
+// it is not nil, is called with the context current, and the function it
+// returns, if not nil, is called after the tests.
+func Main(m *testing.M, title string, setup func() (teardown func())) {
+	if win := openContext(title); win != nil {
+		available = true
+		defer glfw.Terminate()
+		defer win.Destroy()
+		if setup != nil {
+			if teardown := setup(); teardown != nil {
+				defer teardown()
+			}
+		}
+	}
+
+	done := make(chan int)
+	go func() { done <- m.Run() }()
+	for {
+		select {
+		case f := <-mainThread:
+			f()
+		case <-done:
+			// The test binary exits with m.Run's result.
+			return
+		}
+	}
+}
+
+// Available reports whether Main opened a context.
+func Available() bool {
+	return available
+}
+
+// Run calls f on the thread with the context and returns its error. Tests
+// report failures that way, as t.Fatal cannot be called from that thread.
+// Run must only be called if Available.
+func Run(f func() error) error {
+	errs := make(chan error)
+	mainThread <- func() { errs <- f() }
+	return <-errs
+}
+
+// openContext returns a hidden window with a current OpenGL 4.3 context,
+// or nil if there is no display or driver for one.
+func openContext(title string) *glfw.Window {
+	if err := glfw.Init(); err != nil {
+		return nil
+	}
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	win, err := glfw.CreateWindow(16, 16, title, nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil
+	}
+	win.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		win.Destroy()
+		glfw.Terminate()
+		return nil
+	}
+	return win
+}
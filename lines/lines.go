@@ -0,0 +1,356 @@
+// Package lines draws thick, anti-aliased polylines, which the core
+// profile's gl.LineWidth cannot.
+//
+// Each segment of a line strip is one instance of a quad, laid out in
+// window pixels by the vertex shader from the segment's ends and their
+// neighbours, which it reads straight from a shader storage buffer. The
+// fragment shader measures each pixel's distance to the edge of the line,
+// with miter, round or bevel joins and butt, round or square caps, and
+// turns it into coverage, so the edges are smooth without multisampling.
+// Widths are in pixels, and colours and widths can change from vertex to
+// vertex. Lines can be dashed.
+//
+//	r, err := lines.New()
+//	...
+//	r.AddLine(walk, 3, mgl32.Vec4{1, 1, 1, 1})
+//	r.Draw(projection.Mul4(view))
+//
+// It needs OpenGL 4.3 for shader storage buffers.
+package lines
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/compute"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+// Join is how a strip turns its corners.
+type Join int
+
+// Joins.
+const (
+	// JoinMiter extends the outer edges until they meet, or bevels the
+	// corner if they would meet further than MiterLimit half widths away.
+	JoinMiter Join = iota
+	// JoinRound rounds the corner off.
+	JoinRound
+	// JoinBevel cuts the corner off square.
+	JoinBevel
+)
+
+func (j Join) String() string {
+	switch j {
+	case JoinRound:
+		return "round"
+	case JoinBevel:
+		return "bevel"
+	}
+	return "miter"
+}
+
+// Set parses "miter", "round" or "bevel", so that a Join can be a flag.
+func (j *Join) Set(s string) error {
+	switch s {
+	case "miter":
+		*j = JoinMiter
+	case "round":
+		*j = JoinRound
+	case "bevel":
+		*j = JoinBevel
+	default:
+		return fmt.Errorf("lines: unknown join %q, want miter, round or bevel", s)
+	}
+	return nil
+}
+
+// Cap is how a strip ends.
+type Cap int
+
+// Caps.
+const (
+	// CapButt ends the line square at its last point.
+	CapButt Cap = iota
+	// CapRound ends it with a half circle.
+	CapRound
+	// CapSquare ends it square, half its width past its last point.
+	CapSquare
+)
+
+func (c Cap) String() string {
+	switch c {
+	case CapRound:
+		return "round"
+	case CapSquare:
+		return "square"
+	}
+	return "butt"
+}
+
+// Set parses "butt", "round" or "square", so that a Cap can be a flag.
+func (c *Cap) Set(s string) error {
+	switch s {
+	case "butt":
+		*c = CapButt
+	case "round":
+		*c = CapRound
+	case "square":
+		*c = CapSquare
+	default:
+		return fmt.Errorf("lines: unknown cap %q, want butt, round or square", s)
+	}
+	return nil
+}
+
+// MaxDashes is the most lengths a dash pattern can have.
+const MaxDashes = 8
+
+// Vertex is a point of a line strip.
+type Vertex struct {
+	Position mgl32.Vec3
+	// Width is the width of the line at this point, in pixels. Lines
+	// narrower than a pixel are drawn a pixel wide and fainter.
+	Width float32
+	// Color is in straight, not premultiplied, alpha.
+	Color mgl32.Vec4
+}
+
+// point is a Vertex as the shaders' std430 struct sees it.
+type point struct {
+	Vertex
+	// Along is the distance along the strip from its first point, for
+	// dashes.
+	Along float32
+	Flags uint32
+	_     [2]float32
+}
+
+// Flags of a point.
+const (
+	startsStrip = 1 << iota
+	endsStrip
+)
+
+// Renderer draws line strips.
+type Renderer struct {
+	Join Join
+	Cap  Cap
+	// MiterLimit is the longest a miter can be, in half widths of the line,
+	// before it is bevelled instead. New sets it to 4.
+	MiterLimit float32
+	// Dash, if it is not empty, is the lengths of the dashes and the gaps
+	// between them in turn, in the units of the vertex positions and
+	// measured along each strip. An odd number of lengths is repeated to
+	// make an even number, as in SVG. It can have up to MaxDashes lengths.
+	Dash []float32
+	// DashOffset moves the dash pattern along the lines; changing it over
+	// time makes the dashes march.
+	DashOffset float32
+	// Feather is the width, in pixels, over which edges fade out. New sets
+	// it to 1; near 0, edges are as jagged as gl.LINE_STRIP's.
+	Feather float32
+
+	points []point
+	// buffer holds points, once they have been uploaded.
+	buffer *compute.Buffer[point]
+	dirty  bool
+
+	program uint32
+	vao     uint32
+
+	mvp, viewport, rangeUniform, miterLimit, feather int32
+	join, cap, dashes, dashCount, dashPeriod         int32
+	dashOffset                                       int32
+}
+
+// New builds the renderer's program, with no lines to draw. It needs a
+// current GL context.
+func New() (*Renderer, error) {
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return nil, fmt.Errorf("lines: %v", err)
+	}
+	r := &Renderer{
+		MiterLimit: 4,
+		Feather:    1,
+		buffer:     compute.NewBufferLen[point](0),
+		program:    program,
+
+		mvp:          shader.Uniform(program, "mvp"),
+		viewport:     shader.Uniform(program, "viewport"),
+		rangeUniform: shader.Uniform(program, "range"),
+		miterLimit:   shader.Uniform(program, "miterLimit"),
+		feather:      shader.Uniform(program, "feather"),
+		join:         shader.Uniform(program, "join"),
+		cap:          shader.Uniform(program, "cap"),
+		dashes:       shader.Uniform(program, "dashes"),
+		dashCount:    shader.Uniform(program, "dashCount"),
+		dashPeriod:   shader.Uniform(program, "dashPeriod"),
+		dashOffset:   shader.Uniform(program, "dashOffset"),
+	}
+	// The shaders pull their vertices from the buffer, so the vertex array
+	// has no attributes, but one must be bound to draw.
+	gl.GenVertexArrays(1, &r.vao)
+	return r, nil
+}
+
+// AddStrip adds a line strip through vertices. A strip of one vertex draws
+// a dot if the cap is round or square.
+func (r *Renderer) AddStrip(vertices []Vertex) {
+	if len(vertices) == 0 {
+		return
+	}
+	// A single vertex is drawn as a segment of no length.
+	if len(vertices) == 1 {
+		vertices = []Vertex{vertices[0], vertices[0]}
+	}
+	var along float32
+	for i, v := range vertices {
+		if i > 0 {
+			along += v.Position.Sub(vertices[i-1].Position).Len()
+		}
+		p := point{Vertex: v, Along: along}
+		if i == 0 {
+			p.Flags |= startsStrip
+		}
+		if i == len(vertices)-1 {
+			p.Flags |= endsStrip
+		}
+		r.points = append(r.points, p)
+	}
+	r.dirty = true
+}
+
+// AddLine adds a line strip through positions, such as a paths.Polyline,
+// of one width and colour.
+func (r *Renderer) AddLine(positions []mgl32.Vec3, width float32, color mgl32.Vec4) {
+	vertices := make([]Vertex, len(positions))
+	for i, p := range positions {
+		vertices[i] = Vertex{Position: p, Width: width, Color: color}
+	}
+	r.AddStrip(vertices)
+}
+
+// Clear removes every strip.
+func (r *Renderer) Clear() {
+	r.points = r.points[:0]
+	r.dirty = true
+}
+
+// Len returns the number of vertices in all the strips, one after another.
+func (r *Renderer) Len() int {
+	return len(r.points)
+}
+
+// upload copies the points to the buffer if they have changed, growing it
+// by half again when they no longer fit.
+func (r *Renderer) upload() {
+	if !r.dirty {
+		return
+	}
+	if len(r.points) > r.buffer.Len {
+		r.buffer.Resize(len(r.points) + len(r.points)/2)
+	}
+	r.buffer.Write(0, r.points)
+	r.dirty = false
+}
+
+// Draw draws every strip, transformed by mvp, into the current viewport.
+func (r *Renderer) Draw(mvp mgl32.Mat4) {
+	r.DrawRange(mvp, 0, int32(len(r.points)))
+}
+
+// DrawRange draws count vertices from first on, counting through the
+// strips one after another, as reveal.Controller.Range returns them for
+// gl.LINE_STRIP. The ends of the range are capped.
+//
+// Blending is turned on for straight alpha while it draws; the depth test
+// is left as it is.
+func (r *Renderer) DrawRange(mvp mgl32.Mat4, first, count int32) {
+	if first < 0 {
+		count += first
+		first = 0
+	}
+	if n := int32(len(r.points)); first+count > n {
+		count = n - first
+	}
+	if count < 2 {
+		return
+	}
+	r.upload()
+
+	dashes, period := r.dashPattern()
+
+	// Save the state drawing changes.
+	var program, vao, srcRGB, dstRGB, srcAlpha, dstAlpha int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &vao)
+	gl.GetIntegerv(gl.BLEND_SRC_RGB, &srcRGB)
+	gl.GetIntegerv(gl.BLEND_DST_RGB, &dstRGB)
+	gl.GetIntegerv(gl.BLEND_SRC_ALPHA, &srcAlpha)
+	gl.GetIntegerv(gl.BLEND_DST_ALPHA, &dstAlpha)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	blend := gl.IsEnabled(gl.BLEND)
+
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	gl.UseProgram(r.program)
+	gl.UniformMatrix4fv(r.mvp, 1, false, &mvp[0])
+	gl.Uniform4f(r.viewport, float32(viewport[0]), float32(viewport[1]), float32(viewport[2]), float32(viewport[3]))
+	gl.Uniform2i(r.rangeUniform, first, first+count-1)
+	gl.Uniform1f(r.miterLimit, r.MiterLimit)
+	gl.Uniform1f(r.feather, max(r.Feather, 1e-3))
+	gl.Uniform1i(r.join, int32(r.Join))
+	gl.Uniform1i(r.cap, int32(r.Cap))
+	gl.Uniform1i(r.dashCount, int32(len(dashes)))
+	if len(dashes) > 0 {
+		gl.Uniform1fv(r.dashes, int32(len(dashes)), &dashes[0])
+		gl.Uniform1f(r.dashPeriod, period)
+		gl.Uniform1f(r.dashOffset, r.DashOffset)
+	}
+	gl.BindBufferBase(gl.SHADER_STORAGE_BUFFER, 0, r.buffer.ID)
+	gl.BindVertexArray(r.vao)
+	gl.DrawArraysInstanced(gl.TRIANGLE_STRIP, 0, 4, count-1)
+
+	gl.BlendFuncSeparate(uint32(srcRGB), uint32(dstRGB), uint32(srcAlpha), uint32(dstAlpha))
+	if !blend {
+		gl.Disable(gl.BLEND)
+	}
+	gl.UseProgram(uint32(program))
+	gl.BindVertexArray(uint32(vao))
+}
+
+// dashPattern returns Dash with an even number of lengths, no more than
+// MaxDashes and none negative, and its total, or nothing if the line is
+// solid.
+func (r *Renderer) dashPattern() ([]float32, float32) {
+	dashes := r.Dash
+	if len(dashes)%2 == 1 {
+		dashes = append(dashes[:len(dashes):len(dashes)], dashes...)
+	}
+	if len(dashes) > MaxDashes {
+		dashes = dashes[:MaxDashes]
+	}
+	var period float32
+	for _, d := range dashes {
+		if d < 0 {
+			return nil, 0
+		}
+		period += d
+	}
+	if period <= 0 {
+		return nil, 0
+	}
+	return dashes, period
+}
+
+// Delete frees the program and buffers.
+func (r *Renderer) Delete() {
+	gl.DeleteProgram(r.program)
+	gl.DeleteVertexArrays(1, &r.vao)
+	r.buffer.Delete()
+}
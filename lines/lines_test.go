@@ -0,0 +1,125 @@
+package lines
+
+import (
+	"slices"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestPointLayout(t *testing.T) {
+	// The shaders' std430 struct: vec3 position, float width, vec4 color,
+	// float along, uint flags and two floats of padding.
+	var p point
+	if s := unsafe.Sizeof(p); s != 48 {
+		t.Errorf("point is %d bytes, want 48", s)
+	}
+	offsets := []struct {
+		name      string
+		got, want uintptr
+	}{
+		{"Width", unsafe.Offsetof(p.Width), 12},
+		{"Color", unsafe.Offsetof(p.Color), 16},
+		{"Along", unsafe.Offsetof(p.Along), 32},
+		{"Flags", unsafe.Offsetof(p.Flags), 36},
+	}
+	for _, o := range offsets {
+		if o.got != o.want {
+			t.Errorf("%s is at %d, want %d", o.name, o.got, o.want)
+		}
+	}
+}
+
+func TestAddStrip(t *testing.T) {
+	var r Renderer
+	white := mgl32.Vec4{1, 1, 1, 1}
+	r.AddLine([]mgl32.Vec3{{0, 0, 0}, {3, 4, 0}, {3, 4, 2}}, 2, white)
+	r.AddStrip([]Vertex{{Position: mgl32.Vec3{1, 1, 1}, Width: 5}})
+	r.AddStrip(nil)
+
+	want := []struct {
+		position mgl32.Vec3
+		width    float32
+		along    float32
+		flags    uint32
+	}{
+		{mgl32.Vec3{0, 0, 0}, 2, 0, startsStrip},
+		{mgl32.Vec3{3, 4, 0}, 2, 5, 0},
+		{mgl32.Vec3{3, 4, 2}, 2, 7, endsStrip},
+		// One vertex becomes a segment of no length.
+		{mgl32.Vec3{1, 1, 1}, 5, 0, startsStrip},
+		{mgl32.Vec3{1, 1, 1}, 5, 0, endsStrip},
+	}
+	if r.Len() != len(want) {
+		t.Fatalf("%d points, want %d", r.Len(), len(want))
+	}
+	for i, w := range want {
+		p := r.points[i]
+		if p.Position != w.position || p.Width != w.width || p.Along != w.along || p.Flags != w.flags {
+			t.Errorf("point %d is %+v, want %+v", i, p, w)
+		}
+		if i < 3 && p.Color != white {
+			t.Errorf("point %d has colour %v, want %v", i, p.Color, white)
+		}
+	}
+	if !r.dirty {
+		t.Error("adding strips left the renderer clean")
+	}
+
+	r.dirty = false
+	r.Clear()
+	if r.Len() != 0 || !r.dirty {
+		t.Errorf("after Clear: %d points, dirty %v", r.Len(), r.dirty)
+	}
+}
+
+func TestDashPattern(t *testing.T) {
+	tests := []struct {
+		name   string
+		dash   []float32
+		want   []float32
+		period float32
+	}{
+		{"solid", nil, nil, 0},
+		{"even", []float32{3, 1}, []float32{3, 1}, 4},
+		{"odd is repeated", []float32{2, 1, 1}, []float32{2, 1, 1, 2, 1, 1}, 8},
+		{"one length", []float32{2}, []float32{2, 2}, 4},
+		{"too many", []float32{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, []float32{1, 1, 1, 1, 1, 1, 1, 1}, 8},
+		{"negative", []float32{2, -1}, nil, 0},
+		{"nothing long", []float32{0, 0}, nil, 0},
+	}
+	for _, tt := range tests {
+		r := Renderer{Dash: slices.Clone(tt.dash)}
+		got, period := r.dashPattern()
+		if !slices.Equal(got, tt.want) || period != tt.period {
+			t.Errorf("%s: got %v with period %v, want %v with %v", tt.name, got, period, tt.want, tt.period)
+		}
+		if !slices.Equal(r.Dash, tt.dash) {
+			t.Errorf("%s: Dash changed to %v", tt.name, r.Dash)
+		}
+	}
+}
+
+func TestJoinCapSet(t *testing.T) {
+	for _, j := range []Join{JoinMiter, JoinRound, JoinBevel} {
+		var parsed Join
+		if err := parsed.Set(j.String()); err != nil || parsed != j {
+			t.Errorf("Join Set(%q) gave %v, %v", j.String(), parsed, err)
+		}
+	}
+	for _, c := range []Cap{CapButt, CapRound, CapSquare} {
+		var parsed Cap
+		if err := parsed.Set(c.String()); err != nil || parsed != c {
+			t.Errorf("Cap Set(%q) gave %v, %v", c.String(), parsed, err)
+		}
+	}
+	var j Join
+	if err := j.Set("mitre"); err == nil || err.Error() != `lines: unknown join "mitre", want miter, round or bevel` {
+		t.Errorf("Join Set(\"mitre\") gave %v", err)
+	}
+	var c Cap
+	if err := c.Set("flat"); err == nil || err.Error() != `lines: unknown cap "flat", want butt, round or square` {
+		t.Errorf("Cap Set(\"flat\") gave %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package lines
+
+// The vertex shader pulls the two ends of segment range.x + gl_InstanceID,
+// and their neighbours, from the point buffer, projects them to pixels and
+// stretches a quad over the segment, wide enough for its joins or caps and
+// the anti-aliased edge. Segments that end a strip, or
+// that have a point behind the camera, are collapsed to nothing.
+var vertexShader = `
+#version 430
+
+struct Point {
+    vec3 position;
+    float width;
+    vec4 color;
+    float along;
+    uint flags;
+    float pad0, pad1;
+};
+
+layout(std430, binding = 0) readonly buffer Points {
+    Point points[];
+};
+
+const uint startsStrip = 1u;
+const uint endsStrip = 2u;
+
+uniform mat4 mvp;
+uniform vec4 viewport;
+// range is the first and last point drawn.
+uniform ivec2 range;
+uniform float miterLimit;
+uniform float feather;
+
+flat out vec2 a, b, prev, next;
+flat out int ends;
+flat out vec4 colorA, colorB;
+flat out vec2 halfWidth, coverage, along;
+
+// project returns the point in window pixels, with its depth in z, or w
+// of 0 if it is behind the camera.
+vec4 project(vec3 p) {
+    vec4 clip = mvp * vec4(p, 1);
+    if (clip.w <= 0.0) {
+        return vec4(0);
+    }
+    vec3 ndc = clip.xyz / clip.w;
+    return vec4((ndc.xy * 0.5 + 0.5) * viewport.zw + viewport.xy, ndc.z, 1);
+}
+
+void collapse() {
+    gl_Position = vec4(2, 2, 2, 1);
+}
+
+void main() {
+    int i = range.x + gl_InstanceID;
+    Point pa = points[i];
+    Point pb = points[i + 1];
+    if ((pa.flags & endsStrip) != 0u) {
+        collapse();
+        return;
+    }
+
+    vec4 sa = project(pa.position);
+    vec4 sb = project(pb.position);
+    if (sa.w == 0.0 || sb.w == 0.0) {
+        collapse();
+        return;
+    }
+
+    // Bit 0 is set if there is a segment before, bit 1 if there is one after.
+    ends = 0;
+    prev = next = vec2(0);
+    if (i > range.x && (pa.flags & startsStrip) == 0u) {
+        vec4 sp = project(points[i - 1].position);
+        if (sp.w != 0.0) {
+            prev = sp.xy;
+            ends |= 1;
+        }
+    }
+    if (i + 1 < range.y && (pb.flags & endsStrip) == 0u) {
+        vec4 sn = project(points[i + 2].position);
+        if (sn.w != 0.0) {
+            next = sn.xy;
+            ends |= 2;
+        }
+    }
+
+    vec2 d = sb.xy - sa.xy;
+    float len = length(d);
+    vec2 t = vec2(1, 0);
+    if (len > 1e-3) {
+        t = d / len;
+    } else if (ends != 0) {
+        // The neighbours finish with round ends at a point, instead.
+        collapse();
+        return;
+    }
+    vec2 n = vec2(-t.y, t.x);
+
+    // Lines thinner than a pixel are drawn a pixel wide and fainter.
+    halfWidth = max(vec2(pa.width, pb.width), 1.0) * 0.5;
+    coverage = clamp(vec2(pa.width, pb.width), 0.0, 1.0);
+    a = sa.xy;
+    b = sb.xy;
+    colorA = pa.color;
+    colorB = pb.color;
+    along = vec2(pa.along, pb.along);
+
+    // Corners as a triangle strip: the quad reaches past each end far enough
+    // for a miter up to the limit, and beside the segment for the widest end.
+    int end = gl_VertexID >> 1;
+    float side = float(gl_VertexID & 1) * 2.0 - 1.0;
+    float hw = max(halfWidth.x, halfWidth.y);
+    float reach = hw * max(miterLimit, 1.0) + feather;
+    vec2 corner = end == 0 ? a - t * reach : b + t * reach;
+    corner += n * side * (hw + feather);
+    float z = end == 0 ? sa.z : sb.z;
+
+    gl_Position = vec4((corner - viewport.xy) / viewport.zw * 2.0 - 1.0, z, 1);
+}
+` + "\x00"
+
+// The fragment shader finds the distance, in pixels, from the fragment to
+// the edge of the segment's share of the line: its body, and at each end
+// either a cap or its half of the join, split from the neighbour's half
+// along the bisector. That distance gives the anti-aliased coverage, and
+// the dash pattern trims it further.
+var fragmentShader = `
+#version 430
+
+const int joinMiter = 0;
+const int joinRound = 1;
+const int joinBevel = 2;
+const int capButt = 0;
+const int capRound = 1;
+const int capSquare = 2;
+
+uniform int join;
+uniform int cap;
+uniform float miterLimit;
+uniform float feather;
+uniform float dashes[8];
+uniform int dashCount;
+uniform float dashPeriod;
+uniform float dashOffset;
+
+flat in vec2 a, b, prev, next;
+flat in int ends;
+flat in vec4 colorA, colorB;
+flat in vec2 halfWidth, coverage, along;
+
+out vec4 outputColor;
+
+// outside is returned for fragments that belong to the neighbour.
+const float outside = 1e9;
+
+// capDistance trims body, the distance to the sides, at an end of the
+// strip. q is the fragment from the end, and dir points away from the
+// segment, past the end.
+float capDistance(vec2 q, vec2 dir, float hw, float body) {
+    float past = dot(q, dir);
+    if (cap == capRound) {
+        return past > 0.0 ? length(q) - hw : body;
+    }
+    if (cap == capSquare) {
+        return max(body, past - hw);
+    }
+    return max(body, past);
+}
+
+// endDistance trims body at end, which joins on to neighbour if has is
+// set and is a cap otherwise. dir points away from the segment, past end,
+// and start is set if end is the segment's first point.
+float endDistance(vec2 p, vec2 end, vec2 dir, bool start, bool has, vec2 neighbour, float hw, float body) {
+    vec2 q = p - end;
+    if (!has) {
+        return capDistance(q, dir, hw, body);
+    }
+    vec2 on = neighbour - end;
+    if (length(on) < 1e-3) {
+        // A neighbour of no length on screen: end round and let the one
+        // after it do the same.
+        return dot(q, dir) > 0.0 ? length(q) - hw : body;
+    }
+    on = normalize(on);
+
+    // Keep this segment's side of the bisector. Pixels on the bisector go
+    // to the later segment, so that none is drawn twice.
+    float side = dot(q, on + dir);
+    if (side > 0.0 || (start && side == 0.0)) {
+        return outside;
+    }
+
+    vec2 miter = vec2(-dir.y, dir.x) + vec2(-on.y, on.x);
+    if (length(miter) < 1e-3) {
+        // The line doubles back on itself.
+        return join == joinRound && dot(q, dir) > 0.0 ? length(q) - hw : max(body, dot(q, dir));
+    }
+    miter = normalize(miter);
+    float c = dot(miter, vec2(-dir.y, dir.x));
+    // Point the miter at the outside of the turn.
+    if (dir.x * on.y - dir.y * on.x > 0.0) {
+        miter = -miter;
+    }
+    float outer = dot(q, miter);
+
+    if (join == joinRound) {
+        return dot(q, dir) > 0.0 && outer > 0.0 ? length(q) - hw : body;
+    }
+    if (join == joinMiter && 1.0 / c <= miterLimit) {
+        return body;
+    }
+    return max(body, outer - hw * c);
+}
+
+// dashDistance returns the distance, along the line in its own units, to
+// the nearest edge of a dash: negative in a dash and positive in a gap.
+float dashDistance(float x) {
+    x = mod(x + dashOffset, dashPeriod);
+    float start = 0.0;
+    for (int i = 0; i < dashCount; i++) {
+        float end = start + dashes[i];
+        if (x < end || i == dashCount - 1) {
+            float d = min(x - start, end - x);
+            return i % 2 == 0 ? -d : d;
+        }
+        start = end;
+    }
+    return 0.0;
+}
+
+void main() {
+    vec2 p = gl_FragCoord.xy;
+    vec2 d = b - a;
+    float len = length(d);
+    vec2 t = len > 1e-3 ? d / len : vec2(1, 0);
+    vec2 n = vec2(-t.y, t.x);
+
+    float u = len > 1e-3 ? clamp(dot(p - a, t) / len, 0.0, 1.0) : 0.0;
+    float hw = mix(halfWidth.x, halfWidth.y, u);
+    float dist = abs(dot(p - a, n)) - hw;
+    dist = endDistance(p, a, -t, true, (ends & 1) != 0, prev, halfWidth.x, dist);
+    dist = endDistance(p, b, t, false, (ends & 2) != 0, next, halfWidth.y, dist);
+
+    float alpha = clamp(0.5 - dist / feather, 0.0, 1.0) * mix(coverage.x, coverage.y, u);
+
+    if (dashCount > 0) {
+        // Pixels per unit of length along this segment.
+        float scale = len / max(along.y - along.x, 1e-9);
+        float dd = dashDistance(mix(along.x, along.y, u));
+        alpha *= clamp(0.5 - dd * scale / feather, 0.0, 1.0);
+    }
+
+    outputColor = mix(colorA, colorB, u);
+    outputColor.a *= alpha;
+    // Keep the empty part of the quad out of the depth buffer.
+    if (outputColor.a < 1.0 / 255.0) {
+        discard;
+    }
+}
+` + "\x00"
@@ -0,0 +1,226 @@
+package nbody
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// BarnesHut approximates the pull of distant groups of bodies by that of
+// their total mass at their centre of mass, using an octree built afresh
+// for each call. It takes time proportional to N log N, so it can handle
+// far more bodies than Direct or GPU, at the cost of some accuracy.
+type BarnesHut struct {
+	// Theta is the opening angle: a cell of size s at distance d is treated
+	// as one mass if s/d < Theta. Smaller is more accurate and slower; 0
+	// means the default of 0.5.
+	Theta float64
+	// LeafSize is the most bodies a cell holds before it is split; 0 means
+	// the default of 8.
+	LeafSize int
+
+	nodes   []node
+	indices []int32
+	scratch []int32
+}
+
+// node is a cell of the octree.
+type node struct {
+	// centre and half are the cell's centre and half its width.
+	centre [3]float64
+	half   float64
+	// com and mass are the centre of mass and total mass of its bodies.
+	com  [3]float64
+	mass float64
+	// children are the indices of the eight sub-cells, or 0 where one is
+	// empty; a leaf has none, and holds indices[first : first+count].
+	children     [8]int32
+	first, count int32
+	leaf         bool
+}
+
+// maxDepth stops the octree splitting bodies at the same position forever.
+const maxDepth = 32
+
+// Accelerations implements Solver.
+func (t *BarnesHut) Accelerations(bodies []Body, g, softening float32, acc []mgl32.Vec3) error {
+	if len(bodies) == 0 {
+		return nil
+	}
+	theta := t.Theta
+	if theta <= 0 {
+		theta = 0.5
+	}
+	t.build(bodies)
+
+	theta2 := theta * theta
+	eps2 := float64(softening) * float64(softening)
+	compute.ParallelFor(len(bodies), func(i int) {
+		acc[i] = t.accelerate(bodies, int32(i), theta2, eps2).Mul(g)
+	})
+	return nil
+}
+
+// build builds the octree for bodies.
+func (t *BarnesHut) build(bodies []Body) {
+	leafSize := t.LeafSize
+	if leafSize <= 0 {
+		leafSize = 8
+	}
+
+	min, max := bodies[0].Position, bodies[0].Position
+	for _, b := range bodies[1:] {
+		for k := 0; k < 3; k++ {
+			min[k] = float32(math.Min(float64(min[k]), float64(b.Position[k])))
+			max[k] = float32(math.Max(float64(max[k]), float64(b.Position[k])))
+		}
+	}
+	var centre [3]float64
+	half := 0.0
+	for k := 0; k < 3; k++ {
+		centre[k] = (float64(min[k]) + float64(max[k])) / 2
+		half = math.Max(half, (float64(max[k])-float64(min[k]))/2)
+	}
+	// A little larger, so bodies on the far faces fall inside.
+	half = half*1.0001 + 1e-6
+
+	if cap(t.indices) < len(bodies) {
+		t.indices = make([]int32, len(bodies))
+		t.scratch = make([]int32, len(bodies))
+	}
+	t.indices = t.indices[:len(bodies)]
+	t.scratch = t.scratch[:len(bodies)]
+	for i := range t.indices {
+		t.indices[i] = int32(i)
+	}
+	// Node 0 is the root, so 0 can mean no child.
+	t.nodes = t.nodes[:0]
+	t.split(bodies, 0, int32(len(bodies)), centre, half, 0, leafSize)
+}
+
+// split adds the node for the cell holding indices[first : first+count],
+// and returns its index.
+func (t *BarnesHut) split(bodies []Body, first, count int32, centre [3]float64, half float64, depth, leafSize int) int32 {
+	index := int32(len(t.nodes))
+	t.nodes = append(t.nodes, node{centre: centre, half: half, first: first, count: count})
+
+	var com [3]float64
+	var mass float64
+	for _, i := range t.indices[first : first+count] {
+		m := float64(bodies[i].Mass)
+		for k := 0; k < 3; k++ {
+			com[k] += m * float64(bodies[i].Position[k])
+		}
+		mass += m
+	}
+	if mass > 0 {
+		for k := range com {
+			com[k] /= mass
+		}
+	} else {
+		com = centre
+	}
+
+	if int(count) <= leafSize || depth >= maxDepth {
+		n := &t.nodes[index]
+		n.com, n.mass, n.leaf = com, mass, true
+		return index
+	}
+
+	// Sort the indices by octant, counting first.
+	octant := func(i int32) int {
+		p := bodies[i].Position
+		o := 0
+		for k := 0; k < 3; k++ {
+			if float64(p[k]) >= centre[k] {
+				o |= 1 << k
+			}
+		}
+		return o
+	}
+	var starts [9]int32
+	for _, i := range t.indices[first : first+count] {
+		starts[octant(i)+1]++
+	}
+	for o := 1; o < 9; o++ {
+		starts[o] += starts[o-1]
+	}
+	next := starts
+	for _, i := range t.indices[first : first+count] {
+		o := octant(i)
+		t.scratch[first+next[o]] = i
+		next[o]++
+	}
+	copy(t.indices[first:first+count], t.scratch[first:first+count])
+
+	var children [8]int32
+	for o := 0; o < 8; o++ {
+		n := starts[o+1] - starts[o]
+		if n == 0 {
+			continue
+		}
+		var c [3]float64
+		for k := 0; k < 3; k++ {
+			c[k] = centre[k] - half/2
+			if o&(1<<k) != 0 {
+				c[k] = centre[k] + half/2
+			}
+		}
+		children[o] = t.split(bodies, first+starts[o], n, c, half/2, depth+1, leafSize)
+	}
+
+	// t.nodes may have moved while the children were added.
+	n := &t.nodes[index]
+	n.com, n.mass, n.children = com, mass, children
+	return index
+}
+
+// accelerate walks the octree for body i.
+func (t *BarnesHut) accelerate(bodies []Body, i int32, theta2, eps2 float64) mgl32.Vec3 {
+	p := bodies[i].Position
+	px, py, pz := float64(p[0]), float64(p[1]), float64(p[2])
+	var ax, ay, az float64
+	pull := func(x, y, z, m float64) {
+		dx, dy, dz := x-px, y-py, z-pz
+		r2 := dx*dx + dy*dy + dz*dz + eps2
+		if r2 == 0 {
+			return
+		}
+		f := m / (r2 * math.Sqrt(r2))
+		ax += f * dx
+		ay += f * dy
+		az += f * dz
+	}
+
+	var stack [8 * maxDepth]int32
+	top := 1
+	stack[0] = 0
+	for top > 0 {
+		top--
+		n := &t.nodes[stack[top]]
+		if n.leaf {
+			for _, j := range t.indices[n.first : n.first+n.count] {
+				if j != i {
+					q := bodies[j].Position
+					pull(float64(q[0]), float64(q[1]), float64(q[2]), float64(bodies[j].Mass))
+				}
+			}
+			continue
+		}
+		dx, dy, dz := n.com[0]-px, n.com[1]-py, n.com[2]-pz
+		d2 := dx*dx + dy*dy + dz*dz
+		size := 2 * n.half
+		if size*size < theta2*d2 {
+			pull(n.com[0], n.com[1], n.com[2], n.mass)
+			continue
+		}
+		for _, c := range n.children {
+			if c != 0 {
+				stack[top] = c
+				top++
+			}
+		}
+	}
+	return mgl32.Vec3{float32(ax), float32(ay), float32(az)}
+}
@@ -0,0 +1,101 @@
+package nbody
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/pointcloud"
+)
+
+// Names of the scalars a cloud of bodies carries besides their positions.
+var scalarNames = [...]string{"vx", "vy", "vz", "mass"}
+
+// Cloud returns the bodies as a point cloud, with their velocities and
+// masses as scalars and colours from their speeds: slow bodies are red,
+// those at the typical speed white and fast ones blue.
+func Cloud(bodies []Body) *pointcloud.Cloud {
+	c := &pointcloud.Cloud{
+		Positions: make([]mgl32.Vec3, len(bodies)),
+		Colors:    make([]mgl32.Vec4, len(bodies)),
+	}
+	scalars := make([][]float32, len(scalarNames))
+	for k, name := range scalarNames {
+		scalars[k] = make([]float32, len(bodies))
+		c.Scalars = append(c.Scalars, pointcloud.Scalar{Name: name, Values: scalars[k]})
+	}
+
+	var sum float64
+	for _, b := range bodies {
+		sum += float64(b.Velocity.Dot(b.Velocity))
+	}
+	rms := float32(math.Sqrt(sum / math.Max(1, float64(len(bodies)))))
+
+	slow := mgl32.Vec4{1, 0.45, 0.2, 1}
+	white := mgl32.Vec4{1, 1, 1, 1}
+	fast := mgl32.Vec4{0.35, 0.55, 1, 1}
+	for i, b := range bodies {
+		c.Positions[i] = b.Position
+		scalars[0][i] = b.Velocity[0]
+		scalars[1][i] = b.Velocity[1]
+		scalars[2][i] = b.Velocity[2]
+		scalars[3][i] = b.Mass
+
+		t := float32(1)
+		if rms > 0 {
+			t = b.Velocity.Len() / rms
+		}
+		if t < 1 {
+			c.Colors[i] = slow.Add(white.Sub(slow).Mul(t))
+		} else {
+			c.Colors[i] = white.Add(fast.Sub(white).Mul(mgl32.Clamp(t-1, 0, 1)))
+		}
+	}
+	return c
+}
+
+// FromCloud returns the bodies in a point cloud with the scalars Cloud
+// writes. Bodies without a mass weigh 1.
+func FromCloud(c *pointcloud.Cloud) ([]Body, error) {
+	var scalars [len(scalarNames)][]float32
+	for k, name := range scalarNames {
+		scalars[k] = c.Scalar(name)
+		if scalars[k] == nil && name != "mass" {
+			return nil, fmt.Errorf("nbody: point cloud has no %s", name)
+		}
+	}
+
+	bodies := make([]Body, c.Len())
+	for i := range bodies {
+		b := &bodies[i]
+		b.Position = c.Positions[i]
+		b.Velocity = mgl32.Vec3{scalars[0][i], scalars[1][i], scalars[2][i]}
+		b.Mass = 1
+		if scalars[3] != nil {
+			b.Mass = scalars[3][i]
+		}
+	}
+	return bodies, nil
+}
+
+// Save writes the bodies to path as a point cloud, in a format chosen by
+// its extension as for pointcloud.Save.
+func Save(path string, bodies []Body) error {
+	if err := pointcloud.Save(path, Cloud(bodies)); err != nil {
+		return fmt.Errorf("nbody: %v", err)
+	}
+	return nil
+}
+
+// Load reads bodies that Save wrote.
+func Load(path string) ([]Body, error) {
+	c, err := pointcloud.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("nbody: %v", err)
+	}
+	bodies, err := FromCloud(c)
+	if err != nil {
+		return nil, fmt.Errorf("nbody: %s: %v", path, err)
+	}
+	return bodies, nil
+}
@@ -0,0 +1,150 @@
+package nbody
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// Direct sums the pull of every body on every other, across goroutines.
+// It is exact, up to rounding, but takes time proportional to the square
+// of the number of bodies.
+type Direct struct{}
+
+// Accelerations implements Solver.
+func (Direct) Accelerations(bodies []Body, g, softening float32, acc []mgl32.Vec3) error {
+	eps2 := softening * softening
+	compute.ParallelFor(len(bodies), func(i int) {
+		p := bodies[i].Position
+		var a mgl32.Vec3
+		for j := range bodies {
+			d := bodies[j].Position.Sub(p)
+			r2 := d.Dot(d) + eps2
+			if r2 == 0 {
+				continue
+			}
+			inv := 1 / float32(math.Sqrt(float64(r2)))
+			a = a.Add(d.Mul(bodies[j].Mass * inv * inv * inv))
+		}
+		acc[i] = a.Mul(g)
+	})
+	return nil
+}
+
+// forceShader sums the pull of every body on one body per invocation. Each
+// work group loads a tile of bodies into shared memory at a time, so every
+// body is read from the buffer once per group rather than once per
+// invocation.
+const forceShader = `#version 430
+layout(local_size_x = 256) in;
+
+struct Body {
+    vec4 position; // xyz, and the mass in w
+    vec4 velocity;
+};
+layout(std430) readonly buffer Bodies { Body bodies[]; };
+layout(std430) writeonly buffer Accelerations { vec4 accelerations[]; };
+
+uniform uvec3 invocations;
+uniform float G;
+uniform float softening2;
+
+shared vec4 tile[256];
+
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    uint n = invocations.x;
+    // Invocations past the end still help load tiles, so they cannot
+    // return before the barriers.
+    vec3 p = i < n ? bodies[i].position.xyz : vec3(0);
+    vec3 a = vec3(0);
+
+    for (uint start = 0; start < n; start += 256) {
+        uint j = start + gl_LocalInvocationID.x;
+        // Bodies past the end have no mass, and pull on nothing.
+        tile[gl_LocalInvocationID.x] = j < n ? bodies[j].position : vec4(0);
+        barrier();
+        for (uint k = 0; k < 256; k++) {
+            vec3 d = tile[k].xyz - p;
+            float r2 = dot(d, d) + softening2;
+            if (r2 > 0.0) {
+                a += tile[k].w * d * inversesqrt(r2 * r2 * r2);
+            }
+        }
+        barrier();
+    }
+
+    if (i < n) {
+        accelerations[i] = vec4(G * a, 0.0);
+    }
+}
+`
+
+// GPU sums the pull of every body on every other in a compute shader,
+// like Direct but much faster. It needs a current GL context with compute
+// shaders, and copies the bodies to the GPU and the accelerations back on
+// each call.
+type GPU struct {
+	program       *compute.Program
+	bodies        *compute.Buffer[Body]
+	accelerations *compute.Buffer[[4]float32]
+	result        [][4]float32
+}
+
+// NewGPU compiles the GPU solver's shader.
+func NewGPU() (*GPU, error) {
+	if !compute.Supported() {
+		return nil, fmt.Errorf("nbody: compute shaders need OpenGL 4.3")
+	}
+	p, err := compute.NewProgram(forceShader)
+	if err != nil {
+		return nil, fmt.Errorf("nbody: %v", err)
+	}
+	return &GPU{program: p}, nil
+}
+
+// Accelerations implements Solver.
+func (s *GPU) Accelerations(bodies []Body, g, softening float32, acc []mgl32.Vec3) error {
+	n := len(bodies)
+	if n == 0 {
+		return nil
+	}
+	if s.bodies == nil {
+		s.bodies = compute.NewBufferLen[Body](n)
+		s.accelerations = compute.NewBufferLen[[4]float32](n)
+	} else if s.bodies.Len != n {
+		s.bodies.Resize(n)
+		s.accelerations.Resize(n)
+	}
+	if len(s.result) != n {
+		s.result = make([][4]float32, n)
+	}
+
+	s.bodies.Write(0, bodies)
+	if err := s.program.BindBuffer("Bodies", s.bodies.ID); err != nil {
+		return err
+	}
+	if err := s.program.BindBuffer("Accelerations", s.accelerations.ID); err != nil {
+		return err
+	}
+	s.program.Set("G", g)
+	s.program.Set("softening2", softening*softening)
+	s.program.Dispatch(n, 1, 1)
+
+	s.accelerations.ReadInto(0, s.result)
+	for i, a := range s.result {
+		acc[i] = mgl32.Vec3{a[0], a[1], a[2]}
+	}
+	return nil
+}
+
+// Delete frees the shader and buffers.
+func (s *GPU) Delete() {
+	s.program.Delete()
+	if s.bodies != nil {
+		s.bodies.Delete()
+		s.accelerations.Delete()
+	}
+}
@@ -0,0 +1,149 @@
+package nbody
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// randomDirection returns a unit vector uniformly distributed over the
+// sphere.
+func randomDirection(r *rand.Rand) mgl32.Vec3 {
+	z := 2*r.Float64() - 1
+	a := 2 * math.Pi * r.Float64()
+	s := math.Sqrt(1 - z*z)
+	return mgl32.Vec3{float32(s * math.Cos(a)), float32(s * math.Sin(a)), float32(z)}
+}
+
+// Plummer returns n bodies of equal mass, together weighing mass, sampled
+// from a Plummer sphere of scale radius radius in equilibrium, with G 1.
+// The centre of mass is at rest at the origin. Bodies further out than ten
+// times the radius are drawn again, so a few outliers do not set the scale.
+func Plummer(n int, mass, radius float32, r *rand.Rand) []Body {
+	bodies := make([]Body, n)
+	a := float64(radius)
+	for i := range bodies {
+		var d float64
+		for {
+			x := r.Float64()
+			if x == 0 {
+				continue
+			}
+			d = a / math.Sqrt(math.Pow(x, -2.0/3)-1)
+			if d < 10*a {
+				break
+			}
+		}
+
+		// The speed as a fraction q of the escape speed has a distribution
+		// proportional to q²(1 - q²)^3.5, sampled by rejection.
+		var q float64
+		for {
+			q = r.Float64()
+			if 0.1*r.Float64() < q*q*math.Pow(1-q*q, 3.5) {
+				break
+			}
+		}
+		escape := math.Sqrt(2*float64(mass)) * math.Pow(d*d+a*a, -0.25)
+
+		bodies[i] = Body{
+			Position: randomDirection(r).Mul(float32(d)),
+			Mass:     mass / float32(n),
+			Velocity: randomDirection(r).Mul(float32(q * escape)),
+		}
+	}
+	Recentre(bodies)
+	return bodies
+}
+
+// Disk returns a disk of n bodies in the XZ plane, turning about +Y: a
+// central body of mass centralMass at the origin, if that is not 0, and
+// stars of equal mass weighing mass between them, spread evenly over the
+// disk out to radius. Each star is given the speed of a circular orbit
+// around the mass inside it, with G 1. The disk is thin but not flat, and
+// the innermost tenth of its radius is left empty.
+func Disk(n int, mass, radius, centralMass float32, r *rand.Rand) []Body {
+	bodies := make([]Body, 0, n)
+	stars := n
+	if centralMass > 0 && n > 0 {
+		bodies = append(bodies, Body{Mass: centralMass})
+		stars--
+	}
+
+	R := float64(radius)
+	inner := 0.1 * R
+	for i := 0; i < stars; i++ {
+		// Uniform over the annulus.
+		d := math.Sqrt(inner*inner + r.Float64()*(R*R-inner*inner))
+		a := 2 * math.Pi * r.Float64()
+		y := r.NormFloat64() * 0.02 * R
+
+		enclosed := float64(centralMass) + float64(mass)*(d*d-inner*inner)/(R*R-inner*inner)
+		speed := math.Sqrt(enclosed / d)
+
+		cos, sin := math.Cos(a), math.Sin(a)
+		bodies = append(bodies, Body{
+			Position: mgl32.Vec3{float32(d * cos), float32(y), float32(d * sin)},
+			Mass:     mass / float32(stars),
+			// Anticlockwise seen from above, i.e. turning about +Y.
+			Velocity: mgl32.Vec3{float32(speed * sin), 0, float32(-speed * cos)},
+		})
+	}
+	return bodies
+}
+
+// Galaxies returns two disk galaxies of n bodies between them, each like
+// Disk with stars weighing a third of mass and a central body the rest,
+// separation apart and falling towards each other on a near-parabolic
+// orbit. The second disk is tilted, so the encounter is not symmetric.
+func Galaxies(n int, mass, radius, separation float32, r *rand.Rand) []Body {
+	first := Disk(n/2, mass/3, radius, 2*mass/3, r)
+	second := Disk(n-n/2, mass/3, radius, 2*mass/3, r)
+
+	// Each galaxy moves at half the relative speed of a parabolic orbit,
+	// less a little so they are bound, at right angles to the line between
+	// them, plus a closing speed, so they pass near but not through each
+	// other.
+	speed := float32(0.9 * math.Sqrt(2*2*float64(mass)/float64(separation)) / 2)
+	offset := mgl32.Vec3{separation / 2, 0, 0}
+	velocity := mgl32.Vec3{-0.5 * speed, 0, 0.5 * speed}
+
+	Move(first, mgl32.Ident3(), offset.Mul(-1), velocity.Mul(-1))
+	tilt := mgl32.Rotate3DX(mgl32.DegToRad(60)).Mul3(mgl32.Rotate3DZ(mgl32.DegToRad(30)))
+	Move(second, tilt, offset, velocity)
+
+	bodies := append(first, second...)
+	Recentre(bodies)
+	return bodies
+}
+
+// Move rotates bodies about the origin, with their velocities, and then
+// shifts them by offset and adds velocity to them.
+func Move(bodies []Body, rotation mgl32.Mat3, offset, velocity mgl32.Vec3) {
+	for i := range bodies {
+		b := &bodies[i]
+		b.Position = rotation.Mul3x1(b.Position).Add(offset)
+		b.Velocity = rotation.Mul3x1(b.Velocity).Add(velocity)
+	}
+}
+
+// Recentre moves bodies so that their centre of mass is at rest at the
+// origin.
+func Recentre(bodies []Body) {
+	var p, v [3]float64
+	var m float64
+	for _, b := range bodies {
+		for k := 0; k < 3; k++ {
+			p[k] += float64(b.Mass) * float64(b.Position[k])
+			v[k] += float64(b.Mass) * float64(b.Velocity[k])
+		}
+		m += float64(b.Mass)
+	}
+	if m == 0 {
+		return
+	}
+	offset := mgl32.Vec3{float32(-p[0] / m), float32(-p[1] / m), float32(-p[2] / m)}
+	velocity := mgl32.Vec3{float32(-v[0] / m), float32(-v[1] / m), float32(-v[2] / m)}
+	Move(bodies, mgl32.Ident3(), offset, velocity)
+}
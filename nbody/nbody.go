@@ -0,0 +1,233 @@
+// Package nbody simulates gravitating bodies. A Solver finds the
+// acceleration of every body: Direct sums over every pair on the CPU, GPU
+// does the same in a compute shader that shares tiles of bodies between
+// invocations, and BarnesHut approximates distant groups by their centre of
+// mass for large numbers of bodies. An Integrator then moves the bodies on
+// by a time step.
+//
+// Plummer, Disk and Galaxies make initial conditions, Energy and Drift
+// check how well a run conserves energy, and Cloud, Save and Load turn the
+// bodies into point clouds, for drawing with pointcloud.Buffer or dumping
+// to a file.
+//
+// Units are chosen so that G is 1 unless System.G says otherwise.
+package nbody
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// Body is a point mass, laid out as the GPU solver's std430 struct
+// { vec4 position; vec4 velocity; } with the mass in position.w.
+type Body struct {
+	Position mgl32.Vec3
+	Mass     float32
+	Velocity mgl32.Vec3
+	_        float32
+}
+
+// Solver computes the acceleration of each body due to all the others.
+type Solver interface {
+	// Accelerations sets acc[i] to the acceleration of bodies[i], with
+	// gravitational constant g and the force softened by softening: bodies
+	// closer than about that distance pull on each other less than 1/r²
+	// would say, so close encounters do not fling them apart.
+	Accelerations(bodies []Body, g, softening float32, acc []mgl32.Vec3) error
+}
+
+// Integrator is a way of moving bodies on by a time step.
+type Integrator int
+
+// Integrators. Leapfrog and Verlet are symplectic and second order, so
+// energy errors stay bounded over long runs; Euler is first order and only
+// there for comparison.
+const (
+	// Leapfrog drifts the bodies half a step, kicks them with the
+	// accelerations there, and drifts them the other half.
+	Leapfrog Integrator = iota
+	// Verlet is velocity Verlet: a half kick, a drift and another half kick
+	// with the new accelerations, which are kept for the next step.
+	Verlet
+	// Euler is semi-implicit Euler: a kick and then a drift.
+	Euler
+)
+
+func (i Integrator) String() string {
+	switch i {
+	case Verlet:
+		return "verlet"
+	case Euler:
+		return "euler"
+	}
+	return "leapfrog"
+}
+
+// Set parses "leapfrog", "verlet" or "euler", so that an Integrator can be
+// a flag.
+func (i *Integrator) Set(s string) error {
+	switch s {
+	case "leapfrog":
+		*i = Leapfrog
+	case "verlet":
+		*i = Verlet
+	case "euler":
+		*i = Euler
+	default:
+		return fmt.Errorf("nbody: unknown integrator %q, want leapfrog, verlet or euler", s)
+	}
+	return nil
+}
+
+// System is a set of bodies and how to move them.
+type System struct {
+	Bodies []Body
+	// G is the gravitational constant, and Softening the distance below
+	// which gravity is softened; see Solver.
+	G, Softening float32
+	Solver       Solver
+	Integrator   Integrator
+	// Time is the simulated time, and Steps the number of steps taken.
+	Time  float64
+	Steps int
+
+	acc []mgl32.Vec3
+	// current is whether acc holds the accelerations at the bodies'
+	// positions, as Verlet leaves it.
+	current bool
+	// initial is the energy Drift compares with, once it has been measured.
+	initial  float64
+	measured bool
+}
+
+// NewSystem returns a System of bodies with G 1, a little softening, the
+// leapfrog integrator and the given solver.
+func NewSystem(bodies []Body, solver Solver) *System {
+	return &System{
+		Bodies:    bodies,
+		G:         1,
+		Softening: 0.01,
+		Solver:    solver,
+	}
+}
+
+// Reset forgets the accelerations kept between steps and the energy Drift
+// compares with. Call it after changing Bodies other than through Step.
+func (s *System) Reset() {
+	s.current = false
+	s.measured = false
+}
+
+// accelerate computes the accelerations at the bodies' positions.
+func (s *System) accelerate() error {
+	if len(s.acc) != len(s.Bodies) {
+		s.acc = make([]mgl32.Vec3, len(s.Bodies))
+	}
+	if err := s.Solver.Accelerations(s.Bodies, s.G, s.Softening, s.acc); err != nil {
+		return err
+	}
+	s.current = true
+	return nil
+}
+
+// Step moves the bodies on by dt with the system's integrator.
+func (s *System) Step(dt float32) error {
+	if len(s.acc) != len(s.Bodies) {
+		s.current = false
+	}
+	b := s.Bodies
+	drift := func(h float32) {
+		for i := range b {
+			b[i].Position = b[i].Position.Add(b[i].Velocity.Mul(h))
+		}
+		s.current = false
+	}
+	kick := func(h float32) {
+		for i := range b {
+			b[i].Velocity = b[i].Velocity.Add(s.acc[i].Mul(h))
+		}
+	}
+
+	switch s.Integrator {
+	case Leapfrog:
+		drift(dt / 2)
+		if err := s.accelerate(); err != nil {
+			return err
+		}
+		kick(dt)
+		drift(dt / 2)
+	case Verlet:
+		if !s.current {
+			if err := s.accelerate(); err != nil {
+				return err
+			}
+		}
+		kick(dt / 2)
+		drift(dt)
+		if err := s.accelerate(); err != nil {
+			return err
+		}
+		kick(dt / 2)
+	case Euler:
+		if !s.current {
+			if err := s.accelerate(); err != nil {
+				return err
+			}
+		}
+		kick(dt)
+		drift(dt)
+	}
+	s.Time += float64(dt)
+	s.Steps++
+	return nil
+}
+
+// Energy returns the kinetic and potential energy of the bodies, with the
+// same softening as the forces. The potential sums over every pair, across
+// goroutines, so it takes about as long as a step with Direct.
+func (s *System) Energy() (kinetic, potential float64) {
+	b := s.Bodies
+	for i := range b {
+		v := b[i].Velocity
+		kinetic += 0.5 * float64(b[i].Mass) * float64(v.Dot(v))
+	}
+
+	eps2 := float64(s.Softening) * float64(s.Softening)
+	sums := make([]float64, len(b))
+	compute.ParallelFor(len(b), func(i int) {
+		p := b[i].Position
+		var sum float64
+		for j := i + 1; j < len(b); j++ {
+			dx := float64(b[j].Position[0]) - float64(p[0])
+			dy := float64(b[j].Position[1]) - float64(p[1])
+			dz := float64(b[j].Position[2]) - float64(p[2])
+			r2 := dx*dx + dy*dy + dz*dz + eps2
+			if r2 > 0 {
+				sum += float64(b[j].Mass) / math.Sqrt(r2)
+			}
+		}
+		sums[i] = float64(b[i].Mass) * sum
+	})
+	for _, sum := range sums {
+		potential -= sum
+	}
+	return kinetic, potential * float64(s.G)
+}
+
+// Drift returns the relative change in total energy since it was first
+// called, or since Reset: a measure of the error the integrator and time
+// step have built up.
+func (s *System) Drift() float64 {
+	k, p := s.Energy()
+	e := k + p
+	if !s.measured {
+		s.initial, s.measured = e, true
+	}
+	if s.initial == 0 {
+		return e
+	}
+	return (e - s.initial) / math.Abs(s.initial)
+}
@@ -0,0 +1,201 @@
+package nbody
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// relativeError returns the RMS difference between two sets of
+// accelerations, relative to the RMS of want.
+func relativeError(got, want []mgl32.Vec3) float64 {
+	var diff, norm float64
+	for i := range want {
+		d := got[i].Sub(want[i])
+		diff += float64(d.Dot(d))
+		norm += float64(want[i].Dot(want[i]))
+	}
+	return math.Sqrt(diff / norm)
+}
+
+func TestBarnesHutMatchesDirect(t *testing.T) {
+	bodies := Plummer(2000, 1, 1, rand.New(rand.NewSource(1)))
+	want := make([]mgl32.Vec3, len(bodies))
+	if err := (Direct{}).Accelerations(bodies, 1, 0.01, want); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		theta     float64
+		leafSize  int
+		tolerance float64
+	}{
+		// Opening every cell leaves only rounding.
+		{0.01, 8, 1e-5},
+		{0.01, 1, 1e-5},
+		{0.2, 8, 2e-4},
+		{0.5, 8, 1e-2},
+		{0.5, 1, 1e-2},
+		{1, 8, 5e-2},
+	}
+	for _, tt := range tests {
+		bh := &BarnesHut{Theta: tt.theta, LeafSize: tt.leafSize}
+		got := make([]mgl32.Vec3, len(bodies))
+		if err := bh.Accelerations(bodies, 1, 0.01, got); err != nil {
+			t.Fatal(err)
+		}
+		if e := relativeError(got, want); e > tt.tolerance {
+			t.Errorf("theta %v, leaf size %d: error %.2g, want at most %g", bh.Theta, tt.leafSize, e, tt.tolerance)
+		}
+	}
+
+	// Bodies on top of each other must not split the octree forever.
+	same := make([]Body, 20)
+	for i := range same {
+		same[i] = Body{Position: mgl32.Vec3{1, 2, 3}, Mass: 1}
+	}
+	acc := make([]mgl32.Vec3, len(same))
+	if err := (&BarnesHut{LeafSize: 1}).Accelerations(same, 1, 0.01, acc); err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range acc {
+		if a.Len() > 1e-3 {
+			t.Errorf("body %d of a stack is pulled by %v", i, a)
+		}
+	}
+}
+
+func TestEnergyDrift(t *testing.T) {
+	tests := []struct {
+		integrator Integrator
+		maxDrift   float64
+	}{
+		{Leapfrog, 1e-4},
+		{Verlet, 1e-4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.integrator.String(), func(t *testing.T) {
+			s := NewSystem(Plummer(200, 1, 1, rand.New(rand.NewSource(2))), Direct{})
+			s.Softening = 0.05
+			s.Integrator = tt.integrator
+			if d := s.Drift(); d != 0 {
+				t.Fatalf("drift before any steps is %v", d)
+			}
+			// Symplectic integrators keep the error bounded: it wobbles
+			// but does not grow with the number of steps.
+			for i := 0; i < 400; i++ {
+				if err := s.Step(0.005); err != nil {
+					t.Fatal(err)
+				}
+				if i%50 == 49 {
+					if d := s.Drift(); math.Abs(d) > tt.maxDrift {
+						t.Fatalf("drift after %d steps is %.2g, want at most %g", s.Steps, d, tt.maxDrift)
+					}
+				}
+			}
+			if s.Steps != 400 || math.Abs(s.Time-2) > 1e-6 {
+				t.Errorf("after 400 steps of 0.005: %d steps, time %v", s.Steps, s.Time)
+			}
+		})
+	}
+}
+
+func TestInitialConditions(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	tests := []struct {
+		name   string
+		bodies []Body
+		n      int
+	}{
+		{"Plummer", Plummer(500, 2, 1, r), 500},
+		{"Disk", Disk(500, 1, 4, 10, r), 500},
+		{"Galaxies", Galaxies(500, 1, 2, 10, r), 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.bodies) != tt.n {
+				t.Fatalf("got %d bodies, want %d", len(tt.bodies), tt.n)
+			}
+			for i, b := range tt.bodies {
+				if !(b.Mass > 0) {
+					t.Fatalf("body %d has mass %v", i, b.Mass)
+				}
+			}
+
+			// The centre of mass is at rest at the origin, except for Disk,
+			// which puts the central body there instead.
+			if tt.name == "Disk" {
+				if b := tt.bodies[0]; b.Position != (mgl32.Vec3{}) || b.Velocity != (mgl32.Vec3{}) {
+					t.Errorf("central body %+v is not at rest at the origin", b)
+				}
+			} else {
+				var p, v mgl32.Vec3
+				var m float32
+				for _, b := range tt.bodies {
+					p = p.Add(b.Position.Mul(b.Mass))
+					v = v.Add(b.Velocity.Mul(b.Mass))
+					m += b.Mass
+				}
+				if p.Mul(1/m).Len() > 1e-4 || v.Mul(1/m).Len() > 1e-4 {
+					t.Errorf("centre of mass at %v moving at %v", p.Mul(1/m), v.Mul(1/m))
+				}
+			}
+
+			// Each starts bound.
+			s := NewSystem(tt.bodies, Direct{})
+			k, u := s.Energy()
+			if k+u >= 0 {
+				t.Errorf("total energy %v is not negative", k+u)
+			}
+		})
+	}
+
+	// A Plummer sphere is in virial equilibrium: 2K = -U.
+	s := NewSystem(Plummer(2000, 1, 1, rand.New(rand.NewSource(4))), Direct{})
+	s.Softening = 0
+	k, u := s.Energy()
+	if ratio := 2 * k / -u; ratio < 0.85 || ratio > 1.15 {
+		t.Errorf("Plummer sphere has 2K/-U = %v, want about 1", ratio)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	bodies := Plummer(100, 1, 1, rand.New(rand.NewSource(5)))
+	bodies[0].Mass = 3 // not all the same
+	for _, ext := range []string{".ply", ".xyz", ".csv"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bodies"+ext)
+			if err := Save(path, bodies); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(bodies) {
+				t.Fatalf("loaded %d bodies, want %d", len(got), len(bodies))
+			}
+			for i := range bodies {
+				if got[i] != bodies[i] {
+					t.Fatalf("body %d loaded as %+v, want %+v", i, got[i], bodies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIntegratorSet(t *testing.T) {
+	for _, i := range []Integrator{Leapfrog, Verlet, Euler} {
+		var parsed Integrator
+		if err := parsed.Set(i.String()); err != nil || parsed != i {
+			t.Errorf("Set(%q) gave %v, %v", i.String(), parsed, err)
+		}
+	}
+	var i Integrator
+	if err := i.Set("rk4"); err == nil {
+		t.Error("Set(\"rk4\") gave no error")
+	}
+}
@@ -0,0 +1,204 @@
+package numbers
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/pointcloud"
+)
+
+// GoldenAngle is the angle, in degrees, that sunflower seeds turn by from
+// one to the next: 360 / φ², which puts them in Fibonacci numbers of
+// spirals.
+var GoldenAngle = 360 * (2 - (1+math.Sqrt(5))/2)
+
+// ramp returns a colour along a blue, cyan, yellow, red ramp for t in
+// [0, 1].
+func ramp(t float32) mgl32.Vec4 {
+	stops := [...]mgl32.Vec4{
+		{0.2, 0.3, 1, 1},
+		{0.2, 0.9, 1, 1},
+		{1, 0.95, 0.3, 1},
+		{1, 0.3, 0.2, 1},
+	}
+	t = mgl32.Clamp(t, 0, 1) * float32(len(stops)-1)
+	i := int(t)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	f := t - float32(i)
+	return stops[i].Mul(1 - f).Add(stops[i+1].Mul(f))
+}
+
+// builder collects the points of a pattern.
+type builder struct {
+	cloud pointcloud.Cloud
+	// extent is the largest absolute coordinate so far.
+	extent float32
+}
+
+func newBuilder(capacity int) *builder {
+	return &builder{cloud: pointcloud.Cloud{
+		Positions: make([]mgl32.Vec3, 0, capacity),
+		Colors:    make([]mgl32.Vec4, 0, capacity),
+	}}
+}
+
+func (b *builder) add(x, y float64, c mgl32.Vec4) {
+	p := mgl32.Vec3{float32(x), float32(y), 0}
+	b.cloud.Positions = append(b.cloud.Positions, p)
+	b.cloud.Colors = append(b.cloud.Colors, c)
+	if e := mgl32.Abs(p[0]); e > b.extent {
+		b.extent = e
+	}
+	if e := mgl32.Abs(p[1]); e > b.extent {
+		b.extent = e
+	}
+}
+
+// done scales the points into the square from -1 to 1 and returns them.
+func (b *builder) done() *pointcloud.Cloud {
+	if b.extent > 0 {
+		s := 1 / b.extent
+		for i := range b.cloud.Positions {
+			b.cloud.Positions[i] = b.cloud.Positions[i].Mul(s)
+		}
+	}
+	return &b.cloud
+}
+
+// primeCapacity is about the number of primes below limit.
+func primeCapacity(limit int) int {
+	if limit < 10 {
+		return 4
+	}
+	return int(1.26 * float64(limit) / math.Log(float64(limit)))
+}
+
+// Ulam returns the Ulam spiral of the numbers 1 to size²: they wind out
+// from the centre of a size by size grid, anticlockwise, and the primes
+// among them are kept. Primes line up along diagonals, the values of
+// quadratic polynomials rich in primes. Colours go from the centre out.
+func Ulam(size int) *pointcloud.Cloud {
+	n := size * size
+	t := NewTable(n + 1)
+	b := newBuilder(primeCapacity(n))
+
+	// Walk the spiral: 1 step right, 1 up, 2 left, 2 down, 3 right...
+	x, y := 0, 0
+	dx, dy := 1, 0
+	run, left, turns := 1, 1, 0
+	for k := 1; k <= n; k++ {
+		if t.Prime(k) {
+			b.add(float64(x), float64(y), ramp(float32(k)/float32(n)))
+		}
+		x, y = x+dx, y+dy
+		left--
+		if left == 0 {
+			dx, dy = -dy, dx
+			turns++
+			if turns%2 == 0 {
+				run++
+			}
+			left = run
+		}
+	}
+	return b.done()
+}
+
+// Sacks returns the Sacks spiral of the primes below limit: n is at radius
+// √n and angle 2π√n, so the squares line up along the positive X axis and
+// primes gather on curves. Colours go from the centre out.
+func Sacks(limit int) *pointcloud.Cloud {
+	b := newBuilder(primeCapacity(limit))
+	sieve(limit, func(p int) {
+		r := math.Sqrt(float64(p))
+		a := 2 * math.Pi * r
+		b.add(r*math.Cos(a), r*math.Sin(a), ramp(float32(p)/float32(limit)))
+	})
+	return b.done()
+}
+
+// Polar returns the primes below limit in polar coordinates, p at radius
+// p and angle p radians, as 08-UserInput draws them. Since 44/7 and 710/113
+// are close to 2π, the primes form spiral arms that turn into rays further
+// out. Colours go by the gap to the next prime, so twin primes are blue.
+func Polar(limit int) *pointcloud.Cloud {
+	primes := PrimesBelow(limit)
+	b := newBuilder(len(primes))
+	for i, p := range primes {
+		gap := 2
+		if i+1 < len(primes) {
+			gap = primes[i+1] - p
+		}
+		f := float64(p)
+		b.add(f*math.Cos(f), f*math.Sin(f), ramp(float32(math.Log2(float64(gap)))/6))
+	}
+	return b.done()
+}
+
+// Phyllotaxis returns n points arranged like sunflower seeds: point k at
+// radius √k, turned k times angle degrees from the first, which for
+// GoldenAngle packs them evenly. Points are coloured by k modulo arms, so
+// a Fibonacci number of arms, such as 21, 34 or 55, picks out the spirals
+// that the eye sees. Prime k are brighter.
+func Phyllotaxis(n int, angle float64, arms int) *pointcloud.Cloud {
+	if arms < 1 {
+		arms = 1
+	}
+	t := NewTable(n + 1)
+	b := newBuilder(n)
+	a := angle * math.Pi / 180
+	for k := 1; k <= n; k++ {
+		r := math.Sqrt(float64(k))
+		c := ramp(float32(k%arms) / float32(arms))
+		if !t.Prime(k) {
+			c = c.Mul(0.5)
+			c[3] = 1
+		}
+		b.add(r*math.Cos(float64(k)*a), r*math.Sin(float64(k)*a), c)
+	}
+	return b.done()
+}
+
+// GaussianPrimes returns the Gaussian primes a + bi with norm a² + b² up
+// to radius²: those where a and b are both non-zero and the norm is prime,
+// and those on the axes whose other part is, in size, a prime 3 more than
+// a multiple of 4. The pattern has the symmetry of a square. Colours go by
+// the norm.
+func GaussianPrimes(radius int) *pointcloud.Cloud {
+	limit := radius*radius + 1
+	t := NewTable(limit)
+	// About 4/π of the primes below the limit are norms of 8 primes each.
+	b := newBuilder(4 * primeCapacity(limit))
+	for a := -radius; a <= radius; a++ {
+		for c := -radius; c <= radius; c++ {
+			norm := a*a + c*c
+			if norm >= limit {
+				continue
+			}
+			var prime bool
+			switch {
+			case a == 0:
+				prime = gaussianAxisPrime(t, c)
+			case c == 0:
+				prime = gaussianAxisPrime(t, a)
+			default:
+				prime = t.Prime(norm)
+			}
+			if prime {
+				b.add(float64(a), float64(c), ramp(float32(norm)/float32(limit)))
+			}
+		}
+	}
+	return b.done()
+}
+
+// gaussianAxisPrime reports whether the Gaussian integer n or ni is prime,
+// for a whole number n.
+func gaussianAxisPrime(t *Table, n int) bool {
+	if n < 0 {
+		n = -n
+	}
+	return n%4 == 3 && t.Prime(n)
+}
@@ -0,0 +1,133 @@
+// Package numbers finds primes with a segmented sieve of Eratosthenes and
+// lays numbers out as coloured point patterns: the Ulam and Sacks spirals,
+// a polar plot of the primes, phyllotaxis spirals and the Gaussian primes.
+//
+// Patterns are point clouds, scaled to fit the square from -1 to 1 in the
+// XY plane, for drawing with pointcloud.Buffer. They are built for
+// millions of points.
+package numbers
+
+import (
+	"math"
+)
+
+// segmentSize is the number of odd numbers sieved at a time: 32 KB of
+// flags, to stay in a level 1 cache.
+const segmentSize = 1 << 15
+
+// sieve calls visit for every prime below limit, in order. Only odd
+// numbers are sieved, a segment at a time, crossing off multiples of the
+// primes up to the square root of limit.
+func sieve(limit int, visit func(p int)) {
+	if limit <= 2 {
+		return
+	}
+	visit(2)
+
+	// The odd primes up to the square root of limit, by a simple sieve.
+	root := int(math.Sqrt(float64(limit)))
+	for root*root >= limit {
+		root--
+	}
+	for (root+1)*(root+1) < limit {
+		root++
+	}
+	small := make([]bool, root+1)
+	var base []int
+	for i := 3; i <= root; i += 2 {
+		if !small[i] {
+			base = append(base, i)
+			for j := i * i; j <= root; j += 2 * i {
+				small[j] = true
+			}
+		}
+	}
+
+	// next[k] is the next odd multiple of base[k] to cross off.
+	next := make([]int, len(base))
+	for k, p := range base {
+		next[k] = p * p
+	}
+
+	composite := make([]bool, segmentSize)
+	// Segment flag i stands for the odd number lo + 2i.
+	for lo := 3; lo < limit; lo += 2 * segmentSize {
+		hi := lo + 2*segmentSize
+		if hi > limit {
+			hi = limit
+		}
+		for i := range composite {
+			composite[i] = false
+		}
+		for k, p := range base {
+			if p*p >= hi {
+				break
+			}
+			j := next[k]
+			for ; j < hi; j += 2 * p {
+				composite[(j-lo)/2] = true
+			}
+			next[k] = j
+		}
+		for n := lo; n < hi; n += 2 {
+			if !composite[(n-lo)/2] {
+				visit(n)
+			}
+		}
+	}
+}
+
+// PrimesBelow returns the primes below limit, in order.
+func PrimesBelow(limit int) []int {
+	var primes []int
+	if limit > 10 {
+		// The prime counting function is less than 1.26 x / ln x.
+		primes = make([]int, 0, int(1.26*float64(limit)/math.Log(float64(limit))))
+	}
+	sieve(limit, func(p int) {
+		primes = append(primes, p)
+	})
+	return primes
+}
+
+// Primes returns the first n primes.
+func Primes(n int) []int {
+	if n <= 0 {
+		return nil
+	}
+	// For n >= 6 the nth prime is below n (ln n + ln ln n).
+	limit := 15
+	if n >= 6 {
+		ln := math.Log(float64(n))
+		limit = int(float64(n)*(ln+math.Log(ln))) + 1
+	}
+	primes := PrimesBelow(limit)
+	return primes[:n]
+}
+
+// Table records which numbers below its limit are prime, a bit each.
+type Table struct {
+	Limit int
+	bits  []uint64
+}
+
+// NewTable sieves the numbers below limit.
+func NewTable(limit int) *Table {
+	if limit < 0 {
+		limit = 0
+	}
+	t := &Table{Limit: limit, bits: make([]uint64, (limit+63)/64)}
+	sieve(limit, func(p int) {
+		t.bits[p/64] |= 1 << (p % 64)
+	})
+	return t
+}
+
+// Prime reports whether n is prime. Numbers outside the table, below 0 or
+// from its limit on, are reported as not prime.
+func (t *Table) Prime(n int) bool {
+	if n < 0 || n >= t.Limit {
+		return false
+	}
+	return t.bits[n/64]&(1<<(n%64)) != 0
+}
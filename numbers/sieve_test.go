@@ -0,0 +1,138 @@
+package numbers
+
+import (
+	"fmt"
+	"testing"
+)
+
+// isPrime is trial division by every number up to the square root.
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for f := 2; f*f <= n; f++ {
+		if n%f == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// The first segment holds the odd numbers from 3 to 3 + 2*segmentSize, so
+// limits near there and near the squares of the small primes are where the
+// sieve could go wrong.
+var limits = []int{
+	-1, 0, 1, 2, 3, 4, 25, 26, 49, 50, 121,
+	65537, 3 + 2*segmentSize - 2, 3 + 2*segmentSize, 3 + 2*segmentSize + 2,
+	3 + 4*segmentSize + 1, 300007,
+}
+
+func TestPrimesBelow(t *testing.T) {
+	for _, limit := range limits {
+		var want []int
+		for n := 0; n < limit; n++ {
+			if isPrime(n) {
+				want = append(want, n)
+			}
+		}
+
+		got := PrimesBelow(limit)
+		if len(got) != len(want) {
+			t.Fatalf("PrimesBelow(%d) gave %d primes, want %d", limit, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("PrimesBelow(%d)[%d] is %d, want %d", limit, i, got[i], want[i])
+			}
+		}
+
+		table := NewTable(limit)
+		for n := -1; n <= limit; n++ {
+			if got, want := table.Prime(n), n < limit && isPrime(n); got != want {
+				t.Fatalf("NewTable(%d).Prime(%d) is %v, want %v", limit, n, got, want)
+			}
+		}
+	}
+}
+
+func TestPrimes(t *testing.T) {
+	var want []int
+	for n := 2; len(want) < 30000; n++ {
+		if isPrime(n) {
+			want = append(want, n)
+		}
+	}
+	// Around 6, where the bound on the nth prime changes, and past the
+	// first segment.
+	for _, n := range []int{-1, 0, 1, 2, 5, 6, 7, 100, 6542, 6543, 30000} {
+		got := Primes(n)
+		if n <= 0 {
+			if len(got) != 0 {
+				t.Errorf("Primes(%d) gave %d primes, want none", n, len(got))
+			}
+			continue
+		}
+		if len(got) != n {
+			t.Fatalf("Primes(%d) gave %d primes", n, len(got))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("Primes(%d)[%d] is %d, want %d", n, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+// newP is 08-UserInput's prime generator, the baseline the sieve replaces:
+// it returns the primes in turn, finding each by trial division by every
+// number below it.
+func newP() func() int {
+	n := 1
+	return func() int {
+		for {
+			n++
+			for f := 2; ; f++ {
+				if f == n {
+					return n
+				}
+				if n%f == 0 {
+					break
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkNewP(b *testing.B) {
+	// Trial division by every smaller number is quadratic, so it stops at
+	// 10^4; 10^5 takes seconds.
+	for _, limit := range []int{1e3, 1e4} {
+		b.Run(fmt.Sprint(limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				prime := newP()
+				for prime() < limit {
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPrimesBelow(b *testing.B) {
+	for _, limit := range []int{1e3, 1e4, 1e5, 1e6, 1e7} {
+		b.Run(fmt.Sprint(limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				PrimesBelow(limit)
+			}
+		})
+	}
+}
+
+func BenchmarkNewTable(b *testing.B) {
+	for _, limit := range []int{1e3, 1e4, 1e5, 1e6, 1e7} {
+		b.Run(fmt.Sprint(limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewTable(limit)
+			}
+		})
+	}
+}
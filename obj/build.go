@@ -0,0 +1,190 @@
+package obj
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/geometry"
+)
+
+// vertexKey identifies a welded vertex. Corners with a generated normal are
+// keyed by smoothing group, or by face when smoothing is off, so that only
+// corners meant to share a normal are merged.
+type vertexKey struct {
+	v, t, n int
+	smooth  int
+	face    int
+}
+
+// smoothKey picks out a generated normal shared by one position within one
+// smoothing group.
+type smoothKey struct {
+	v, smooth int
+}
+
+// build triangulates the faces of p into mesh, generating normals and
+// welding identical corners.
+func (d *decoder) build(p *part, mesh *geometry.Mesh) {
+	faceNormals := make([]mgl32.Vec3, len(p.faces))
+	smoothed := make(map[smoothKey]mgl32.Vec3)
+
+	// Area-weighted normals for each smoothing group.
+	for i, f := range p.faces {
+		n := d.newellNormal(f.corners)
+		faceNormals[i] = n.Normalize()
+		if f.smooth == 0 {
+			continue
+		}
+		for _, c := range f.corners {
+			if c.n < 0 {
+				k := smoothKey{c.v, f.smooth}
+				smoothed[k] = smoothed[k].Add(n)
+			}
+		}
+	}
+
+	welded := make(map[vertexKey]uint32)
+	vertex := func(fi int, f face, c corner) uint32 {
+		k := vertexKey{v: c.v, t: c.t, n: c.n, smooth: -1, face: -1}
+		if c.n < 0 {
+			if f.smooth == 0 {
+				k.face = fi
+			} else {
+				k.smooth = f.smooth
+			}
+		}
+		if i, ok := welded[k]; ok {
+			return i
+		}
+
+		v := geometry.Vertex{Position: d.positions[c.v]}
+		if c.t >= 0 {
+			v.UV = d.uvs[c.t]
+		}
+		switch {
+		case c.n >= 0:
+			v.Normal = d.normals[c.n]
+		case f.smooth == 0:
+			v.Normal = faceNormals[fi]
+		default:
+			v.Normal = smoothed[smoothKey{c.v, f.smooth}].Normalize()
+		}
+
+		i := uint32(len(mesh.Vertices))
+		mesh.Vertices = append(mesh.Vertices, v)
+		welded[k] = i
+
+		return i
+	}
+
+	for fi, f := range p.faces {
+		for _, tri := range d.triangulate(f.corners, faceNormals[fi]) {
+			for _, ci := range tri {
+				mesh.Indices = append(mesh.Indices, vertex(fi, f, f.corners[ci]))
+			}
+		}
+	}
+
+	mesh.ComputeTangents()
+}
+
+// newellNormal returns the (unnormalized) normal of a polygon. Its length is
+// twice the polygon's area.
+func (d *decoder) newellNormal(corners []corner) mgl32.Vec3 {
+	var n mgl32.Vec3
+	for i, c := range corners {
+		a := d.positions[c.v]
+		b := d.positions[corners[(i+1)%len(corners)].v]
+		n[0] += (a[1] - b[1]) * (a[2] + b[2])
+		n[1] += (a[2] - b[2]) * (a[0] + b[0])
+		n[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	return n
+}
+
+// triangulate splits a polygon into triangles of corner numbers by ear
+// clipping in the plane facing normal. Triangles keep the polygon's winding.
+func (d *decoder) triangulate(corners []corner, normal mgl32.Vec3) [][3]int {
+	n := len(corners)
+	if n == 3 {
+		return [][3]int{{0, 1, 2}}
+	}
+
+	// Drop the axis the normal points along most, leaving a 2D polygon
+	// wound counter-clockwise.
+	ax, ay := 0, 1
+	abs := func(f float32) float32 {
+		if f < 0 {
+			return -f
+		}
+		return f
+	}
+	flip := normal[2] < 0
+	switch {
+	case abs(normal[0]) >= abs(normal[1]) && abs(normal[0]) >= abs(normal[2]):
+		ax, ay = 1, 2
+		flip = normal[0] < 0
+	case abs(normal[1]) >= abs(normal[2]):
+		ax, ay = 2, 0
+		flip = normal[1] < 0
+	}
+
+	pts := make([]mgl32.Vec2, n)
+	for i, c := range corners {
+		p := d.positions[c.v]
+		pts[i] = mgl32.Vec2{p[ax], p[ay]}
+		if flip {
+			pts[i][0] = -pts[i][0]
+		}
+	}
+
+	cross := func(a, b, c mgl32.Vec2) float32 {
+		return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	}
+	inside := func(p, a, b, c mgl32.Vec2) bool {
+		return cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0
+	}
+
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	tris := make([][3]int, 0, n-2)
+	for len(remaining) > 3 {
+		clipped := false
+		for i := range remaining {
+			m := len(remaining)
+			a, b, c := remaining[(i+m-1)%m], remaining[i], remaining[(i+1)%m]
+
+			if cross(pts[a], pts[b], pts[c]) <= 0 {
+				continue // reflex or degenerate corner
+			}
+
+			ear := true
+			for _, o := range remaining {
+				if o != a && o != b && o != c && inside(pts[o], pts[a], pts[b], pts[c]) {
+					ear = false
+					break
+				}
+			}
+			if !ear {
+				continue
+			}
+
+			tris = append(tris, [3]int{a, b, c})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+
+		if !clipped {
+			// Self-intersecting or otherwise broken polygon: fall back
+			// to a fan over what is left.
+			for i := 1; i+1 < len(remaining); i++ {
+				tris = append(tris, [3]int{remaining[0], remaining[i], remaining[i+1]})
+			}
+			return tris
+		}
+	}
+
+	return append(tris, [3]int{remaining[0], remaining[1], remaining[2]})
+}
@@ -0,0 +1,191 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Material is one newmtl entry from an MTL file.
+type Material struct {
+	Name string
+
+	Ambient  mgl32.Vec3 // Ka
+	Diffuse  mgl32.Vec3 // Kd
+	Specular mgl32.Vec3 // Ks
+	Emissive mgl32.Vec3 // Ke
+
+	Shininess float32 // Ns
+	Opacity   float32 // d, or 1 - Tr
+	IOR       float32 // Ni
+	Illum     int     // illumination model
+
+	AmbientMap  TextureMap // map_Ka
+	DiffuseMap  TextureMap // map_Kd
+	SpecularMap TextureMap // map_Ks
+	EmissiveMap TextureMap // map_Ke
+	AlphaMap    TextureMap // map_d
+	BumpMap     TextureMap // map_Bump, bump
+	NormalMap   TextureMap // norm
+}
+
+// TextureMap is a texture file referred to by a material.
+type TextureMap struct {
+	// File is the path to the image, resolved relative to the MTL file.
+	File string
+	// Texture is the GL texture name, set by Model.LoadTextures.
+	Texture uint32
+}
+
+func (m *Material) textures() []*TextureMap {
+	return []*TextureMap{
+		&m.AmbientMap, &m.DiffuseMap, &m.SpecularMap, &m.EmissiveMap,
+		&m.AlphaMap, &m.BumpMap, &m.NormalMap,
+	}
+}
+
+// loadMaterials reads an MTL file into d.materials.
+func (d *decoder) loadMaterials(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	line := 0
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s:%d: %s", path, line, fmt.Sprintf(format, args...))
+	}
+
+	var m *Material
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line++
+		text := s.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		keyword, args := fields[0], fields[1:]
+
+		if strings.EqualFold(keyword, "newmtl") {
+			if len(args) == 0 {
+				return errorf("newmtl needs a name")
+			}
+			m = &Material{Name: strings.Join(args, " "), Opacity: 1, IOR: 1}
+			d.materials[m.Name] = m
+			continue
+		}
+		if m == nil {
+			return errorf("%q before any newmtl", keyword)
+		}
+
+		colour := func(c *mgl32.Vec3) error {
+			if len(args) > 0 && (args[0] == "spectral" || args[0] == "xyz") {
+				return errorf("%s %s colours are not supported", keyword, args[0])
+			}
+			if len(args) != 1 && len(args) != 3 {
+				return errorf("%s needs 1 or 3 numbers", keyword)
+			}
+			for i := 0; i < 3; i++ {
+				a := args[0]
+				if len(args) == 3 {
+					a = args[i]
+				}
+				v, err := strconv.ParseFloat(a, 32)
+				if err != nil {
+					return errorf("bad number %q", a)
+				}
+				c[i] = float32(v)
+			}
+			return nil
+		}
+		scalar := func(f *float32) error {
+			if len(args) != 1 {
+				return errorf("%s needs one number", keyword)
+			}
+			v, err := strconv.ParseFloat(args[0], 32)
+			if err != nil {
+				return errorf("bad number %q", args[0])
+			}
+			*f = float32(v)
+			return nil
+		}
+		texture := func(t *TextureMap) error {
+			if len(args) == 0 {
+				return errorf("%s needs a file name", keyword)
+			}
+			// Options such as -bm or -s come before the file name;
+			// only the file name (which may not contain spaces) is kept.
+			t.File = filepath.Join(dir, filepath.FromSlash(args[len(args)-1]))
+			return nil
+		}
+
+		switch strings.ToLower(keyword) {
+		case "ka":
+			err = colour(&m.Ambient)
+		case "kd":
+			err = colour(&m.Diffuse)
+		case "ks":
+			err = colour(&m.Specular)
+		case "ke":
+			err = colour(&m.Emissive)
+		case "ns":
+			err = scalar(&m.Shininess)
+		case "ni":
+			err = scalar(&m.IOR)
+		case "d":
+			// "d -halo 0.5" is treated as a plain dissolve.
+			if len(args) == 2 && args[0] == "-halo" {
+				args = args[1:]
+			}
+			err = scalar(&m.Opacity)
+		case "tr":
+			var tr float32
+			err = scalar(&tr)
+			m.Opacity = 1 - tr
+		case "illum":
+			if len(args) != 1 {
+				return errorf("illum needs one number")
+			}
+			m.Illum, err = strconv.Atoi(args[0])
+			if err != nil {
+				return errorf("bad illumination model %q", args[0])
+			}
+		case "map_ka":
+			err = texture(&m.AmbientMap)
+		case "map_kd":
+			err = texture(&m.DiffuseMap)
+		case "map_ks":
+			err = texture(&m.SpecularMap)
+		case "map_ke":
+			err = texture(&m.EmissiveMap)
+		case "map_d":
+			err = texture(&m.AlphaMap)
+		case "map_bump", "bump":
+			err = texture(&m.BumpMap)
+		case "norm", "map_kn":
+			err = texture(&m.NormalMap)
+		default:
+			// Other statements (Tf, sharpness, PBR extensions, ...)
+			// are not used.
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+// Package obj reads Wavefront OBJ models and their MTL material libraries
+// into geometry meshes, and writes meshes back out.
+//
+// Positions, texture coordinates and normals are supported, as are negative
+// (relative) indices, polygons of any size (triangulated by ear clipping),
+// objects (o), groups (g), smoothing groups (s) and materials (mtllib,
+// usemtl). Faces without normals get normals generated from their smoothing
+// group. Each distinct object, group and material combination becomes one
+// indexed Mesh, even if its faces are split up in the file, with identical
+// corners welded into a single vertex. Meshes are in the order their first
+// faces appear.
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/geometry"
+)
+
+// Model is everything read from an OBJ file.
+type Model struct {
+	Meshes []*Mesh
+	// Materials are keyed by name, from every mtllib the file refers to.
+	Materials map[string]*Material
+}
+
+// Mesh is the triangles of one object, group and material.
+type Mesh struct {
+	Object   string
+	Group    string
+	Material *Material // nil if none was set or it was not found
+	geometry.Mesh
+}
+
+// TextureLoader uploads an image file and returns the GL texture name,
+// usually by calling texture.Load. OBJ texture coordinates have v pointing
+// up, so images want texture.Options.FlipY.
+type TextureLoader func(file string) (uint32, error)
+
+// Load reads the OBJ file at path and any material libraries it uses. If
+// loadTexture is not nil, it is called once for each texture file the
+// materials refer to.
+func Load(path string, loadTexture TextureLoader) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := Decode(f, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if loadTexture != nil {
+		if err := m.LoadTextures(loadTexture); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Decode reads an OBJ model from r. name is used in error messages and to
+// find material libraries, which are resolved relative to its directory.
+func Decode(r io.Reader, name string) (*Model, error) {
+	d := &decoder{
+		name:      name,
+		dir:       filepath.Dir(name),
+		materials: make(map[string]*Material),
+		byKey:     make(map[partKey]*part),
+	}
+	if err := d.decode(r); err != nil {
+		return nil, err
+	}
+
+	return d.model(), nil
+}
+
+// LoadTextures loads every texture referred to by the model's materials,
+// loading each file only once.
+func (m *Model) LoadTextures(loadTexture TextureLoader) error {
+	loaded := make(map[string]uint32)
+
+	for _, mat := range m.Materials {
+		for _, t := range mat.textures() {
+			if t.File == "" {
+				continue
+			}
+			id, ok := loaded[t.File]
+			if !ok {
+				var err error
+				id, err = loadTexture(t.File)
+				if err != nil {
+					return fmt.Errorf("material %q: %v", mat.Name, err)
+				}
+				loaded[t.File] = id
+			}
+			t.Texture = id
+		}
+	}
+
+	return nil
+}
+
+// corner is one vertex of a face, as 0-based indices. t and n are -1 when
+// absent.
+type corner struct {
+	v, t, n int
+}
+
+type face struct {
+	corners []corner
+	smooth  int
+}
+
+// partKey is the object, group and material that faces share to go in
+// the same Mesh.
+type partKey struct {
+	object, group, material string
+}
+
+// part collects the faces of one Mesh while decoding.
+type part struct {
+	partKey
+	faces []face
+}
+
+type decoder struct {
+	name string
+	dir  string
+	line int
+
+	positions []mgl32.Vec3
+	uvs       []mgl32.Vec2
+	normals   []mgl32.Vec3
+
+	materials map[string]*Material
+	// parts are in the order they first have faces, and byKey finds them.
+	parts []*part
+	byKey map[partKey]*part
+
+	object, group, material string
+	smooth                  int
+}
+
+// errorf reports a problem at the current line.
+func (d *decoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", d.name, d.line, fmt.Sprintf(format, args...))
+}
+
+func (d *decoder) decode(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var continued string
+	for s.Scan() {
+		d.line++
+		text := continued + s.Text()
+		continued = ""
+
+		// A trailing backslash joins the next line on.
+		if strings.HasSuffix(text, "\\") {
+			continued = strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := d.statement(fields[0], fields[1:]); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("%s: %v", d.name, err)
+	}
+
+	return nil
+}
+
+func (d *decoder) statement(keyword string, args []string) error {
+	switch keyword {
+	case "v":
+		p, err := d.floats(args, 3, 4)
+		if err != nil {
+			return err
+		}
+		// Only x, y, z are kept; w and per-vertex colours are ignored.
+		d.positions = append(d.positions, mgl32.Vec3{p[0], p[1], p[2]})
+
+	case "vt":
+		t, err := d.floats(args, 1, 3)
+		if err != nil {
+			return err
+		}
+		uv := mgl32.Vec2{t[0], 0}
+		if len(t) > 1 {
+			uv[1] = t[1]
+		}
+		d.uvs = append(d.uvs, uv)
+
+	case "vn":
+		n, err := d.floats(args, 3, 3)
+		if err != nil {
+			return err
+		}
+		// A zero normal, as written for meshes without normals, is kept as
+		// zero rather than normalized to NaN, and corner treats it as absent.
+		normal := mgl32.Vec3{n[0], n[1], n[2]}
+		if normal.Len() > 0 {
+			normal = normal.Normalize()
+		}
+		d.normals = append(d.normals, normal)
+
+	case "f":
+		return d.face(args)
+
+	case "o":
+		d.object = strings.Join(args, " ")
+
+	case "g":
+		d.group = strings.Join(args, " ")
+
+	case "usemtl":
+		if len(args) == 0 {
+			return d.errorf("usemtl needs a material name")
+		}
+		d.material = strings.Join(args, " ")
+
+	case "mtllib":
+		if len(args) == 0 {
+			return d.errorf("mtllib needs a file name")
+		}
+		for _, file := range args {
+			if err := d.loadMaterials(filepath.Join(d.dir, file)); err != nil {
+				return d.errorf("%v", err)
+			}
+		}
+
+	case "s":
+		if len(args) != 1 {
+			return d.errorf("s needs one argument")
+		}
+		if args[0] == "off" {
+			d.smooth = 0
+			break
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return d.errorf("bad smoothing group %q", args[0])
+		}
+		d.smooth = n
+
+	default:
+		// Lines, points, free-form geometry and vendor extensions are
+		// not supported and are skipped rather than rejected.
+	}
+
+	return nil
+}
+
+// floats parses between min and max numbers.
+func (d *decoder) floats(args []string, min, max int) ([]float32, error) {
+	if len(args) < min {
+		return nil, d.errorf("expected at least %d numbers, got %d", min, len(args))
+	}
+	if len(args) > max {
+		args = args[:max]
+	}
+
+	f := make([]float32, len(args))
+	for i, a := range args {
+		v, err := strconv.ParseFloat(a, 32)
+		if err != nil {
+			return nil, d.errorf("bad number %q", a)
+		}
+		f[i] = float32(v)
+	}
+
+	return f, nil
+}
+
+func (d *decoder) face(args []string) error {
+	if len(args) < 3 {
+		return d.errorf("a face needs at least 3 vertices, got %d", len(args))
+	}
+
+	f := face{corners: make([]corner, len(args)), smooth: d.smooth}
+	for i, a := range args {
+		c, err := d.corner(a)
+		if err != nil {
+			return err
+		}
+		f.corners[i] = c
+	}
+
+	p := d.current()
+	p.faces = append(p.faces, f)
+
+	return nil
+}
+
+// corner parses v, v/t, v//n or v/t/n.
+func (d *decoder) corner(s string) (corner, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return corner{}, d.errorf("bad face vertex %q", s)
+	}
+
+	c := corner{t: -1, n: -1}
+	var err error
+
+	if c.v, err = d.index(parts[0], len(d.positions), "position"); err != nil {
+		return c, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if c.t, err = d.index(parts[1], len(d.uvs), "texture coordinate"); err != nil {
+			return c, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if c.n, err = d.index(parts[2], len(d.normals), "normal"); err != nil {
+			return c, err
+		}
+		if d.normals[c.n] == (mgl32.Vec3{}) {
+			c.n = -1
+		}
+	}
+
+	return c, nil
+}
+
+// index converts a 1-based or negative (counting back from the last one
+// read) index to 0-based.
+func (d *decoder) index(s string, count int, what string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, d.errorf("bad %s index %q", what, s)
+	}
+
+	switch {
+	case i > 0 && i <= count:
+		return i - 1, nil
+	case i < 0 && -i <= count:
+		return count + i, nil
+	}
+
+	return 0, d.errorf("%s index %d out of range (have %d)", what, i, count)
+}
+
+// current returns the part for the current object, group and material,
+// which faces join wherever they are in the file.
+func (d *decoder) current() *part {
+	k := partKey{d.object, d.group, d.material}
+	if p, ok := d.byKey[k]; ok {
+		return p
+	}
+
+	p := &part{partKey: k}
+	d.parts = append(d.parts, p)
+	d.byKey[k] = p
+
+	return p
+}
+
+func (d *decoder) model() *Model {
+	m := &Model{Materials: d.materials}
+
+	for _, p := range d.parts {
+		if len(p.faces) == 0 {
+			continue
+		}
+		mesh := &Mesh{
+			Object:   p.object,
+			Group:    p.group,
+			Material: d.materials[p.material],
+		}
+		d.build(p, &mesh.Mesh)
+		m.Meshes = append(m.Meshes, mesh)
+	}
+
+	return m
+}
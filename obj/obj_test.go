@@ -0,0 +1,312 @@
+package obj
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func decode(t *testing.T, src string) *Model {
+	t.Helper()
+	m, err := Decode(strings.NewReader(src), "test.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestZeroNormal(t *testing.T) {
+	m := decode(t, `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+vn 0 0 0
+vn 0 0 2
+f 1//1 2//1 3//1
+f 1//2 3//2 2//2
+`)
+	if len(m.Meshes) != 1 || m.Meshes[0].Triangles() != 2 {
+		t.Fatalf("got %d meshes, want 1 of 2 triangles", len(m.Meshes))
+	}
+	mesh := m.Meshes[0]
+	// The zero normal is replaced by the face's, and the other normalized.
+	for i, want := range []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}} {
+		if n := mesh.Vertices[mesh.Indices[3*i]].Normal; n != want {
+			t.Errorf("triangle %d: normal %v, want %v", i, n, want)
+		}
+	}
+}
+
+func TestPartsMerge(t *testing.T) {
+	// Group a's red faces are split up by group b's, but still make one
+	// mesh.
+	m := decode(t, `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+v 1 1 0
+g a
+usemtl red
+f 1 2 3
+g b
+f 2 4 3
+usemtl blue
+f 1 2 3
+g a
+usemtl red
+f 2 4 3
+`)
+	want := []struct {
+		group     string
+		triangles int
+	}{
+		{"a", 2},
+		{"b", 1}, // red
+		{"b", 1}, // blue
+	}
+	if len(m.Meshes) != len(want) {
+		t.Fatalf("got %d meshes, want %d", len(m.Meshes), len(want))
+	}
+	for i, w := range want {
+		if g := m.Meshes[i]; g.Group != w.group || g.Triangles() != w.triangles {
+			t.Errorf("mesh %d is group %q with %d triangles, want %q with %d", i, g.Group, g.Triangles(), w.group, w.triangles)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"v 0 0 0\nv 1 0\n", "test.obj:2: expected at least 3 numbers, got 2"},
+		{"v 0 0 x\n", `test.obj:1: bad number "x"`},
+		{"v 0 0 0\nv 1 0 0\nf 1 2\n", "test.obj:3: a face needs at least 3 vertices, got 2"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", "test.obj:4: position index 4 out of range (have 3)"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 -4\n", "test.obj:4: position index -4 out of range (have 3)"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 0\n", "test.obj:4: position index 0 out of range (have 3)"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2 3\n", "test.obj:4: texture coordinate index 1 out of range (have 0)"},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1//a 2 3\n", `test.obj:4: bad normal index "a"`},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1/1/1 2 3\n", `test.obj:4: bad face vertex "1/1/1/1"`},
+		{"# comment\n\ns 1 2\n", "test.obj:3: s needs one argument"},
+		{"s smooth\n", `test.obj:1: bad smoothing group "smooth"`},
+		{"usemtl\n", "test.obj:1: usemtl needs a material name"},
+		// A continued line is reported at its last line.
+		{"v 0 \\\n0\n", "test.obj:2: expected at least 3 numbers, got 2"},
+	}
+	for _, tt := range tests {
+		_, err := Decode(strings.NewReader(tt.src), "test.obj")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%q: got error %v, want %q", tt.src, err, tt.err)
+		}
+	}
+}
+
+func TestNegativeIndices(t *testing.T) {
+	// Each face refers to the three vertices above it, counting back.
+	m := decode(t, `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 0 1
+vn 0 0 1
+f -3/-3/-1 -2/-2/-1 -1/-1/-1
+v 5 0 0
+v 6 0 0
+v 5 1 0
+f -3 -2 -1
+`)
+	mesh := m.Meshes[0]
+	want := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {5, 0, 0}, {6, 0, 0}, {5, 1, 0}}
+	if len(mesh.Indices) != len(want) {
+		t.Fatalf("got %d corners, want %d", len(mesh.Indices), len(want))
+	}
+	for i, w := range want {
+		v := mesh.Vertices[mesh.Indices[i]]
+		if v.Position != w {
+			t.Errorf("corner %d at %v, want %v", i, v.Position, w)
+		}
+		if i < 3 && v.UV != (mgl32.Vec2{w[0], w[1]}) {
+			t.Errorf("corner %d has UV %v, want %v", i, v.UV, w.Vec2())
+		}
+	}
+}
+
+func TestConcavePolygons(t *testing.T) {
+	tests := []struct {
+		name   string
+		points [][2]float32
+	}{
+		{"L", [][2]float32{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}},
+		// The first corner is reflex, so it cannot be clipped as an ear,
+		// and a fan from the second would leave the polygon.
+		{"arrow", [][2]float32{{1, 1}, {0, 3}, {0, 0}, {3, 0}}},
+		{"comb", [][2]float32{{0, 0}, {5, 0}, {5, 3}, {4, 3}, {4, 1}, {3, 1}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}}},
+	}
+	// Each polygon is tried facing each way along each axis.
+	type plane struct {
+		name   string
+		normal mgl32.Vec3
+		place  func(p [2]float32) mgl32.Vec3
+	}
+	planes := []plane{
+		{"+z", mgl32.Vec3{0, 0, 1}, func(p [2]float32) mgl32.Vec3 { return mgl32.Vec3{p[0], p[1], 0} }},
+		{"-z", mgl32.Vec3{0, 0, -1}, func(p [2]float32) mgl32.Vec3 { return mgl32.Vec3{p[1], p[0], 0} }},
+		{"+x", mgl32.Vec3{1, 0, 0}, func(p [2]float32) mgl32.Vec3 { return mgl32.Vec3{0, p[0], p[1]} }},
+		{"-y", mgl32.Vec3{0, -1, 0}, func(p [2]float32) mgl32.Vec3 { return mgl32.Vec3{p[0], 0, p[1]} }},
+	}
+	for _, tt := range tests {
+		for _, pl := range planes {
+			var src strings.Builder
+			face := "f"
+			for i, p := range tt.points {
+				v := pl.place(p)
+				fmt.Fprintf(&src, "v %v %v %v\n", v[0], v[1], v[2])
+				face += fmt.Sprintf(" %d", i+1)
+			}
+			src.WriteString(face + "\n")
+			mesh := decode(t, src.String()).Meshes[0]
+
+			if got, want := mesh.Triangles(), len(tt.points)-2; got != want {
+				t.Fatalf("%s %s: %d triangles, want %d", tt.name, pl.name, got, want)
+			}
+			// Every triangle faces the polygon's way, so none overlap or
+			// leave it, and together they cover its area.
+			var area float32
+			for i := 0; i < len(mesh.Indices); i += 3 {
+				a := mesh.Vertices[mesh.Indices[i]].Position
+				b := mesh.Vertices[mesh.Indices[i+1]].Position
+				c := mesh.Vertices[mesh.Indices[i+2]].Position
+				n := b.Sub(a).Cross(c.Sub(a))
+				if n.Dot(pl.normal) <= 0 {
+					t.Errorf("%s %s: triangle %v %v %v faces %v", tt.name, pl.name, a, b, c, n)
+				}
+				area += n.Len() / 2
+			}
+			var want float32
+			for i, p := range tt.points {
+				q := tt.points[(i+1)%len(tt.points)]
+				want += (p[0]*q[1] - q[0]*p[1]) / 2
+			}
+			if want < 0 {
+				want = -want
+			}
+			if area != want {
+				t.Errorf("%s %s: triangles cover %v, want %v", tt.name, pl.name, area, want)
+			}
+		}
+	}
+}
+
+func TestMaterials(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("scene.mtl", `# Two materials.
+NEWMTL shiny red
+Ka 0.1
+Kd 1 0 0
+KS 0.5 0.5 0.5
+Ke 0 0 0.25
+Ns 250
+Ni 1.5
+Tr 0.25
+illum 2
+map_Kd -s 1 1 1 textures/red.png
+Bump bump.png
+
+newmtl glass
+d -halo 0.5
+norm normal.png
+map_d textures/red.png
+`)
+	write("scene.obj", `mtllib scene.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+usemtl shiny red
+f 1 2 3
+usemtl missing
+f 1 3 2
+`)
+
+	var loaded []string
+	m, err := Load(filepath.Join(dir, "scene.obj"), func(file string) (uint32, error) {
+		loaded = append(loaded, file)
+		return uint32(len(loaded)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	red, glass := m.Materials["shiny red"], m.Materials["glass"]
+	if len(m.Materials) != 2 || red == nil || glass == nil {
+		t.Fatalf("got materials %v", m.Materials)
+	}
+	wantRed := Material{
+		Name:       "shiny red",
+		Ambient:    mgl32.Vec3{0.1, 0.1, 0.1},
+		Diffuse:    mgl32.Vec3{1, 0, 0},
+		Specular:   mgl32.Vec3{0.5, 0.5, 0.5},
+		Emissive:   mgl32.Vec3{0, 0, 0.25},
+		Shininess:  250,
+		Opacity:    0.75,
+		IOR:        1.5,
+		Illum:      2,
+		DiffuseMap: TextureMap{File: filepath.Join(dir, "textures", "red.png"), Texture: red.DiffuseMap.Texture},
+		BumpMap:    TextureMap{File: filepath.Join(dir, "bump.png"), Texture: red.BumpMap.Texture},
+	}
+	if *red != wantRed {
+		t.Errorf("got %+v, want %+v", *red, wantRed)
+	}
+	if glass.Opacity != 0.5 || glass.IOR != 1 || glass.NormalMap.File != filepath.Join(dir, "normal.png") {
+		t.Errorf("got %+v", *glass)
+	}
+
+	// Each file is loaded once, however many maps use it.
+	if len(loaded) != 3 {
+		t.Errorf("loaded %v, want 3 files", loaded)
+	}
+	if red.DiffuseMap.Texture == 0 || glass.AlphaMap.Texture != red.DiffuseMap.Texture {
+		t.Errorf("red.png is textures %d and %d", red.DiffuseMap.Texture, glass.AlphaMap.Texture)
+	}
+
+	if len(m.Meshes) != 2 || m.Meshes[0].Material != red || m.Meshes[1].Material != nil {
+		t.Errorf("meshes do not have the materials they use")
+	}
+}
+
+func TestMaterialErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"Kd 1 0 0\n", `:1: "Kd" before any newmtl`},
+		{"newmtl\n", ":1: newmtl needs a name"},
+		{"newmtl a\nKd 1 0\n", ":2: Kd needs 1 or 3 numbers"},
+		{"newmtl a\nKd spectral file.rfl\n", ":2: Kd spectral colours are not supported"},
+		{"newmtl a\n\nNs shiny\n", `:3: bad number "shiny"`},
+		{"newmtl a\nillum\n", ":2: illum needs one number"},
+		{"newmtl a\nmap_Kd\n", ":2: map_Kd needs a file name"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		mtl := filepath.Join(dir, "bad.mtl")
+		if err := os.WriteFile(mtl, []byte(tt.src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Decode(strings.NewReader("# materials\nmtllib bad.mtl\n"), filepath.Join(dir, "test.obj"))
+		want := filepath.Join(dir, "test.obj") + ":2: " + mtl + tt.err
+		if err == nil || err.Error() != want {
+			t.Errorf("%q: got error %v, want %q", tt.src, err, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Encode writes meshes as OBJ. Every vertex is written with its position,
+// texture coordinate and normal, so Decode reads the same vertices back.
+// mtllib, if not empty, names the material library for usemtl to refer to.
+func Encode(w io.Writer, meshes []*Mesh, mtllib string) error {
+	bw := bufio.NewWriter(w)
+
+	floats := func(keyword string, f ...float32) {
+		bw.WriteString(keyword)
+		for _, x := range f {
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+		}
+		bw.WriteByte('\n')
+	}
+
+	fmt.Fprintf(bw, "# written by GopenGL\n")
+	if mtllib != "" {
+		fmt.Fprintf(bw, "mtllib %s\n", mtllib)
+	}
+
+	base := 1
+	object, group := "", ""
+	var material *Material
+	for _, m := range meshes {
+		if m.Object != object {
+			object = m.Object
+			fmt.Fprintf(bw, "o %s\n", object)
+		}
+		if m.Group != group {
+			group = m.Group
+			fmt.Fprintf(bw, "g %s\n", group)
+		}
+		if m.Material != material {
+			material = m.Material
+			// OBJ cannot go back to no material, so refer to one that
+			// does not exist instead, which Decode reads as nil.
+			name := "none"
+			if material != nil {
+				name = material.Name
+			}
+			fmt.Fprintf(bw, "usemtl %s\n", name)
+		}
+
+		for _, v := range m.Vertices {
+			floats("v", v.Position[0], v.Position[1], v.Position[2])
+		}
+		for _, v := range m.Vertices {
+			floats("vt", v.UV[0], v.UV[1])
+		}
+		for _, v := range m.Vertices {
+			floats("vn", v.Normal[0], v.Normal[1], v.Normal[2])
+		}
+
+		for t := 0; t+2 < len(m.Indices); t += 3 {
+			bw.WriteString("f")
+			for _, i := range m.Indices[t : t+3] {
+				fmt.Fprintf(bw, " %[1]d/%[1]d/%[1]d", base+int(i))
+			}
+			bw.WriteByte('\n')
+		}
+		base += len(m.Vertices)
+	}
+
+	return bw.Flush()
+}
+
+// EncodeMaterials writes materials as an MTL library. Texture paths are
+// written relative to dir, the directory the library is saved in.
+func EncodeMaterials(w io.Writer, materials []*Material, dir string) error {
+	bw := bufio.NewWriter(w)
+
+	colour := func(keyword string, c mgl32.Vec3) {
+		fmt.Fprintf(bw, "%s %g %g %g\n", keyword, c[0], c[1], c[2])
+	}
+	texture := func(keyword string, t TextureMap) {
+		if t.File == "" {
+			return
+		}
+		file := t.File
+		if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		fmt.Fprintf(bw, "%s %s\n", keyword, filepath.ToSlash(file))
+	}
+
+	for _, m := range materials {
+		fmt.Fprintf(bw, "newmtl %s\n", m.Name)
+		colour("Ka", m.Ambient)
+		colour("Kd", m.Diffuse)
+		colour("Ks", m.Specular)
+		colour("Ke", m.Emissive)
+		fmt.Fprintf(bw, "Ns %g\nNi %g\nd %g\nillum %d\n", m.Shininess, m.IOR, m.Opacity, m.Illum)
+		texture("map_Ka", m.AmbientMap)
+		texture("map_Kd", m.DiffuseMap)
+		texture("map_Ks", m.SpecularMap)
+		texture("map_Ke", m.EmissiveMap)
+		texture("map_d", m.AlphaMap)
+		texture("map_Bump", m.BumpMap)
+		texture("norm", m.NormalMap)
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// Save writes meshes to the OBJ file at path. If any of them has a
+// material, the materials are saved next to it in a library with the same
+// name and a .mtl extension.
+func Save(path string, meshes ...*Mesh) error {
+	var materials []*Material
+	seen := make(map[*Material]bool)
+	for _, m := range meshes {
+		if m.Material != nil && !seen[m.Material] {
+			seen[m.Material] = true
+			materials = append(materials, m.Material)
+		}
+	}
+
+	mtllib := ""
+	if len(materials) > 0 {
+		mtlPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mtl"
+		mtllib = filepath.Base(mtlPath)
+		if err := writeFile(mtlPath, func(w io.Writer) error {
+			return EncodeMaterials(w, materials, filepath.Dir(mtlPath))
+		}); err != nil {
+			return err
+		}
+	}
+
+	return writeFile(path, func(w io.Writer) error {
+		return Encode(w, meshes, mtllib)
+	})
+}
+
+func writeFile(path string, encode func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = encode(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
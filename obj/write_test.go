@@ -0,0 +1,45 @@
+package obj
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/purelazy/GopenGL/geometry"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	meshes := []*Mesh{
+		{Object: "shapes", Group: "ball", Mesh: *geometry.UVSphere(1, 12, 6)},
+		{Object: "shapes", Group: "box", Mesh: *geometry.Cube(2, 2)},
+		{Object: "ring", Mesh: *geometry.Torus(1, 0.3, 10, 6)},
+	}
+	var b bytes.Buffer
+	if err := Encode(&b, meshes, ""); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Decode(&b, "round-trip.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Meshes) != len(meshes) {
+		t.Fatalf("got %d meshes, want %d", len(m.Meshes), len(meshes))
+	}
+	for i, want := range meshes {
+		got := m.Meshes[i]
+		if got.Object != want.Object || got.Group != want.Group || got.Material != nil {
+			t.Errorf("mesh %d is %q/%q with material %v, want %q/%q with none",
+				i, got.Object, got.Group, got.Material, want.Object, want.Group)
+		}
+		// Vertices no triangle uses are not read back, so compare corners.
+		if len(got.Indices) != len(want.Indices) {
+			t.Fatalf("mesh %d has %d indices, want %d", i, len(got.Indices), len(want.Indices))
+		}
+		for j, wi := range want.Indices {
+			w, g := want.Vertices[wi], got.Vertices[got.Indices[j]]
+			if g.Position != w.Position || g.UV != w.UV || !g.Normal.ApproxEqualThreshold(w.Normal, 1e-6) {
+				t.Fatalf("mesh %d corner %d is %+v, want %+v", i, j, g, w)
+			}
+		}
+	}
+}
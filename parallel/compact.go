@@ -0,0 +1,92 @@
+package parallel
+
+import (
+	"fmt"
+
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// keepShader turns Flags into ones and zeros, for scanning.
+var keepShader = `
+layout(std430) readonly buffer Flags { uint flags[]; };
+layout(std430) writeonly buffer Keep { uint keep[]; };
+uniform uint n;
+
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i < n) {
+        keep[i] = flags[i] != 0u ? 1u : 0u;
+    }
+}
+`
+
+// compactShader copies the kept elements of Input to their places in
+// Output.
+var compactShader = `
+layout(std430) readonly buffer Input { T data[]; };
+layout(std430) readonly buffer Keep { uint keep[]; };
+layout(std430) readonly buffer Positions { uint positions[]; };
+layout(std430) writeonly buffer Output { T compacted[]; };
+uniform uint n;
+
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i < n && keep[i] != 0u) {
+        compacted[positions[i]] = data[i];
+    }
+}
+`
+
+// Compact copies the elements of in whose flags are not 0 to the start of
+// out, in order, and returns how many there are. flags must be as long as
+// in, and out long enough for the elements kept.
+func Compact[T Number](k *Kernels, in *compute.Buffer[T], flags *compute.Buffer[uint32], out *compute.Buffer[T]) (int, error) {
+	n := in.Len
+	if flags.Len < n {
+		return 0, fmt.Errorf("parallel: %d flags for %d elements", flags.Len, n)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	keepProgram, err := k.program(keepShader)
+	if err != nil {
+		return 0, err
+	}
+	compact, err := k.program(compactShader, "T "+glslType[T]())
+	if err != nil {
+		return 0, err
+	}
+
+	keep := compute.NewBufferLen[uint32](n)
+	defer keep.Delete()
+	if err := bind(keepProgram, map[string]uint32{"Flags": flags.ID, "Keep": keep.ID}); err != nil {
+		return 0, err
+	}
+	keepProgram.SetUint("n", uint32(n))
+	keepProgram.Dispatch(n, 1, 1)
+
+	positions := compute.NewBufferLen[uint32](n)
+	defer positions.Delete()
+	if err := Scan(k, keep, positions, false); err != nil {
+		return 0, err
+	}
+
+	// The number kept is where the last element went, plus one if it is
+	// kept itself.
+	var last [1]uint32
+	var lastKept [1]uint32
+	positions.ReadInto(n-1, last[:])
+	keep.ReadInto(n-1, lastKept[:])
+	kept := int(last[0] + lastKept[0])
+	if out.Len < kept {
+		return 0, fmt.Errorf("parallel: %d elements kept, but room for %d", kept, out.Len)
+	}
+
+	buffers := map[string]uint32{"Input": in.ID, "Keep": keep.ID, "Positions": positions.ID, "Output": out.ID}
+	if err := bind(compact, buffers); err != nil {
+		return 0, err
+	}
+	compact.SetUint("n", uint32(n))
+	compact.Dispatch(n, 1, 1)
+	return kept, nil
+}
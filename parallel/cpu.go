@@ -0,0 +1,90 @@
+package parallel
+
+import "sort"
+
+// The CPU reference implementations. They define what the kernels compute,
+// one element at a time.
+
+// ReduceCPU combines the elements of data with op, as Reduce does.
+func ReduceCPU[T Number](op Op, data []T) T {
+	r := identity[T](op)
+	for _, v := range data {
+		switch op {
+		case Min:
+			if v < r {
+				r = v
+			}
+		case Max:
+			if v > r {
+				r = v
+			}
+		default:
+			r += v
+		}
+	}
+	return r
+}
+
+// ScanCPU returns the inclusive or exclusive prefix sums of data, as Scan
+// does.
+func ScanCPU[T Number](data []T, inclusive bool) []T {
+	out := make([]T, len(data))
+	var sum T
+	for i, v := range data {
+		if inclusive {
+			sum += v
+			out[i] = sum
+		} else {
+			out[i] = sum
+			sum += v
+		}
+	}
+	return out
+}
+
+// SortCPU sorts keys in place, as Sort does.
+func SortCPU(keys []uint32) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+}
+
+// SortPairsCPU sorts keys in place and moves values along with them,
+// keeping pairs with equal keys in order, as SortPairs does.
+func SortPairsCPU(keys, values []uint32) {
+	sort.Stable(pairs{keys, values})
+}
+
+type pairs struct {
+	keys, values []uint32
+}
+
+func (p pairs) Len() int           { return len(p.keys) }
+func (p pairs) Less(i, j int) bool { return p.keys[i] < p.keys[j] }
+func (p pairs) Swap(i, j int) {
+	p.keys[i], p.keys[j] = p.keys[j], p.keys[i]
+	p.values[i], p.values[j] = p.values[j], p.values[i]
+}
+
+// CompactCPU returns the elements of data whose flags are not 0, as
+// Compact does.
+func CompactCPU[T Number](data []T, flags []uint32) []T {
+	var out []T
+	for i, v := range data {
+		if flags[i] != 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// HistogramCPU counts the elements of data in each of bins equal bins
+// between min and max, as Histogram does.
+func HistogramCPU[T Number](data []T, min, max float32, bins int) []uint32 {
+	counts := make([]uint32, bins)
+	scale := binScale(min, max, bins)
+	for _, v := range data {
+		if b := binOf(float32(v), min, scale, bins); b >= 0 {
+			counts[b]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,142 @@
+package parallel
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestReduceCPU(t *testing.T) {
+	if got := ReduceCPU(Sum, []uint32(nil)); got != 0 {
+		t.Errorf("empty sum is %v, want 0", got)
+	}
+	if got := ReduceCPU(Min, []int32{}); got != math.MaxInt32 {
+		t.Errorf("empty int32 min is %v, want the largest int32", got)
+	}
+	if got := ReduceCPU(Max, []int32{}); got != math.MinInt32 {
+		t.Errorf("empty int32 max is %v, want the smallest int32", got)
+	}
+	if got := ReduceCPU(Min, []float32{}); !math.IsInf(float64(got), 1) {
+		t.Errorf("empty float32 min is %v, want +Inf", got)
+	}
+	if got := ReduceCPU(Max, []uint32{}); got != 0 {
+		t.Errorf("empty uint32 max is %v, want 0", got)
+	}
+
+	for _, op := range []Op{Sum, Min, Max} {
+		if got := ReduceCPU(op, []int32{-7}); got != -7 {
+			t.Errorf("op %d of a single -7 is %v", op, got)
+		}
+	}
+
+	data := []int32{3, -2, 9, 0, -5, 4}
+	for op, want := range map[Op]int32{Sum: 9, Min: -5, Max: 9} {
+		if got := ReduceCPU(op, data); got != want {
+			t.Errorf("op %d is %v, want %v", op, got, want)
+		}
+	}
+}
+
+func TestScanCPU(t *testing.T) {
+	tests := []struct {
+		in                   []uint32
+		inclusive, exclusive []uint32
+	}{
+		{nil, []uint32{}, []uint32{}},
+		{[]uint32{5}, []uint32{5}, []uint32{0}},
+		{[]uint32{1, 2, 3, 4}, []uint32{1, 3, 6, 10}, []uint32{0, 1, 3, 6}},
+		{[]uint32{0, 7, 0, 0, 1}, []uint32{0, 7, 7, 7, 8}, []uint32{0, 0, 7, 7, 7}},
+	}
+	for _, tt := range tests {
+		if got := ScanCPU(tt.in, true); !slices.Equal(got, tt.inclusive) {
+			t.Errorf("inclusive scan of %v is %v, want %v", tt.in, got, tt.inclusive)
+		}
+		if got := ScanCPU(tt.in, false); !slices.Equal(got, tt.exclusive) {
+			t.Errorf("exclusive scan of %v is %v, want %v", tt.in, got, tt.exclusive)
+		}
+	}
+
+	if got := ScanCPU([]float32{0.5, -1, 2}, true); !slices.Equal(got, []float32{0.5, -0.5, 1.5}) {
+		t.Errorf("float32 inclusive scan is %v", got)
+	}
+}
+
+func TestSortCPU(t *testing.T) {
+	for _, keys := range [][]uint32{nil, {42}, {3, 1, 2}, {math.MaxUint32, 0, 7, 7, 1}} {
+		got := append([]uint32(nil), keys...)
+		SortCPU(got)
+		for i := 1; i < len(got); i++ {
+			if got[i-1] > got[i] {
+				t.Errorf("sorting %v gave %v", keys, got)
+				break
+			}
+		}
+		if len(got) != len(keys) {
+			t.Errorf("sorting %v gave %d keys", keys, len(got))
+		}
+	}
+}
+
+func TestSortPairsCPU(t *testing.T) {
+	var keys, values []uint32
+	SortPairsCPU(keys, values)
+
+	keys, values = []uint32{9}, []uint32{1}
+	SortPairsCPU(keys, values)
+	if keys[0] != 9 || values[0] != 1 {
+		t.Errorf("single pair became %v, %v", keys, values)
+	}
+
+	// Values record the original order, which equal keys keep.
+	keys = []uint32{3, 1, 3, 0, 1, 3, 0}
+	values = []uint32{0, 1, 2, 3, 4, 5, 6}
+	SortPairsCPU(keys, values)
+	wantKeys := []uint32{0, 0, 1, 1, 3, 3, 3}
+	wantValues := []uint32{3, 6, 1, 4, 0, 2, 5}
+	if !slices.Equal(keys, wantKeys) || !slices.Equal(values, wantValues) {
+		t.Errorf("got keys %v and values %v, want %v and %v", keys, values, wantKeys, wantValues)
+	}
+}
+
+func TestCompactCPU(t *testing.T) {
+	tests := []struct {
+		data  []float32
+		flags []uint32
+		want  []float32
+	}{
+		{nil, nil, nil},
+		{[]float32{1.5}, []uint32{1}, []float32{1.5}},
+		{[]float32{1.5}, []uint32{0}, nil},
+		// Any flag but 0 keeps an element, in order.
+		{[]float32{1, 2, 3, 4, 5}, []uint32{1, 0, 7, 0, math.MaxUint32}, []float32{1, 3, 5}},
+	}
+	for _, tt := range tests {
+		if got := CompactCPU(tt.data, tt.flags); !slices.Equal(got, tt.want) {
+			t.Errorf("compacting %v by %v gave %v, want %v", tt.data, tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestHistogramCPU(t *testing.T) {
+	if got := HistogramCPU([]float32{}, 0, 1, 3); !slices.Equal(got, []uint32{0, 0, 0}) {
+		t.Errorf("empty histogram is %v", got)
+	}
+	if got := HistogramCPU([]int32{-3}, -4, 4, 2); !slices.Equal(got, []uint32{1, 0}) {
+		t.Errorf("single element histogram is %v", got)
+	}
+
+	// The range includes min but not max.
+	data := []float32{
+		-0.001, -10, // below
+		0, 0.999, // bin 0, from min up
+		1, 2.5, // bins 1 and 2
+		3, 3.999, // bin 3, up to max
+		4, 100, // at max and above
+	}
+	if got, want := HistogramCPU(data, 0, 4, 4), []uint32{2, 1, 1, 2}; !slices.Equal(got, want) {
+		t.Errorf("histogram is %v, want %v", got, want)
+	}
+	if got, want := HistogramCPU([]uint32{0, 1, 2, 3, 4, 5}, 1, 5, 2), []uint32{2, 2}; !slices.Equal(got, want) {
+		t.Errorf("uint32 histogram is %v, want %v", got, want)
+	}
+}
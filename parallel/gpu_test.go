@@ -0,0 +1,212 @@
+package parallel
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/purelazy/GopenGL/compute"
+	"github.com/purelazy/GopenGL/internal/gltest"
+)
+
+// kernels is set if TestMain opened a GL context with compute shaders.
+var kernels *Kernels
+
+func TestMain(m *testing.M) {
+	gltest.Main(m, "parallel", func() func() {
+		kernels = NewKernels()
+		return kernels.Delete
+	})
+}
+
+// onGPU runs f with the GL context current, or skips the test if there is
+// none. f reports failures by returning them, as t.Fatal cannot be called
+// from the main thread.
+func onGPU(t *testing.T, f func() error) {
+	t.Helper()
+	if kernels == nil {
+		t.Skip("no OpenGL 4.3 context")
+	}
+	if err := gltest.Run(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sizes cover no elements, one, part of a block and several levels of
+// blocks.
+var sizes = []int{0, 1, 100, blockSize*2 + 3, 300000}
+
+// floatTolerance is how far, relative to the larger of 1 and the CPU's
+// result, float32 sums may differ for being added in another order.
+const floatTolerance = 1e-4
+
+// closeEnough reports whether a float32 result from the GPU is within
+// floatTolerance of the CPU's.
+func closeEnough(gpu, cpu float32) bool {
+	return math.Abs(float64(gpu-cpu)) <= floatTolerance*max(math.Abs(float64(cpu)), 1)
+}
+
+func randomData(n int) (u []uint32, i []int32, f []float32) {
+	r := rand.New(rand.NewSource(int64(n)))
+	u, i, f = make([]uint32, n), make([]int32, n), make([]float32, n)
+	for k := 0; k < n; k++ {
+		u[k] = r.Uint32() >> 12 // small enough not to overflow a sum
+		i[k] = int32(r.Intn(2001) - 1000)
+		f[k] = r.Float32()*2 - 1
+	}
+	return u, i, f
+}
+
+func reduceGPU[T Number](op Op, data []T) (T, error) {
+	in := compute.NewBuffer(data)
+	defer in.Delete()
+	return Reduce(kernels, op, in)
+}
+
+func TestReduceGPU(t *testing.T) {
+	for _, n := range sizes {
+		u, i, f := randomData(n)
+		for _, op := range []Op{Sum, Min, Max} {
+			onGPU(t, func() error {
+				if got, err := reduceGPU(op, u); err != nil || got != ReduceCPU(op, u) {
+					return fmt.Errorf("n %d op %d: uint32 %v, %v, want %v", n, op, got, err, ReduceCPU(op, u))
+				}
+				if got, err := reduceGPU(op, i); err != nil || got != ReduceCPU(op, i) {
+					return fmt.Errorf("n %d op %d: int32 %v, %v, want %v", n, op, got, err, ReduceCPU(op, i))
+				}
+				got, err := reduceGPU(op, f)
+				if want := ReduceCPU(op, f); err != nil || !closeEnough(got, want) {
+					return fmt.Errorf("n %d op %d: float32 %v, %v, want %v", n, op, got, err, want)
+				}
+				return nil
+			})
+		}
+	}
+}
+
+func TestScanGPU(t *testing.T) {
+	for _, n := range sizes {
+		u, _, f := randomData(n)
+		for _, inclusive := range []bool{true, false} {
+			onGPU(t, func() error {
+				in, out := compute.NewBuffer(u), compute.NewBufferLen[uint32](n)
+				defer in.Delete()
+				defer out.Delete()
+				if err := Scan(kernels, in, out, inclusive); err != nil {
+					return err
+				}
+				if got, want := out.Read(), ScanCPU(u, inclusive); !slices.Equal(got, want) {
+					return fmt.Errorf("n %d inclusive %v: uint32 scans differ", n, inclusive)
+				}
+
+				fin, fout := compute.NewBuffer(f), compute.NewBufferLen[float32](n)
+				defer fin.Delete()
+				defer fout.Delete()
+				if err := Scan(kernels, fin, fout, inclusive); err != nil {
+					return err
+				}
+				got, want := fout.Read(), ScanCPU(f, inclusive)
+				for k := range want {
+					if !closeEnough(got[k], want[k]) {
+						return fmt.Errorf("n %d inclusive %v: float32 element %d is %v, want %v", n, inclusive, k, got[k], want[k])
+					}
+				}
+				return nil
+			})
+		}
+	}
+}
+
+func TestSortGPU(t *testing.T) {
+	for _, n := range sizes {
+		u, _, _ := randomData(n)
+		onGPU(t, func() error {
+			keys := compute.NewBuffer(u)
+			defer keys.Delete()
+			if err := Sort(kernels, keys); err != nil {
+				return err
+			}
+			want := append([]uint32(nil), u...)
+			SortCPU(want)
+			if !slices.Equal(keys.Read(), want) {
+				return fmt.Errorf("n %d: sorts differ", n)
+			}
+
+			// Few distinct keys, so that stability shows.
+			k, v := make([]uint32, n), make([]uint32, n)
+			for i := range k {
+				k[i], v[i] = u[i]%16, uint32(i)
+			}
+			keys.Write(0, k)
+			values := compute.NewBuffer(v)
+			defer values.Delete()
+			if err := SortPairs(kernels, keys, values); err != nil {
+				return err
+			}
+			SortPairsCPU(k, v)
+			if !slices.Equal(keys.Read(), k) || !slices.Equal(values.Read(), v) {
+				return fmt.Errorf("n %d: pair sorts differ", n)
+			}
+			return nil
+		})
+	}
+}
+
+func TestCompactGPU(t *testing.T) {
+	for _, n := range sizes {
+		_, _, f := randomData(n)
+		flags := make([]uint32, n)
+		for i := range flags {
+			if f[i] > 0.2 {
+				flags[i] = uint32(i + 1)
+			}
+		}
+		onGPU(t, func() error {
+			in, fl, out := compute.NewBuffer(f), compute.NewBuffer(flags), compute.NewBufferLen[float32](n)
+			defer in.Delete()
+			defer fl.Delete()
+			defer out.Delete()
+			count, err := Compact(kernels, in, fl, out)
+			if err != nil {
+				return err
+			}
+			want := CompactCPU(f, flags)
+			if count != len(want) || !slices.Equal(out.Read()[:count], want) {
+				return fmt.Errorf("n %d: kept %d, want %d", n, count, len(want))
+			}
+			return nil
+		})
+	}
+}
+
+func TestHistogramGPU(t *testing.T) {
+	for _, n := range sizes {
+		_, i, f := randomData(n)
+		onGPU(t, func() error {
+			bins := compute.NewBufferLen[uint32](10)
+			defer bins.Delete()
+
+			// Part of the range, so that some elements are outside it.
+			in := compute.NewBuffer(f)
+			defer in.Delete()
+			if err := Histogram(kernels, in, -0.5, 0.75, bins); err != nil {
+				return err
+			}
+			if got, want := bins.Read(), HistogramCPU(f, -0.5, 0.75, 10); !slices.Equal(got, want) {
+				return fmt.Errorf("n %d: float32 histogram %v, want %v", n, got, want)
+			}
+
+			ints := compute.NewBuffer(i)
+			defer ints.Delete()
+			if err := Histogram(kernels, ints, -500, 500, bins); err != nil {
+				return err
+			}
+			if got, want := bins.Read(), HistogramCPU(i, -500, 500, 10); !slices.Equal(got, want) {
+				return fmt.Errorf("n %d: int32 histogram %v, want %v", n, got, want)
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package parallel
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// MaxBins is the most bins a Histogram can have, as each work group counts
+// into shared memory first.
+const MaxBins = 4096
+
+// histogramShader counts the elements of Input in each bin, first per work
+// group and then into Bins. precise keeps the bin arithmetic the same as
+// binOf's.
+var histogramShader = `
+layout(std430) readonly buffer Input { T data[]; };
+layout(std430) buffer Bins { uint bins[]; };
+uniform uint n;
+uniform float lo;
+uniform float scale;
+
+shared uint local[BINS];
+
+void main() {
+    uint l = gl_LocalInvocationID.x;
+    for (uint b = l; b < BINS; b += gl_WorkGroupSize.x) {
+        local[b] = 0u;
+    }
+    barrier();
+    uint i = gl_GlobalInvocationID.x;
+    if (i < n) {
+        precise float x = (float(data[i]) - lo) * scale;
+        if (x >= 0.0 && x < float(BINS)) {
+            atomicAdd(local[uint(x)], 1u);
+        }
+    }
+    barrier();
+    for (uint b = l; b < BINS; b += gl_WorkGroupSize.x) {
+        if (local[b] != 0u) {
+            atomicAdd(bins[b], local[b]);
+        }
+    }
+}
+`
+
+// Histogram counts the elements of in in each of bins.Len equal bins
+// between min and max, overwriting bins. Elements outside [min, max) are
+// not counted.
+func Histogram[T Number](k *Kernels, in *compute.Buffer[T], min, max float32, bins *compute.Buffer[uint32]) error {
+	if bins.Len < 1 || bins.Len > MaxBins {
+		return fmt.Errorf("parallel: %d bins, want 1 to %d", bins.Len, MaxBins)
+	}
+	if !(max > min) {
+		return fmt.Errorf("parallel: histogram range [%v, %v) is empty", min, max)
+	}
+	bins.Write(0, make([]uint32, bins.Len))
+	if in.Len == 0 {
+		return nil
+	}
+
+	p, err := k.program(histogramShader, "T "+glslType[T](), "BINS "+strconv.Itoa(bins.Len)+"u")
+	if err != nil {
+		return err
+	}
+	if err := bind(p, map[string]uint32{"Input": in.ID, "Bins": bins.ID}); err != nil {
+		return err
+	}
+	p.SetUint("n", uint32(in.Len))
+	p.Set("lo", min)
+	p.Set("scale", binScale(min, max, bins.Len))
+	p.Dispatch(in.Len, 1, 1)
+	return nil
+}
+
+// binScale returns the factor from distance above min to bin.
+func binScale(min, max float32, bins int) float32 {
+	return float32(bins) / (max - min)
+}
+
+// binOf returns the bin of v, or -1 if it is outside them.
+func binOf(v, min, scale float32, bins int) int {
+	// The conversion stops the subtraction being fused with the multiply,
+	// which the shader does not do either.
+	x := float32(v-min) * scale
+	if x >= 0 && x < float32(bins) {
+		return int(x)
+	}
+	return -1
+}
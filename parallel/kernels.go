@@ -0,0 +1,116 @@
+// Package parallel provides data parallel building blocks that run as
+// compute shaders over compute.Buffers: reduction, prefix scan, radix sort,
+// stream compaction and histograms.
+//
+// Each has a CPU reference implementation, ReduceCPU, ScanCPU and so on,
+// that gives the same results in plain Go, for checking the GPU versions
+// and as a fallback.
+//
+// The shaders are compiled the first time they are needed, and kept in a
+// Kernels until it is deleted:
+//
+//	k := parallel.NewKernels()
+//	defer k.Delete()
+//	sum, err := parallel.Reduce(k, parallel.Sum, values)
+package parallel
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// Number is an element type the kernels can work on, as a uint, int or
+// float in GLSL.
+type Number interface {
+	~uint32 | ~int32 | ~float32
+}
+
+// blockSize is the work group size of every kernel.
+const blockSize = 256
+
+// Kernels holds the compiled compute shaders. It needs a current GL
+// context, with compute shaders.
+type Kernels struct {
+	programs map[string]*compute.Program
+}
+
+// NewKernels returns an empty set of kernels.
+func NewKernels() *Kernels {
+	return &Kernels{programs: make(map[string]*compute.Program)}
+}
+
+// program returns the kernel compiled from source with the given #defines,
+// compiling it the first time.
+func (k *Kernels) program(source string, defines ...string) (*compute.Program, error) {
+	var header strings.Builder
+	header.WriteString("#version 430\n")
+	fmt.Fprintf(&header, "layout(local_size_x = %d) in;\n", blockSize)
+	for _, d := range defines {
+		header.WriteString("#define " + d + "\n")
+	}
+	source = header.String() + source
+
+	if p, ok := k.programs[source]; ok {
+		return p, nil
+	}
+	p, err := compute.NewProgram(source)
+	if err != nil {
+		return nil, fmt.Errorf("parallel: %v", err)
+	}
+	k.programs[source] = p
+	return p, nil
+}
+
+// Delete frees the kernels.
+func (k *Kernels) Delete() {
+	for source, p := range k.programs {
+		p.Delete()
+		delete(k.programs, source)
+	}
+}
+
+// glslType returns the GLSL type of T.
+func glslType[T Number]() string {
+	var zero T
+	switch reflect.TypeOf(zero).Kind() {
+	case reflect.Uint32:
+		return "uint"
+	case reflect.Int32:
+		return "int"
+	}
+	return "float"
+}
+
+// bind binds buffers, by ID, to the named storage blocks of p.
+func bind(p *compute.Program, buffers map[string]uint32) error {
+	for block, id := range buffers {
+		if err := p.BindBuffer(block, id); err != nil {
+			return fmt.Errorf("parallel: %v", err)
+		}
+	}
+	return nil
+}
+
+// groups returns the number of blocks of size needed to cover n elements.
+func groups(n, size int) int {
+	return (n + size - 1) / size
+}
+
+// limits returns the largest and smallest values of T.
+func limits[T Number]() (max, min T) {
+	var zero T
+	switch reflect.TypeOf(zero).Kind() {
+	case reflect.Uint32:
+		u32max := uint32(math.MaxUint32)
+		return T(u32max), 0
+	case reflect.Int32:
+		i32max, i32min := int32(math.MaxInt32), int32(math.MinInt32)
+		return T(i32max), T(i32min)
+	}
+	inf, ninf := float32(math.Inf(1)), float32(math.Inf(-1))
+	return T(inf), T(ninf)
+}
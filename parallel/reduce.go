@@ -0,0 +1,128 @@
+package parallel
+
+import (
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// Op is a reduction.
+type Op int
+
+// Reductions.
+const (
+	Sum Op = iota
+	Min
+	Max
+)
+
+// reduceShader reduces each block of 2 * blockSize elements of Input to
+// one element of Output.
+var reduceShader = `
+layout(std430) readonly buffer Input { T data[]; };
+layout(std430) writeonly buffer Output { T partial[]; };
+uniform uint n;
+
+shared T s[gl_WorkGroupSize.x];
+
+void main() {
+    uint l = gl_LocalInvocationID.x;
+    uint i = gl_WorkGroupID.x * gl_WorkGroupSize.x * 2u + l;
+    T a = i < n ? data[i] : IDENTITY;
+    T b = i + gl_WorkGroupSize.x < n ? data[i + gl_WorkGroupSize.x] : IDENTITY;
+    s[l] = OP(a, b);
+    barrier();
+    for (uint stride = gl_WorkGroupSize.x / 2u; stride > 0u; stride >>= 1) {
+        if (l < stride) {
+            s[l] = OP(s[l], s[l + stride]);
+        }
+        barrier();
+    }
+    if (l == 0u) {
+        partial[gl_WorkGroupID.x] = s[0];
+    }
+}
+`
+
+// reduceDefines returns the #defines of reduceShader for op over T.
+func reduceDefines[T Number](op Op) []string {
+	t := glslType[T]()
+	defines := []string{"T " + t}
+	switch op {
+	case Min:
+		defines = append(defines, "OP(a, b) min(a, b)", "IDENTITY "+largest(t))
+	case Max:
+		defines = append(defines, "OP(a, b) max(a, b)", "IDENTITY "+smallest(t))
+	default:
+		defines = append(defines, "OP(a, b) ((a) + (b))", "IDENTITY "+t+"(0)")
+	}
+	return defines
+}
+
+// largest and smallest return GLSL expressions for the limits of type t.
+func largest(t string) string {
+	switch t {
+	case "uint":
+		return "0xFFFFFFFFu"
+	case "int":
+		return "0x7FFFFFFF"
+	}
+	return "uintBitsToFloat(0x7F800000u)"
+}
+
+func smallest(t string) string {
+	switch t {
+	case "uint":
+		return "0u"
+	case "int":
+		return "int(0x80000000u)"
+	}
+	return "uintBitsToFloat(0xFF800000u)"
+}
+
+// Reduce combines the elements of in with op. An empty buffer reduces to
+// the identity of op: 0 for Sum, and the largest or smallest value of T
+// for Min and Max. Floating point sums are added in a different order from
+// ReduceCPU's, so they can differ in the last bits.
+func Reduce[T Number](k *Kernels, op Op, in *compute.Buffer[T]) (T, error) {
+	if in.Len == 0 {
+		return identity[T](op), nil
+	}
+	p, err := k.program(reduceShader, reduceDefines[T](op)...)
+	if err != nil {
+		return 0, err
+	}
+
+	src, n := in, in.Len
+	for n > 1 {
+		dst := compute.NewBufferLen[T](groups(n, 2*blockSize))
+		if err := bind(p, map[string]uint32{"Input": src.ID, "Output": dst.ID}); err != nil {
+			dst.Delete()
+			return 0, err
+		}
+		p.SetUint("n", uint32(n))
+		p.DispatchGroups(uint32(dst.Len), 1, 1)
+
+		if src != in {
+			src.Delete()
+		}
+		src, n = dst, dst.Len
+	}
+
+	result := make([]T, 1)
+	src.ReadInto(0, result)
+	if src != in {
+		src.Delete()
+	}
+	return result[0], nil
+}
+
+// identity returns the value that op leaves other values unchanged with.
+func identity[T Number](op Op) T {
+	max, min := limits[T]()
+	switch op {
+	case Min:
+		return max
+	case Max:
+		return min
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package parallel
+
+import (
+	"fmt"
+
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// scanShader scans each block of blockSize elements of Input into Output,
+// and writes the block's total to Sums.
+var scanShader = `
+layout(std430) readonly buffer Input { T data[]; };
+layout(std430) writeonly buffer Output { T scanned[]; };
+layout(std430) writeonly buffer Sums { T sums[]; };
+uniform uint n;
+uniform uint inclusive;
+
+shared T s[gl_WorkGroupSize.x];
+
+void main() {
+    uint l = gl_LocalInvocationID.x;
+    uint i = gl_GlobalInvocationID.x;
+    T x = i < n ? data[i] : T(0);
+    s[l] = x;
+    barrier();
+    // Hillis and Steele: after the step for offset, s[l] is the sum of the
+    // 2 * offset elements up to and including l.
+    for (uint offset = 1u; offset < gl_WorkGroupSize.x; offset <<= 1) {
+        T v = l >= offset ? s[l - offset] : T(0);
+        barrier();
+        s[l] += v;
+        barrier();
+    }
+    if (i < n) {
+        scanned[i] = inclusive != 0u ? s[l] : s[l] - x;
+    }
+    if (l == gl_WorkGroupSize.x - 1u) {
+        sums[gl_WorkGroupID.x] = s[l];
+    }
+}
+`
+
+// addShader adds to each block of Output the element of Offsets for the
+// block.
+var addShader = `
+layout(std430) buffer Output { T scanned[]; };
+layout(std430) readonly buffer Offsets { T offsets[]; };
+uniform uint n;
+
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i < n) {
+        scanned[i] += offsets[gl_WorkGroupID.x];
+    }
+}
+`
+
+// Scan writes the prefix sums of in to out, which must be at least as
+// long. An inclusive scan sums each element and those before it, an
+// exclusive scan only those before it, so starts with 0.
+func Scan[T Number](k *Kernels, in, out *compute.Buffer[T], inclusive bool) error {
+	if out.Len < in.Len {
+		return fmt.Errorf("parallel: scan of %d elements into a buffer of %d", in.Len, out.Len)
+	}
+	n := in.Len
+	if n == 0 {
+		return nil
+	}
+	defines := []string{"T " + glslType[T]()}
+	scan, err := k.program(scanShader, defines...)
+	if err != nil {
+		return err
+	}
+
+	blocks := groups(n, blockSize)
+	sums := compute.NewBufferLen[T](blocks)
+	defer sums.Delete()
+	if err := bind(scan, map[string]uint32{"Input": in.ID, "Output": out.ID, "Sums": sums.ID}); err != nil {
+		return err
+	}
+	scan.SetUint("n", uint32(n))
+	scan.SetUint("inclusive", boolToUint(inclusive))
+	scan.DispatchGroups(uint32(blocks), 1, 1)
+	if blocks == 1 {
+		return nil
+	}
+
+	// Each block starts from the total of the blocks before it.
+	offsets := compute.NewBufferLen[T](blocks)
+	defer offsets.Delete()
+	if err := Scan(k, sums, offsets, false); err != nil {
+		return err
+	}
+	add, err := k.program(addShader, defines...)
+	if err != nil {
+		return err
+	}
+	if err := bind(add, map[string]uint32{"Output": out.ID, "Offsets": offsets.ID}); err != nil {
+		return err
+	}
+	add.SetUint("n", uint32(n))
+	add.DispatchGroups(uint32(blocks), 1, 1)
+	return nil
+}
+
+func boolToUint(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
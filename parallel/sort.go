@@ -0,0 +1,162 @@
+package parallel
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/purelazy/GopenGL/compute"
+)
+
+// radixBits is the number of key bits each pass of the radix sort sorts
+// by, so there are radixDigits digits.
+const (
+	radixBits   = 4
+	radixDigits = 1 << radixBits
+)
+
+// countShader counts the keys in each block with each digit, into Counts
+// laid out digit by digit, so that an exclusive scan of it gives where
+// each block's keys with each digit go.
+var countShader = `
+layout(std430) readonly buffer Keys { uint keys[]; };
+layout(std430) writeonly buffer Counts { uint counts[]; };
+uniform uint n;
+uniform uint shift;
+
+shared uint local[DIGITS];
+
+void main() {
+    uint l = gl_LocalInvocationID.x;
+    if (l < DIGITS) {
+        local[l] = 0u;
+    }
+    barrier();
+    uint i = gl_GlobalInvocationID.x;
+    if (i < n) {
+        atomicAdd(local[(keys[i] >> shift) & (DIGITS - 1u)], 1u);
+    }
+    barrier();
+    if (l < DIGITS) {
+        counts[l * gl_NumWorkGroups.x + gl_WorkGroupID.x] = local[l];
+    }
+}
+`
+
+// scatterShader moves each key, and its value, to its place for this pass.
+// Within a block, keys with the same digit keep their order, which makes
+// the sort stable.
+var scatterShader = `
+layout(std430) readonly buffer Keys { uint keys[]; };
+layout(std430) writeonly buffer SortedKeys { uint sortedKeys[]; };
+layout(std430) readonly buffer Offsets { uint offsets[]; };
+#ifdef VALUES
+layout(std430) readonly buffer Values { uint values[]; };
+layout(std430) writeonly buffer SortedValues { uint sortedValues[]; };
+#endif
+uniform uint n;
+uniform uint shift;
+
+shared uint digits[gl_WorkGroupSize.x];
+
+void main() {
+    uint l = gl_LocalInvocationID.x;
+    uint i = gl_GlobalInvocationID.x;
+    uint d = i < n ? (keys[i] >> shift) & (DIGITS - 1u) : DIGITS;
+    digits[l] = d;
+    barrier();
+    if (i >= n) {
+        return;
+    }
+    uint rank = 0u;
+    for (uint j = 0u; j < l; j++) {
+        rank += digits[j] == d ? 1u : 0u;
+    }
+    uint to = offsets[d * gl_NumWorkGroups.x + gl_WorkGroupID.x] + rank;
+    sortedKeys[to] = keys[i];
+#ifdef VALUES
+    sortedValues[to] = values[i];
+#endif
+}
+`
+
+// Sort sorts keys into ascending order, in place.
+func Sort(k *Kernels, keys *compute.Buffer[uint32]) error {
+	return radixSort(k, keys, nil)
+}
+
+// SortPairs sorts keys into ascending order, in place, and moves values,
+// which must be at least as long, along with them. Pairs with equal keys
+// keep their order.
+func SortPairs(k *Kernels, keys, values *compute.Buffer[uint32]) error {
+	if values.Len < keys.Len {
+		return fmt.Errorf("parallel: %d values for %d keys", values.Len, keys.Len)
+	}
+	return radixSort(k, keys, values)
+}
+
+// radixSort sorts keys, and values if it is not nil, by radixBits at a
+// time from the least significant.
+func radixSort(k *Kernels, keys, values *compute.Buffer[uint32]) error {
+	n := keys.Len
+	if n < 2 {
+		return nil
+	}
+	digits := "DIGITS " + strconv.Itoa(radixDigits) + "u"
+	count, err := k.program(countShader, digits)
+	if err != nil {
+		return err
+	}
+	defines := []string{digits}
+	if values != nil {
+		defines = append(defines, "VALUES")
+	}
+	scatter, err := k.program(scatterShader, defines...)
+	if err != nil {
+		return err
+	}
+
+	blocks := groups(n, blockSize)
+	counts := compute.NewBufferLen[uint32](radixDigits * blocks)
+	defer counts.Delete()
+	offsets := compute.NewBufferLen[uint32](radixDigits * blocks)
+	defer offsets.Delete()
+
+	// The passes alternate between the buffers given and these; there is an
+	// even number of them, so the result ends up back in the buffers given.
+	src, dst := keys, compute.NewBufferLen[uint32](n)
+	defer dst.Delete()
+	var srcValues, dstValues *compute.Buffer[uint32]
+	if values != nil {
+		srcValues, dstValues = values, compute.NewBufferLen[uint32](n)
+		defer dstValues.Delete()
+	}
+
+	for shift := uint32(0); shift < 32; shift += radixBits {
+		if err := bind(count, map[string]uint32{"Keys": src.ID, "Counts": counts.ID}); err != nil {
+			return err
+		}
+		count.SetUint("n", uint32(n))
+		count.SetUint("shift", shift)
+		count.DispatchGroups(uint32(blocks), 1, 1)
+
+		if err := Scan(k, counts, offsets, false); err != nil {
+			return err
+		}
+
+		buffers := map[string]uint32{"Keys": src.ID, "SortedKeys": dst.ID, "Offsets": offsets.ID}
+		if values != nil {
+			buffers["Values"] = srcValues.ID
+			buffers["SortedValues"] = dstValues.ID
+		}
+		if err := bind(scatter, buffers); err != nil {
+			return err
+		}
+		scatter.SetUint("n", uint32(n))
+		scatter.SetUint("shift", shift)
+		scatter.DispatchGroups(uint32(blocks), 1, 1)
+
+		src, dst = dst, src
+		srcValues, dstValues = dstValues, srcValues
+	}
+	return nil
+}
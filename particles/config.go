@@ -0,0 +1,210 @@
+package particles
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Limits on the number of things a Config can have, set by the uniform
+// arrays in the shaders.
+const (
+	MaxEmitters   = 16
+	MaxAttractors = 8
+	MaxPlanes     = 8
+)
+
+// Config describes a particle system: how many particles it holds, the
+// forces on them, what they collide with, where they come from and how they
+// are drawn. It is usually loaded from a JSON file with LoadConfig. The
+// forces and planes can be changed while the system runs.
+type Config struct {
+	// Max is the number of particles the system has room for. Emitters stop
+	// spawning while all are alive.
+	Max int `json:"max"`
+
+	Gravity [3]float32 `json:"gravity"`
+	// Drag slows particles down in proportion to their speed, per second.
+	Drag float32 `json:"drag"`
+	Curl Curl    `json:"curl"`
+
+	Attractors []Attractor `json:"attractors"`
+	Planes     []Plane     `json:"planes"`
+	Emitters   []Emitter   `json:"emitters"`
+
+	// Render is "points", for point sprites, or "quads", for camera-facing
+	// quads. Both are blended additively.
+	Render string `json:"render"`
+}
+
+// Curl is turbulence from curl noise, which swirls particles around
+// without bunching them up.
+type Curl struct {
+	Strength float32 `json:"strength"`
+	// Scale is the frequency of the noise in space, per unit.
+	Scale float32 `json:"scale"`
+	// Speed is how fast the noise field changes with time.
+	Speed float32 `json:"speed"`
+}
+
+// Attractor pulls particles towards Position, or pushes them away if
+// Strength is negative, with an inverse square force.
+type Attractor struct {
+	Position [3]float32 `json:"position"`
+	Strength float32    `json:"strength"`
+}
+
+// Plane is a surface particles bounce off: the points p with
+// dot(Normal, p) + Offset < 0 are behind it.
+type Plane struct {
+	Normal [3]float32 `json:"normal"`
+	Offset float32    `json:"offset"`
+	// Restitution is the fraction of the speed into the plane kept as a
+	// bounce, and Friction the fraction of the speed along it lost.
+	Restitution float32 `json:"restitution"`
+	Friction    float32 `json:"friction"`
+}
+
+// Emitter shapes.
+const (
+	ShapePoint  = "point"
+	ShapeSphere = "sphere"
+	ShapeBox    = "box"
+	ShapeDisk   = "disk"
+)
+
+// Emitter spawns particles.
+type Emitter struct {
+	Name     string     `json:"name"`
+	Position [3]float32 `json:"position"`
+	// Shape is the volume particles start in: ShapePoint, ShapeSphere
+	// (radius Extent[0]), ShapeBox (half sizes Extent) or ShapeDisk
+	// (radius Extent[0], across Direction).
+	Shape  string     `json:"shape"`
+	Extent [3]float32 `json:"extent"`
+
+	// Rate is the number of particles spawned per second, and Burst the
+	// number spawned at once when the system starts.
+	Rate  float32 `json:"rate"`
+	Burst int     `json:"burst"`
+
+	// Particles leave within Spread degrees of Direction, at a speed
+	// between Speed[0] and Speed[1].
+	Direction [3]float32 `json:"direction"`
+	Spread    float32    `json:"spread"`
+	Speed     [2]float32 `json:"speed"`
+	// Lifetime is the range of seconds particles live for.
+	Lifetime [2]float32 `json:"lifetime"`
+
+	// Particles change from ColorStart and SizeStart when they are born to
+	// ColorEnd and SizeEnd when they die. Colours are linear RGBA; the alpha
+	// scales what is added to the screen.
+	ColorStart [4]float32 `json:"colorStart"`
+	ColorEnd   [4]float32 `json:"colorEnd"`
+	SizeStart  float32    `json:"sizeStart"`
+	SizeEnd    float32    `json:"sizeEnd"`
+}
+
+// shape returns the number the spawn shader knows the shape by.
+func (e *Emitter) shape() int32 {
+	switch e.Shape {
+	case ShapeSphere:
+		return 1
+	case ShapeBox:
+		return 2
+	case ShapeDisk:
+		return 3
+	}
+	return 0
+}
+
+// LoadConfig reads a Config from a JSON file.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("particles: %v", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("particles: %s: %v", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return c, fmt.Errorf("particles: %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// validate checks the config, and fills in defaults for what was left out.
+func (c *Config) validate() error {
+	if c.Max <= 0 {
+		c.Max = 100000
+	}
+	if c.Render == "" {
+		c.Render = "points"
+	}
+	if c.Render != "points" && c.Render != "quads" {
+		return fmt.Errorf("render is %q, want points or quads", c.Render)
+	}
+	if len(c.Emitters) > MaxEmitters {
+		return fmt.Errorf("%d emitters, at most %d", len(c.Emitters), MaxEmitters)
+	}
+	if len(c.Attractors) > MaxAttractors {
+		return fmt.Errorf("%d attractors, at most %d", len(c.Attractors), MaxAttractors)
+	}
+	if len(c.Planes) > MaxPlanes {
+		return fmt.Errorf("%d planes, at most %d", len(c.Planes), MaxPlanes)
+	}
+	for i := range c.Planes {
+		p := &c.Planes[i]
+		if !normalize(&p.Normal) {
+			return fmt.Errorf("plane %d has no normal", i)
+		}
+	}
+
+	for i := range c.Emitters {
+		e := &c.Emitters[i]
+		if e.Name == "" {
+			e.Name = fmt.Sprintf("emitter %d", i)
+		}
+		switch e.Shape {
+		case "", ShapePoint, ShapeSphere, ShapeBox, ShapeDisk:
+		default:
+			return fmt.Errorf("emitter %q has unknown shape %q", e.Name, e.Shape)
+		}
+		if !normalize(&e.Direction) {
+			e.Direction = [3]float32{0, 1, 0}
+		}
+		if e.Lifetime[1] < e.Lifetime[0] {
+			e.Lifetime[1] = e.Lifetime[0]
+		}
+		if e.Lifetime[1] <= 0 {
+			return fmt.Errorf("emitter %q has no lifetime", e.Name)
+		}
+		if e.Speed[1] < e.Speed[0] {
+			e.Speed[1] = e.Speed[0]
+		}
+		if e.ColorStart == ([4]float32{}) {
+			e.ColorStart = [4]float32{1, 1, 1, 1}
+		}
+		if e.SizeStart <= 0 {
+			e.SizeStart = 0.05
+		}
+		if e.SizeEnd <= 0 {
+			e.SizeEnd = e.SizeStart
+		}
+	}
+	return nil
+}
+
+// normalize makes v unit length, and reports false if it is zero.
+func normalize(v *[3]float32) bool {
+	l := math.Sqrt(float64(v[0]*v[0] + v[1]*v[1] + v[2]*v[2]))
+	if l == 0 {
+		return false
+	}
+	for i := range v {
+		v[i] = float32(float64(v[i]) / l)
+	}
+	return true
+}
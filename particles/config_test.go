@@ -0,0 +1,117 @@
+package particles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	c := Config{
+		Planes: []Plane{{Normal: [3]float32{0, 2, 0}}},
+		Emitters: []Emitter{
+			{Lifetime: [2]float32{2, 1}, Speed: [2]float32{3, 0}},
+			{Name: "jet", Shape: ShapeDisk, Direction: [3]float32{3, 0, 4}, Lifetime: [2]float32{0, 1}, SizeStart: 0.5},
+		},
+	}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Max != 100000 || c.Render != "points" {
+		t.Errorf("max %d, render %q, want 100000 and points", c.Max, c.Render)
+	}
+	if c.Planes[0].Normal != [3]float32{0, 1, 0} {
+		t.Errorf("plane normal %v, want it normalized", c.Planes[0].Normal)
+	}
+
+	e := c.Emitters[0]
+	if e.Name != "emitter 0" || e.shape() != 0 {
+		t.Errorf("unnamed emitter is %q with shape %d", e.Name, e.shape())
+	}
+	if e.Direction != [3]float32{0, 1, 0} {
+		t.Errorf("direction %v, want up", e.Direction)
+	}
+	// Ranges given backwards become a single value.
+	if e.Lifetime != [2]float32{2, 2} || e.Speed != [2]float32{3, 3} {
+		t.Errorf("lifetime %v and speed %v, want [2 2] and [3 3]", e.Lifetime, e.Speed)
+	}
+	if e.ColorStart != [4]float32{1, 1, 1, 1} || e.SizeStart != 0.05 || e.SizeEnd != 0.05 {
+		t.Errorf("colour %v and size %v to %v, want white and 0.05", e.ColorStart, e.SizeStart, e.SizeEnd)
+	}
+
+	jet := c.Emitters[1]
+	if jet.Name != "jet" || jet.shape() != 3 || jet.Direction != [3]float32{0.6, 0, 0.8} || jet.SizeEnd != 0.5 {
+		t.Errorf("got %+v", jet)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	lived := func(shape string) Emitter {
+		return Emitter{Shape: shape, Lifetime: [2]float32{1, 1}}
+	}
+	tests := []struct {
+		name string
+		c    Config
+		err  string
+	}{
+		{"render", Config{Render: "lines"}, `render is "lines", want points or quads`},
+		{"emitters", Config{Emitters: make([]Emitter, MaxEmitters+1)}, "17 emitters, at most 16"},
+		{"attractors", Config{Attractors: make([]Attractor, MaxAttractors+1)}, "9 attractors, at most 8"},
+		{"planes", Config{Planes: make([]Plane, MaxPlanes+1)}, "9 planes, at most 8"},
+		{"no normal", Config{Planes: []Plane{{Normal: [3]float32{0, 1, 0}}, {Offset: 1}}}, "plane 1 has no normal"},
+		{"shape", Config{Emitters: []Emitter{lived(ShapeBox), lived("cone")}}, `emitter "emitter 1" has unknown shape "cone"`},
+		{"no lifetime", Config{Emitters: []Emitter{{Name: "spark"}}}, `emitter "spark" has no lifetime`},
+		{"negative lifetime", Config{Emitters: []Emitter{{Lifetime: [2]float32{-2, -1}}}}, `emitter "emitter 0" has no lifetime`},
+	}
+	for _, tt := range tests {
+		if err := tt.c.validate(); err == nil || err.Error() != tt.err {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+
+	// The limits themselves are fine.
+	full := Config{
+		Emitters:   make([]Emitter, MaxEmitters),
+		Attractors: make([]Attractor, MaxAttractors),
+		Render:     "quads",
+	}
+	for i := range full.Emitters {
+		full.Emitters[i] = lived("")
+	}
+	if err := full.validate(); err != nil {
+		t.Errorf("a config at the limits: %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	c, err := LoadConfig(filepath.Join("..", "cmd", "02-Basics", "13-Particles", "fountain.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Emitters) == 0 || c.Max <= 0 {
+		t.Errorf("fountain has %d emitters and room for %d particles", len(c.Emitters), c.Max)
+	}
+
+	dir := t.TempDir()
+	tests := []struct {
+		name, json, err string
+	}{
+		{"bad.json", `{"max": "lots"}`, "cannot unmarshal string"},
+		{"invalid.json", `{"render": "sprites"}`, `invalid.json: render is "sprites", want points or quads`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(path)
+		if err == nil || !strings.HasPrefix(err.Error(), "particles: "+path) || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loading a missing file gave no error")
+	}
+}
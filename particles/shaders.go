@@ -0,0 +1,316 @@
+package particles
+
+// particleBlock declares the particle storage block, laid out like
+// Particle.
+const particleBlock = `
+struct Particle {
+    vec3 position;
+    float age;
+    vec3 velocity;
+    float lifetime;
+    vec4 color;
+    float size;
+    uint emitter;
+    vec2 pad;
+};
+layout(std430, binding = 0) buffer Particles { Particle particles[]; };
+`
+
+// freeListBlock declares the free list: the number of free particles, and
+// their indices below it.
+const freeListBlock = `
+layout(std430, binding = 1) buffer FreeList {
+    int freeCount;
+    uint freeIndices[];
+};
+`
+
+// randomFunctions is a PCG hash, for random numbers that depend only on
+// the invocation and a seed.
+const randomFunctions = `
+uint rngState;
+
+uint pcg(uint v) {
+    uint state = v * 747796405u + 2891336453u;
+    uint word = ((state >> ((state >> 28u) + 4u)) ^ state) * 277803737u;
+    return (word >> 22u) ^ word;
+}
+
+// random returns a number in [0, 1).
+float random() {
+    rngState = pcg(rngState);
+    return float(rngState >> 8) / 16777216.0;
+}
+
+vec3 randomDirection() {
+    float z = random() * 2.0 - 1.0;
+    float a = random() * 6.2831853;
+    float r = sqrt(max(0.0, 1.0 - z * z));
+    return vec3(r * cos(a), r * sin(a), z);
+}
+`
+
+// spawnShader takes particles off the free list and starts them at an
+// emitter, one invocation per particle.
+const spawnShader = `#version 430
+layout(local_size_x = 256) in;
+` + particleBlock + freeListBlock + randomFunctions + `
+uniform uvec3 invocations;
+uniform uint seed;
+
+uniform uint emitter;
+uniform vec3 origin;
+uniform int shape;
+uniform vec3 extent;
+uniform vec3 direction;
+uniform float cosSpread;
+uniform vec2 speed;
+uniform vec2 lifetime;
+uniform vec4 color;
+uniform float size;
+
+// basis returns two unit vectors at right angles to n and each other.
+void basis(vec3 n, out vec3 t, out vec3 b) {
+    t = normalize(abs(n.x) < 0.9 ? cross(n, vec3(1, 0, 0)) : cross(n, vec3(0, 1, 0)));
+    b = cross(n, t);
+}
+
+vec3 offset() {
+    if (shape == 1) {
+        return randomDirection() * extent.x * pow(random(), 1.0 / 3.0);
+    }
+    if (shape == 2) {
+        return (vec3(random(), random(), random()) * 2.0 - 1.0) * extent;
+    }
+    if (shape == 3) {
+        vec3 t, b;
+        basis(direction, t, b);
+        float a = random() * 6.2831853;
+        return (t * cos(a) + b * sin(a)) * extent.x * sqrt(random());
+    }
+    return vec3(0);
+}
+
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i >= invocations.x) return;
+
+    int top = atomicAdd(freeCount, -1);
+    if (top <= 0) {
+        // Every particle is alive.
+        atomicAdd(freeCount, 1);
+        return;
+    }
+    uint index = freeIndices[top - 1];
+
+    rngState = pcg(i ^ pcg(seed));
+
+    // A direction in the cone around the emitter's, uniform over the cap
+    // of the sphere it cuts.
+    vec3 t, b;
+    basis(direction, t, b);
+    float z = mix(cosSpread, 1.0, random());
+    float a = random() * 6.2831853;
+    float r = sqrt(max(0.0, 1.0 - z * z));
+    vec3 d = direction * z + (t * cos(a) + b * sin(a)) * r;
+
+    Particle p;
+    p.position = origin + offset();
+    p.age = 0.0;
+    p.velocity = d * mix(speed.x, speed.y, random());
+    p.lifetime = mix(lifetime.x, lifetime.y, random());
+    p.color = color;
+    p.size = size;
+    p.emitter = emitter;
+    p.pad = vec2(0);
+    particles[index] = p;
+}
+`
+
+// updateShader ages the particles, applies the forces, moves them and
+// bounces them off the planes. Particles that die go back on the free
+// list.
+const updateShader = `#version 430
+layout(local_size_x = 256) in;
+` + particleBlock + freeListBlock + `
+uniform uvec3 invocations;
+uniform float dt;
+uniform float time;
+
+uniform vec3 gravity;
+uniform float drag;
+uniform vec3 curl; // strength, scale, speed
+
+uniform int attractorCount;
+uniform vec4 attractors[8]; // position, strength
+uniform int planeCount;
+uniform vec4 planes[8];     // normal, offset
+uniform vec2 responses[8];  // restitution, friction
+
+float hash(vec3 p) {
+    p = fract(p * 0.3183099 + 0.1);
+    p *= 17.0;
+    return fract(p.x * p.y * p.z * (p.x + p.y + p.z)) * 2.0 - 1.0;
+}
+
+// noise is smoothly interpolated value noise in [-1, 1].
+float noise(vec3 p) {
+    vec3 i = floor(p);
+    vec3 f = fract(p);
+    f = f * f * (3.0 - 2.0 * f);
+    return mix(mix(mix(hash(i), hash(i + vec3(1, 0, 0)), f.x),
+                   mix(hash(i + vec3(0, 1, 0)), hash(i + vec3(1, 1, 0)), f.x), f.y),
+               mix(mix(hash(i + vec3(0, 0, 1)), hash(i + vec3(1, 0, 1)), f.x),
+                   mix(hash(i + vec3(0, 1, 1)), hash(i + vec3(1, 1, 1)), f.x), f.y), f.z);
+}
+
+vec3 potential(vec3 p) {
+    return vec3(noise(p), noise(p + vec3(31.4, 15.9, 26.5)), noise(p + vec3(-35.8, 97.9, -32.3)));
+}
+
+// curlNoise is the curl of a noise vector field, found by central
+// differences. It has no divergence, so particles swirl without bunching.
+vec3 curlNoise(vec3 p) {
+    const float e = 0.01;
+    vec3 dx = potential(p + vec3(e, 0, 0)) - potential(p - vec3(e, 0, 0));
+    vec3 dy = potential(p + vec3(0, e, 0)) - potential(p - vec3(0, e, 0));
+    vec3 dz = potential(p + vec3(0, 0, e)) - potential(p - vec3(0, 0, e));
+    return vec3(dy.z - dz.y, dz.x - dx.z, dx.y - dy.x) / (2.0 * e);
+}
+
+void main() {
+    uint i = gl_GlobalInvocationID.x;
+    if (i >= invocations.x) return;
+
+    Particle p = particles[i];
+    if (p.lifetime <= 0.0) return;
+
+    p.age += dt;
+    if (p.age >= p.lifetime) {
+        particles[i].lifetime = 0.0;
+        int top = atomicAdd(freeCount, 1);
+        freeIndices[top] = i;
+        return;
+    }
+
+    vec3 a = gravity;
+    for (int k = 0; k < attractorCount; k++) {
+        vec3 d = attractors[k].xyz - p.position;
+        // Softened, so particles passing through the centre are not flung
+        // off to infinity.
+        float r2 = dot(d, d) + 0.01;
+        a += attractors[k].w * d * inversesqrt(r2 * r2 * r2);
+    }
+    if (curl.x != 0.0) {
+        a += curl.x * curlNoise(p.position * curl.y + vec3(0, 0, time * curl.z));
+    }
+
+    p.velocity += a * dt;
+    p.velocity *= exp(-drag * dt);
+    p.position += p.velocity * dt;
+
+    for (int k = 0; k < planeCount; k++) {
+        vec3 n = planes[k].xyz;
+        float d = dot(n, p.position) + planes[k].w;
+        if (d < 0.0) {
+            p.position -= n * d;
+            float vn = dot(p.velocity, n);
+            if (vn < 0.0) {
+                vec3 tangent = p.velocity - n * vn;
+                p.velocity = tangent * (1.0 - responses[k].y) - n * vn * responses[k].x;
+            }
+        }
+    }
+
+    particles[i] = p;
+}
+`
+
+// pointVertexShader draws each particle as a point sprite, sized in world
+// units.
+const pointVertexShader = `#version 430
+` + particleBlock + `
+uniform mat4 view;
+uniform mat4 projection;
+uniform float viewportHeight;
+uniform vec4 colorEnd[16];
+uniform float sizeEnd[16];
+
+out vec4 color;
+
+void main() {
+    Particle p = particles[gl_VertexID];
+    if (p.lifetime <= 0.0) {
+        // Outside the clip volume, so it is discarded.
+        gl_Position = vec4(2.0, 2.0, 2.0, 1.0);
+        gl_PointSize = 1.0;
+        color = vec4(0);
+        return;
+    }
+    float t = p.age / p.lifetime;
+    color = mix(p.color, colorEnd[p.emitter], t);
+    float size = mix(p.size, sizeEnd[p.emitter], t);
+
+    gl_Position = projection * view * vec4(p.position, 1.0);
+    gl_PointSize = max(1.0, size * projection[1][1] * viewportHeight * 0.5 / gl_Position.w);
+}
+`
+
+const pointFragmentShader = `#version 430
+in vec4 color;
+out vec4 fragColor;
+
+void main() {
+    vec2 d = gl_PointCoord * 2.0 - 1.0;
+    float r2 = dot(d, d);
+    if (r2 > 1.0) discard;
+    float falloff = (1.0 - r2) * (1.0 - r2);
+    fragColor = vec4(color.rgb * color.a * falloff, 1.0);
+}
+`
+
+// quadVertexShader draws each particle as a quad facing the camera, one
+// instance per particle.
+const quadVertexShader = `#version 430
+` + particleBlock + `
+uniform mat4 view;
+uniform mat4 projection;
+uniform vec4 colorEnd[16];
+uniform float sizeEnd[16];
+
+out vec4 color;
+out vec2 corner;
+
+void main() {
+    Particle p = particles[gl_InstanceID];
+    if (p.lifetime <= 0.0) {
+        gl_Position = vec4(2.0, 2.0, 2.0, 1.0);
+        color = vec4(0);
+        corner = vec2(0);
+        return;
+    }
+    float t = p.age / p.lifetime;
+    color = mix(p.color, colorEnd[p.emitter], t);
+    float size = mix(p.size, sizeEnd[p.emitter], t);
+
+    corner = vec2(gl_VertexID & 1, gl_VertexID >> 1) * 2.0 - 1.0;
+    // The camera's right and up are the first two rows of the view matrix.
+    vec3 right = vec3(view[0][0], view[1][0], view[2][0]);
+    vec3 up = vec3(view[0][1], view[1][1], view[2][1]);
+    vec3 position = p.position + (right * corner.x + up * corner.y) * size * 0.5;
+    gl_Position = projection * view * vec4(position, 1.0);
+}
+`
+
+const quadFragmentShader = `#version 430
+in vec4 color;
+in vec2 corner;
+out vec4 fragColor;
+
+void main() {
+    float r2 = dot(corner, corner);
+    if (r2 > 1.0) discard;
+    float falloff = (1.0 - r2) * (1.0 - r2);
+    fragColor = vec4(color.rgb * color.a * falloff, 1.0);
+}
+`
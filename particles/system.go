@@ -0,0 +1,327 @@
+// Package particles simulates particles on the GPU. Emitters spawn
+// particles into a shader storage buffer, a compute shader moves them
+// under gravity, drag, curl noise and attractors and bounces them off
+// planes, and particles that die go back on a free list for emitters to
+// reuse. They are drawn as point sprites or camera-facing quads, blended
+// additively, straight from the buffer.
+//
+// A system is described by a Config, usually loaded from a JSON file:
+//
+//	sys, err := particles.Load("fountain.json")
+//	...
+//	sys.Update(dt)
+//	sys.Draw(cam, height)
+//
+// It needs OpenGL 4.3 for compute shaders.
+package particles
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/compute"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+// Particle is one particle, laid out as the shaders' std430 struct. It is
+// dead when Lifetime is 0.
+type Particle struct {
+	Position [3]float32
+	Age      float32
+	Velocity [3]float32
+	Lifetime float32
+	// Color and Size are what the particle was born with. It is drawn
+	// changing from them to its emitter's ColorEnd and SizeEnd.
+	Color   [4]float32
+	Size    float32
+	Emitter uint32
+	_       [2]float32
+}
+
+// System is a running particle system.
+type System struct {
+	// Config may be changed between updates, except for Max and Render.
+	Config Config
+	// Particles holds Config.Max particles, alive or dead.
+	Particles *compute.Buffer[Particle]
+	// Time is the number of seconds Update has simulated.
+	Time float32
+
+	// free holds the number of free particles and then their indices.
+	free    *compute.Buffer[uint32]
+	spawn   *compute.Program
+	update  *compute.Program
+	program uint32
+	vao     uint32
+	// owed is the fraction of a particle each emitter is due to spawn.
+	owed []float32
+	seed uint32
+
+	attractors, planes, responses int32
+	view, projection, height      int32
+	colorEnd, sizeEnd             int32
+}
+
+// Load creates a System from a JSON config file.
+func Load(path string) (*System, error) {
+	c, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(c)
+}
+
+// New creates a System with every particle dead, and spawns each emitter's
+// Burst. It needs a current GL context with compute shaders.
+func New(c Config) (*System, error) {
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("particles: %v", err)
+	}
+	if !compute.Supported() {
+		return nil, fmt.Errorf("particles: compute shaders need OpenGL 4.3")
+	}
+
+	s := &System{Config: c}
+	var err error
+	if s.spawn, err = compute.NewProgram(spawnShader); err != nil {
+		return nil, fmt.Errorf("particles: spawn: %v", err)
+	}
+	if s.update, err = compute.NewProgram(updateShader); err != nil {
+		s.spawn.Delete()
+		return nil, fmt.Errorf("particles: update: %v", err)
+	}
+	vertex, fragment := pointVertexShader, pointFragmentShader
+	if c.Render == "quads" {
+		vertex, fragment = quadVertexShader, quadFragmentShader
+	}
+	if s.program, err = shader.NewProgram(vertex, fragment); err != nil {
+		s.spawn.Delete()
+		s.update.Delete()
+		return nil, fmt.Errorf("particles: render: %v", err)
+	}
+
+	s.Particles = compute.NewBufferLen[Particle](c.Max)
+	s.free = compute.NewBufferLen[uint32](c.Max + 1)
+	for _, p := range []*compute.Program{s.spawn, s.update} {
+		// Particles first, so the blocks keep the bindings the shaders
+		// declare.
+		if err := p.BindBuffer("Particles", s.Particles.ID); err != nil {
+			s.Delete()
+			return nil, err
+		}
+		if err := p.BindBuffer("FreeList", s.free.ID); err != nil {
+			s.Delete()
+			return nil, err
+		}
+	}
+	// The vertex shaders read the particles from the buffer by index, so
+	// the vertex array has no attributes.
+	gl.GenVertexArrays(1, &s.vao)
+
+	s.attractors = shader.Uniform(s.update.ID, "attractors")
+	s.planes = shader.Uniform(s.update.ID, "planes")
+	s.responses = shader.Uniform(s.update.ID, "responses")
+	s.view = shader.Uniform(s.program, "view")
+	s.projection = shader.Uniform(s.program, "projection")
+	s.height = shader.Uniform(s.program, "viewportHeight")
+	s.colorEnd = shader.Uniform(s.program, "colorEnd")
+	s.sizeEnd = shader.Uniform(s.program, "sizeEnd")
+
+	s.Reset()
+	return s, nil
+}
+
+// Reset kills every particle, sets Time back to 0 and spawns each
+// emitter's Burst again.
+func (s *System) Reset() {
+	s.Particles.Write(0, make([]Particle, s.Config.Max))
+	free := make([]uint32, s.Config.Max+1)
+	free[0] = uint32(s.Config.Max)
+	for i := 1; i < len(free); i++ {
+		free[i] = uint32(i - 1)
+	}
+	s.free.Write(0, free)
+
+	s.Time = 0
+	s.owed = make([]float32, len(s.Config.Emitters))
+	for i, e := range s.Config.Emitters {
+		s.Emit(i, e.Burst)
+	}
+}
+
+// Emit spawns n particles from the emitter with index i, as far as there
+// are dead particles to reuse.
+func (s *System) Emit(i, n int) {
+	if i < 0 || i >= len(s.Config.Emitters) || n <= 0 {
+		return
+	}
+	if n > s.Config.Max {
+		n = s.Config.Max
+	}
+	e := &s.Config.Emitters[i]
+	dir := e.Direction
+	if !normalize(&dir) {
+		dir = [3]float32{0, 1, 0}
+	}
+
+	p := s.spawn
+	s.seed++
+	p.SetUint("seed", s.seed)
+	p.SetUint("emitter", uint32(i))
+	p.Set("origin", e.Position[:]...)
+	p.SetInt("shape", e.shape())
+	p.Set("extent", e.Extent[:]...)
+	p.Set("direction", dir[:]...)
+	p.Set("cosSpread", float32(math.Cos(float64(e.Spread)*math.Pi/180)))
+	p.Set("speed", e.Speed[:]...)
+	p.Set("lifetime", e.Lifetime[:]...)
+	p.Set("color", e.ColorStart[:]...)
+	p.Set("size", e.SizeStart)
+	p.Dispatch(n, 1, 1)
+}
+
+// Update advances the simulation by dt seconds: the emitters spawn their
+// particles for the time, and then every particle moves.
+func (s *System) Update(dt float32) {
+	if dt <= 0 {
+		return
+	}
+	c := &s.Config
+	for len(s.owed) < len(c.Emitters) {
+		s.owed = append(s.owed, 0)
+	}
+	for i, e := range c.Emitters {
+		s.owed[i] += e.Rate * dt
+		n := int(s.owed[i])
+		s.owed[i] -= float32(n)
+		s.Emit(i, n)
+	}
+	s.Time += dt
+
+	p := s.update
+	p.Set("dt", dt)
+	p.Set("time", s.Time)
+	p.Set("gravity", c.Gravity[:]...)
+	p.Set("drag", c.Drag)
+	scale := c.Curl.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	p.Set("curl", c.Curl.Strength, scale, c.Curl.Speed)
+
+	var attractors [MaxAttractors][4]float32
+	n := len(c.Attractors)
+	if n > MaxAttractors {
+		n = MaxAttractors
+	}
+	for i, a := range c.Attractors[:n] {
+		attractors[i] = [4]float32{a.Position[0], a.Position[1], a.Position[2], a.Strength}
+	}
+	p.SetInt("attractorCount", int32(n))
+	if n > 0 {
+		gl.ProgramUniform4fv(p.ID, s.attractors, int32(n), &attractors[0][0])
+	}
+
+	var planes [MaxPlanes][4]float32
+	var responses [MaxPlanes][2]float32
+	n = len(c.Planes)
+	if n > MaxPlanes {
+		n = MaxPlanes
+	}
+	for i, pl := range c.Planes[:n] {
+		normal := pl.Normal
+		normalize(&normal)
+		planes[i] = [4]float32{normal[0], normal[1], normal[2], pl.Offset}
+		responses[i] = [2]float32{pl.Restitution, pl.Friction}
+	}
+	p.SetInt("planeCount", int32(n))
+	if n > 0 {
+		gl.ProgramUniform4fv(p.ID, s.planes, int32(n), &planes[0][0])
+		gl.ProgramUniform2fv(p.ID, s.responses, int32(n), &responses[0][0])
+	}
+
+	p.Dispatch(c.Max, 1, 1)
+}
+
+// Alive returns the number of live particles. It reads from the GPU, so it
+// waits for the simulation to catch up.
+func (s *System) Alive() int {
+	var free [1]uint32
+	s.free.ReadInto(0, free[:])
+	return s.Config.Max - int(int32(free[0]))
+}
+
+// Draw draws the live particles as seen by cam, into a viewport
+// viewportHeight pixels high. They are added to what is already drawn,
+// tested against the depth buffer but not written to it.
+func (s *System) Draw(cam *camera.Camera, viewportHeight int) {
+	compute.Barrier(gl.SHADER_STORAGE_BARRIER_BIT)
+
+	var colorEnd [MaxEmitters][4]float32
+	var sizeEnd [MaxEmitters]float32
+	for i, e := range s.Config.Emitters {
+		if i == MaxEmitters {
+			break
+		}
+		colorEnd[i] = e.ColorEnd
+		sizeEnd[i] = e.SizeEnd
+	}
+
+	// Save the state drawing changes.
+	var program, vao, srcRGB, dstRGB, srcAlpha, dstAlpha int32
+	var depthMask bool
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &vao)
+	gl.GetIntegerv(gl.BLEND_SRC_RGB, &srcRGB)
+	gl.GetIntegerv(gl.BLEND_DST_RGB, &dstRGB)
+	gl.GetIntegerv(gl.BLEND_SRC_ALPHA, &srcAlpha)
+	gl.GetIntegerv(gl.BLEND_DST_ALPHA, &dstAlpha)
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &depthMask)
+	blend := gl.IsEnabled(gl.BLEND)
+	pointSize := gl.IsEnabled(gl.PROGRAM_POINT_SIZE)
+
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE)
+	gl.DepthMask(false)
+
+	view, projection := cam.View(), cam.Projection()
+	gl.UseProgram(s.program)
+	gl.UniformMatrix4fv(s.view, 1, false, &view[0])
+	gl.UniformMatrix4fv(s.projection, 1, false, &projection[0])
+	gl.Uniform1f(s.height, float32(viewportHeight))
+	gl.Uniform4fv(s.colorEnd, MaxEmitters, &colorEnd[0][0])
+	gl.Uniform1fv(s.sizeEnd, MaxEmitters, &sizeEnd[0])
+	gl.BindBufferBase(gl.SHADER_STORAGE_BUFFER, 0, s.Particles.ID)
+	gl.BindVertexArray(s.vao)
+	if s.Config.Render == "quads" {
+		gl.DrawArraysInstanced(gl.TRIANGLE_STRIP, 0, 4, int32(s.Config.Max))
+	} else {
+		gl.Enable(gl.PROGRAM_POINT_SIZE)
+		gl.DrawArrays(gl.POINTS, 0, int32(s.Config.Max))
+		if !pointSize {
+			gl.Disable(gl.PROGRAM_POINT_SIZE)
+		}
+	}
+
+	gl.DepthMask(depthMask)
+	gl.BlendFuncSeparate(uint32(srcRGB), uint32(dstRGB), uint32(srcAlpha), uint32(dstAlpha))
+	if !blend {
+		gl.Disable(gl.BLEND)
+	}
+	gl.UseProgram(uint32(program))
+	gl.BindVertexArray(uint32(vao))
+}
+
+// Delete frees the programs and buffers.
+func (s *System) Delete() {
+	s.spawn.Delete()
+	s.update.Delete()
+	gl.DeleteProgram(s.program)
+	if s.vao != 0 {
+		gl.DeleteVertexArrays(1, &s.vao)
+	}
+	s.Particles.Delete()
+	s.free.Delete()
+}
@@ -0,0 +1,86 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Flow is a system of ordinary differential equations in three variables:
+// it returns the rate of change at (x, y, z).
+type Flow func(x, y, z float64) (dx, dy, dz float64)
+
+// LorenzFlow returns the Lorenz system, chaotic with the classic
+// parameters sigma 10, rho 28 and beta 8/3.
+func LorenzFlow(sigma, rho, beta float64) Flow {
+	return func(x, y, z float64) (float64, float64, float64) {
+		return sigma * (y - x), x*(rho-z) - y, x*y - beta*z
+	}
+}
+
+// RosslerFlow returns the Rössler system, chaotic with the classic
+// parameters a 0.2, b 0.2 and c 5.7.
+func RosslerFlow(a, b, c float64) Flow {
+	return func(x, y, z float64) (float64, float64, float64) {
+		return -y - z, x + a*y, b + z*(x-c)
+	}
+}
+
+// Integrate follows f from start for steps steps of dt with fourth-order
+// Runge-Kutta, and returns the steps+1 points it passes through.
+func Integrate(f Flow, start mgl32.Vec3, dt float64, steps int) Polyline {
+	p := make(Polyline, steps+1)
+	x, y, z := float64(start[0]), float64(start[1]), float64(start[2])
+	p[0] = start
+	for i := 1; i <= steps; i++ {
+		k1x, k1y, k1z := f(x, y, z)
+		k2x, k2y, k2z := f(x+dt/2*k1x, y+dt/2*k1y, z+dt/2*k1z)
+		k3x, k3y, k3z := f(x+dt/2*k2x, y+dt/2*k2y, z+dt/2*k2z)
+		k4x, k4y, k4z := f(x+dt*k3x, y+dt*k3y, z+dt*k3z)
+		x += dt / 6 * (k1x + 2*k2x + 2*k3x + k4x)
+		y += dt / 6 * (k1y + 2*k2y + 2*k3y + k4y)
+		z += dt / 6 * (k1z + 2*k2z + 2*k3z + k4z)
+		p[i] = mgl32.Vec3{float32(x), float32(y), float32(z)}
+	}
+	return p
+}
+
+// jitter returns a point within 0.5 of p in each coordinate, so different
+// seeds give different orbits.
+func jitter(r *rand.Rand, p mgl32.Vec3) mgl32.Vec3 {
+	return p.Add(mgl32.Vec3{r.Float32() - 0.5, r.Float32() - 0.5, r.Float32() - 0.5})
+}
+
+// Lorenz returns steps steps of dt along an orbit of the Lorenz attractor,
+// with the classic parameters, from a random start near (1, 1, 1).
+func Lorenz(r *rand.Rand, steps int, dt float64) Polyline {
+	return Integrate(LorenzFlow(10, 28, 8.0/3), jitter(r, mgl32.Vec3{1, 1, 1}), dt, steps)
+}
+
+// Rossler returns steps steps of dt along an orbit of the Rössler
+// attractor, with the classic parameters, from a random start near
+// (1, 1, 0).
+func Rossler(r *rand.Rand, steps int, dt float64) Polyline {
+	return Integrate(RosslerFlow(0.2, 0.2, 5.7), jitter(r, mgl32.Vec3{1, 1, 0}), dt, steps)
+}
+
+// Clifford returns steps iterations of the Clifford attractor
+//
+//	x' = sin(a y) + c cos(a x)
+//	y' = sin(b x) + d cos(b y)
+//
+// in the XY plane, from a random start in [-1, 1]². Like the other paths,
+// it has steps+1 points, the first being the start. Consecutive points are
+// far apart, so it is best drawn as points rather than a line; with
+// a, b, c, d = -1.4, 1.6, 1.0, 0.7 it draws a swirl of filaments.
+func Clifford(r *rand.Rand, steps int, a, b, c, d float64) Polyline {
+	p := make(Polyline, steps+1)
+	x, y := 2*r.Float64()-1, 2*r.Float64()-1
+	p[0] = mgl32.Vec3{float32(x), float32(y), 0}
+	for i := 1; i <= steps; i++ {
+		x, y = math.Sin(a*y)+c*math.Cos(a*x), math.Sin(b*x)+d*math.Cos(b*y)
+		p[i] = mgl32.Vec3{float32(x), float32(y), 0}
+	}
+	return p
+}
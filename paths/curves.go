@@ -0,0 +1,66 @@
+package paths
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Hilbert returns the Hilbert curve of the given order in the XY plane:
+// 4^order points on a grid spanning a square size wide, centred on the
+// origin, each next to the one before.
+func Hilbert(order int, size float32) Polyline {
+	n := 1 << order
+	p := make(Polyline, n*n)
+	for d := range p {
+		x, y := hilbertPoint(n, d)
+		p[d] = mgl32.Vec3{float32(x), float32(y), 0}
+	}
+	p.Fit(size)
+	return p
+}
+
+// hilbertPoint returns the cell at distance d along the Hilbert curve
+// through an n by n grid, n a power of two.
+func hilbertPoint(n, d int) (x, y int) {
+	for s := 1; s < n; s *= 2 {
+		rx := 1 & (d / 2)
+		ry := 1 & (d ^ rx)
+		if ry == 0 {
+			if rx == 1 {
+				x, y = s-1-x, s-1-y
+			}
+			x, y = y, x
+		}
+		x += s * rx
+		y += s * ry
+		d /= 4
+	}
+	return x, y
+}
+
+// Peano returns the Peano curve of the given order in the XY plane: 9^order
+// points on a grid spanning a square size wide, centred on the origin.
+func Peano(order int, size float32) Polyline {
+	return single(PeanoCurve.Draw(order, 1), size)
+}
+
+// Dragon returns the Heighway dragon curve of the given order, 2^order
+// segments long, in the XY plane, scaled so its longer side is size long.
+func Dragon(order int, size float32) Polyline {
+	return single(DragonCurve.Draw(order, 1), size)
+}
+
+// single returns the one line of a curve that never lifts the pen, fitted
+// to size, with the turtle's rounding errors taken off the grid points.
+func single(lines []Polyline, size float32) Polyline {
+	if len(lines) == 0 {
+		return Polyline{{}}
+	}
+	p := lines[0]
+	for i := range p {
+		for k := 0; k < 3; k++ {
+			p[i][k] = mgl32.Round(p[i][k], 0)
+		}
+	}
+	p.Fit(size)
+	return p
+}
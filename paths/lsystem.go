@@ -0,0 +1,165 @@
+package paths
+
+import (
+	"math"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MaxLength is the longest string LSystem.Expand will grow.
+const MaxLength = 1 << 24
+
+// LSystem is a Lindenmayer system: a string that grows by replacing every
+// symbol that has a rule with the rule's string, all at once, each
+// generation.
+type LSystem struct {
+	Axiom string
+	Rules map[byte]string
+	// Angle is the turtle's turning angle, in degrees.
+	Angle float32
+}
+
+// Expand returns the string after generations rewrites of the axiom. It
+// stops early if the string would grow past MaxLength.
+func (l *LSystem) Expand(generations int) string {
+	s := l.Axiom
+	for g := 0; g < generations; g++ {
+		n := 0
+		for i := 0; i < len(s); i++ {
+			if rule, ok := l.Rules[s[i]]; ok {
+				n += len(rule)
+			} else {
+				n++
+			}
+		}
+		if n > MaxLength {
+			break
+		}
+
+		var b strings.Builder
+		b.Grow(n)
+		for i := 0; i < len(s); i++ {
+			if rule, ok := l.Rules[s[i]]; ok {
+				b.WriteString(rule)
+			} else {
+				b.WriteByte(s[i])
+			}
+		}
+		s = b.String()
+	}
+	return s
+}
+
+// Draw expands the system and draws the result with a turtle taking steps
+// step long and turning by the system's angle.
+func (l *LSystem) Draw(generations int, step float32) []Polyline {
+	t := Turtle{Step: step, Angle: l.Angle}
+	return t.Draw(l.Expand(generations))
+}
+
+// Turtle draws L-system strings. It starts at the origin heading up the Y
+// axis, with its left along -X, and reads the symbols:
+//
+//	F G  move forward a step, drawing
+//	f    move forward a step without drawing
+//	+ -  turn left or right
+//	& ^  pitch down or up
+//	\ /  roll left or right
+//	|    turn around
+//	[ ]  save or restore the position and heading
+//
+// Every other symbol is ignored. Moving without drawing, and restoring,
+// start a new polyline.
+type Turtle struct {
+	Step float32
+	// Angle is the angle each turn, pitch or roll is by, in degrees.
+	Angle float32
+}
+
+// turtleState is where a turtle is and which way it faces.
+type turtleState struct {
+	position, heading, left, up mgl32.Vec3
+}
+
+// Draw returns the polylines the turtle draws reading s. Polylines of a
+// single point, from moves that drew nothing, are left out.
+func (t Turtle) Draw(s string) []Polyline {
+	state := turtleState{
+		heading: mgl32.Vec3{0, 1, 0},
+		left:    mgl32.Vec3{-1, 0, 0},
+		up:      mgl32.Vec3{0, 0, 1},
+	}
+	var stack []turtleState
+	var lines []Polyline
+	line := Polyline{state.position}
+	flush := func() {
+		if len(line) > 1 {
+			lines = append(lines, line)
+		}
+		line = Polyline{state.position}
+	}
+
+	a := float64(mgl32.DegToRad(t.Angle))
+	cos, sin := float32(math.Cos(a)), float32(math.Sin(a))
+	// rotate turns x towards y by the angle, or away from it if sign is -1.
+	rotate := func(x, y *mgl32.Vec3, sign float32) {
+		nx := x.Mul(cos).Add(y.Mul(sign * sin))
+		ny := y.Mul(cos).Sub(x.Mul(sign * sin))
+		*x, *y = nx, ny
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 'F', 'G':
+			state.position = state.position.Add(state.heading.Mul(t.Step))
+			line = append(line, state.position)
+		case 'f':
+			state.position = state.position.Add(state.heading.Mul(t.Step))
+			flush()
+		case '+':
+			rotate(&state.heading, &state.left, 1)
+		case '-':
+			rotate(&state.heading, &state.left, -1)
+		case '&':
+			rotate(&state.heading, &state.up, -1)
+		case '^':
+			rotate(&state.heading, &state.up, 1)
+		case '\\':
+			rotate(&state.left, &state.up, 1)
+		case '/':
+			rotate(&state.left, &state.up, -1)
+		case '|':
+			state.heading = state.heading.Mul(-1)
+			state.left = state.left.Mul(-1)
+		case '[':
+			stack = append(stack, state)
+		case ']':
+			if len(stack) == 0 {
+				continue
+			}
+			state = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			flush()
+		}
+	}
+	flush()
+	return lines
+}
+
+// Some well-known L-systems.
+var (
+	// Koch is the Koch snowflake.
+	Koch = LSystem{Axiom: "F--F--F", Rules: map[byte]string{'F': "F+F--F+F"}, Angle: 60}
+	// Sierpinski is the Sierpiński arrowhead curve.
+	Sierpinski = LSystem{Axiom: "F", Rules: map[byte]string{'F': "G-F-G", 'G': "F+G+F"}, Angle: 60}
+	// Plant is a fractal plant, which branches.
+	Plant = LSystem{Axiom: "X", Rules: map[byte]string{'X': "F+[[X]-X]-F[-FX]+X", 'F': "FF"}, Angle: 25}
+	// PeanoCurve is the Peano curve.
+	PeanoCurve = LSystem{Axiom: "X", Rules: map[byte]string{
+		'X': "XFYFX+F+YFXFY-F-XFYFX",
+		'Y': "YFXFY-F-XFYFX+F+YFXFY",
+	}, Angle: 90}
+	// DragonCurve is the Heighway dragon.
+	DragonCurve = LSystem{Axiom: "FX", Rules: map[byte]string{'X': "X+YF+", 'Y': "-FX-Y"}, Angle: 90}
+)
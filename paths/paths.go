@@ -0,0 +1,83 @@
+// Package paths generates polylines: random walks on a lattice and in
+// continuous space, Lévy flights and self-avoiding walks; L-systems drawn
+// by a turtle; Hilbert, Peano and dragon curves; and the orbits of the
+// Lorenz, Rössler and Clifford attractors.
+//
+// Everything random takes a *rand.Rand, so the same seed gives the same
+// path. Points are in the order they are visited, so drawing the first n
+// of them with gl.LINE_STRIP draws the path as it is made.
+package paths
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Polyline is a path through its points in order. It is tightly packed, so
+// it can be handed straight to gl.BufferData.
+type Polyline []mgl32.Vec3
+
+// Length returns the length of the path.
+func (p Polyline) Length() float32 {
+	var l float32
+	for i := 1; i < len(p); i++ {
+		l += p[i].Sub(p[i-1]).Len()
+	}
+	return l
+}
+
+// Bounds returns the axis-aligned box around the points.
+func (p Polyline) Bounds() (min, max mgl32.Vec3) {
+	if len(p) == 0 {
+		return
+	}
+	min, max = p[0], p[0]
+	for _, v := range p[1:] {
+		for k := 0; k < 3; k++ {
+			if v[k] < min[k] {
+				min[k] = v[k]
+			}
+			if v[k] > max[k] {
+				max[k] = v[k]
+			}
+		}
+	}
+	return min, max
+}
+
+// Fit scales and moves the points, keeping their proportions, so that
+// their bounds are centred on the origin and the longest side is size
+// long, e.g. 2 to fill clip space.
+func (p Polyline) Fit(size float32) {
+	min, max := p.Bounds()
+	centre := min.Add(max).Mul(0.5)
+	d := max.Sub(min)
+	longest := mgl32.Abs(d[0])
+	if d[1] > longest {
+		longest = d[1]
+	}
+	if d[2] > longest {
+		longest = d[2]
+	}
+	scale := float32(1)
+	if longest > 0 {
+		scale = size / longest
+	}
+	for i := range p {
+		p[i] = p[i].Sub(centre).Mul(scale)
+	}
+}
+
+// Join joins polylines into one, in order, for drawing with a single line
+// strip. Each join adds a segment from the end of one to the start of the
+// next.
+func Join(lines []Polyline) Polyline {
+	n := 0
+	for _, l := range lines {
+		n += len(l)
+	}
+	joined := make(Polyline, 0, n)
+	for _, l := range lines {
+		joined = append(joined, l...)
+	}
+	return joined
+}
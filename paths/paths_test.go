@@ -0,0 +1,123 @@
+package paths
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestPointCounts(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name string
+		p    Polyline
+		want int
+	}{
+		{"Hilbert", Hilbert(3, 2), 64},
+		{"Peano", Peano(2, 2), 81},
+		{"Dragon", Dragon(5, 2), 33},
+		{"LatticeWalk", LatticeWalk(r, 100, 1, 2), 101},
+		{"RandomWalk", RandomWalk(r, 100, 1, 3), 101},
+		{"LevyFlight", LevyFlight(r, 100, 0.1, 1.5, 3), 101},
+		{"SelfAvoidingWalk", SelfAvoidingWalk(r, 50, 1, 3, 100), 51},
+		{"Lorenz", Lorenz(r, 100, 0.01), 101},
+		{"Rossler", Rossler(r, 100, 0.01), 101},
+		{"Clifford", Clifford(r, 100, -1.4, 1.6, 1.0, 0.7), 101},
+		{"no steps", RandomWalk(r, 0, 1, 3), 1},
+	}
+	for _, tt := range tests {
+		if len(tt.p) != tt.want {
+			t.Errorf("%s has %d points, want %d", tt.name, len(tt.p), tt.want)
+		}
+	}
+}
+
+// cells returns the grid cells of a path whose points are 1 apart. The test
+// fails if a point is off the grid or a step is not one cell along an axis.
+func cells(t *testing.T, p Polyline) []mgl32.Vec3 {
+	t.Helper()
+	c := make([]mgl32.Vec3, len(p))
+	for i, v := range p {
+		for k := 0; k < 3; k++ {
+			c[i][k] = mgl32.Round(v[k], 0)
+			if d := v[k] - c[i][k]; d < -1e-3 || d > 1e-3 {
+				t.Fatalf("point %d at %v is off the grid", i, v)
+			}
+		}
+		if i > 0 {
+			d := c[i].Sub(c[i-1])
+			if d.Dot(d) != 1 {
+				t.Fatalf("step %d from %v to %v is not one cell along an axis", i, c[i-1], c[i])
+			}
+		}
+	}
+	return c
+}
+
+func TestSpaceFillingCurves(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve func(order int, size float32) Polyline
+		base  int // cells along a side at order 1
+	}{
+		{"Hilbert", Hilbert, 2},
+		{"Peano", Peano, 3},
+	}
+	for _, tt := range tests {
+		n := 1
+		for order := 1; order <= 3; order++ {
+			n *= tt.base
+			// Sized so the points are 1 apart, moved so they are whole.
+			p := tt.curve(order, float32(n-1))
+			for i := range p {
+				p[i] = p[i].Add(mgl32.Vec3{float32(n-1) / 2, float32(n-1) / 2, 0})
+			}
+
+			seen := make(map[mgl32.Vec3]bool)
+			for _, c := range cells(t, p) {
+				if c[0] < 0 || c[0] >= float32(n) || c[1] < 0 || c[1] >= float32(n) || c[2] != 0 {
+					t.Fatalf("%s order %d: cell %v is outside the %dx%d grid", tt.name, order, c, n, n)
+				}
+				if seen[c] {
+					t.Fatalf("%s order %d visits %v twice", tt.name, order, c)
+				}
+				seen[c] = true
+			}
+			if len(seen) != n*n {
+				t.Errorf("%s order %d visits %d cells, want %d", tt.name, order, len(seen), n*n)
+			}
+		}
+	}
+}
+
+func TestSelfAvoidingWalk(t *testing.T) {
+	for _, dims := range []int{2, 3} {
+		for seed := int64(0); seed < 20; seed++ {
+			p := SelfAvoidingWalk(rand.New(rand.NewSource(seed)), 200, 1, dims, 10)
+			if len(p) < 2 || len(p) > 201 {
+				t.Fatalf("%dD seed %d: %d points", dims, seed, len(p))
+			}
+			seen := make(map[mgl32.Vec3]bool)
+			for i, c := range cells(t, p) {
+				if seen[c] {
+					t.Fatalf("%dD seed %d: point %d revisits %v", dims, seed, i, c)
+				}
+				if dims == 2 && c[2] != 0 {
+					t.Fatalf("2D seed %d: point %d at %v leaves the plane", seed, i, c)
+				}
+				seen[c] = true
+			}
+		}
+	}
+}
+
+func TestSameSeedSamePath(t *testing.T) {
+	a := RandomWalk(rand.New(rand.NewSource(7)), 50, 1, 3)
+	b := RandomWalk(rand.New(rand.NewSource(7)), 50, 1, 3)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("point %d differs: %v and %v", i, a[i], b[i])
+		}
+	}
+}
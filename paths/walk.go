@@ -0,0 +1,114 @@
+package paths
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// axes are the lattice directions: ±X and ±Y in two dimensions, and ±Z as
+// well in three.
+var axes = [6]mgl32.Vec3{{-1, 0, 0}, {1, 0, 0}, {0, -1, 0}, {0, 1, 0}, {0, 0, -1}, {0, 0, 1}}
+
+// directions returns the lattice directions for 2 or 3 dimensions; any
+// other number is taken as 3.
+func directions(dims int) []mgl32.Vec3 {
+	if dims == 2 {
+		return axes[:4]
+	}
+	return axes[:]
+}
+
+// LatticeWalk returns a random walk of steps steps from the origin, each
+// step long along a lattice direction chosen at random: in the XY plane if
+// dims is 2, and in space if it is 3. The path has steps+1 points.
+func LatticeWalk(r *rand.Rand, steps int, step float32, dims int) Polyline {
+	dirs := directions(dims)
+	p := make(Polyline, steps+1)
+	for i := 1; i <= steps; i++ {
+		p[i] = p[i-1].Add(dirs[r.Intn(len(dirs))].Mul(step))
+	}
+	return p
+}
+
+// randomDirection returns a unit vector uniformly distributed over the
+// circle in the XY plane if dims is 2, or over the sphere.
+func randomDirection(r *rand.Rand, dims int) mgl32.Vec3 {
+	a := 2 * math.Pi * r.Float64()
+	if dims == 2 {
+		return mgl32.Vec3{float32(math.Cos(a)), float32(math.Sin(a)), 0}
+	}
+	z := 2*r.Float64() - 1
+	s := math.Sqrt(1 - z*z)
+	return mgl32.Vec3{float32(s * math.Cos(a)), float32(s * math.Sin(a)), float32(z)}
+}
+
+// RandomWalk returns a random walk of steps steps from the origin, each
+// step long in a direction chosen uniformly at random, in the XY plane if
+// dims is 2.
+func RandomWalk(r *rand.Rand, steps int, step float32, dims int) Polyline {
+	p := make(Polyline, steps+1)
+	for i := 1; i <= steps; i++ {
+		p[i] = p[i-1].Add(randomDirection(r, dims).Mul(step))
+	}
+	return p
+}
+
+// LevyFlight returns a Lévy flight of steps steps from the origin: a walk
+// in random directions whose step lengths follow a power law, at least
+// minStep long with P(length > l) = (minStep/l)^alpha. For alpha between 0
+// and 2 the odd long jump dominates, so the path looks like clusters of
+// short steps joined by long flights. Steps are cut at 1000 times minStep,
+// so a rare jump does not dwarf the rest.
+func LevyFlight(r *rand.Rand, steps int, minStep, alpha float32, dims int) Polyline {
+	p := make(Polyline, steps+1)
+	for i := 1; i <= steps; i++ {
+		u := 1 - r.Float64() // in (0, 1], so the power is finite
+		l := float64(minStep) * math.Pow(u, -1/float64(alpha))
+		if l > 1000*float64(minStep) {
+			l = 1000 * float64(minStep)
+		}
+		p[i] = p[i-1].Add(randomDirection(r, dims).Mul(float32(l)))
+	}
+	return p
+}
+
+// SelfAvoidingWalk returns a lattice walk of up to steps steps that never
+// visits a point twice, in the XY plane if dims is 2. Each step is chosen
+// at random from the neighbours not yet visited, so a walk can trap itself
+// before it is done; the walk is then started again, up to attempts times
+// in all, and the longest is returned.
+func SelfAvoidingWalk(r *rand.Rand, steps int, step float32, dims, attempts int) Polyline {
+	dirs := directions(dims)
+	var best Polyline
+	for a := 0; a < attempts || a == 0; a++ {
+		type cell [3]int32
+		visited := map[cell]bool{{}: true}
+		var at cell
+		p := Polyline{{}}
+		free := make([]cell, 0, len(dirs))
+		for len(p) <= steps {
+			free = free[:0]
+			for _, d := range dirs {
+				c := cell{at[0] + int32(d[0]), at[1] + int32(d[1]), at[2] + int32(d[2])}
+				if !visited[c] {
+					free = append(free, c)
+				}
+			}
+			if len(free) == 0 {
+				break
+			}
+			at = free[r.Intn(len(free))]
+			visited[at] = true
+			p = append(p, mgl32.Vec3{float32(at[0]), float32(at[1]), float32(at[2])}.Mul(step))
+		}
+		if len(p) > len(best) {
+			best = p
+		}
+		if len(best) > steps {
+			break
+		}
+	}
+	return best
+}
@@ -0,0 +1,138 @@
+package pick
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// bvhLeafSize is the most triangles a BVH leaf holds.
+const bvhLeafSize = 4
+
+// BVH is a bounding volume hierarchy over a triangle list.
+type BVH struct {
+	positions []mgl32.Vec3
+	indices   []uint32
+	// tris is the triangle numbers, reordered so each node owns a range.
+	tris  []int
+	nodes []bvhNode
+}
+
+type bvhNode struct {
+	box bounds
+	// Leaves own tris[start:end]. Inner nodes have their children at
+	// left and left+1.
+	start, end int
+	left       int
+}
+
+func (n *bvhNode) leaf() bool { return n.left == 0 }
+
+// NewBVH builds a BVH over triangles. indices holds three vertex numbers per
+// triangle; if it is nil, positions is a plain triangle list as drawn by
+// gl.DrawArrays(gl.TRIANGLES, ...).
+func NewBVH(positions []mgl32.Vec3, indices []uint32) *BVH {
+	if indices == nil {
+		indices = make([]uint32, len(positions)/3*3)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	b := &BVH{
+		positions: positions,
+		indices:   indices,
+		tris:      make([]int, len(indices)/3),
+	}
+	for i := range b.tris {
+		b.tris[i] = i
+	}
+
+	if len(b.tris) > 0 {
+		b.nodes = make([]bvhNode, 1, 2*len(b.tris)/bvhLeafSize+1)
+		b.build(0, 0, len(b.tris))
+	}
+
+	return b
+}
+
+func (b *BVH) triangle(t int) (mgl32.Vec3, mgl32.Vec3, mgl32.Vec3) {
+	i := b.indices[3*t : 3*t+3]
+	return b.positions[i[0]], b.positions[i[1]], b.positions[i[2]]
+}
+
+func (b *BVH) centroid(t int) mgl32.Vec3 {
+	p0, p1, p2 := b.triangle(t)
+	return p0.Add(p1).Add(p2).Mul(1.0 / 3)
+}
+
+// build fills in node n for tris[start:end], splitting at the median centroid
+// along the longest axis.
+func (b *BVH) build(n, start, end int) {
+	box := emptyBounds()
+	centres := emptyBounds()
+	for _, t := range b.tris[start:end] {
+		p0, p1, p2 := b.triangle(t)
+		box.add(p0)
+		box.add(p1)
+		box.add(p2)
+		centres.add(b.centroid(t))
+	}
+	b.nodes[n] = bvhNode{box: box, start: start, end: end}
+
+	if end-start <= bvhLeafSize {
+		return
+	}
+
+	axis := centres.longestAxis()
+	tris := b.tris[start:end]
+	sort.Slice(tris, func(i, j int) bool {
+		return b.centroid(tris[i])[axis] < b.centroid(tris[j])[axis]
+	})
+	mid := start + (end-start)/2
+
+	left := len(b.nodes)
+	b.nodes = append(b.nodes, bvhNode{}, bvhNode{})
+	b.nodes[n].left = left
+
+	b.build(left, start, mid)
+	b.build(left+1, mid, end)
+}
+
+// IntersectRay returns the nearest triangle hit by r.
+func (b *BVH) IntersectRay(r camera.Ray) (Hit, bool) {
+	var best Hit
+	found := false
+
+	if len(b.nodes) == 0 {
+		return best, false
+	}
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		n := &b.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		tmin, _, ok := intersectBox(r, n.box.min, n.box.max)
+		if !ok || (found && tmin > best.T) {
+			continue
+		}
+
+		if !n.leaf() {
+			stack = append(stack, n.left, n.left+1)
+			continue
+		}
+
+		for _, t := range b.tris[n.start:n.end] {
+			p0, p1, p2 := b.triangle(t)
+			d, u, v, ok := intersectTriangle(r, p0, p1, p2)
+			if ok && (!found || d < best.T) {
+				best = Hit{Index: t, T: d, Point: r.At(d), U: u, V: v}
+				found = true
+			}
+		}
+	}
+
+	return best, found
+}
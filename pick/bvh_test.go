@@ -0,0 +1,81 @@
+package pick
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// bruteForceTriangles tests r against every triangle.
+func bruteForceTriangles(r camera.Ray, positions []mgl32.Vec3, indices []uint32) (Hit, bool) {
+	var best Hit
+	found := false
+	for t := 0; t < len(indices)/3; t++ {
+		i := indices[3*t : 3*t+3]
+		d, u, v, ok := intersectTriangle(r, positions[i[0]], positions[i[1]], positions[i[2]])
+		if ok && (!found || d < best.T) {
+			best = Hit{Index: t, T: d, Point: r.At(d), U: u, V: v}
+			found = true
+		}
+	}
+	return best, found
+}
+
+func TestBVH(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, n := range []int{1, 3, 50, 1000} {
+		// Small triangles scattered through a cube, sharing vertices.
+		positions := make([]mgl32.Vec3, 0, 3*n)
+		indices := make([]uint32, 0, 3*n)
+		for i := 0; i < n; i++ {
+			centre := randomVec3(r, 1)
+			for k := 0; k < 3; k++ {
+				positions = append(positions, centre.Add(randomVec3(r, 0.2)))
+			}
+			if i > 0 && r.Intn(2) == 0 {
+				// Reuse a vertex of the last triangle.
+				indices = append(indices, uint32(len(positions)-4))
+			} else {
+				indices = append(indices, uint32(len(positions)-3))
+			}
+			indices = append(indices, uint32(len(positions)-2), uint32(len(positions)-1))
+		}
+
+		bvh := NewBVH(positions, indices)
+		hits := 0
+		for i := 0; i < 2000; i++ {
+			ray := randomRay(r, 1)
+			got, gotOK := bvh.IntersectRay(ray)
+			want, wantOK := bruteForceTriangles(ray, positions, indices)
+			if gotOK != wantOK || got != want {
+				t.Fatalf("%d triangles, ray %v: got %+v, %v, want %+v, %v", n, ray, got, gotOK, want, wantOK)
+			}
+			if gotOK {
+				hits++
+			}
+		}
+		if n >= 50 && hits == 0 {
+			t.Errorf("%d triangles: no hits", n)
+		}
+	}
+}
+
+func TestBVHTriangleList(t *testing.T) {
+	// Without indices, every three positions are a triangle.
+	positions := []mgl32.Vec3{
+		{-1, -1, 0}, {1, -1, 0}, {0, 1, 0},
+		{-1, -1, -1}, {1, -1, -1}, {0, 1, -1},
+		{5, 5, 5}, // not a whole triangle
+	}
+	bvh := NewBVH(positions, nil)
+	hit, ok := bvh.IntersectRay(camera.Ray{Origin: mgl32.Vec3{0, 0, -5}, Dir: mgl32.Vec3{0, 0, 1}})
+	if !ok || hit.Index != 1 || hit.T != 4 {
+		t.Errorf("got %+v, %v, want triangle 1 at 4", hit, ok)
+	}
+
+	if _, ok := NewBVH(nil, nil).IntersectRay(camera.Ray{Dir: mgl32.Vec3{0, 0, 1}}); ok {
+		t.Error("hit an empty BVH")
+	}
+}
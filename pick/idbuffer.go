@@ -0,0 +1,122 @@
+package pick
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// IDFragmentShader writes the IDs an IDBuffer expects. Pair it with the
+// vertex shader used for normal drawing and set objectID (never 0, which is
+// the background) before each draw call.
+var IDFragmentShader = `
+#version 430 core
+
+uniform uint objectID;
+
+out uvec2 id;
+
+void main() {
+	// gl_PrimitiveID counts points, lines or triangles from the start of
+	// the draw call.
+	id = uvec2(objectID, uint(gl_PrimitiveID));
+}
+` + "\x00"
+
+// IDBuffer is an off-screen framebuffer holding an object ID and a primitive
+// ID per pixel, plus a depth buffer.
+type IDBuffer struct {
+	Width, Height int
+
+	fbo   uint32
+	ids   uint32
+	depth uint32
+
+	// framebuffer and viewport are those Begin found, for End to restore.
+	framebuffer int32
+	viewport    [4]int32
+}
+
+// NewIDBuffer creates a width x height ID buffer.
+func NewIDBuffer(width, height int) (*IDBuffer, error) {
+	b := &IDBuffer{}
+	gl.GenFramebuffers(1, &b.fbo)
+	gl.GenRenderbuffers(1, &b.ids)
+	gl.GenRenderbuffers(1, &b.depth)
+
+	if err := b.Resize(width, height); err != nil {
+		b.Delete()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Resize reallocates the attachments, e.g. after the window has been resized.
+func (b *IDBuffer) Resize(width, height int) error {
+	b.Width, b.Height = width, height
+
+	gl.BindRenderbuffer(gl.RENDERBUFFER, b.ids)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.RG32UI, int32(width), int32(height))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, b.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT32F, int32(width), int32(height))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, b.fbo)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, b.ids)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, b.depth)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("ID buffer is incomplete: status 0x%x", status)
+	}
+	return nil
+}
+
+// Begin binds the buffer for drawing, sets the viewport to cover it and
+// clears it to ID 0. clearDepth is 1 for a normal depth test and 0 for
+// reverse-Z.
+func (b *IDBuffer) Begin(clearDepth float32) {
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &b.framebuffer)
+	gl.GetIntegerv(gl.VIEWPORT, &b.viewport[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, b.fbo)
+	gl.Viewport(0, 0, int32(b.Width), int32(b.Height))
+
+	zero := [4]uint32{}
+	gl.ClearBufferuiv(gl.COLOR, 0, &zero[0])
+	gl.ClearBufferfv(gl.DEPTH, 0, &clearDepth)
+}
+
+// End goes back to the framebuffer and viewport that were in use when Begin
+// was called.
+func (b *IDBuffer) End() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(b.framebuffer))
+	gl.Viewport(b.viewport[0], b.viewport[1], b.viewport[2], b.viewport[3])
+}
+
+// Read returns the IDs and window depth (0 to 1) at framebuffer pixel x, y,
+// measured from the top left. object is 0 where nothing was drawn.
+func (b *IDBuffer) Read(x, y int) (object, primitive uint32, depth float32) {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return 0, 0, 0
+	}
+	y = b.Height - 1 - y
+
+	var id [2]uint32
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, b.fbo)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.ReadPixels(int32(x), int32(y), 1, 1, gl.RG_INTEGER, gl.UNSIGNED_INT, gl.Ptr(&id[0]))
+	gl.ReadPixels(int32(x), int32(y), 1, 1, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(&depth))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+
+	return id[0], id[1], depth
+}
+
+// Delete frees the GL objects.
+func (b *IDBuffer) Delete() {
+	gl.DeleteFramebuffers(1, &b.fbo)
+	gl.DeleteRenderbuffers(1, &b.ids)
+	gl.DeleteRenderbuffers(1, &b.depth)
+}
@@ -0,0 +1,119 @@
+package pick
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// kdLeafSize is the most points a kd-tree leaf holds.
+const kdLeafSize = 8
+
+// KDTree is a kd-tree over a point cloud.
+type KDTree struct {
+	// Radius is how close, in model units, the ray has to pass to a point
+	// to hit it.
+	Radius float32
+
+	points []mgl32.Vec3
+	// order is the point numbers, reordered so each node owns a range.
+	order []int
+	nodes []kdNode
+}
+
+type kdNode struct {
+	box        bounds
+	start, end int
+	left       int
+}
+
+// NewKDTree builds a kd-tree over points.
+func NewKDTree(points []mgl32.Vec3, radius float32) *KDTree {
+	k := &KDTree{
+		Radius: radius,
+		points: points,
+		order:  make([]int, len(points)),
+	}
+	for i := range k.order {
+		k.order[i] = i
+	}
+
+	if len(points) > 0 {
+		k.nodes = make([]kdNode, 1, 2*len(points)/kdLeafSize+1)
+		k.build(0, 0, len(points))
+	}
+
+	return k
+}
+
+func (k *KDTree) build(n, start, end int) {
+	box := emptyBounds()
+	for _, i := range k.order[start:end] {
+		box.add(k.points[i])
+	}
+	k.nodes[n] = kdNode{box: box, start: start, end: end}
+
+	if end-start <= kdLeafSize {
+		return
+	}
+
+	axis := box.longestAxis()
+	order := k.order[start:end]
+	sort.Slice(order, func(i, j int) bool {
+		return k.points[order[i]][axis] < k.points[order[j]][axis]
+	})
+	mid := start + (end-start)/2
+
+	left := len(k.nodes)
+	k.nodes = append(k.nodes, kdNode{}, kdNode{})
+	k.nodes[n].left = left
+
+	k.build(left, start, mid)
+	k.build(left+1, mid, end)
+}
+
+// IntersectRay returns the point nearest the start of r that lies within
+// Radius of it.
+func (k *KDTree) IntersectRay(r camera.Ray) (Hit, bool) {
+	var best Hit
+	found := false
+
+	if len(k.nodes) == 0 {
+		return best, false
+	}
+
+	pad := mgl32.Vec3{k.Radius, k.Radius, k.Radius}
+	r2 := k.Radius * k.Radius
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		n := &k.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		tmin, _, ok := intersectBox(r, n.box.min.Sub(pad), n.box.max.Add(pad))
+		if !ok || (found && tmin > best.T) {
+			continue
+		}
+
+		if n.left != 0 {
+			stack = append(stack, n.left, n.left+1)
+			continue
+		}
+
+		for _, i := range k.order[n.start:n.end] {
+			p := k.points[i]
+			d := p.Sub(r.Origin)
+			t := d.Dot(r.Dir)
+			if t < 0 || (found && t >= best.T) {
+				continue
+			}
+			if d.Dot(d)-t*t <= r2 {
+				best = Hit{Index: i, T: t, Point: p}
+				found = true
+			}
+		}
+	}
+
+	return best, found
+}
@@ -0,0 +1,66 @@
+package pick
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// bruteForcePoints tests r against every point.
+func bruteForcePoints(r camera.Ray, points []mgl32.Vec3, radius float32) (Hit, bool) {
+	var best Hit
+	found := false
+	for i, p := range points {
+		d := p.Sub(r.Origin)
+		t := d.Dot(r.Dir)
+		if t < 0 || (found && t >= best.T) {
+			continue
+		}
+		if d.Dot(d)-t*t <= radius*radius {
+			best = Hit{Index: i, T: t, Point: p}
+			found = true
+		}
+	}
+	return best, found
+}
+
+func TestKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, n := range []int{1, 8, 9, 100, 5000} {
+		points := make([]mgl32.Vec3, n)
+		for i := range points {
+			points[i] = randomVec3(r, 1)
+		}
+		// A flat cloud, to split along a degenerate axis.
+		if n == 100 {
+			for i := range points {
+				points[i][2] = 0
+			}
+		}
+
+		for _, radius := range []float32{0.01, 0.1} {
+			k := NewKDTree(points, radius)
+			hits := 0
+			for i := 0; i < 2000; i++ {
+				ray := randomRay(r, 1)
+				got, gotOK := k.IntersectRay(ray)
+				want, wantOK := bruteForcePoints(ray, points, radius)
+				if gotOK != wantOK || got != want {
+					t.Fatalf("%d points, radius %v, ray %v: got %+v, %v, want %+v, %v", n, radius, ray, got, gotOK, want, wantOK)
+				}
+				if gotOK {
+					hits++
+				}
+			}
+			if n >= 100 && hits == 0 {
+				t.Errorf("%d points, radius %v: no hits", n, radius)
+			}
+		}
+	}
+
+	if _, ok := NewKDTree(nil, 1).IntersectRay(camera.Ray{Dir: mgl32.Vec3{0, 0, 1}}); ok {
+		t.Error("hit an empty kd-tree")
+	}
+}
@@ -0,0 +1,131 @@
+// Package pick finds what is under the mouse.
+//
+// Two strategies are offered. The CPU strategy casts a ray from the camera
+// (see camera.RayFromPixel) against copies of the mesh data kept on the Go
+// side: a BVH for triangles and a kd-tree for point clouds. The GPU strategy
+// renders object and primitive IDs into an integer framebuffer (IDBuffer) and
+// reads back the single pixel under the cursor.
+//
+// A Picker ties either strategy to a window's mouse button and delivers the
+// results as Events on a channel, which the render loop drains.
+package pick
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// Hit is a ray intersection with one primitive of a Shape, in the shape's own
+// (model) space.
+type Hit struct {
+	// Index is the point index, or the triangle index (first vertex / 3).
+	Index int
+	// T is the distance along the ray.
+	T float32
+	// Point is where the ray meets the primitive. For points it is the point
+	// itself.
+	Point mgl32.Vec3
+	// U and V are the barycentric coordinates of Point for triangles.
+	U, V float32
+}
+
+// Shape is something a ray can be cast against.
+type Shape interface {
+	IntersectRay(r camera.Ray) (Hit, bool)
+}
+
+// intersectTriangle is the Möller–Trumbore ray/triangle test. Both faces are
+// hit.
+func intersectTriangle(r camera.Ray, a, b, c mgl32.Vec3) (t, u, v float32, ok bool) {
+	const epsilon = 1e-7
+
+	e1 := b.Sub(a)
+	e2 := c.Sub(a)
+	p := r.Dir.Cross(e2)
+	det := e1.Dot(p)
+	if det > -epsilon && det < epsilon {
+		return 0, 0, 0, false
+	}
+	inv := 1 / det
+
+	s := r.Origin.Sub(a)
+	u = s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+
+	q := s.Cross(e1)
+	v = r.Dir.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+
+	t = e2.Dot(q) * inv
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+
+	return t, u, v, true
+}
+
+// intersectBox is the slab test. It returns the distances at which the ray
+// enters and leaves the box min, max.
+func intersectBox(r camera.Ray, min, max mgl32.Vec3) (tmin, tmax float32, ok bool) {
+	tmin, tmax = 0, float32(math.Inf(1))
+
+	for i := 0; i < 3; i++ {
+		inv := 1 / r.Dir[i]
+		t0 := (min[i] - r.Origin[i]) * inv
+		t1 := (max[i] - r.Origin[i]) * inv
+		if inv < 0 {
+			t0, t1 = t1, t0
+		}
+		// NaN (0 * Inf) compares false and leaves tmin/tmax alone.
+		if t0 > tmin {
+			tmin = t0
+		}
+		if t1 < tmax {
+			tmax = t1
+		}
+		if tmax < tmin {
+			return 0, 0, false
+		}
+	}
+
+	return tmin, tmax, true
+}
+
+// bounds is an axis-aligned bounding box.
+type bounds struct {
+	min, max mgl32.Vec3
+}
+
+func emptyBounds() bounds {
+	inf := float32(math.Inf(1))
+	return bounds{mgl32.Vec3{inf, inf, inf}, mgl32.Vec3{-inf, -inf, -inf}}
+}
+
+func (b *bounds) add(p mgl32.Vec3) {
+	for i := 0; i < 3; i++ {
+		if p[i] < b.min[i] {
+			b.min[i] = p[i]
+		}
+		if p[i] > b.max[i] {
+			b.max[i] = p[i]
+		}
+	}
+}
+
+// longestAxis returns 0, 1 or 2 for X, Y or Z.
+func (b bounds) longestAxis() int {
+	d := b.max.Sub(b.min)
+	if d[0] >= d[1] && d[0] >= d[2] {
+		return 0
+	}
+	if d[1] >= d[2] {
+		return 1
+	}
+	return 2
+}
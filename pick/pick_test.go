@@ -0,0 +1,83 @@
+package pick
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// randomVec3 returns a point in the cube from -size to size.
+func randomVec3(r *rand.Rand, size float32) mgl32.Vec3 {
+	return mgl32.Vec3{
+		size * (2*r.Float32() - 1),
+		size * (2*r.Float32() - 1),
+		size * (2*r.Float32() - 1),
+	}
+}
+
+// randomRay returns a ray from outside the cube from -size to size towards
+// a point inside it.
+func randomRay(r *rand.Rand, size float32) camera.Ray {
+	origin := randomVec3(r, 1).Normalize().Mul(3 * size)
+	return camera.Ray{Origin: origin, Dir: randomVec3(r, size).Sub(origin).Normalize()}
+}
+
+func TestIntersectTriangle(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		a, b, c := randomVec3(r, 1), randomVec3(r, 1), randomVec3(r, 1)
+		ray := randomRay(r, 1)
+
+		// Meet the triangle's plane, then find the barycentric coordinates
+		// of that point by area.
+		e1, e2 := b.Sub(a), c.Sub(a)
+		n := e1.Cross(e2)
+		if math.Abs(float64(ray.Dir.Dot(n))) < 1e-3*float64(n.Len()) {
+			continue // nearly edge on
+		}
+		wantT := a.Sub(ray.Origin).Dot(n) / ray.Dir.Dot(n)
+		p := ray.At(wantT).Sub(a)
+		wantU := p.Cross(e2).Dot(n) / n.Dot(n)
+		wantV := e1.Cross(p).Dot(n) / n.Dot(n)
+		inside := wantT >= 0 && wantU >= 0 && wantV >= 0 && wantU+wantV <= 1
+
+		// Leave out rays that graze an edge, where rounding decides.
+		const margin = 1e-4
+		if min(abs(wantU), abs(wantV), abs(1-wantU-wantV)) < margin {
+			continue
+		}
+
+		gotT, gotU, gotV, ok := intersectTriangle(ray, a, b, c)
+		if ok != inside {
+			t.Fatalf("ray %v and triangle %v %v %v: hit %v, want %v", ray, a, b, c, ok, inside)
+		}
+		if !ok {
+			continue
+		}
+		hits++
+		if abs(gotT-wantT) > 1e-4 || abs(gotU-wantU) > 1e-4 || abs(gotV-wantV) > 1e-4 {
+			t.Errorf("ray %v and triangle %v %v %v: t, u, v are %v, %v, %v, want %v, %v, %v",
+				ray, a, b, c, gotT, gotU, gotV, wantT, wantU, wantV)
+		}
+	}
+	if hits < 100 {
+		t.Errorf("only %d hits", hits)
+	}
+
+	// A ray pointing away does not hit what is behind it.
+	ray := camera.Ray{Origin: mgl32.Vec3{0, 0, 1}, Dir: mgl32.Vec3{0, 0, 1}}
+	if _, _, _, ok := intersectTriangle(ray, mgl32.Vec3{-1, -1, 0}, mgl32.Vec3{1, -1, 0}, mgl32.Vec3{0, 1, 0}); ok {
+		t.Error("hit a triangle behind the ray")
+	}
+}
+
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
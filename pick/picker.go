@@ -0,0 +1,209 @@
+package pick
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+)
+
+// Strategy selects how a Picker finds what is under the cursor.
+type Strategy int
+
+const (
+	// CPU casts a ray against each Object's Shape.
+	CPU Strategy = iota
+	// GPU draws IDs into an IDBuffer (via Picker.DrawIDs) and reads one
+	// pixel back.
+	GPU
+)
+
+// Event reports a successful pick.
+type Event struct {
+	Strategy Strategy
+	// X and Y are the cursor position in window coordinates.
+	X, Y float64
+	// Object is the ID of the Object that was hit.
+	Object uint32
+	// Index is the point or triangle that was hit.
+	Index int
+	// Position is the world-space position of the hit.
+	Position mgl32.Vec3
+	// Distance is from the camera ray origin (near plane) to Position.
+	Distance float32
+}
+
+// Object is a pickable Shape placed in the world by Model. Update Model when
+// the object moves.
+type Object struct {
+	ID    uint32
+	Shape Shape
+	Model mgl32.Mat4
+}
+
+// Picker turns mouse clicks into Events.
+type Picker struct {
+	Camera   *camera.Camera
+	Strategy Strategy
+
+	// Events receives one Event per click that hits something. It is
+	// buffered; events are dropped if nobody drains it.
+	Events chan Event
+
+	// DrawIDs is called by the GPU strategy with the IDBuffer bound. It must
+	// draw every object using IDFragmentShader, setting objectID to the
+	// Object's ID.
+	DrawIDs func(objects []*Object)
+
+	objects []*Object
+	ids     *IDBuffer
+}
+
+// NewPicker returns a Picker looking through cam.
+func NewPicker(cam *camera.Camera) *Picker {
+	return &Picker{
+		Camera: cam,
+		Events: make(chan Event, 16),
+	}
+}
+
+// Add registers a shape and returns its Object. IDs are handed out from 1.
+func (p *Picker) Add(shape Shape, model mgl32.Mat4) *Object {
+	o := &Object{ID: uint32(len(p.objects) + 1), Shape: shape, Model: model}
+	p.objects = append(p.objects, o)
+
+	return o
+}
+
+// Attach makes left clicks in win pick. Any mouse button callback already set
+// on the window is still called.
+func (p *Picker) Attach(win *glfw.Window) {
+	var previous glfw.MouseButtonCallback
+	previous = win.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if previous != nil {
+			previous(w, button, action, mods)
+		}
+		if button == glfw.MouseButtonLeft && action == glfw.Press {
+			x, y := w.GetCursorPos()
+			p.Click(w, x, y)
+		}
+	})
+}
+
+// Click picks at cursor position x, y in win and sends an Event if anything
+// was hit.
+func (p *Picker) Click(win *glfw.Window, x, y float64) {
+	var e Event
+	var ok bool
+
+	switch p.Strategy {
+	case GPU:
+		width, height := win.GetSize()
+		fbWidth, fbHeight := win.GetFramebufferSize()
+		// Cursor positions are in screen coordinates, which differ from
+		// pixels on high-DPI displays.
+		sx := float64(fbWidth) / float64(width)
+		sy := float64(fbHeight) / float64(height)
+		e, ok = p.PickGPU(x*sx, y*sy, fbWidth, fbHeight)
+	default:
+		width, height := win.GetSize()
+		e, ok = p.PickCPU(x, y, width, height)
+	}
+	if !ok {
+		return
+	}
+	e.X, e.Y = x, y
+
+	select {
+	case p.Events <- e:
+	default:
+	}
+}
+
+// PickCPU casts a ray through window position x, y of a width x height
+// viewport and returns the nearest hit over all objects.
+func (p *Picker) PickCPU(x, y float64, width, height int) (Event, bool) {
+	ray := p.Camera.RayFromPixel(x, y, width, height)
+
+	var best Event
+	found := false
+
+	for _, o := range p.objects {
+		inv := o.Model.Inv()
+		local := camera.Ray{
+			Origin: inv.Mul4x1(ray.Origin.Vec4(1)).Vec3(),
+			Dir:    inv.Mul4x1(ray.Dir.Vec4(0)).Vec3().Normalize(),
+		}
+
+		hit, ok := o.Shape.IntersectRay(local)
+		if !ok {
+			continue
+		}
+
+		world := o.Model.Mul4x1(hit.Point.Vec4(1)).Vec3()
+		d := world.Sub(ray.Origin).Len()
+		if !found || d < best.Distance {
+			best = Event{Strategy: CPU, Object: o.ID, Index: hit.Index, Position: world, Distance: d}
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// PickGPU draws the objects' IDs and reads back framebuffer pixel x, y of a
+// width x height framebuffer.
+func (p *Picker) PickGPU(x, y float64, width, height int) (Event, bool) {
+	if p.DrawIDs == nil {
+		return Event{}, false
+	}
+
+	if p.ids == nil {
+		ids, err := NewIDBuffer(width, height)
+		if err != nil {
+			return Event{}, false
+		}
+		p.ids = ids
+	} else if p.ids.Width != width || p.ids.Height != height {
+		if err := p.ids.Resize(width, height); err != nil {
+			return Event{}, false
+		}
+	}
+
+	clearDepth := float32(1)
+	if p.Camera.ReverseZ {
+		clearDepth = 0
+	}
+	p.ids.Begin(clearDepth)
+	p.DrawIDs(p.objects)
+	p.ids.End()
+
+	object, primitive, depth := p.ids.Read(int(x), int(y))
+	if object == 0 {
+		return Event{}, false
+	}
+
+	// Window depth is 0..1; normalized device depth is -1..1 unless the
+	// camera uses reverse-Z's 0..1 clip range.
+	z := depth
+	if !p.Camera.ReverseZ {
+		z = 2*depth - 1
+	}
+	pos := p.Camera.Unproject(float32(x), float32(y), z, width, height)
+	origin := p.Camera.RayFromPixel(x, y, width, height).Origin
+
+	return Event{
+		Strategy: GPU,
+		Object:   object,
+		Index:    int(primitive),
+		Position: pos,
+		Distance: pos.Sub(origin).Len(),
+	}, true
+}
+
+// Delete frees the IDBuffer used by the GPU strategy.
+func (p *Picker) Delete() {
+	if p.ids != nil {
+		p.ids.Delete()
+		p.ids = nil
+	}
+}
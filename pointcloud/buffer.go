@@ -0,0 +1,218 @@
+package pointcloud
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Vertex is the interleaved layout of a Buffer.
+type Vertex struct {
+	Position mgl32.Vec3
+	Color    mgl32.Vec4
+	Normal   mgl32.Vec3
+}
+
+// Attribute locations used by Buffer's vertex array.
+const (
+	PositionLocation = 0
+	ColorLocation    = 1
+	NormalLocation   = 2
+)
+
+// Buffer is a vertex buffer of points that can be added to a chunk at a time.
+// Points without colours are white.
+type Buffer struct {
+	VAO, VBO uint32
+	// Count is the number of points uploaded; Capacity is how many fit
+	// before the buffer has to grow.
+	Count, Capacity int
+	// Min and Max bound the points uploaded so far.
+	Min, Max mgl32.Vec3
+}
+
+// NewBuffer creates an empty buffer with room for capacity points.
+func NewBuffer(capacity int) *Buffer {
+	b := &Buffer{}
+	gl.GenVertexArrays(1, &b.VAO)
+	b.allocate(capacity)
+	return b
+}
+
+// allocate makes a new VBO for capacity points, copying over the points
+// already uploaded.
+func (b *Buffer) allocate(capacity int) {
+	if capacity < 1 {
+		capacity = 1
+	}
+	size := int(unsafe.Sizeof(Vertex{}))
+
+	var vbo uint32
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, capacity*size, nil, gl.DYNAMIC_DRAW)
+
+	if b.VBO != 0 {
+		gl.BindBuffer(gl.COPY_READ_BUFFER, b.VBO)
+		gl.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.ARRAY_BUFFER, 0, 0, b.Count*size)
+		gl.DeleteBuffers(1, &b.VBO)
+	}
+	b.VBO, b.Capacity = vbo, capacity
+
+	stride := int32(size)
+	gl.BindVertexArray(b.VAO)
+	gl.VertexAttribPointer(PositionLocation, 3, gl.FLOAT, false, stride, gl.PtrOffset(int(unsafe.Offsetof(Vertex{}.Position))))
+	gl.VertexAttribPointer(ColorLocation, 4, gl.FLOAT, false, stride, gl.PtrOffset(int(unsafe.Offsetof(Vertex{}.Color))))
+	gl.VertexAttribPointer(NormalLocation, 3, gl.FLOAT, false, stride, gl.PtrOffset(int(unsafe.Offsetof(Vertex{}.Normal))))
+	gl.EnableVertexAttribArray(PositionLocation)
+	gl.EnableVertexAttribArray(ColorLocation)
+	gl.EnableVertexAttribArray(NormalLocation)
+}
+
+// Append uploads the points of c after those already in the buffer, growing
+// it if need be.
+func (b *Buffer) Append(c *Cloud) {
+	n := c.Len()
+	if n == 0 {
+		return
+	}
+
+	if b.Count+n > b.Capacity {
+		capacity := 2 * b.Capacity
+		if capacity < b.Count+n {
+			capacity = b.Count + n
+		}
+		b.allocate(capacity)
+	}
+
+	vertices := make([]Vertex, n)
+	for i, p := range c.Positions {
+		v := &vertices[i]
+		v.Position = p
+		v.Color = mgl32.Vec4{1, 1, 1, 1}
+		if c.Colors != nil {
+			v.Color = c.Colors[i]
+		}
+		if c.Normals != nil {
+			v.Normal = c.Normals[i]
+		}
+	}
+
+	min, max := c.Bounds()
+	if b.Count == 0 {
+		b.Min, b.Max = min, max
+	}
+	for i := 0; i < 3; i++ {
+		if min[i] < b.Min[i] {
+			b.Min[i] = min[i]
+		}
+		if max[i] > b.Max[i] {
+			b.Max[i] = max[i]
+		}
+	}
+
+	size := int(unsafe.Sizeof(Vertex{}))
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.VBO)
+	gl.BufferSubData(gl.ARRAY_BUFFER, b.Count*size, n*size, gl.Ptr(vertices))
+	b.Count += n
+}
+
+// Clear empties the buffer, keeping its capacity, so that points that move
+// can be uploaded again each frame.
+func (b *Buffer) Clear() {
+	b.Count = 0
+	b.Min, b.Max = mgl32.Vec3{}, mgl32.Vec3{}
+}
+
+// Draw draws the points with the current program.
+func (b *Buffer) Draw() {
+	gl.BindVertexArray(b.VAO)
+	gl.DrawArrays(gl.POINTS, 0, int32(b.Count))
+}
+
+// Delete frees the GL objects.
+func (b *Buffer) Delete() {
+	gl.DeleteBuffers(1, &b.VBO)
+	gl.DeleteVertexArrays(1, &b.VAO)
+}
+
+// Loader reads a point cloud file on its own goroutine. GL calls have to
+// stay on the thread that owns the context, so the chunks it reads are
+// uploaded by calling Poll from the render loop.
+type Loader struct {
+	// Total is the number of points in the file, or -1 if the format does
+	// not say.
+	Total int
+
+	chunks chan *Cloud
+	err    error
+	done   bool
+}
+
+// StartLoading opens path and starts reading it chunkSize points at a time.
+// Errors in the header are returned straight away; later ones come from
+// Poll.
+func StartLoading(path string, chunkSize int) (*Loader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f, path)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	l := &Loader{Total: r.Len(), chunks: make(chan *Cloud, 4)}
+
+	go func() {
+		defer f.Close()
+		defer close(l.chunks)
+		for {
+			c, err := r.Next(chunkSize)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				// Written before the channel is closed, so Poll sees
+				// it once it has drained the chunks.
+				l.err = fmt.Errorf("%s: %v", path, err)
+				return
+			}
+			l.chunks <- c
+		}
+	}()
+
+	return l, nil
+}
+
+// NewBuffer returns a buffer sized for the whole file when its length is
+// known.
+func (l *Loader) NewBuffer() *Buffer {
+	if l.Total > 0 {
+		return NewBuffer(l.Total)
+	}
+	return NewBuffer(ChunkSize)
+}
+
+// Poll uploads to b whatever chunks have been read since the last call,
+// without waiting for more. It returns true once the whole file is loaded.
+func (l *Loader) Poll(b *Buffer) (done bool, err error) {
+	for !l.done {
+		select {
+		case c, ok := <-l.chunks:
+			if !ok {
+				l.done = true
+				return true, l.err
+			}
+			b.Append(c)
+		default:
+			return false, nil
+		}
+	}
+	return true, l.err
+}
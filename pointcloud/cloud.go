@@ -0,0 +1,188 @@
+// Package pointcloud reads and writes point clouds in PLY (ASCII and binary,
+// either byte order) and plain XYZ/CSV text files, and uploads them to the
+// GPU for drawing as points.
+//
+// Files are read in chunks through a Reader, so a cloud never has to fit in
+// memory all at once. A Loader parses a file on another goroutine and hands
+// its chunks to a Buffer on the GL thread as they arrive, so points appear
+// while the rest of the file is still being read.
+package pointcloud
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ChunkSize is the number of points Load and Loader read at a time.
+const ChunkSize = 1 << 16
+
+// Cloud is a set of points. Normals and Colors are nil when the points do
+// not have them; otherwise they have one entry per position.
+type Cloud struct {
+	Positions []mgl32.Vec3
+	Normals   []mgl32.Vec3
+	// Colors are RGBA in [0, 1].
+	Colors []mgl32.Vec4
+	// Scalars are any other per-point values, such as intensity.
+	Scalars []Scalar
+}
+
+// Scalar is a named per-point value.
+type Scalar struct {
+	Name   string
+	Values []float32
+}
+
+// Len returns the number of points.
+func (c *Cloud) Len() int {
+	return len(c.Positions)
+}
+
+// Scalar returns the values of the named scalar, or nil.
+func (c *Cloud) Scalar(name string) []float32 {
+	for _, s := range c.Scalars {
+		if s.Name == name {
+			return s.Values
+		}
+	}
+	return nil
+}
+
+// Append adds the points of o to c. Attributes that only one of the two
+// clouds has are filled with zeros (or white, for colours) on the other.
+func (c *Cloud) Append(o *Cloud) {
+	n, m := c.Len(), o.Len()
+
+	if c.Normals != nil || o.Normals != nil {
+		c.Normals = appendOrPad(c.Normals, n, o.Normals, m, mgl32.Vec3{})
+	}
+	if c.Colors != nil || o.Colors != nil {
+		c.Colors = appendOrPad(c.Colors, n, o.Colors, m, mgl32.Vec4{1, 1, 1, 1})
+	}
+
+	for _, s := range o.Scalars {
+		if c.Scalar(s.Name) == nil {
+			c.Scalars = append(c.Scalars, Scalar{Name: s.Name, Values: make([]float32, n)})
+		}
+	}
+	for i := range c.Scalars {
+		s := &c.Scalars[i]
+		s.Values = appendOrPad(s.Values, n, o.Scalar(s.Name), m, 0)
+	}
+
+	c.Positions = append(c.Positions, o.Positions...)
+}
+
+// appendOrPad appends b (of length m) to a (of length n), using pad for
+// whichever of them is nil.
+func appendOrPad[T any](a []T, n int, b []T, m int, pad T) []T {
+	for len(a) < n {
+		a = append(a, pad)
+	}
+	if b != nil {
+		return append(a, b...)
+	}
+	for i := 0; i < m; i++ {
+		a = append(a, pad)
+	}
+	return a
+}
+
+// Bounds returns the axis-aligned box around the points.
+func (c *Cloud) Bounds() (min, max mgl32.Vec3) {
+	if c.Len() == 0 {
+		return
+	}
+	min, max = c.Positions[0], c.Positions[0]
+	for _, p := range c.Positions[1:] {
+		for i := 0; i < 3; i++ {
+			if p[i] < min[i] {
+				min[i] = p[i]
+			}
+			if p[i] > max[i] {
+				max[i] = p[i]
+			}
+		}
+	}
+	return min, max
+}
+
+// Reader reads a cloud a chunk at a time.
+type Reader interface {
+	// Next returns up to max more points. It returns io.EOF, and no
+	// cloud, once every point has been read.
+	Next(max int) (*Cloud, error)
+	// Len returns the number of points in the file, or -1 if the format
+	// does not say.
+	Len() int
+}
+
+// NewReader returns a reader for r, choosing the format from the extension
+// of name: .ply, or .xyz, .txt, .csv and .pts for text.
+func NewReader(r io.Reader, name string) (Reader, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ply":
+		return NewPLYReader(r)
+	case ".xyz", ".txt", ".csv", ".pts":
+		return NewXYZReader(r)
+	}
+	return nil, fmt.Errorf("%s: unknown point cloud format", name)
+}
+
+// Load reads the whole point cloud at path.
+func Load(path string) (*Cloud, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := NewReader(f, path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cloud{}
+	for {
+		chunk, err := r.Next(ChunkSize)
+		if err == io.EOF {
+			return c, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		c.Append(chunk)
+	}
+}
+
+// Save writes c to path, choosing the format from its extension: .ply is
+// written as binary little-endian PLY, .csv as comma separated values with a
+// header line and anything else as space separated XYZ.
+func Save(path string, c *Cloud) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ply":
+		err = WritePLY(f, c, BinaryLittleEndian)
+	case ".csv":
+		err = WriteCSV(f, c)
+	default:
+		err = WriteXYZ(f, c)
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,470 @@
+package pointcloud
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Format is the encoding of a PLY file's body.
+type Format int
+
+const (
+	ASCII Format = iota
+	BinaryLittleEndian
+	BinaryBigEndian
+)
+
+var formatNames = map[string]Format{
+	"ascii":                ASCII,
+	"binary_little_endian": BinaryLittleEndian,
+	"binary_big_endian":    BinaryBigEndian,
+}
+
+func (f Format) String() string {
+	for name, g := range formatNames {
+		if f == g {
+			return name
+		}
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// plyType is a PLY scalar type.
+type plyType struct {
+	size    int
+	float   bool
+	signed  bool
+	maxUint float64 // for normalizing colours
+}
+
+var plyTypes = map[string]plyType{
+	"char": {1, false, true, 127}, "int8": {1, false, true, 127},
+	"uchar": {1, false, false, 255}, "uint8": {1, false, false, 255},
+	"short": {2, false, true, 32767}, "int16": {2, false, true, 32767},
+	"ushort": {2, false, false, 65535}, "uint16": {2, false, false, 65535},
+	"int": {4, false, true, math.MaxInt32}, "int32": {4, false, true, math.MaxInt32},
+	"uint": {4, false, false, math.MaxUint32}, "uint32": {4, false, false, math.MaxUint32},
+	"float": {4, true, true, 1}, "float32": {4, true, true, 1},
+	"double": {8, true, true, 1}, "float64": {8, true, true, 1},
+}
+
+type plyProperty struct {
+	name string
+	typ  plyType
+	// count is the type of a list's length; nil for a plain property.
+	count *plyType
+}
+
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// Where each vertex property goes.
+const (
+	toScalar = iota
+	toPosition
+	toNormal
+	toColor
+	toSkip
+)
+
+// PLYReader reads the vertices of a PLY file. Other elements, such as faces,
+// are skipped.
+type PLYReader struct {
+	Format   Format
+	Comments []string
+
+	r        *bufio.Reader
+	order    binary.ByteOrder
+	elements []plyElement
+	vertex   int // index of the vertex element
+	read     int // vertices read so far
+	started  bool
+	scratch  [8]byte
+
+	// Per vertex property: its destination and component within it.
+	dest      []int
+	component []int
+	scalars   []string
+	hasNormal bool
+	hasColor  bool
+	hasAlpha  bool
+}
+
+// NewPLYReader reads the header from r.
+func NewPLYReader(r io.Reader) (*PLYReader, error) {
+	p := &PLYReader{r: bufio.NewReaderSize(r, 1<<16), vertex: -1}
+
+	line := 0
+	next := func() ([]string, error) {
+		s, err := p.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("PLY header is missing end_header")
+			}
+			return nil, err
+		}
+		line++
+		return strings.Fields(s), nil
+	}
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("PLY header line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+
+	fields, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 1 || fields[0] != "ply" {
+		return nil, fmt.Errorf("not a PLY file")
+	}
+
+	hasFormat := false
+	for {
+		fields, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return nil, errorf("bad format line")
+			}
+			f, ok := formatNames[fields[1]]
+			if !ok {
+				return nil, errorf("unknown format %q", fields[1])
+			}
+			p.Format, hasFormat = f, true
+
+		case "comment", "obj_info":
+			p.Comments = append(p.Comments, strings.Join(fields[1:], " "))
+
+		case "element":
+			if len(fields) != 3 {
+				return nil, errorf("bad element line")
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n < 0 {
+				return nil, errorf("bad element count %q", fields[2])
+			}
+			if fields[1] == "vertex" && p.vertex < 0 {
+				p.vertex = len(p.elements)
+			}
+			p.elements = append(p.elements, plyElement{name: fields[1], count: n})
+
+		case "property":
+			if len(p.elements) == 0 {
+				return nil, errorf("property before any element")
+			}
+			e := &p.elements[len(p.elements)-1]
+			var prop plyProperty
+			switch {
+			case len(fields) == 3:
+				t, ok := plyTypes[fields[1]]
+				if !ok {
+					return nil, errorf("unknown type %q", fields[1])
+				}
+				prop = plyProperty{name: fields[2], typ: t}
+			case len(fields) == 5 && fields[1] == "list":
+				c, ok := plyTypes[fields[2]]
+				t, ok2 := plyTypes[fields[3]]
+				if !ok || !ok2 || c.float {
+					return nil, errorf("bad list property")
+				}
+				prop = plyProperty{name: fields[4], typ: t, count: &c}
+			default:
+				return nil, errorf("bad property line")
+			}
+			e.properties = append(e.properties, prop)
+
+		case "end_header":
+			if !hasFormat {
+				return nil, fmt.Errorf("PLY header has no format")
+			}
+			if p.vertex < 0 {
+				return nil, fmt.Errorf("PLY file has no vertex element")
+			}
+			p.order = binary.LittleEndian
+			if p.Format == BinaryBigEndian {
+				p.order = binary.BigEndian
+			}
+			p.mapProperties()
+			return p, nil
+
+		default:
+			return nil, errorf("unknown keyword %q", fields[0])
+		}
+	}
+}
+
+// mapProperties decides where each vertex property is stored.
+func (p *PLYReader) mapProperties() {
+	for _, prop := range p.elements[p.vertex].properties {
+		dest, comp := toScalar, 0
+		switch {
+		case prop.count != nil:
+			// Lists on vertices have no place in a Cloud.
+			dest = toSkip
+		case prop.name == "x" || prop.name == "y" || prop.name == "z":
+			dest, comp = toPosition, int(prop.name[0]-'x')
+		case prop.name == "nx" || prop.name == "ny" || prop.name == "nz":
+			dest, comp = toNormal, int(prop.name[1]-'x')
+			p.hasNormal = true
+		case colorComponent(prop.name) >= 0:
+			dest, comp = toColor, colorComponent(prop.name)
+			p.hasColor = true
+			p.hasAlpha = p.hasAlpha || comp == 3
+		default:
+			p.scalars = append(p.scalars, prop.name)
+		}
+		p.dest = append(p.dest, dest)
+		p.component = append(p.component, comp)
+	}
+}
+
+// Len returns the number of vertices.
+func (p *PLYReader) Len() int {
+	return p.elements[p.vertex].count
+}
+
+// Next reads up to max more vertices.
+func (p *PLYReader) Next(max int) (*Cloud, error) {
+	if !p.started {
+		p.started = true
+		for _, e := range p.elements[:p.vertex] {
+			for i := 0; i < e.count; i++ {
+				if err := p.skipElement(e); err != nil {
+					return nil, fmt.Errorf("reading %s %d: %v", e.name, i, err)
+				}
+			}
+		}
+	}
+
+	n := p.Len() - p.read
+	if n == 0 {
+		return nil, io.EOF
+	}
+	if n > max {
+		n = max
+	}
+
+	c := &Cloud{Positions: make([]mgl32.Vec3, n)}
+	if p.hasNormal {
+		c.Normals = make([]mgl32.Vec3, n)
+	}
+	if p.hasColor {
+		c.Colors = make([]mgl32.Vec4, n)
+	}
+	for _, name := range p.scalars {
+		c.Scalars = append(c.Scalars, Scalar{Name: name, Values: make([]float32, n)})
+	}
+
+	e := p.elements[p.vertex]
+	for i := 0; i < n; i++ {
+		if c.Colors != nil && !p.hasAlpha {
+			c.Colors[i][3] = 1
+		}
+		s := 0
+		for j, prop := range e.properties {
+			if p.dest[j] == toSkip {
+				if err := p.skipList(prop); err != nil {
+					return nil, fmt.Errorf("reading vertex %d: %v", p.read+i, err)
+				}
+				continue
+			}
+
+			v, err := p.value(prop.typ)
+			if err != nil {
+				return nil, fmt.Errorf("reading vertex %d: %v", p.read+i, err)
+			}
+
+			switch p.dest[j] {
+			case toPosition:
+				c.Positions[i][p.component[j]] = float32(v)
+			case toNormal:
+				c.Normals[i][p.component[j]] = float32(v)
+			case toColor:
+				if !prop.typ.float {
+					v /= prop.typ.maxUint
+				}
+				c.Colors[i][p.component[j]] = float32(v)
+			case toScalar:
+				c.Scalars[s].Values[i] = float32(v)
+				s++
+			}
+		}
+	}
+	p.read += n
+
+	return c, nil
+}
+
+// value reads one number.
+func (p *PLYReader) value(t plyType) (float64, error) {
+	if p.Format == ASCII {
+		word, err := p.word()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseFloat(word, 64)
+	}
+
+	b := p.scratch[:t.size]
+	if _, err := io.ReadFull(p.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	switch {
+	case t.float && t.size == 4:
+		return float64(math.Float32frombits(p.order.Uint32(b))), nil
+	case t.float:
+		return math.Float64frombits(p.order.Uint64(b)), nil
+	case t.size == 1 && t.signed:
+		return float64(int8(b[0])), nil
+	case t.size == 1:
+		return float64(b[0]), nil
+	case t.size == 2 && t.signed:
+		return float64(int16(p.order.Uint16(b))), nil
+	case t.size == 2:
+		return float64(p.order.Uint16(b)), nil
+	case t.signed:
+		return float64(int32(p.order.Uint32(b))), nil
+	}
+	return float64(p.order.Uint32(b)), nil
+}
+
+// word reads the next whitespace separated word of an ASCII body.
+func (p *PLYReader) word() (string, error) {
+	var b []byte
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(b) > 0 {
+				return string(b), nil
+			}
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			if len(b) > 0 {
+				return string(b), nil
+			}
+			continue
+		}
+		b = append(b, c)
+	}
+}
+
+func (p *PLYReader) skipList(prop plyProperty) error {
+	n, err := p.value(*prop.count)
+	if err != nil {
+		return err
+	}
+	for k := 0; k < int(n); k++ {
+		if _, err := p.value(prop.typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PLYReader) skipElement(e plyElement) error {
+	for _, prop := range e.properties {
+		var err error
+		if prop.count != nil {
+			err = p.skipList(prop)
+		} else {
+			_, err = p.value(prop.typ)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePLY writes c as a PLY file in the given format. Positions, normals
+// and scalars are written as floats and colours as unsigned bytes.
+func WritePLY(w io.Writer, c *Cloud, f Format) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "ply\nformat %s 1.0\ncomment written by GopenGL\n", f)
+	fmt.Fprintf(bw, "element vertex %d\n", c.Len())
+	fmt.Fprintf(bw, "property float x\nproperty float y\nproperty float z\n")
+	if c.Normals != nil {
+		fmt.Fprintf(bw, "property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	if c.Colors != nil {
+		fmt.Fprintf(bw, "property uchar red\nproperty uchar green\nproperty uchar blue\nproperty uchar alpha\n")
+	}
+	for _, s := range c.Scalars {
+		fmt.Fprintf(bw, "property float %s\n", s.Name)
+	}
+	fmt.Fprintf(bw, "end_header\n")
+
+	var order binary.AppendByteOrder = binary.LittleEndian
+	if f == BinaryBigEndian {
+		order = binary.BigEndian
+	}
+
+	var buf []byte
+	floats := func(v ...float32) {
+		for _, x := range v {
+			if f == ASCII {
+				buf = strconv.AppendFloat(buf, float64(x), 'g', -1, 32)
+				buf = append(buf, ' ')
+			} else {
+				buf = order.AppendUint32(buf, math.Float32bits(x))
+			}
+		}
+	}
+
+	for i, p := range c.Positions {
+		buf = buf[:0]
+		floats(p[0], p[1], p[2])
+		if c.Normals != nil {
+			n := c.Normals[i]
+			floats(n[0], n[1], n[2])
+		}
+		if c.Colors != nil {
+			for _, x := range c.Colors[i] {
+				b := byte(mgl32.Clamp(x, 0, 1)*255 + 0.5)
+				if f == ASCII {
+					buf = strconv.AppendUint(buf, uint64(b), 10)
+					buf = append(buf, ' ')
+				} else {
+					buf = append(buf, b)
+				}
+			}
+		}
+		for _, s := range c.Scalars {
+			floats(s.Values[i])
+		}
+		if f == ASCII {
+			buf[len(buf)-1] = '\n'
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
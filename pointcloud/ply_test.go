@@ -0,0 +1,125 @@
+package pointcloud
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testCloud returns n random points with normals, colours that survive
+// being stored as bytes, and an intensity.
+func testCloud(n int) *Cloud {
+	r := rand.New(rand.NewSource(1))
+	c := &Cloud{
+		Positions: make([]mgl32.Vec3, n),
+		Normals:   make([]mgl32.Vec3, n),
+		Colors:    make([]mgl32.Vec4, n),
+		Scalars:   []Scalar{{Name: "intensity", Values: make([]float32, n)}},
+	}
+	for i := 0; i < n; i++ {
+		c.Positions[i] = mgl32.Vec3{r.Float32()*200 - 100, r.Float32() * 1e-3, r.Float32() * 1e6}
+		c.Normals[i] = mgl32.Vec3{r.Float32(), r.Float32(), r.Float32()}.Normalize()
+		for k := range c.Colors[i] {
+			c.Colors[i][k] = float32(float64(r.Intn(256)) / 255)
+		}
+		c.Scalars[0].Values[i] = r.Float32()
+	}
+	return c
+}
+
+// readAll reads every point from r, a few at a time.
+func readAll(t *testing.T, r Reader) *Cloud {
+	t.Helper()
+	c := &Cloud{}
+	for {
+		chunk, err := r.Next(7)
+		if err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			return c
+		}
+		c.Append(chunk)
+	}
+}
+
+func TestPLYRoundTrip(t *testing.T) {
+	want := testCloud(100)
+	for _, f := range []Format{ASCII, BinaryLittleEndian, BinaryBigEndian} {
+		t.Run(f.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WritePLY(&buf, want, f); err != nil {
+				t.Fatal(err)
+			}
+			r, err := NewPLYReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Format != f || r.Len() != want.Len() {
+				t.Fatalf("read format %v with %d points, want %v with %d", r.Format, r.Len(), f, want.Len())
+			}
+			if got := readAll(t, r); !reflect.DeepEqual(got, want) {
+				t.Errorf("read back a different cloud")
+			}
+		})
+	}
+}
+
+func TestPLYSkipsOtherElements(t *testing.T) {
+	const file = `ply
+format ascii 1.0
+element material 1
+property uchar red
+property list uchar int ids
+element vertex 2
+property double x
+property double y
+property double z
+property list uchar int faces
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+end_header
+255 2 7 8
+1 2 3 0 255 0 0
+4 5 6 2 0 1 0 255 0
+3 0 1 1
+`
+	r, err := NewPLYReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readAll(t, r)
+	want := &Cloud{
+		Positions: []mgl32.Vec3{{1, 2, 3}, {4, 5, 6}},
+		Colors:    []mgl32.Vec4{{1, 0, 0, 1}, {0, 1, 0, 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	want := testCloud(50)
+	for _, ext := range []string{".ply", ".xyz", ".csv"} {
+		path := filepath.Join(t.TempDir(), "cloud"+ext)
+		if err := Save(path, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: loaded a different cloud", ext)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package pointcloud
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// XYZReader reads points from text, one per line, with the values separated
+// by spaces, tabs, commas or semicolons. Lines starting with # are comments.
+//
+// The columns are named by a header line, either of words (as in a CSV
+// file) or a comment starting with // (as CloudCompare writes). The names x,
+// y, z, nx, ny, nz, r, g, b, a (or red, green, blue, alpha) are recognised and
+// any others become scalars. Without a header the columns are taken to be
+//
+//	x y z
+//	x y z intensity
+//	x y z r g b
+//	x y z intensity r g b
+//	x y z r g b nx ny nz
+//
+// by how many there are. Colours are integers from 0 to 255. A line holding
+// a single integer before the first point, the point count that PTS files
+// start with, is skipped.
+type XYZReader struct {
+	r       *bufio.Reader
+	line    int
+	pending []string
+
+	dest      []int
+	component []int
+	scalars   []string
+	hasNormal bool
+	hasColor  bool
+	hasAlpha  bool
+}
+
+// NewXYZReader reads up to the first point, to find the columns.
+func NewXYZReader(r io.Reader) (*XYZReader, error) {
+	x := &XYZReader{r: bufio.NewReaderSize(r, 1<<16)}
+
+	counted := false
+	for {
+		text, err := x.readLine()
+		if err == io.EOF {
+			return x, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		header := strings.HasPrefix(text, "//")
+		fields := splitFields(strings.TrimPrefix(text, "//"))
+		if len(fields) == 0 {
+			continue
+		}
+		if !header {
+			if _, err := strconv.Atoi(fields[0]); err == nil && len(fields) == 1 && !counted {
+				// The point count at the top of a PTS file.
+				counted = true
+				continue
+			}
+			if _, err := strconv.ParseFloat(fields[0], 32); err == nil {
+				// No header; guess from the first point.
+				x.pending = fields
+				return x, x.guessColumns(len(fields))
+			}
+		}
+		return x, x.namedColumns(fields)
+	}
+}
+
+// readLine returns the next line that is not a comment.
+func (x *XYZReader) readLine() (string, error) {
+	for {
+		s, err := x.r.ReadString('\n')
+		if err != nil && (err != io.EOF || s == "") {
+			return "", err
+		}
+		x.line++
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, "#") {
+			continue
+		}
+		return s, nil
+	}
+}
+
+func splitFields(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == ';'
+	})
+}
+
+// colorComponent returns which of r, g, b, a a property or column name is,
+// or -1.
+func colorComponent(name string) int {
+	switch strings.TrimPrefix(name, "diffuse_") {
+	case "r", "red":
+		return 0
+	case "g", "green":
+		return 1
+	case "b", "blue":
+		return 2
+	case "a", "alpha":
+		return 3
+	}
+	return -1
+}
+
+func (x *XYZReader) namedColumns(names []string) error {
+	for _, name := range names {
+		name = strings.Trim(name, `"'`)
+		lower := strings.ToLower(name)
+		dest, comp := toScalar, 0
+		switch {
+		case lower == "x" || lower == "y" || lower == "z":
+			dest, comp = toPosition, int(lower[0]-'x')
+		case lower == "nx" || lower == "ny" || lower == "nz":
+			dest, comp = toNormal, int(lower[1]-'x')
+			x.hasNormal = true
+		case colorComponent(lower) >= 0:
+			dest, comp = toColor, colorComponent(lower)
+			x.hasColor = true
+			x.hasAlpha = x.hasAlpha || comp == 3
+		default:
+			x.scalars = append(x.scalars, name)
+		}
+		x.dest = append(x.dest, dest)
+		x.component = append(x.component, comp)
+	}
+
+	axes := 0
+	for _, d := range x.dest {
+		if d == toPosition {
+			axes++
+		}
+	}
+	if axes != 3 {
+		return fmt.Errorf("line %d: the header needs x, y and z columns", x.line)
+	}
+	return nil
+}
+
+func (x *XYZReader) guessColumns(n int) error {
+	layouts := map[int][]string{
+		3: {"x", "y", "z"},
+		4: {"x", "y", "z", "intensity"},
+		6: {"x", "y", "z", "r", "g", "b"},
+		7: {"x", "y", "z", "intensity", "r", "g", "b"},
+		9: {"x", "y", "z", "r", "g", "b", "nx", "ny", "nz"},
+	}
+	names, ok := layouts[n]
+	if !ok {
+		return fmt.Errorf("line %d: cannot tell what %d columns without a header are", x.line, n)
+	}
+	return x.namedColumns(names)
+}
+
+// Len returns -1, as text files do not say how many points they hold.
+func (x *XYZReader) Len() int {
+	return -1
+}
+
+// Next reads up to max more points.
+func (x *XYZReader) Next(max int) (*Cloud, error) {
+	c := &Cloud{}
+	if x.hasNormal {
+		c.Normals = []mgl32.Vec3{}
+	}
+	if x.hasColor {
+		c.Colors = []mgl32.Vec4{}
+	}
+	for _, name := range x.scalars {
+		c.Scalars = append(c.Scalars, Scalar{Name: name})
+	}
+
+	for c.Len() < max {
+		fields := x.pending
+		x.pending = nil
+		if fields == nil {
+			text, err := x.readLine()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if fields = splitFields(text); len(fields) == 0 {
+				continue
+			}
+		}
+		if len(fields) != len(x.dest) {
+			return nil, fmt.Errorf("line %d: %d columns, expected %d", x.line, len(fields), len(x.dest))
+		}
+
+		var p, n mgl32.Vec3
+		col := mgl32.Vec4{0, 0, 0, 1}
+		s := 0
+		for j, f := range fields {
+			v, err := strconv.ParseFloat(f, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad number %q", x.line, f)
+			}
+			switch x.dest[j] {
+			case toPosition:
+				p[x.component[j]] = float32(v)
+			case toNormal:
+				n[x.component[j]] = float32(v)
+			case toColor:
+				col[x.component[j]] = float32(v / 255)
+			case toScalar:
+				c.Scalars[s].Values = append(c.Scalars[s].Values, float32(v))
+				s++
+			}
+		}
+
+		c.Positions = append(c.Positions, p)
+		if x.hasNormal {
+			c.Normals = append(c.Normals, n)
+		}
+		if x.hasColor {
+			c.Colors = append(c.Colors, col)
+		}
+	}
+
+	if c.Len() == 0 {
+		return nil, io.EOF
+	}
+	return c, nil
+}
+
+// WriteXYZ writes c as space separated text, with a // header line naming
+// the columns.
+func WriteXYZ(w io.Writer, c *Cloud) error {
+	return writeText(w, c, ' ', "//")
+}
+
+// WriteCSV writes c as comma separated values with a header line.
+func WriteCSV(w io.Writer, c *Cloud) error {
+	return writeText(w, c, ',', "")
+}
+
+func writeText(w io.Writer, c *Cloud, sep byte, headerPrefix string) error {
+	bw := bufio.NewWriter(w)
+
+	names := []string{"x", "y", "z"}
+	if c.Colors != nil {
+		names = append(names, "r", "g", "b", "a")
+	}
+	if c.Normals != nil {
+		names = append(names, "nx", "ny", "nz")
+	}
+	for _, s := range c.Scalars {
+		names = append(names, s.Name)
+	}
+	fmt.Fprintf(bw, "%s%s\n", headerPrefix, strings.Join(names, string(sep)))
+
+	var buf []byte
+	floats := func(v ...float32) {
+		for _, f := range v {
+			buf = strconv.AppendFloat(buf, float64(f), 'g', -1, 32)
+			buf = append(buf, sep)
+		}
+	}
+
+	for i, p := range c.Positions {
+		buf = buf[:0]
+		floats(p[0], p[1], p[2])
+		if c.Colors != nil {
+			for _, f := range c.Colors[i] {
+				buf = strconv.AppendInt(buf, int64(mgl32.Clamp(f, 0, 1)*255+0.5), 10)
+				buf = append(buf, sep)
+			}
+		}
+		if c.Normals != nil {
+			n := c.Normals[i]
+			floats(n[0], n[1], n[2])
+		}
+		for _, s := range c.Scalars {
+			floats(s.Values[i])
+		}
+		buf[len(buf)-1] = '\n'
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,105 @@
+package pointcloud
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestXYZRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(*bytes.Buffer, *Cloud) error
+	}{
+		{"XYZ", func(b *bytes.Buffer, c *Cloud) error { return WriteXYZ(b, c) }},
+		{"CSV", func(b *bytes.Buffer, c *Cloud) error { return WriteCSV(b, c) }},
+	}
+	for _, tt := range tests {
+		for _, want := range []*Cloud{testCloud(100), {Positions: testCloud(10).Positions}} {
+			var buf bytes.Buffer
+			if err := tt.write(&buf, want); err != nil {
+				t.Fatal(err)
+			}
+			r, err := NewXYZReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, r); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: read back a different cloud", tt.name)
+			}
+		}
+	}
+}
+
+func TestPTS(t *testing.T) {
+	// PTS files start with the number of points, then give the position,
+	// intensity and colour of each.
+	const file = `3
+1 2 3 -100 255 0 0
+4 5 6 0 0 255 0
+7 8 9 100 0 0 255
+`
+	r, err := NewReader(strings.NewReader(file), "scan.pts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readAll(t, r)
+	want := &Cloud{
+		Positions: []mgl32.Vec3{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}},
+		Colors:    []mgl32.Vec4{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 1}},
+		Scalars:   []Scalar{{Name: "intensity", Values: []float32{-100, 0, 100}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestXYZColumns(t *testing.T) {
+	tests := []struct {
+		name, file string
+		want       *Cloud
+	}{
+		{
+			"no header",
+			"# a comment\n1 2 3\n\n4 5 6\n",
+			&Cloud{Positions: []mgl32.Vec3{{1, 2, 3}, {4, 5, 6}}},
+		},
+		{
+			"named columns in any order",
+			"Z;X;Y;temperature\n3;1;2;20.5\n",
+			&Cloud{
+				Positions: []mgl32.Vec3{{1, 2, 3}},
+				Scalars:   []Scalar{{Name: "temperature", Values: []float32{20.5}}},
+			},
+		},
+		{
+			"a point count",
+			"1\n1 2 3\n",
+			&Cloud{Positions: []mgl32.Vec3{{1, 2, 3}}},
+		},
+	}
+	for _, tt := range tests {
+		r, err := NewXYZReader(strings.NewReader(tt.file))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := readAll(t, r); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	errors := []struct {
+		name, file, err string
+	}{
+		{"two counts", "1\n2\n1 2 3\n", "line 2: cannot tell what 1 columns without a header are"},
+		{"no z", "x y\n1 2\n", "line 1: the header needs x, y and z columns"},
+	}
+	for _, tt := range errors {
+		if _, err := NewXYZReader(strings.NewReader(tt.file)); err == nil || err.Error() != tt.err {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
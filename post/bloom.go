@@ -0,0 +1,145 @@
+package post
+
+import (
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/framebuffer"
+)
+
+var brightShader = `
+#version 330
+
+uniform sampler2D source;
+uniform float threshold;
+uniform float knee;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    vec3 c = texture(source, uv).rgb;
+    float brightness = max(c.r, max(c.g, c.b));
+    // A soft threshold: a quadratic ramp over the knee below it.
+    float soft = clamp(brightness - threshold + knee, 0.0, 2.0 * knee);
+    soft = soft * soft / (4.0 * knee + 1e-5);
+    float contribution = max(soft, brightness - threshold) / max(brightness, 1e-5);
+    outputColor = vec4(c * contribution, 1);
+}
+` + "\x00"
+
+var compositeShader = `
+#version 330
+
+uniform sampler2D source;
+uniform sampler2D bloom;
+uniform float intensity;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    vec4 c = texture(source, uv);
+    outputColor = vec4(c.rgb + texture(bloom, uv).rgb * intensity, c.a);
+}
+` + "\x00"
+
+// Bloom is an effect, "bloom", that makes bright parts of the image glow:
+// it keeps what is brighter than Threshold, blurs it at half resolution and
+// adds it back. It belongs before tone mapping, while colours above 1 still
+// mark what is bright. Its fields can be changed between runs.
+type Bloom struct {
+	// Threshold is the brightness, the largest of red, green and blue, from
+	// which pixels glow.
+	Threshold float32
+	// Knee is the width of the ramp below Threshold over which the glow fades
+	// in, so there is no hard edge.
+	Knee float32
+	// Intensity scales the glow added back.
+	Intensity float32
+	// Radius and Sigma shape each blur pass, in half resolution pixels, as
+	// in GaussianBlur.
+	Radius int
+	Sigma  float32
+	// Passes is the number of times the glow is blurred; more passes spread
+	// it further.
+	Passes int
+
+	bright    *Shader
+	composite *Shader
+	blur      *blur
+	half      [2]*framebuffer.Framebuffer
+}
+
+// NewBloom returns a bloom with a threshold of 1, a knee of 0.5, an
+// intensity of 1 and two passes of radius 8.
+func NewBloom() (*Bloom, error) {
+	b := &Bloom{Threshold: 1, Knee: 0.5, Intensity: 1, Radius: 8, Passes: 2}
+	var err error
+	if b.bright, err = NewShader("bloom bright pass", brightShader); err != nil {
+		b.Delete()
+		return nil, err
+	}
+	if b.composite, err = NewShader("bloom composite", compositeShader); err != nil {
+		b.Delete()
+		return nil, err
+	}
+	if b.blur, err = newBlur(); err != nil {
+		b.Delete()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Name returns "bloom".
+func (b *Bloom) Name() string {
+	return "bloom"
+}
+
+// Apply draws source with its glow added into target.
+func (b *Bloom) Apply(c *Chain, source uint32, target *framebuffer.Framebuffer) error {
+	width, height := c.Width/2, c.Height/2
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	for i := range b.half {
+		if err := resizeTarget(&b.half[i], width, height); err != nil {
+			return err
+		}
+	}
+
+	b.bright.Set("threshold", b.Threshold)
+	b.bright.Set("knee", b.Knee)
+	b.bright.Draw(c, source, c.Width, c.Height, b.half[0])
+
+	b.blur.setKernel(b.Radius, b.Sigma)
+	for i := 0; i < b.Passes; i++ {
+		b.blur.draw(c, b.half[0].Textures[0], width, height, b.half[1], b.half[0])
+	}
+
+	// The half size textures change when the chain is resized.
+	b.composite.SetTexture("bloom", gl.TEXTURE_2D, b.half[0].Textures[0])
+	b.composite.Set("intensity", b.Intensity)
+	return b.composite.Apply(c, source, target)
+}
+
+// Delete frees the effect.
+func (b *Bloom) Delete() {
+	for _, s := range []*Shader{b.bright, b.composite} {
+		if s != nil {
+			s.Delete()
+		}
+	}
+	if b.blur != nil {
+		b.blur.delete()
+	}
+	for i, t := range b.half {
+		if t != nil {
+			t.Delete()
+			b.half[i] = nil
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package post
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/purelazy/GopenGL/framebuffer"
+)
+
+// maxBlurRadius is the largest radius the weights array in blurShader holds.
+const maxBlurRadius = 32
+
+var blurShader = `
+#version 330
+
+uniform sampler2D source;
+uniform vec2 texelSize;
+uniform vec2 direction;
+uniform int radius;
+uniform float weights[33];
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    vec2 step = direction * texelSize;
+    vec4 sum = texture(source, uv) * weights[0];
+    for (int i = 1; i <= radius; i++) {
+        sum += texture(source, uv + step * float(i)) * weights[i];
+        sum += texture(source, uv - step * float(i)) * weights[i];
+    }
+    outputColor = sum;
+}
+` + "\x00"
+
+// gaussianWeights returns the weights of the centre pixel and the radius
+// pixels either side of it, normalised so that all 2*radius+1 sum to 1.
+func gaussianWeights(radius int, sigma float32) []float32 {
+	weights := make([]float32, radius+1)
+	var sum float64
+	for i := range weights {
+		w := math.Exp(-float64(i*i) / (2 * float64(sigma) * float64(sigma)))
+		weights[i] = float32(w)
+		if i == 0 {
+			sum += w
+		} else {
+			sum += 2 * w
+		}
+	}
+	for i := range weights {
+		weights[i] = float32(float64(weights[i]) / sum)
+	}
+	return weights
+}
+
+// blur draws a separable Gaussian blur: horizontally from source into temp,
+// then vertically from temp into target.
+type blur struct {
+	shader *Shader
+
+	radius int
+	sigma  float32
+}
+
+func newBlur() (*blur, error) {
+	s, err := NewShader("blur", blurShader)
+	if err != nil {
+		return nil, err
+	}
+	return &blur{shader: s}, nil
+}
+
+// setKernel sets the weights for radius and sigma, if they have changed.
+// Radius is limited to maxBlurRadius, and sigma defaults to radius/2.
+func (b *blur) setKernel(radius int, sigma float32) {
+	if radius > maxBlurRadius {
+		radius = maxBlurRadius
+	}
+	if radius < 0 {
+		radius = 0
+	}
+	if sigma <= 0 {
+		sigma = float32(math.Max(float64(radius)/2, 0.5))
+	}
+	if radius == b.radius && sigma == b.sigma {
+		return
+	}
+	b.radius, b.sigma = radius, sigma
+
+	s := b.shader
+	s.SetInt("radius", int32(radius))
+	for i, w := range gaussianWeights(radius, sigma) {
+		// Arrays are set element by element, each with a location of its own.
+		s.Set("weights["+strconv.Itoa(i)+"]", w)
+	}
+}
+
+func (b *blur) draw(c *Chain, source uint32, width, height int, temp, target *framebuffer.Framebuffer) {
+	b.shader.Set("direction", 1, 0)
+	b.shader.Draw(c, source, width, height, temp)
+	b.shader.Set("direction", 0, 1)
+	b.shader.Draw(c, temp.Textures[0], width, height, target)
+}
+
+func (b *blur) delete() {
+	b.shader.Delete()
+}
+
+// GaussianBlur is an effect, "blur", that blurs the whole image. Its
+// fields can be changed between runs.
+type GaussianBlur struct {
+	// Radius is the number of pixels either side that contribute, at most
+	// 32.
+	Radius int
+	// Sigma is the standard deviation of the Gaussian, in pixels. If it is
+	// 0, Radius/2 is used.
+	Sigma float32
+
+	blur *blur
+	temp *framebuffer.Framebuffer
+}
+
+// NewGaussianBlur returns a blur of the given radius.
+func NewGaussianBlur(radius int) (*GaussianBlur, error) {
+	b, err := newBlur()
+	if err != nil {
+		return nil, err
+	}
+	return &GaussianBlur{Radius: radius, blur: b}, nil
+}
+
+// Name returns "blur".
+func (g *GaussianBlur) Name() string {
+	return "blur"
+}
+
+// Apply blurs source into target.
+func (g *GaussianBlur) Apply(c *Chain, source uint32, target *framebuffer.Framebuffer) error {
+	if err := resizeTarget(&g.temp, c.Width, c.Height); err != nil {
+		return err
+	}
+	g.blur.setKernel(g.Radius, g.Sigma)
+	g.blur.draw(c, source, c.Width, c.Height, g.temp, target)
+	return nil
+}
+
+// Delete frees the effect.
+func (g *GaussianBlur) Delete() {
+	g.blur.delete()
+	if g.temp != nil {
+		g.temp.Delete()
+		g.temp = nil
+	}
+}
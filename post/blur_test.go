@@ -0,0 +1,37 @@
+package post
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGaussianWeights(t *testing.T) {
+	tests := []struct {
+		radius int
+		sigma  float32
+	}{
+		{0, 0.5},
+		{1, 0.5},
+		{4, 2},
+		{8, 4},
+		{maxBlurRadius, 16},
+		{maxBlurRadius, 1},
+	}
+	for _, tt := range tests {
+		w := gaussianWeights(tt.radius, tt.sigma)
+		if len(w) != tt.radius+1 {
+			t.Fatalf("radius %d: %d weights, want %d", tt.radius, len(w), tt.radius+1)
+		}
+		// The centre counts once and the others twice, once on each side.
+		sum := float64(w[0])
+		for i := 1; i < len(w); i++ {
+			sum += 2 * float64(w[i])
+			if w[i] > w[i-1] || w[i] < 0 {
+				t.Errorf("radius %d, sigma %v: weight %d is %v after %v", tt.radius, tt.sigma, i, w[i], w[i-1])
+			}
+		}
+		if math.Abs(sum-1) > 1e-6 {
+			t.Errorf("radius %d, sigma %v: weights sum to %v", tt.radius, tt.sigma, sum)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package post applies full-screen effects to a rendered image: tone
+// mapping, gamma, FXAA, bloom, vignette, chromatic aberration, colour
+// grading with a 3D LUT and Gaussian blur, or any effect written as a
+// single fragment shader.
+//
+// Render the scene into a framebuffer, then hand its colour texture to a
+// Chain. The chain runs its enabled effects in order, each reading the
+// previous one's output from one of two ping-pong targets, and the last
+// writing to the screen or a framebuffer of your choice.
+package post
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/framebuffer"
+)
+
+// Effect is a step of a Chain.
+type Effect interface {
+	// Name identifies the effect within a chain.
+	Name() string
+	// Apply reads the texture source, the size of the chain, and draws the
+	// result into target, or the default framebuffer if target is nil. It
+	// returns an error if it cannot, such as when a framebuffer of its own
+	// cannot be allocated.
+	Apply(c *Chain, source uint32, target *framebuffer.Framebuffer) error
+	// Delete frees the effect's GL objects.
+	Delete()
+}
+
+// vertexShader draws a triangle covering the screen, from three vertices
+// with no attributes, and passes on texture coordinates in [0, 1] across
+// the screen.
+var vertexShader = `
+#version 330
+
+out vec2 uv;
+
+void main() {
+    vec2 p = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    uv = p;
+    gl_Position = vec4(p * 2.0 - 1.0, 0, 1);
+}
+` + "\x00"
+
+var copyShader = `
+#version 330
+
+uniform sampler2D source;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    outputColor = texture(source, uv);
+}
+` + "\x00"
+
+type entry struct {
+	effect  Effect
+	enabled bool
+}
+
+// Chain runs effects in order over an image.
+type Chain struct {
+	// Width and Height are the size of the images passing through.
+	Width, Height int
+
+	// Time is given to effects as the uniform time, in seconds, for those
+	// that animate.
+	Time float32
+
+	entries []entry
+	targets [2]*framebuffer.Framebuffer
+	vao     uint32
+	copy    *Shader
+}
+
+// NewChain returns an empty chain for width x height images. Its ping-pong
+// targets are RGBA16F, so HDR values survive until tone mapping. It needs a
+// current GL context.
+func NewChain(width, height int) (*Chain, error) {
+	c := &Chain{Width: width, Height: height}
+	for i := range c.targets {
+		target, err := newTarget(width, height)
+		if err != nil {
+			c.Delete()
+			return nil, err
+		}
+		c.targets[i] = target
+	}
+
+	copy, err := NewShader("copy", copyShader)
+	if err != nil {
+		c.Delete()
+		return nil, err
+	}
+	c.copy = copy
+
+	gl.GenVertexArrays(1, &c.vao)
+	return c, nil
+}
+
+// newTarget creates an RGBA16F framebuffer for effects to draw into.
+func newTarget(width, height int) (*framebuffer.Framebuffer, error) {
+	return framebuffer.New(framebuffer.Spec{
+		Width:  width,
+		Height: height,
+		Colors: []framebuffer.Attachment{{Name: "outputColor", Format: gl.RGBA16F}},
+	})
+}
+
+// resizeTarget makes *target a width x height RGBA16F framebuffer, creating
+// it if it is nil.
+func resizeTarget(target **framebuffer.Framebuffer, width, height int) error {
+	if *target == nil {
+		t, err := newTarget(width, height)
+		if err != nil {
+			return err
+		}
+		*target = t
+		return nil
+	}
+	return (*target).Resize(width, height)
+}
+
+// Add appends an effect, enabled.
+func (c *Chain) Add(e Effect) {
+	c.entries = append(c.entries, entry{effect: e, enabled: true})
+}
+
+// Effect returns the named effect, or nil.
+func (c *Chain) Effect(name string) Effect {
+	for _, e := range c.entries {
+		if e.effect.Name() == name {
+			return e.effect
+		}
+	}
+	return nil
+}
+
+// SetEnabled turns the named effect on or off. It returns false if there is
+// no such effect.
+func (c *Chain) SetEnabled(name string, enabled bool) bool {
+	for i := range c.entries {
+		if c.entries[i].effect.Name() == name {
+			c.entries[i].enabled = enabled
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether the named effect is on.
+func (c *Chain) Enabled(name string) bool {
+	for _, e := range c.entries {
+		if e.effect.Name() == name {
+			return e.enabled
+		}
+	}
+	return false
+}
+
+// Resize changes the size of the images passing through, e.g. after the
+// window has been resized.
+func (c *Chain) Resize(width, height int) error {
+	c.Width, c.Height = width, height
+	for i := range c.targets {
+		if err := c.targets[i].Resize(width, height); err != nil {
+			return fmt.Errorf("post: %v", err)
+		}
+	}
+	return nil
+}
+
+// Run passes the texture source through the enabled effects and draws the
+// result into output, or the default framebuffer if output is nil. The
+// depth test, blending and face culling are off while it runs. If an effect
+// fails, the rest are skipped and its error returned.
+func (c *Chain) Run(source uint32, output *framebuffer.Framebuffer) error {
+	depthTest := gl.IsEnabled(gl.DEPTH_TEST)
+	blend := gl.IsEnabled(gl.BLEND)
+	cullFace := gl.IsEnabled(gl.CULL_FACE)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.CULL_FACE)
+
+	var enabled []Effect
+	for _, e := range c.entries {
+		if e.enabled {
+			enabled = append(enabled, e.effect)
+		}
+	}
+	if len(enabled) == 0 {
+		enabled = append(enabled, c.copy)
+	}
+
+	var err error
+	for i, e := range enabled {
+		target := c.targets[i%2]
+		if i == len(enabled)-1 {
+			target = output
+		}
+		if err = e.Apply(c, source, target); err != nil {
+			err = fmt.Errorf("post: effect %q: %v", e.Name(), err)
+			break
+		}
+		if target != nil {
+			source = target.Textures[0]
+		}
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if depthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	}
+	if blend {
+		gl.Enable(gl.BLEND)
+	}
+	if cullFace {
+		gl.Enable(gl.CULL_FACE)
+	}
+	return err
+}
+
+// Bind makes target, or the default framebuffer if it is nil, the target
+// of drawing, with a viewport the size of the chain or target.
+func (c *Chain) Bind(target *framebuffer.Framebuffer) {
+	if target == nil {
+		framebuffer.BindDefault(c.Width, c.Height)
+		return
+	}
+	target.Bind()
+}
+
+// DrawFullScreen draws the full-screen triangle, with the program that
+// shades it already in use.
+func (c *Chain) DrawFullScreen() {
+	gl.BindVertexArray(c.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.BindVertexArray(0)
+}
+
+// Delete frees the chain, its effects and its targets.
+func (c *Chain) Delete() {
+	for _, e := range c.entries {
+		e.effect.Delete()
+	}
+	c.entries = nil
+	for i, t := range c.targets {
+		if t != nil {
+			t.Delete()
+			c.targets[i] = nil
+		}
+	}
+	if c.copy != nil {
+		c.copy.Delete()
+		c.copy = nil
+	}
+	if c.vao != 0 {
+		gl.DeleteVertexArrays(1, &c.vao)
+	}
+}
@@ -0,0 +1,201 @@
+package post
+
+// The single pass effects. Each constructor returns a Shader with its
+// parameters set to defaults; change them with Set.
+
+// Tone mapping operators, for NewToneMap.
+const (
+	Reinhard int32 = iota
+	ACES
+)
+
+var toneMapShader = `
+#version 330
+
+uniform sampler2D source;
+uniform float exposure;
+uniform int operator;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+// Narkowicz's fit of the ACES filmic curve.
+vec3 aces(vec3 x) {
+    return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+void main() {
+    vec4 c = texture(source, uv);
+    vec3 x = c.rgb * exposure;
+    outputColor = vec4(operator == 1 ? aces(x) : x / (1.0 + x), c.a);
+}
+` + "\x00"
+
+// NewToneMap returns an effect, "tonemap", that maps HDR colours into
+// [0, 1]. Its parameters are exposure, a multiplier applied first (1), and
+// operator, Reinhard or ACES (set with SetInt).
+func NewToneMap(operator int32) (*Shader, error) {
+	s, err := NewShader("tonemap", toneMapShader)
+	if err != nil {
+		return nil, err
+	}
+	s.Set("exposure", 1)
+	s.SetInt("operator", operator)
+	return s, nil
+}
+
+var gammaShader = `
+#version 330
+
+uniform sampler2D source;
+uniform float gamma;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    vec4 c = texture(source, uv);
+    outputColor = vec4(pow(max(c.rgb, 0.0), vec3(1.0 / gamma)), c.a);
+}
+` + "\x00"
+
+// NewGamma returns an effect, "gamma", that encodes linear colours for
+// display. Its parameter is gamma (2.2).
+func NewGamma() (*Shader, error) {
+	s, err := NewShader("gamma", gammaShader)
+	if err != nil {
+		return nil, err
+	}
+	s.Set("gamma", 2.2)
+	return s, nil
+}
+
+var fxaaShader = `
+#version 330
+
+uniform sampler2D source;
+uniform vec2 texelSize;
+uniform float spanMax;
+uniform float reduceMin;
+uniform float reduceMul;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+float luma(vec3 c) { return dot(c, vec3(0.299, 0.587, 0.114)); }
+
+void main() {
+    vec3 rgbNW = texture(source, uv + vec2(-1, -1) * texelSize).rgb;
+    vec3 rgbNE = texture(source, uv + vec2(1, -1) * texelSize).rgb;
+    vec3 rgbSW = texture(source, uv + vec2(-1, 1) * texelSize).rgb;
+    vec3 rgbSE = texture(source, uv + vec2(1, 1) * texelSize).rgb;
+    vec4 centre = texture(source, uv);
+
+    float lumaNW = luma(rgbNW);
+    float lumaNE = luma(rgbNE);
+    float lumaSW = luma(rgbSW);
+    float lumaSE = luma(rgbSE);
+    float lumaM = luma(centre.rgb);
+    float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+    float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+    // Blur along the edge, which runs across the steepest luma gradient.
+    vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+    float reduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * reduceMul, reduceMin);
+    float scale = 1.0 / (min(abs(dir.x), abs(dir.y)) + reduce);
+    dir = clamp(dir * scale, vec2(-spanMax), vec2(spanMax)) * texelSize;
+
+    vec3 a = 0.5 * (texture(source, uv + dir * (1.0 / 3.0 - 0.5)).rgb +
+                    texture(source, uv + dir * (2.0 / 3.0 - 0.5)).rgb);
+    vec3 b = a * 0.5 + 0.25 * (texture(source, uv - dir * 0.5).rgb +
+                               texture(source, uv + dir * 0.5).rgb);
+    float lumaB = luma(b);
+    outputColor = vec4(lumaB < lumaMin || lumaB > lumaMax ? a : b, centre.a);
+}
+` + "\x00"
+
+// NewFXAA returns an effect, "fxaa", that smooths jagged edges. It expects
+// colours in [0, 1], so it goes after tone mapping. Its parameters are
+// spanMax, the longest blur in pixels (8), reduceMin (1/128) and reduceMul
+// (1/8), which keep it from blurring flat or dark areas.
+func NewFXAA() (*Shader, error) {
+	s, err := NewShader("fxaa", fxaaShader)
+	if err != nil {
+		return nil, err
+	}
+	s.Set("spanMax", 8)
+	s.Set("reduceMin", 1.0/128)
+	s.Set("reduceMul", 1.0/8)
+	return s, nil
+}
+
+var vignetteShader = `
+#version 330
+
+uniform sampler2D source;
+uniform float radius;
+uniform float softness;
+uniform float strength;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    vec4 c = texture(source, uv);
+    float d = length(uv - 0.5) * 1.41421356;
+    float v = smoothstep(radius, radius - softness, d);
+    outputColor = vec4(c.rgb * mix(1.0, v, strength), c.a);
+}
+` + "\x00"
+
+// NewVignette returns an effect, "vignette", that darkens the corners. Its
+// parameters are radius, where darkening is complete, as a fraction of the
+// distance from the centre to a corner (1), softness, the width of the fade
+// (0.6), and strength (0.8).
+func NewVignette() (*Shader, error) {
+	s, err := NewShader("vignette", vignetteShader)
+	if err != nil {
+		return nil, err
+	}
+	s.Set("radius", 1)
+	s.Set("softness", 0.6)
+	s.Set("strength", 0.8)
+	return s, nil
+}
+
+var chromaticAberrationShader = `
+#version 330
+
+uniform sampler2D source;
+uniform vec2 texelSize;
+uniform float amount;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    // Red and blue land either side of green, further apart at the edges.
+    vec2 offset = (uv - 0.5) * amount * texelSize * 2.0;
+    vec4 c = texture(source, uv);
+    float r = texture(source, uv + offset).r;
+    float b = texture(source, uv - offset).b;
+    outputColor = vec4(r, c.g, b, c.a);
+}
+` + "\x00"
+
+// NewChromaticAberration returns an effect, "chromatic", that splits the
+// red and blue channels away from the centre like a cheap lens. Its
+// parameter is amount, the split at the edges in pixels (4).
+func NewChromaticAberration() (*Shader, error) {
+	s, err := NewShader("chromatic", chromaticAberrationShader)
+	if err != nil {
+		return nil, err
+	}
+	s.Set("amount", 4)
+	return s, nil
+}
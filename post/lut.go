@@ -0,0 +1,213 @@
+package post
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// LUT is a 3D colour lookup table: a Size x Size x Size RGB texture that
+// maps a colour, as coordinates scaled from the domain [Min, Max], to its
+// graded colour.
+type LUT struct {
+	ID       uint32
+	Size     int
+	Min, Max [3]float32
+}
+
+// NewLUT builds a size³ table by calling grade for every entry, with red,
+// green and blue in [0, 1]. It needs a current GL context.
+func NewLUT(size int, grade func(r, g, b float32) (float32, float32, float32)) (*LUT, error) {
+	if size < 2 {
+		return nil, fmt.Errorf("post: LUT size %d is less than 2", size)
+	}
+	data := make([]float32, 0, size*size*size*3)
+	step := 1 / float32(size-1)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				gr, gg, gb := grade(float32(r)*step, float32(g)*step, float32(b)*step)
+				data = append(data, gr, gg, gb)
+			}
+		}
+	}
+	return newLUT(size, data, [3]float32{0, 0, 0}, [3]float32{1, 1, 1}), nil
+}
+
+// IdentityLUT returns a table that leaves colours as they are, a starting
+// point for grading in an image editor.
+func IdentityLUT(size int) (*LUT, error) {
+	return NewLUT(size, func(r, g, b float32) (float32, float32, float32) { return r, g, b })
+}
+
+// LoadLUT reads a table from an Adobe/Resolve .cube file.
+func LoadLUT(path string) (*LUT, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("post: %v", err)
+	}
+	defer f.Close()
+
+	size, data, min, max, err := parseCube(f)
+	if err != nil {
+		return nil, fmt.Errorf("post: %s: %v", path, err)
+	}
+	return newLUT(size, data, min, max), nil
+}
+
+// parseCube reads a .cube file with a LUT_3D_SIZE: its entries, red
+// changing fastest, and its domain.
+func parseCube(r io.Reader) (size int, data []float32, min, max [3]float32, err error) {
+	max = [3]float32{1, 1, 1}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "TITLE":
+			continue
+		case "LUT_1D_SIZE":
+			return 0, nil, min, max, fmt.Errorf("line %d: 1D tables are not supported", line)
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 {
+				return 0, nil, min, max, fmt.Errorf("line %d: LUT_3D_SIZE needs one value", line)
+			}
+			if size != 0 {
+				return 0, nil, min, max, fmt.Errorf("line %d: LUT_3D_SIZE given twice", line)
+			}
+			size, err = strconv.Atoi(fields[1])
+			if err != nil || size < 2 || size > 256 {
+				return 0, nil, min, max, fmt.Errorf("line %d: bad LUT_3D_SIZE %q", line, fields[1])
+			}
+			data = make([]float32, 0, size*size*size*3)
+			continue
+		case "DOMAIN_MIN", "DOMAIN_MAX":
+			v, err := parseTriple(fields[1:])
+			if err != nil {
+				return 0, nil, min, max, fmt.Errorf("line %d: %s: %v", line, fields[0], err)
+			}
+			if fields[0] == "DOMAIN_MIN" {
+				min = v
+			} else {
+				max = v
+			}
+			continue
+		}
+
+		if size == 0 {
+			return 0, nil, min, max, fmt.Errorf("line %d: entry before LUT_3D_SIZE", line)
+		}
+		v, err := parseTriple(fields)
+		if err != nil {
+			return 0, nil, min, max, fmt.Errorf("line %d: %v", line, err)
+		}
+		if len(data) == cap(data) {
+			return 0, nil, min, max, fmt.Errorf("line %d: more than %d entries", line, size*size*size)
+		}
+		data = append(data, v[0], v[1], v[2])
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, min, max, err
+	}
+	if size == 0 {
+		return 0, nil, min, max, fmt.Errorf("no LUT_3D_SIZE")
+	}
+	if len(data) != cap(data) {
+		return 0, nil, min, max, fmt.Errorf("%d entries, want %d", len(data)/3, size*size*size)
+	}
+	return size, data, min, max, nil
+}
+
+// parseTriple parses three floats.
+func parseTriple(fields []string) ([3]float32, error) {
+	var v [3]float32
+	if len(fields) != 3 {
+		return v, fmt.Errorf("want 3 values, have %d", len(fields))
+	}
+	for i, field := range fields {
+		f, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// newLUT uploads size³ RGB entries, red changing fastest.
+func newLUT(size int, data []float32, min, max [3]float32) *LUT {
+	l := &LUT{Size: size, Min: min, Max: max}
+	gl.GenTextures(1, &l.ID)
+	gl.BindTexture(gl.TEXTURE_3D, l.ID)
+	gl.TexStorage3D(gl.TEXTURE_3D, 1, gl.RGB16F, int32(size), int32(size), int32(size))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexSubImage3D(gl.TEXTURE_3D, 0, 0, 0, 0, int32(size), int32(size), int32(size), gl.RGB, gl.FLOAT, gl.Ptr(data))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gl.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_3D, 0)
+	return l
+}
+
+// Delete frees the texture.
+func (l *LUT) Delete() {
+	gl.DeleteTextures(1, &l.ID)
+}
+
+var colorGradeShader = `
+#version 330
+
+uniform sampler2D source;
+uniform sampler3D lut;
+uniform float lutSize;
+uniform vec3 domainMin;
+uniform vec3 domainMax;
+uniform float strength;
+
+in vec2 uv;
+
+out vec4 outputColor;
+
+void main() {
+    vec4 c = texture(source, uv);
+    vec3 x = clamp((c.rgb - domainMin) / (domainMax - domainMin), 0.0, 1.0);
+    // Sample the centres of the first and last texels at 0 and 1.
+    vec3 graded = texture(lut, x * ((lutSize - 1.0) / lutSize) + 0.5 / lutSize).rgb;
+    outputColor = vec4(mix(c.rgb, graded, strength), c.a);
+}
+` + "\x00"
+
+// NewColorGrade returns an effect, "grade", that looks colours up in lut.
+// Tables are made for display colours, so it goes after tone mapping and
+// gamma. Its parameter is strength, the mix from the original colour to the
+// graded one (1). The effect does not own lut; delete it separately.
+func NewColorGrade(lut *LUT) (*Shader, error) {
+	s, err := NewShader("grade", colorGradeShader)
+	if err != nil {
+		return nil, err
+	}
+	SetLUT(s, lut)
+	s.Set("strength", 1)
+	return s, nil
+}
+
+// SetLUT switches a colour grading effect to another table.
+func SetLUT(grade *Shader, lut *LUT) {
+	grade.SetTexture("lut", gl.TEXTURE_3D, lut.ID)
+	grade.Set("lutSize", float32(lut.Size))
+	grade.Set("domainMin", lut.Min[:]...)
+	grade.Set("domainMax", lut.Max[:]...)
+}
@@ -0,0 +1,76 @@
+package post
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCube(t *testing.T) {
+	const identity2 = `# An identity LUT.
+TITLE "identity"
+LUT_3D_SIZE 2
+DOMAIN_MIN 0 0 0
+DOMAIN_MAX 1 1 2
+
+0 0 0
+1 0 0
+0 1 0
+1 1 0
+0 0 1
+1 0 1
+0 1 1
+1 1 1
+`
+	size, data, min, max, err := parseCube(strings.NewReader(identity2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 2 || len(data) != 2*2*2*3 {
+		t.Fatalf("got size %d with %d values, want 2 with 24", size, len(data))
+	}
+	if min != [3]float32{0, 0, 0} || max != [3]float32{1, 1, 2} {
+		t.Errorf("domain %v to %v, want [0 0 0] to [1 1 2]", min, max)
+	}
+	// Red changes fastest.
+	for i := 0; i < 8; i++ {
+		want := [3]float32{float32(i & 1), float32(i >> 1 & 1), float32(i >> 2)}
+		if got := [3]float32(data[3*i : 3*i+3]); got != want {
+			t.Errorf("entry %d is %v, want %v", i, got, want)
+		}
+	}
+
+	// The domain defaults to the unit cube.
+	_, _, min, max, err = parseCube(strings.NewReader("LUT_3D_SIZE 2\n" + strings.Repeat("0.5 0.5 0.5\n", 8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min != [3]float32{0, 0, 0} || max != [3]float32{1, 1, 1} {
+		t.Errorf("default domain %v to %v, want the unit cube", min, max)
+	}
+}
+
+func TestParseCubeErrors(t *testing.T) {
+	entries := func(n int) string { return strings.Repeat("0 0 0\n", n) }
+	tests := []struct {
+		name, file, err string
+	}{
+		{"too few entries", "LUT_3D_SIZE 2\n" + entries(7), "7 entries, want 8"},
+		{"too many entries", "LUT_3D_SIZE 2\n" + entries(9), "line 10: more than 8 entries"},
+		{"no size", entries(8), "line 1: entry before LUT_3D_SIZE"},
+		{"empty", "# nothing\n", "no LUT_3D_SIZE"},
+		{"size too small", "LUT_3D_SIZE 1\n0 0 0\n", `line 1: bad LUT_3D_SIZE "1"`},
+		{"size not a number", "LUT_3D_SIZE two\n", `line 1: bad LUT_3D_SIZE "two"`},
+		{"size given twice", "LUT_3D_SIZE 2\n" + entries(8) + "LUT_3D_SIZE 3\n", "line 10: LUT_3D_SIZE given twice"},
+		{"1D table", "LUT_1D_SIZE 16\n", "line 1: 1D tables are not supported"},
+		{"short entry", "LUT_3D_SIZE 2\n0 0\n", "line 2: want 3 values, have 2"},
+		{"bad domain", "DOMAIN_MIN 0 0\n", "line 1: DOMAIN_MIN: want 3 values, have 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, _, err := parseCube(strings.NewReader(tt.file))
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package post
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/framebuffer"
+	"github.com/purelazy/GopenGL/shader"
+	"github.com/purelazy/GopenGL/texture"
+)
+
+// Shader is an effect drawn by a single fragment shader. The shader reads
+// the image from
+//
+//	uniform sampler2D source;
+//	in vec2 uv;
+//
+// and writes
+//
+//	out vec4 outputColor;
+//
+// If it declares them, the chain also sets uniform vec2 texelSize to the
+// size of one pixel of source in texture coordinates, and uniform float
+// time to Chain.Time. Other uniforms are set with Set and SetTexture, and
+// keep their values between runs.
+type Shader struct {
+	Program uint32
+
+	name      string
+	units     *texture.Units
+	locations map[string]int32
+	textures  map[string]boundTexture
+}
+
+// boundTexture is a texture of any target, by ID, that texture.Units can
+// bind.
+type boundTexture struct {
+	target, id uint32
+}
+
+func (t boundTexture) Bind(unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(t.target, t.id)
+}
+
+// NewShader compiles an effect from the source of its fragment shader. It
+// needs a current GL context.
+func NewShader(name, fragmentSource string) (*Shader, error) {
+	if !strings.HasSuffix(fragmentSource, "\x00") {
+		fragmentSource += "\x00"
+	}
+	program, err := shader.NewProgram(vertexShader, fragmentSource)
+	if err != nil {
+		return nil, fmt.Errorf("post: effect %q: %v", name, err)
+	}
+	return &Shader{
+		Program:   program,
+		name:      name,
+		units:     texture.NewUnits(program),
+		locations: make(map[string]int32),
+		textures:  make(map[string]boundTexture),
+	}, nil
+}
+
+// LoadShader compiles an effect from a fragment shader file, named after
+// the file without its extension.
+func LoadShader(path string) (*Shader, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("post: %v", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return NewShader(name, string(source))
+}
+
+// Name returns the effect's name.
+func (s *Shader) Name() string {
+	return s.name
+}
+
+// location returns the location of the named uniform, asking the program
+// only the first time.
+func (s *Shader) location(name string) int32 {
+	location, ok := s.locations[name]
+	if !ok {
+		location = shader.Uniform(s.Program, name)
+		s.locations[name] = location
+	}
+	return location
+}
+
+// Has reports whether the shader uses the named uniform.
+func (s *Shader) Has(name string) bool {
+	return s.location(name) >= 0
+}
+
+// Set sets the named float, vec2, vec3 or vec4 uniform, according to the
+// number of values. Uniforms the shader does not use are ignored, so an
+// effect can be tuned without knowing which parameters it has.
+func (s *Shader) Set(name string, values ...float32) {
+	location := s.location(name)
+	if location < 0 {
+		return
+	}
+	switch len(values) {
+	case 1:
+		gl.ProgramUniform1f(s.Program, location, values[0])
+	case 2:
+		gl.ProgramUniform2f(s.Program, location, values[0], values[1])
+	case 3:
+		gl.ProgramUniform3f(s.Program, location, values[0], values[1], values[2])
+	case 4:
+		gl.ProgramUniform4f(s.Program, location, values[0], values[1], values[2], values[3])
+	}
+}
+
+// SetInt sets the named int uniform, e.g. to choose between variants of an
+// effect.
+func (s *Shader) SetInt(name string, value int32) {
+	if location := s.location(name); location >= 0 {
+		gl.ProgramUniform1i(s.Program, location, value)
+	}
+}
+
+// SetTexture gives the named sampler uniform the texture id, of the given
+// target such as TEXTURE_2D or TEXTURE_3D, each time the effect is applied.
+func (s *Shader) SetTexture(name string, target, id uint32) {
+	s.textures[name] = boundTexture{target: target, id: id}
+}
+
+// Apply draws source through the shader into target.
+func (s *Shader) Apply(c *Chain, source uint32, target *framebuffer.Framebuffer) error {
+	s.Draw(c, source, c.Width, c.Height, target)
+	return nil
+}
+
+// Draw draws source, which is width x height, through the shader into
+// target. Effects of several passes use it to draw at other sizes than the
+// chain's.
+func (s *Shader) Draw(c *Chain, source uint32, width, height int, target *framebuffer.Framebuffer) {
+	c.Bind(target)
+	gl.UseProgram(s.Program)
+
+	s.units.Bind("source", boundTexture{gl.TEXTURE_2D, source})
+	for name, t := range s.textures {
+		s.units.Bind(name, t)
+	}
+	s.Set("texelSize", 1/float32(width), 1/float32(height))
+	s.Set("time", c.Time)
+
+	c.DrawFullScreen()
+}
+
+// Delete frees the program.
+func (s *Shader) Delete() {
+	gl.DeleteProgram(s.Program)
+}
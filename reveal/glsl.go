@@ -0,0 +1,39 @@
+package reveal
+
+import (
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+// GLSL declares the uniforms Apply sets and a function to fade vertices by
+// their age, for shaders to include after their #version line:
+//
+//	float revealAlpha(float index)
+//
+// returns 0 for a vertex not yet revealed, the fraction revealed for the
+// one at the head, and for older ones a brightness falling from 1 to the
+// floor over the fade length. In a vertex shader, index is usually
+// float(gl_VertexID); for indexed meshes, whose gl_VertexID is the index
+// value rather than its position, use float(gl_PrimitiveID * 3) in the
+// fragment shader instead.
+const GLSL = `
+uniform float revealHead;  // vertices revealed, with a fraction for the head
+uniform float revealFade;  // vertices behind the head to fade over, 0 for none
+uniform float revealFloor; // brightness faded vertices keep
+
+float revealAlpha(float index) {
+    float age = revealHead - index;
+    if (age <= 0.0) return 0.0;
+    if (age < 1.0) return age;
+    if (revealFade <= 0.0) return 1.0;
+    return mix(1.0, revealFloor, clamp((age - 1.0) / revealFade, 0.0, 1.0));
+}
+`
+
+// Apply sets the GLSL uniforms of program for the controller's current
+// head, fade and floor. The program must be in use.
+func (c *Controller) Apply(program uint32) {
+	gl.Uniform1f(shader.Uniform(program, "revealHead"), c.Head())
+	gl.Uniform1f(shader.Uniform(program, "revealFade"), c.Fade)
+	gl.Uniform1f(shader.Uniform(program, "revealFloor"), c.Floor)
+}
@@ -0,0 +1,71 @@
+package reveal
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// ScrubStep is how far, in seconds, the arrow keys scrub; with shift held
+// they scrub a tenth of it.
+var ScrubStep = 0.25
+
+// Attach lets the keyboard in win control the reveal:
+//
+//	Space         pause and resume
+//	Left, Right   scrub back and forward, a little with shift
+//	Home, End     go to the start or the end
+//	Up, Down      double or halve the speed
+//	M             switch between once, loop and ping-pong
+//
+// Any key callback already set on the window is still called.
+func (c *Controller) Attach(win *glfw.Window) {
+	var previous glfw.KeyCallback
+	previous = win.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if previous != nil {
+			previous(w, key, scancode, action, mods)
+		}
+		if action == glfw.Release {
+			return
+		}
+		c.Key(key, mods, action == glfw.Repeat)
+	})
+}
+
+// Key handles a key press, or a repeat if repeat is set, as Attach
+// describes, and reports whether the key was one of its own.
+func (c *Controller) Key(key glfw.Key, mods glfw.ModifierKey, repeat bool) bool {
+	step := ScrubStep
+	if mods&glfw.ModShift != 0 {
+		step /= 10
+	}
+	switch key {
+	case glfw.KeyLeft:
+		c.Scrub(-step)
+	case glfw.KeyRight:
+		c.Scrub(step)
+	case glfw.KeySpace:
+		if !repeat {
+			c.Paused = !c.Paused
+		}
+	case glfw.KeyHome:
+		c.Seek(0)
+		c.backwards = false
+	case glfw.KeyEnd:
+		c.Seek(c.Duration)
+	case glfw.KeyUp:
+		if !repeat {
+			c.Speed *= 2
+		}
+	case glfw.KeyDown:
+		if !repeat {
+			c.Speed /= 2
+		}
+	case glfw.KeyM:
+		if !repeat {
+			c.Mode = (c.Mode + 1) % 3
+			c.backwards = false
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,247 @@
+// Package reveal animates drawing a vertex buffer a little at a time. A
+// Controller maps time to the range of vertices drawn so far, with easing,
+// and can play once, loop, or go back and forth. It works in seconds
+// rather than frames, so the animation runs at the same speed at any frame
+// rate, and it can be paused and scrubbed from the keyboard.
+//
+// Shaders that include GLSL can fade vertices by how long ago they were
+// revealed, leaving a trail behind the head of the animation.
+package reveal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Mode is what a Controller does when it reaches the end.
+type Mode int
+
+// Modes.
+const (
+	// Once stops at the end, with everything drawn.
+	Once Mode = iota
+	// Loop starts again from nothing.
+	Loop
+	// PingPong runs backwards to the start, and then forwards again.
+	PingPong
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Loop:
+		return "loop"
+	case PingPong:
+		return "ping-pong"
+	}
+	return "once"
+}
+
+// Set parses "once", "loop" or "ping-pong", so that a Mode can be a flag.
+func (m *Mode) Set(s string) error {
+	switch s {
+	case "once":
+		*m = Once
+	case "loop":
+		*m = Loop
+	case "ping-pong", "pingpong":
+		*m = PingPong
+	default:
+		return fmt.Errorf("reveal: unknown mode %q, want once, loop or ping-pong", s)
+	}
+	return nil
+}
+
+// Easing maps the fraction of the duration gone, from 0 to 1, to the
+// fraction of the vertices revealed, from 0 to 1.
+type Easing func(t float64) float64
+
+// Easings.
+var (
+	Linear Easing = func(t float64) float64 { return t }
+	// EaseIn starts slowly and speeds up.
+	EaseIn Easing = func(t float64) float64 { return t * t }
+	// EaseOut starts quickly and slows down.
+	EaseOut Easing = func(t float64) float64 { return t * (2 - t) }
+	// EaseInOut starts and ends slowly.
+	EaseInOut Easing = func(t float64) float64 { return t * t * (3 - 2*t) }
+)
+
+// Controller reveals Count vertices over Duration seconds.
+type Controller struct {
+	Count int
+	// Duration is the time, in seconds, to reveal every vertex.
+	Duration float64
+	Mode     Mode
+	// Ease shapes the reveal; nil means Linear.
+	Ease Easing
+	// Speed multiplies the passing of time; negative runs backwards.
+	Speed  float64
+	Paused bool
+
+	// Primitive is the kind of primitive drawn, such as gl.POINTS,
+	// gl.LINE_STRIP or gl.TRIANGLES. Draw and DrawElements draw it, and
+	// Range rounds down to whole primitives.
+	Primitive uint32
+	// Trail, if it is not 0, is the most vertices drawn behind the head,
+	// so older ones disappear altogether.
+	Trail int
+	// Fade is how many vertices behind the head the GLSL fade takes to
+	// reach Floor; 0 turns the fade off.
+	Fade float32
+	// Floor is the brightness faded vertices are left at, from 0 to 1.
+	Floor float32
+
+	// time is where the controller is in its timeline, from 0 to Duration.
+	time float64
+	// backwards is set while PingPong runs back to the start.
+	backwards bool
+}
+
+// New returns a controller that reveals count vertices of primitive over
+// duration seconds, once, with linear easing.
+func New(count int, duration float64, primitive uint32) *Controller {
+	return &Controller{
+		Count:     count,
+		Duration:  duration,
+		Speed:     1,
+		Primitive: primitive,
+	}
+}
+
+// Update moves the controller on by dt seconds, unless it is paused.
+func (c *Controller) Update(dt float64) {
+	if c.Paused {
+		return
+	}
+	step := dt * c.Speed
+	if c.backwards {
+		step = -step
+	}
+	c.advance(step)
+}
+
+// advance moves the timeline by step seconds, dealing with its ends
+// according to the mode.
+func (c *Controller) advance(step float64) {
+	if c.Duration <= 0 {
+		c.time = 0
+		return
+	}
+	c.time += step
+	switch c.Mode {
+	case Once:
+		c.time = math.Max(0, math.Min(c.time, c.Duration))
+	case Loop:
+		c.time = math.Mod(c.time, c.Duration)
+		if c.time < 0 {
+			c.time += c.Duration
+		}
+	case PingPong:
+		// Reflect off the ends, as often as the step crosses them.
+		for c.time > c.Duration || c.time < 0 {
+			if c.time > c.Duration {
+				c.time = 2*c.Duration - c.time
+			} else {
+				c.time = -c.time
+			}
+			c.backwards = !c.backwards
+		}
+	}
+}
+
+// Seek moves to t seconds into the reveal.
+func (c *Controller) Seek(t float64) {
+	c.time = math.Max(0, math.Min(t, c.Duration))
+}
+
+// Scrub moves the reveal on by dt seconds, or back if dt is negative,
+// whether or not it is paused. It does not wrap round in any mode.
+func (c *Controller) Scrub(dt float64) {
+	c.Seek(c.time + dt)
+}
+
+// Time returns how many seconds into the reveal the controller is.
+func (c *Controller) Time() float64 {
+	return c.time
+}
+
+// Progress returns the fraction of the vertices revealed, after easing.
+func (c *Controller) Progress() float64 {
+	if c.Duration <= 0 {
+		return 1
+	}
+	ease := c.Ease
+	if ease == nil {
+		ease = Linear
+	}
+	return math.Max(0, math.Min(ease(c.time/c.Duration), 1))
+}
+
+// Head returns the position of the head of the reveal, in vertices: the
+// vertices before it are drawn, and the one it is in part way. The GLSL
+// fade is measured from it.
+func (c *Controller) Head() float32 {
+	return float32(c.Progress() * float64(c.Count))
+}
+
+// verticesPer returns the number of vertices in each primitive, for the
+// primitives that are drawn a whole number at a time.
+func verticesPer(primitive uint32) int {
+	switch primitive {
+	case gl.LINES:
+		return 2
+	case gl.TRIANGLES:
+		return 3
+	case gl.LINES_ADJACENCY:
+		return 4
+	case gl.TRIANGLES_ADJACENCY:
+		return 6
+	}
+	return 1
+}
+
+// Range returns the first vertex to draw and how many, for the head and
+// trail, in whole primitives.
+func (c *Controller) Range() (first, count int32) {
+	per := verticesPer(c.Primitive)
+	end := int(math.Ceil(float64(c.Head())))
+	if end > c.Count {
+		end = c.Count
+	}
+	end -= end % per
+	start := 0
+	if c.Trail > 0 && end > c.Trail {
+		// Round up, so that no more than Trail vertices are drawn.
+		start = (end - c.Trail + per - 1) / per * per
+	}
+	return int32(start), int32(end - start)
+}
+
+// Draw draws the revealed range of the bound vertex array with
+// gl.DrawArrays.
+func (c *Controller) Draw() {
+	first, count := c.Range()
+	if count > 0 {
+		gl.DrawArrays(c.Primitive, first, count)
+	}
+}
+
+// DrawElements draws the revealed range of the bound element buffer, with
+// indices of type xtype such as gl.UNSIGNED_INT, for meshes; Count is then
+// the number of indices.
+func (c *Controller) DrawElements(xtype uint32) {
+	first, count := c.Range()
+	if count == 0 {
+		return
+	}
+	size := 4
+	switch xtype {
+	case gl.UNSIGNED_SHORT:
+		size = 2
+	case gl.UNSIGNED_BYTE:
+		size = 1
+	}
+	gl.DrawElements(c.Primitive, count, xtype, gl.PtrOffset(int(first)*size))
+}
@@ -0,0 +1,143 @@
+package reveal
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		count        int
+		primitive    uint32
+		trail        int
+		time         float64 // of 2 seconds
+		first, drawn int32
+	}{
+		{"points at the start", 100, gl.POINTS, 0, 0, 0, 0},
+		{"points at the midpoint", 100, gl.POINTS, 0, 1, 0, 50},
+		{"points at the end", 100, gl.POINTS, 0, 2, 0, 100},
+		{"points part way into one", 100, gl.POINTS, 0, 0.01, 0, 1},
+		{"triangles at the start", 99, gl.TRIANGLES, 0, 0, 0, 0},
+		{"triangles at the midpoint", 99, gl.TRIANGLES, 0, 1, 0, 48},
+		{"triangles at the end", 99, gl.TRIANGLES, 0, 2, 0, 99},
+		{"lines with a trail", 100, gl.LINES, 11, 1, 40, 10},
+		{"trail longer than the head", 100, gl.POINTS, 80, 1, 0, 50},
+		{"trail at the end", 100, gl.POINTS, 10, 2, 90, 10},
+	}
+	for _, tt := range tests {
+		c := New(tt.count, 2, tt.primitive)
+		c.Trail = tt.trail
+		c.Seek(tt.time)
+		first, drawn := c.Range()
+		if first != tt.first || drawn != tt.drawn {
+			t.Errorf("%s: range is %d, %d, want %d, %d", tt.name, first, drawn, tt.first, tt.drawn)
+		}
+	}
+
+	// With no duration everything is drawn at once.
+	c := New(10, 0, gl.POINTS)
+	if first, drawn := c.Range(); first != 0 || drawn != 10 {
+		t.Errorf("no duration: range is %d, %d, want 0, 10", first, drawn)
+	}
+}
+
+func TestModes(t *testing.T) {
+	type step struct {
+		dt        float64
+		time      float64
+		backwards bool
+	}
+	tests := []struct {
+		mode  Mode
+		steps []step
+	}{
+		{Once, []step{{1.5, 1.5, false}, {1.5, 2, false}, {1, 2, false}}},
+		{Loop, []step{{1.5, 1.5, false}, {1, 0.5, false}, {4, 0.5, false}}},
+		{PingPong, []step{
+			{1.5, 1.5, false},
+			{1, 1.5, true}, // off the end and back
+			{0.5, 1, true},
+			{1.5, 0.5, false}, // off the start and forwards
+			{4, 0.5, false},   // there and back again
+		}},
+	}
+	for _, tt := range tests {
+		c := New(100, 2, gl.POINTS)
+		c.Mode = tt.mode
+		for i, s := range tt.steps {
+			c.Update(s.dt)
+			if c.Time() != s.time || c.backwards != s.backwards {
+				t.Errorf("%v step %d: time %v, backwards %v, want %v, %v", tt.mode, i, c.Time(), c.backwards, s.time, s.backwards)
+			}
+		}
+	}
+
+	// Running backwards loops round from the start.
+	c := New(100, 2, gl.POINTS)
+	c.Mode = Loop
+	c.Speed = -1
+	c.Update(0.5)
+	if c.Time() != 1.5 {
+		t.Errorf("loop at speed -1: time %v, want 1.5", c.Time())
+	}
+
+	// Paused controllers stay put, but can be scrubbed, without wrapping.
+	c.Paused = true
+	c.Update(1)
+	if c.Time() != 1.5 {
+		t.Errorf("paused: time %v, want 1.5", c.Time())
+	}
+	c.Scrub(1)
+	if c.Time() != 2 {
+		t.Errorf("scrubbed past the end: time %v, want 2", c.Time())
+	}
+}
+
+func TestModeSet(t *testing.T) {
+	for _, m := range []Mode{Once, Loop, PingPong} {
+		var parsed Mode
+		if err := parsed.Set(m.String()); err != nil || parsed != m {
+			t.Errorf("Set(%q) gave %v, %v", m.String(), parsed, err)
+		}
+	}
+	var m Mode
+	if err := m.Set("bounce"); err == nil {
+		t.Error("Set(\"bounce\") gave no error")
+	}
+}
+
+func TestEasings(t *testing.T) {
+	tests := []struct {
+		name string
+		ease Easing
+		mid  float64
+	}{
+		{"Linear", Linear, 0.5},
+		{"EaseIn", EaseIn, 0.25},
+		{"EaseOut", EaseOut, 0.75},
+		{"EaseInOut", EaseInOut, 0.5},
+	}
+	for _, tt := range tests {
+		if a, b := tt.ease(0), tt.ease(1); a != 0 || b != 1 {
+			t.Errorf("%s runs from %v to %v, want 0 to 1", tt.name, a, b)
+		}
+		if m := tt.ease(0.5); m != tt.mid {
+			t.Errorf("%s(0.5) = %v, want %v", tt.name, m, tt.mid)
+		}
+		for i := 1; i <= 100; i++ {
+			if a, b := tt.ease(float64(i-1)/100), tt.ease(float64(i)/100); b < a {
+				t.Errorf("%s goes down from %v to %v at %v", tt.name, a, b, float64(i)/100)
+			}
+		}
+
+		// The controller applies it.
+		c := New(100, 2, gl.POINTS)
+		c.Ease = tt.ease
+		c.Seek(1)
+		if p := c.Progress(); p != tt.mid {
+			t.Errorf("%s: progress at the midpoint is %v, want %v", tt.name, p, tt.mid)
+		}
+	}
+}
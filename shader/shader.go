@@ -0,0 +1,138 @@
+// Package shader compiles and links GLSL programs, for the examples and
+// for the packages that draw things themselves.
+package shader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Compile compiles source as a shader of the given type, such as
+// gl.VERTEX_SHADER. The NUL terminator GL needs is added if source lacks
+// one.
+func Compile(source string, shaderType uint32) (uint32, error) {
+	if !strings.HasSuffix(source, "\x00") {
+		source += "\x00"
+	}
+	shader := gl.CreateShader(shaderType)
+
+	csources, free := gl.Strs(source)
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		gl.DeleteShader(shader)
+
+		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+	}
+
+	return shader, nil
+}
+
+// Link links compiled shaders into a program. The shaders are deleted
+// whether or not it succeeds.
+func Link(shaders ...uint32) (uint32, error) {
+	return link(nil, shaders)
+}
+
+// LinkFeedback links compiled shaders into a program that records the
+// named outputs with transform feedback, in mode gl.INTERLEAVED_ATTRIBS or
+// gl.SEPARATE_ATTRIBS. The shaders are deleted whether or not it succeeds.
+func LinkFeedback(varyings []string, mode uint32, shaders ...uint32) (uint32, error) {
+	return link(func(program uint32) {
+		names := make([]string, len(varyings))
+		for i, v := range varyings {
+			names[i] = v + "\x00"
+		}
+		cnames, free := gl.Strs(names...)
+		gl.TransformFeedbackVaryings(program, int32(len(names)), cnames, mode)
+		free()
+	}, shaders)
+}
+
+// link links shaders, calling before, if it is not nil, with the program
+// they are attached to just before linking.
+func link(before func(program uint32), shaders []uint32) (uint32, error) {
+	program := gl.CreateProgram()
+	for _, s := range shaders {
+		gl.AttachShader(program, s)
+	}
+	if before != nil {
+		before(program)
+	}
+	gl.LinkProgram(program)
+	for _, s := range shaders {
+		gl.DeleteShader(s)
+	}
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		gl.DeleteProgram(program)
+
+		return 0, fmt.Errorf("failed to link program: %v", log)
+	}
+
+	return program, nil
+}
+
+// NewProgram compiles and links a vertex and a fragment shader.
+func NewProgram(vertexSource, fragmentSource string) (uint32, error) {
+	vertexShader, err := Compile(vertexSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+
+	fragmentShader, err := Compile(fragmentSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		gl.DeleteShader(vertexShader)
+		return 0, err
+	}
+
+	return Link(vertexShader, fragmentShader)
+}
+
+// NewGeometryProgram compiles and links a vertex, a geometry and a fragment
+// shader.
+func NewGeometryProgram(vertexSource, geometrySource, fragmentSource string) (uint32, error) {
+	vertexShader, err := Compile(vertexSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+
+	geometryShader, err := Compile(geometrySource, gl.GEOMETRY_SHADER)
+	if err != nil {
+		gl.DeleteShader(vertexShader)
+		return 0, err
+	}
+
+	fragmentShader, err := Compile(fragmentSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		gl.DeleteShader(vertexShader)
+		gl.DeleteShader(geometryShader)
+		return 0, err
+	}
+
+	return Link(vertexShader, geometryShader, fragmentShader)
+}
+
+// Uniform returns the location of the named uniform in program, or -1 if
+// it does not exist or was optimised away.
+func Uniform(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
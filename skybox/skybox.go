@@ -0,0 +1,191 @@
+// Package skybox draws a cube map as the background of a scene.
+//
+// The sky is drawn after the opaque geometry, at the far plane, with a depth
+// test that lets it through only where nothing else was drawn. Drawing it
+// last saves shading every pixel the scene covers.
+package skybox
+
+import (
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/camera"
+	"github.com/purelazy/GopenGL/shader"
+	"github.com/purelazy/GopenGL/texture"
+)
+
+var vertexShader = `
+#version 330
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform float farDepth;
+
+in vec3 vert;
+
+out vec3 direction;
+
+void main() {
+    direction = vert;
+    vec4 p = projection * view * vec4(vert, 1);
+    // Put every vertex on the far plane: depth 1, or 0 with reverse-Z.
+    gl_Position = vec4(p.xy, farDepth * p.w, p.w);
+}
+` + "\x00"
+
+var fragmentShader = `
+#version 330
+
+uniform samplerCube sky;
+
+in vec3 direction;
+
+out vec4 outputColor;
+
+void main() {
+    outputColor = texture(sky, direction);
+}
+` + "\x00"
+
+// The corners of a cube around the origin, and the 12 triangles of its
+// faces. The camera is inside, so which way they wind does not matter;
+// culling is turned off while drawing.
+var corners = []float32{
+	-1, -1, -1,
+	1, -1, -1,
+	1, 1, -1,
+	-1, 1, -1,
+	-1, -1, 1,
+	1, -1, 1,
+	1, 1, 1,
+	-1, 1, 1,
+}
+
+var indices = []uint32{
+	0, 1, 2, 2, 3, 0, // -Z
+	4, 6, 5, 6, 4, 7, // +Z
+	0, 3, 7, 7, 4, 0, // -X
+	1, 5, 6, 6, 2, 1, // +X
+	0, 4, 5, 5, 1, 0, // -Y
+	3, 2, 6, 6, 7, 3, // +Y
+}
+
+// Skybox draws Cube around the camera.
+type Skybox struct {
+	Cube *texture.TextureCube
+	// Unit is the texture unit Cube is bound to while drawing, 0 unless
+	// set. Whatever was bound there before is put back afterwards.
+	Unit uint32
+
+	program       uint32
+	vao, vbo, ebo uint32
+
+	projectionUniform, viewUniform, farDepthUniform, skyUniform int32
+}
+
+// New builds the program and cube the skybox draws with. It needs a current
+// GL context, and turns on seamless cube map filtering.
+func New(cube *texture.TextureCube) (*Skybox, error) {
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Skybox{
+		Cube:              cube,
+		program:           program,
+		projectionUniform: shader.Uniform(program, "projection"),
+		viewUniform:       shader.Uniform(program, "view"),
+		farDepthUniform:   shader.Uniform(program, "farDepth"),
+		skyUniform:        shader.Uniform(program, "sky"),
+	}
+
+	gl.GenVertexArrays(1, &s.vao)
+	gl.BindVertexArray(s.vao)
+
+	gl.GenBuffers(1, &s.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(corners)*4, gl.Ptr(corners), gl.STATIC_DRAW)
+
+	gl.GenBuffers(1, &s.ebo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, s.ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	vertAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vert\x00")))
+	gl.EnableVertexAttribArray(vertAttrib)
+	gl.VertexAttribPointer(vertAttrib, 3, gl.FLOAT, false, 3*4, gl.PtrOffset(0))
+
+	gl.BindVertexArray(0)
+
+	// Filter across the edges between faces, rather than within each face.
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+
+	return s, nil
+}
+
+// Draw draws the sky as seen by c. Call it after the opaque geometry and
+// before anything transparent. The depth state should match c, as set by
+// glstate.ApplyDepth(c.ReverseZ).
+func (s *Skybox) Draw(c *camera.Camera) {
+	s.DrawMatrices(c.View(), c.Projection(), c.ReverseZ)
+}
+
+// DrawMatrices draws the sky with the given view and projection. The view's
+// translation is ignored, so the sky stays put as the camera moves.
+// reverseZ says whether the projection maps the far plane to depth 0.
+func (s *Skybox) DrawMatrices(view, projection mgl32.Mat4, reverseZ bool) {
+	// Keep only the rotation.
+	view = view.Mat3().Mat4()
+
+	// Save the state the sky changes.
+	var depthFunc, program, vao, activeTexture, cube int32
+	var depthMask bool
+	gl.GetIntegerv(gl.DEPTH_FUNC, &depthFunc)
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &depthMask)
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &vao)
+	gl.GetIntegerv(gl.ACTIVE_TEXTURE, &activeTexture)
+	gl.ActiveTexture(gl.TEXTURE0 + s.Unit)
+	gl.GetIntegerv(gl.TEXTURE_BINDING_CUBE_MAP, &cube)
+	cullFace := gl.IsEnabled(gl.CULL_FACE)
+
+	// The sky lands exactly on the cleared depth, so it needs an "or equal"
+	// test to pass, and must not write depth itself.
+	farDepth := float32(1)
+	if reverseZ {
+		farDepth = 0
+		gl.DepthFunc(gl.GEQUAL)
+	} else {
+		gl.DepthFunc(gl.LEQUAL)
+	}
+	gl.DepthMask(false)
+	gl.Disable(gl.CULL_FACE)
+
+	gl.UseProgram(s.program)
+	gl.UniformMatrix4fv(s.projectionUniform, 1, false, &projection[0])
+	gl.UniformMatrix4fv(s.viewUniform, 1, false, &view[0])
+	gl.Uniform1f(s.farDepthUniform, farDepth)
+	gl.Uniform1i(s.skyUniform, int32(s.Unit))
+	s.Cube.Bind(s.Unit)
+
+	gl.BindVertexArray(s.vao)
+	gl.DrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_INT, gl.PtrOffset(0))
+
+	gl.DepthFunc(uint32(depthFunc))
+	gl.DepthMask(depthMask)
+	if cullFace {
+		gl.Enable(gl.CULL_FACE)
+	}
+	gl.UseProgram(uint32(program))
+	gl.BindVertexArray(uint32(vao))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, uint32(cube))
+	gl.ActiveTexture(uint32(activeTexture))
+}
+
+// Delete frees the program and buffers. The cube map belongs to the caller
+// and is left alone.
+func (s *Skybox) Delete() {
+	gl.DeleteProgram(s.program)
+	gl.DeleteVertexArrays(1, &s.vao)
+	gl.DeleteBuffers(1, &s.vbo)
+	gl.DeleteBuffers(1, &s.ebo)
+}
@@ -0,0 +1,230 @@
+// Package sprite draws textured quads in bulk, usually from an atlas.
+//
+// A Batch collects sprites between Begin and End and draws them all in one
+// instanced draw call: a single quad, stretched, turned, tinted and mapped
+// to its atlas region per instance in the vertex shader.
+package sprite
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/atlas"
+	"github.com/purelazy/GopenGL/shader"
+	"github.com/purelazy/GopenGL/texture"
+)
+
+// Sprite is one quad. Its layout is uploaded as it is, one per instance.
+type Sprite struct {
+	// Position is where Origin lands, in the units of the projection.
+	Position mgl32.Vec2
+	// Depth is the sprite's z, for depth testing against other sprites or a
+	// scene.
+	Depth float32
+	// Size is the width and height of the quad.
+	Size mgl32.Vec2
+	// Origin is the point of the quad at Position, which it also turns
+	// about: (0, 0) is the top-left corner and (1, 1) the bottom-right.
+	Origin mgl32.Vec2
+	// Rotation turns the quad about Origin, in radians, counter-clockwise
+	// when y is up.
+	Rotation float32
+	// UV is the texture rectangle as u0, v0, u1, v1, with (u0, v0) at the
+	// top-left corner, as in atlas.Region.
+	UV [4]float32
+	// Tint multiplies the texture colour.
+	Tint mgl32.Vec4
+}
+
+// FromRegion returns a white sprite the size of an atlas region, centred on
+// its position.
+func FromRegion(r atlas.Region) Sprite {
+	w, h := r.Size()
+	return Sprite{
+		Size:   mgl32.Vec2{float32(w), float32(h)},
+		Origin: mgl32.Vec2{0.5, 0.5},
+		UV:     r.UV,
+		Tint:   mgl32.Vec4{1, 1, 1, 1},
+	}
+}
+
+var vertexShader = `
+#version 330
+
+uniform mat4 projection;
+
+in vec3 position;
+in vec2 size;
+in vec2 origin;
+in float rotation;
+in vec4 uv;
+in vec4 tint;
+
+out vec2 fragTexCoord;
+out vec4 fragTint;
+
+void main() {
+    // The quad's corners as a triangle strip: (0, 0), (1, 0), (0, 1), (1, 1).
+    vec2 corner = vec2(gl_VertexID & 1, gl_VertexID >> 1);
+
+    vec2 p = (corner - origin) * size;
+    float c = cos(rotation), s = sin(rotation);
+    p = vec2(c * p.x - s * p.y, s * p.x + c * p.y);
+
+    fragTexCoord = mix(uv.xy, uv.zw, corner);
+    fragTint = tint;
+    gl_Position = projection * vec4(position.xy + p, position.z, 1);
+}
+` + "\x00"
+
+var fragmentShader = `
+#version 330
+
+uniform sampler2D tex;
+
+in vec2 fragTexCoord;
+in vec4 fragTint;
+
+out vec4 outputColor;
+
+void main() {
+    outputColor = texture(tex, fragTexCoord) * fragTint;
+    // Let transparent pixels through the depth test to whatever is behind.
+    if (outputColor.a < 1.0 / 255.0) {
+        discard;
+    }
+}
+` + "\x00"
+
+// Batch draws sprites from one texture.
+type Batch struct {
+	Texture *texture.Texture2D
+
+	sprites []Sprite
+
+	program           uint32
+	vao, vbo          uint32
+	capacity          int
+	projectionUniform int32
+}
+
+// NewBatch builds the program and buffers for drawing sprites from tex. It
+// needs a current GL context.
+func NewBatch(tex *texture.Texture2D) (*Batch, error) {
+	program, err := shader.NewProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Batch{
+		Texture:           tex,
+		program:           program,
+		projectionUniform: shader.Uniform(program, "projection"),
+	}
+
+	gl.UseProgram(program)
+	gl.Uniform1i(shader.Uniform(program, "tex"), 0)
+
+	gl.GenVertexArrays(1, &b.vao)
+	gl.BindVertexArray(b.vao)
+	gl.GenBuffers(1, &b.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+
+	// Every attribute advances once per sprite rather than per vertex.
+	var s Sprite
+	stride := int32(unsafe.Sizeof(s))
+	attrib := func(name string, size int32, offset uintptr) {
+		loc := gl.GetAttribLocation(program, gl.Str(name+"\x00"))
+		if loc < 0 {
+			return
+		}
+		gl.EnableVertexAttribArray(uint32(loc))
+		gl.VertexAttribPointer(uint32(loc), size, gl.FLOAT, false, stride, gl.PtrOffset(int(offset)))
+		gl.VertexAttribDivisor(uint32(loc), 1)
+	}
+	// Depth follows Position, so the two make one vec3.
+	attrib("position", 3, unsafe.Offsetof(s.Position))
+	attrib("size", 2, unsafe.Offsetof(s.Size))
+	attrib("origin", 2, unsafe.Offsetof(s.Origin))
+	attrib("rotation", 1, unsafe.Offsetof(s.Rotation))
+	attrib("uv", 4, unsafe.Offsetof(s.UV))
+	attrib("tint", 4, unsafe.Offsetof(s.Tint))
+
+	gl.BindVertexArray(0)
+	return b, nil
+}
+
+// Begin empties the batch.
+func (b *Batch) Begin() {
+	b.sprites = b.sprites[:0]
+}
+
+// Add queues a sprite.
+func (b *Batch) Add(s Sprite) {
+	b.sprites = append(b.sprites, s)
+}
+
+// Len returns the number of sprites queued.
+func (b *Batch) Len() int {
+	return len(b.sprites)
+}
+
+// End draws the queued sprites, in the order they were added, with one
+// draw call. Blending is turned on for straight (not premultiplied) alpha
+// while it draws; the depth test is left as it is.
+func (b *Batch) End(projection mgl32.Mat4) {
+	n := len(b.sprites)
+	if n == 0 {
+		return
+	}
+	size := n * int(unsafe.Sizeof(b.sprites[0]))
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+	if n > b.capacity {
+		// Grow by half again, so a batch that creeps up does not reallocate
+		// every frame.
+		b.capacity = n + n/2
+		gl.BufferData(gl.ARRAY_BUFFER, b.capacity*int(unsafe.Sizeof(b.sprites[0])), nil, gl.STREAM_DRAW)
+	}
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(b.sprites))
+
+	// Save the state the batch changes.
+	var program, vao, activeTexture, texture2D, srcRGB, dstRGB, srcAlpha, dstAlpha int32
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &vao)
+	gl.GetIntegerv(gl.ACTIVE_TEXTURE, &activeTexture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &texture2D)
+	gl.GetIntegerv(gl.BLEND_SRC_RGB, &srcRGB)
+	gl.GetIntegerv(gl.BLEND_DST_RGB, &dstRGB)
+	gl.GetIntegerv(gl.BLEND_SRC_ALPHA, &srcAlpha)
+	gl.GetIntegerv(gl.BLEND_DST_ALPHA, &dstAlpha)
+	blend := gl.IsEnabled(gl.BLEND)
+
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	gl.UseProgram(b.program)
+	gl.UniformMatrix4fv(b.projectionUniform, 1, false, &projection[0])
+	b.Texture.Bind(0)
+	gl.BindVertexArray(b.vao)
+	gl.DrawArraysInstanced(gl.TRIANGLE_STRIP, 0, 4, int32(n))
+
+	gl.BlendFuncSeparate(uint32(srcRGB), uint32(dstRGB), uint32(srcAlpha), uint32(dstAlpha))
+	if !blend {
+		gl.Disable(gl.BLEND)
+	}
+	gl.UseProgram(uint32(program))
+	gl.BindVertexArray(uint32(vao))
+	gl.BindTexture(gl.TEXTURE_2D, uint32(texture2D))
+	gl.ActiveTexture(uint32(activeTexture))
+}
+
+// Delete frees the program and buffers. The texture belongs to the caller
+// and is left alone.
+func (b *Batch) Delete() {
+	gl.DeleteProgram(b.program)
+	gl.DeleteVertexArrays(1, &b.vao)
+	gl.DeleteBuffers(1, &b.vbo)
+}
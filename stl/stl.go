@@ -0,0 +1,272 @@
+// Package stl reads and writes STL files, the triangle soup understood by
+// slicers for 3D printing and by most CAD tools.
+//
+// Both the binary and the ASCII forms are supported. STL keeps only
+// positions and one normal per facet, so writing drops normals, UVs and
+// tangents and reading gives a mesh with three vertices per triangle, each
+// carrying its facet's normal.
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/geometry"
+)
+
+const (
+	headerSize = 80
+	facetSize  = 50 // normal, three corners and a 2-byte attribute
+)
+
+// FacetNormal returns the unit normal of a counter-clockwise triangle, or
+// zero if it is degenerate.
+func FacetNormal(a, b, c mgl32.Vec3) mgl32.Vec3 {
+	n := b.Sub(a).Cross(c.Sub(a))
+	if l := n.Len(); l > 0 {
+		return n.Mul(1 / l)
+	}
+	return mgl32.Vec3{}
+}
+
+// WriteBinary writes m as binary STL.
+func WriteBinary(w io.Writer, m *geometry.Mesh) error {
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, headerSize)
+	copy(header, "binary STL written by GopenGL")
+	bw.Write(header)
+
+	var buf [facetSize]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(m.Triangles()))
+	bw.Write(buf[:4])
+
+	for t := 0; t+2 < len(m.Indices); t += 3 {
+		a := m.Vertices[m.Indices[t]].Position
+		b := m.Vertices[m.Indices[t+1]].Position
+		c := m.Vertices[m.Indices[t+2]].Position
+
+		off := 0
+		for _, v := range [4]mgl32.Vec3{FacetNormal(a, b, c), a, b, c} {
+			for _, f := range v {
+				binary.LittleEndian.PutUint32(buf[off:], math.Float32bits(f))
+				off += 4
+			}
+		}
+		buf[48], buf[49] = 0, 0
+
+		if _, err := bw.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteASCII writes m as ASCII STL, naming the solid name.
+func WriteASCII(w io.Writer, m *geometry.Mesh, name string) error {
+	bw := bufio.NewWriter(w)
+
+	vec := func(v mgl32.Vec3) string {
+		return fmt.Sprintf("%s %s %s",
+			strconv.FormatFloat(float64(v[0]), 'e', -1, 32),
+			strconv.FormatFloat(float64(v[1]), 'e', -1, 32),
+			strconv.FormatFloat(float64(v[2]), 'e', -1, 32))
+	}
+
+	fmt.Fprintf(bw, "solid %s\n", name)
+	for t := 0; t+2 < len(m.Indices); t += 3 {
+		a := m.Vertices[m.Indices[t]].Position
+		b := m.Vertices[m.Indices[t+1]].Position
+		c := m.Vertices[m.Indices[t+2]].Position
+
+		fmt.Fprintf(bw, "  facet normal %s\n    outer loop\n", vec(FacetNormal(a, b, c)))
+		for _, v := range [3]mgl32.Vec3{a, b, c} {
+			fmt.Fprintf(bw, "      vertex %s\n", vec(v))
+		}
+		if _, err := fmt.Fprintf(bw, "    endloop\n  endfacet\n"); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(bw, "endsolid %s\n", name)
+
+	return bw.Flush()
+}
+
+// Save writes m to path as binary STL.
+func Save(path string, m *geometry.Mesh) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = WriteBinary(f, m)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// Load reads the binary or ASCII STL file at path.
+func Load(path string) (*geometry.Mesh, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Decode reads binary or ASCII STL from r. Binary files may have data
+// after their triangles, and may also start with "solid", so a file that
+// does is only read as binary if its size matches the triangle count in
+// the binary header exactly, or if reading it as ASCII gives an error or
+// no triangles.
+func Decode(r io.Reader) (*geometry.Mesh, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whether the triangles the binary header counts fit in the data, and
+	// fill it exactly.
+	isBinary, exact := false, false
+	var count uint64
+	if len(data) >= headerSize+4 {
+		count = uint64(binary.LittleEndian.Uint32(data[headerSize:]))
+		room := uint64(len(data) - headerSize - 4)
+		isBinary = count <= room/facetSize
+		exact = count*facetSize == room
+	}
+	solid := bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid"))
+
+	switch {
+	case isBinary && (exact || !solid):
+		return decodeBinary(data[headerSize+4:], int(count)), nil
+	case solid:
+		m, err := decodeASCII(data)
+		if isBinary && count > 0 && (err != nil || m.Triangles() == 0) {
+			return decodeBinary(data[headerSize+4:], int(count)), nil
+		}
+		return m, err
+	case len(data) >= headerSize+4:
+		return nil, fmt.Errorf("binary STL is truncated: %d triangles need %d bytes, have %d",
+			count, headerSize+4+count*facetSize, len(data))
+	}
+
+	return nil, fmt.Errorf("not an STL file")
+}
+
+func decodeBinary(data []byte, n int) *geometry.Mesh {
+	m := &geometry.Mesh{}
+	for t := 0; t < n; t++ {
+		facet := data[t*facetSize:]
+		var v [4]mgl32.Vec3
+		for i := range v {
+			for j := 0; j < 3; j++ {
+				v[i][j] = math.Float32frombits(binary.LittleEndian.Uint32(facet[12*i+4*j:]))
+			}
+		}
+		addFacet(m, v[0], v[1], v[2], v[3])
+	}
+	m.ComputeTangents()
+	return m
+}
+
+func decodeASCII(data []byte) (*geometry.Mesh, error) {
+	m := &geometry.Mesh{}
+
+	var normal mgl32.Vec3
+	var corners []mgl32.Vec3
+	line := 0
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+
+	vec := func(args []string) (mgl32.Vec3, error) {
+		var v mgl32.Vec3
+		if len(args) != 3 {
+			return v, errorf("expected 3 numbers, got %d", len(args))
+		}
+		for i, a := range args {
+			f, err := strconv.ParseFloat(a, 32)
+			if err != nil {
+				return v, errorf("bad number %q", a)
+			}
+			v[i] = float32(f)
+		}
+		return v, nil
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line++
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "facet":
+			if len(fields) < 2 || fields[1] != "normal" {
+				return nil, errorf("expected facet normal")
+			}
+			normal, err = vec(fields[2:])
+			corners = corners[:0]
+		case "vertex":
+			var v mgl32.Vec3
+			v, err = vec(fields[1:])
+			corners = append(corners, v)
+		case "endfacet":
+			if len(corners) != 3 {
+				return nil, errorf("facet has %d vertices, expected 3", len(corners))
+			}
+			addFacet(m, normal, corners[0], corners[1], corners[2])
+		case "solid", "endsolid", "outer", "endloop":
+		default:
+			return nil, errorf("unknown keyword %q", fields[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	m.ComputeTangents()
+	return m, nil
+}
+
+// addFacet appends a triangle. The normal in the file is used unless it is
+// missing (zero), when it is computed from the corners.
+func addFacet(m *geometry.Mesh, n, a, b, c mgl32.Vec3) {
+	if n.Len() == 0 {
+		n = FacetNormal(a, b, c)
+	} else {
+		n = n.Normalize()
+	}
+
+	base := uint32(len(m.Vertices))
+	for _, p := range [3]mgl32.Vec3{a, b, c} {
+		m.Vertices = append(m.Vertices, geometry.Vertex{Position: p, Normal: n})
+	}
+	m.Indices = append(m.Indices, base, base+1, base+2)
+}
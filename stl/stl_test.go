@@ -0,0 +1,155 @@
+package stl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/purelazy/GopenGL/geometry"
+)
+
+// roundTrip checks that got has the triangles of want, corner by corner,
+// each with its facet's normal.
+func roundTrip(t *testing.T, want, got *geometry.Mesh) {
+	t.Helper()
+	if got.Triangles() != want.Triangles() {
+		t.Fatalf("got %d triangles, want %d", got.Triangles(), want.Triangles())
+	}
+	for i, wi := range want.Indices {
+		p, q := want.Vertices[wi].Position, got.Vertices[got.Indices[i]].Position
+		if p != q {
+			t.Fatalf("corner %d at %v, want %v", i, q, p)
+		}
+	}
+	for i := 0; i < len(got.Indices); i += 3 {
+		a := got.Vertices[got.Indices[i]]
+		b := got.Vertices[got.Indices[i+1]]
+		c := got.Vertices[got.Indices[i+2]]
+		n := FacetNormal(a.Position, b.Position, c.Position)
+		for _, v := range [3]geometry.Vertex{a, b, c} {
+			if !v.Normal.ApproxEqualThreshold(n, 1e-5) {
+				t.Fatalf("triangle %d: normal %v, want %v", i/3, v.Normal, n)
+			}
+		}
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	m := geometry.Icosphere(1.5, 2)
+	var b bytes.Buffer
+	if err := WriteBinary(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	if want := headerSize + 4 + m.Triangles()*facetSize; b.Len() != want {
+		t.Fatalf("wrote %d bytes, want %d", b.Len(), want)
+	}
+	got, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, m, got)
+}
+
+func TestASCIIRoundTrip(t *testing.T) {
+	m := geometry.Torus(1, 0.25, 12, 8)
+	var b bytes.Buffer
+	if err := WriteASCII(&b, m, "torus"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "solid torus\n") {
+		t.Fatalf("starts %q", b.String()[:20])
+	}
+	got, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, m, got)
+}
+
+func TestDecodeASCII(t *testing.T) {
+	// A missing (zero) normal is computed from the corners, and others are
+	// normalized.
+	const src = `solid two
+facet normal 0 0 0
+ outer loop
+  vertex 0 0 0
+  vertex 1 0 0
+  vertex 0 1 0
+ endloop
+endfacet
+facet normal 0 0 -2
+ outer loop
+  vertex 0 0 0
+  vertex 0 1 0
+  vertex 1 0 0
+ endloop
+endfacet
+endsolid two
+`
+	m, err := Decode(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Triangles() != 2 || len(m.Vertices) != 6 {
+		t.Fatalf("got %d triangles and %d vertices, want 2 and 6", m.Triangles(), len(m.Vertices))
+	}
+	if n := m.Vertices[0].Normal; n != (mgl32.Vec3{0, 0, 1}) {
+		t.Errorf("computed normal %v, want +Z", n)
+	}
+	if n := m.Vertices[3].Normal; n != (mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("normal %v, want -Z", n)
+	}
+
+	for _, bad := range []string{
+		"solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendfacet\n",
+		"solid x\nfacet normal 0 0 one\n",
+		"solid x\nfacet\n",
+		"solid x\nvertices 0 0 0\n",
+		"not an stl file",
+	} {
+		if _, err := Decode(strings.NewReader(bad)); err == nil {
+			t.Errorf("no error decoding %q", bad)
+		}
+	}
+}
+
+func TestDecodeBinaryForms(t *testing.T) {
+	m := geometry.Icosphere(1, 1)
+	var b bytes.Buffer
+	if err := WriteBinary(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	plain := b.Bytes()
+	solidHeader := bytes.Clone(plain)
+	copy(solidHeader, "solid exported by a CAD program")
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"trailing data", append(bytes.Clone(plain), "extra"...)},
+		{"header starting solid", solidHeader},
+		{"header starting solid and trailing data", append(bytes.Clone(solidHeader), make([]byte, 7)...)},
+	}
+	for _, tt := range tests {
+		got, err := Decode(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		roundTrip(t, m, got)
+	}
+
+	// In ASCII, the text where a binary header's count would be makes the
+	// count far too big for the file.
+	ascii := "solid x\n" + strings.Repeat(" ", headerSize) + "\nendsolid x\n"
+	if got, err := Decode(strings.NewReader(ascii)); err != nil || got.Triangles() != 0 {
+		t.Errorf("padded empty ASCII solid: %v", err)
+	}
+
+	truncated := plain[:len(plain)-1]
+	want := "binary STL is truncated: 80 triangles need 4084 bytes, have 4083"
+	if _, err := Decode(bytes.NewReader(truncated)); err == nil || err.Error() != want {
+		t.Errorf("truncated: got error %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,152 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Texture2DArray is a stack of same-sized 2D images sampled as one texture,
+// by a sampler2DArray, with the layer as the third coordinate.
+type Texture2DArray struct {
+	ID             uint32
+	Width, Height  int
+	Layers         int
+	InternalFormat uint32
+	// Levels is the number of mipmap levels.
+	Levels int32
+}
+
+// NewTexture2DArray uploads images as the layers of an array texture, the
+// first image as layer 0. The images must all be the same size. It needs a
+// current GL context.
+func NewTexture2DArray(images []image.Image, opts Options) (*Texture2DArray, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("texture: array has no images")
+	}
+	internal, format, err := opts.formats()
+	if err != nil {
+		return nil, err
+	}
+
+	channels := opts.Channels
+	if channels == 0 {
+		channels = 4
+	}
+
+	layers := make([][]byte, len(images))
+	var width, height int
+	for i, img := range images {
+		pix, w, h := pixels(img, channels, opts.FlipY)
+		if i == 0 {
+			width, height = w, h
+		} else if w != width || h != height {
+			return nil, fmt.Errorf("texture: array layer %d is %dx%d, not %dx%d", i, w, h, width, height)
+		}
+		layers[i] = pix
+	}
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("texture: image is empty")
+	}
+
+	t := &Texture2DArray{Width: width, Height: height, Layers: len(images), InternalFormat: internal, Levels: 1}
+	if opts.Mipmaps {
+		t.Levels = mipLevels(width, height)
+	}
+
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, t.ID)
+
+	w, h, n := int32(width), int32(height), int32(len(images))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	if opts.Immutable {
+		gl.TexStorage3D(gl.TEXTURE_2D_ARRAY, t.Levels, internal, w, h, n)
+	} else {
+		gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, int32(internal), w, h, n, 0, format, gl.UNSIGNED_BYTE, nil)
+		gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAX_LEVEL, t.Levels-1)
+	}
+	for i, pix := range layers {
+		gl.TexSubImage3D(gl.TEXTURE_2D_ARRAY, 0, 0, 0, int32(i), w, h, 1, format, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	if opts.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
+	}
+
+	setParameters(gl.TEXTURE_2D_ARRAY, opts, opts.Mipmaps)
+	return t, nil
+}
+
+// LoadTexture2DArray reads image files and uploads them as the layers of an
+// array texture, in order.
+func LoadTexture2DArray(paths []string, opts Options) (*Texture2DArray, error) {
+	images := make([]image.Image, len(paths))
+	for i, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("texture %q not found on disk: %v", path, err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: texture: %v", path, err)
+		}
+		images[i] = img
+	}
+	return NewTexture2DArray(images, opts)
+}
+
+// LoadTexture2DArrayFS reads images in fsys, such as an embed.FS, and
+// uploads them as the layers of an array texture, in order.
+func LoadTexture2DArrayFS(fsys fs.FS, paths []string, opts Options) (*Texture2DArray, error) {
+	images := make([]image.Image, len(paths))
+	for i, path := range paths {
+		f, err := fsys.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: texture: %v", path, err)
+		}
+		images[i] = img
+	}
+	return NewTexture2DArray(images, opts)
+}
+
+// Bind makes t the array texture of texture unit unit (0 for TEXTURE0).
+func (t *Texture2DArray) Bind(unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, t.ID)
+}
+
+// SetFilter sets the minifying and magnifying filters.
+func (t *Texture2DArray) SetFilter(min, mag int32) {
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, min)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, mag)
+}
+
+// SetWrap sets how coordinates outside [0, 1] are treated.
+func (t *Texture2DArray) SetWrap(s, tWrap int32) {
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, s)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, tWrap)
+}
+
+// GenerateMipmaps rebuilds the mipmaps of every layer from level 0.
+func (t *Texture2DArray) GenerateMipmaps() {
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, t.ID)
+	gl.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
+}
+
+// Delete frees the texture.
+func (t *Texture2DArray) Delete() {
+	gl.DeleteTextures(1, &t.ID)
+	t.ID = 0
+}
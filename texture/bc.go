@@ -0,0 +1,113 @@
+package texture
+
+import (
+	"encoding/binary"
+)
+
+// blockDecoders decode one block of each 8-bit format into 16 RGBA pixels,
+// row by row.
+var blockDecoders = [...]func(block []byte, out *[16][4]byte){
+	BC1:       func(b []byte, out *[16][4]byte) { decodeBC1(b, out, true) },
+	BC2:       decodeBC2,
+	BC3:       decodeBC3,
+	BC4:       decodeBC4,
+	BC5:       decodeBC5,
+	BC7:       decodeBC7,
+	ETC2RGB:   func(b []byte, out *[16][4]byte) { decodeETC2(b, out, false) },
+	ETC2RGBA1: func(b []byte, out *[16][4]byte) { decodeETC2(b, out, true) },
+	ETC2RGBA:  decodeETC2RGBA,
+}
+
+// rgb565 expands a packed 5:6:5 colour to 8 bits a channel.
+func rgb565(c uint16) [4]byte {
+	r, g, b := byte(c>>11&31), byte(c>>5&63), byte(c&31)
+	return [4]byte{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 255}
+}
+
+// decodeBC1 decodes a BC1 colour block. Blocks whose first colour is not
+// greater than the second have three colours and transparent black, unless
+// they are part of BC2 or BC3, where alpha is stored separately.
+func decodeBC1(block []byte, out *[16][4]byte, punchThrough bool) {
+	c0 := binary.LittleEndian.Uint16(block)
+	c1 := binary.LittleEndian.Uint16(block[2:])
+
+	var palette [4][4]byte
+	palette[0], palette[1] = rgb565(c0), rgb565(c1)
+	p0, p1 := palette[0], palette[1]
+	if c0 > c1 || !punchThrough {
+		for i := 0; i < 3; i++ {
+			palette[2][i] = byte((2*int(p0[i]) + int(p1[i]) + 1) / 3)
+			palette[3][i] = byte((int(p0[i]) + 2*int(p1[i]) + 1) / 3)
+		}
+		palette[2][3], palette[3][3] = 255, 255
+	} else {
+		for i := 0; i < 3; i++ {
+			palette[2][i] = byte((int(p0[i]) + int(p1[i])) / 2)
+		}
+		palette[2][3] = 255
+	}
+
+	indices := binary.LittleEndian.Uint32(block[4:])
+	for i := range out {
+		out[i] = palette[indices>>(2*i)&3]
+	}
+}
+
+func decodeBC2(block []byte, out *[16][4]byte) {
+	decodeBC1(block[8:], out, false)
+	for i := range out {
+		a := block[i/2] >> (4 * (i & 1)) & 15
+		out[i][3] = a<<4 | a
+	}
+}
+
+func decodeBC3(block []byte, out *[16][4]byte) {
+	decodeBC1(block[8:], out, false)
+	alpha := decodeBC4Channel(block)
+	for i := range out {
+		out[i][3] = alpha[i]
+	}
+}
+
+func decodeBC4(block []byte, out *[16][4]byte) {
+	red := decodeBC4Channel(block)
+	for i := range out {
+		out[i] = [4]byte{red[i], 0, 0, 255}
+	}
+}
+
+func decodeBC5(block []byte, out *[16][4]byte) {
+	red := decodeBC4Channel(block)
+	green := decodeBC4Channel(block[8:])
+	for i := range out {
+		out[i] = [4]byte{red[i], green[i], 0, 255}
+	}
+}
+
+// decodeBC4Channel decodes an 8-byte block of one interpolated channel, as
+// used by BC4, BC5 and the alpha of BC3.
+func decodeBC4Channel(block []byte) (out [16]byte) {
+	a0, a1 := int(block[0]), int(block[1])
+
+	var palette [8]byte
+	palette[0], palette[1] = byte(a0), byte(a1)
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = byte(((7-i)*a0 + i*a1 + 3) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = byte(((5-i)*a0 + i*a1 + 2) / 5)
+		}
+		palette[6], palette[7] = 0, 255
+	}
+
+	var indices uint64
+	for i := 7; i >= 2; i-- {
+		indices = indices<<8 | uint64(block[i])
+	}
+	for i := range out {
+		out[i] = palette[indices>>(3*i)&7]
+	}
+	return out
+}
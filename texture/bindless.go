@@ -0,0 +1,152 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// bindless is set once BindlessSupported has asked the driver; 1 for yes, -1
+// for no.
+var bindless int
+
+// BindlessSupported reports whether the driver has ARB_bindless_texture. It
+// needs a current GL context.
+func BindlessSupported() bool {
+	if bindless == 0 {
+		bindless = -1
+		var extensions int32
+		gl.GetIntegerv(gl.NUM_EXTENSIONS, &extensions)
+		for i := int32(0); i < extensions; i++ {
+			if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))) == "GL_ARB_bindless_texture" {
+				bindless = 1
+				break
+			}
+		}
+	}
+	return bindless > 0
+}
+
+// TextureSet is a list of same-sized images that shaders pick from by
+// index, without a texture unit each.
+//
+// With ARB_bindless_texture every image is a Texture2D of its own, passed
+// to the shader as a resident handle in a sampler array. Without it the
+// images are the layers of one Texture2DArray on one unit. Shaders call
+// sampleSet(index, uv) either way; Declare adds it to their source.
+type TextureSet struct {
+	// Bindless is set if the images are Textures with handles, rather than
+	// the layers of Array.
+	Bindless bool
+	Textures []*Texture2D
+	Array    *Texture2DArray
+
+	handles []uint64
+}
+
+// NewTextureSet uploads images, which must be the same size. It uses
+// bindless handles if preferBindless is set and the driver supports them,
+// and an array texture otherwise. It needs a current GL context.
+func NewTextureSet(images []image.Image, opts Options, preferBindless bool) (*TextureSet, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("texture: set has no images")
+	}
+	if !preferBindless || !BindlessSupported() {
+		array, err := NewTexture2DArray(images, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &TextureSet{Array: array}, nil
+	}
+
+	s := &TextureSet{Bindless: true}
+	for i, img := range images {
+		t, err := NewTexture2D(img, opts)
+		if err == nil && i > 0 && (t.Width != s.Textures[0].Width || t.Height != s.Textures[0].Height) {
+			err = fmt.Errorf("texture: set image %d is %dx%d, not %dx%d", i, t.Width, t.Height, s.Textures[0].Width, s.Textures[0].Height)
+			t.Delete()
+		}
+		if err != nil {
+			s.Delete()
+			return nil, err
+		}
+		s.Textures = append(s.Textures, t)
+
+		// A handle freezes the texture's state, so it comes last.
+		handle := gl.GetTextureHandleARB(t.ID)
+		gl.MakeTextureHandleResidentARB(handle)
+		s.handles = append(s.handles, handle)
+	}
+	return s, nil
+}
+
+// Len returns the number of images in the set.
+func (s *TextureSet) Len() int {
+	if s.Bindless {
+		return len(s.Textures)
+	}
+	return s.Array.Layers
+}
+
+// Declarations returns the GLSL that declares the set's sampler uniform,
+// textureSet, and the function sampleSet(int index, vec2 uv). It has to
+// follow the #version line, as the bindless variant enables the extension.
+// Keep index dynamically uniform: the same for every invocation of a draw.
+func (s *TextureSet) Declarations() string {
+	if s.Bindless {
+		return fmt.Sprintf(`#extension GL_ARB_bindless_texture : require
+layout(bindless_sampler) uniform sampler2D textureSet[%d];
+vec4 sampleSet(int index, vec2 uv) { return texture(textureSet[index], uv); }
+`, len(s.Textures))
+	}
+	return `uniform sampler2DArray textureSet;
+vec4 sampleSet(int index, vec2 uv) { return texture(textureSet, vec3(uv, index)); }
+`
+}
+
+// Declare inserts Declarations into source after its #version line.
+func (s *TextureSet) Declare(source string) string {
+	i := strings.Index(source, "#version")
+	if i < 0 {
+		return s.Declarations() + source
+	}
+	end := strings.IndexByte(source[i:], '\n')
+	if end < 0 {
+		return source + "\n" + s.Declarations()
+	}
+	end += i + 1
+	return source[:end] + s.Declarations() + source[end:]
+}
+
+// Use makes the set available to the program units allocates for: it sets
+// the handles, or binds the array texture to the textureSet sampler's unit.
+func (s *TextureSet) Use(units *Units) error {
+	if !s.Bindless {
+		return units.Bind("textureSet", s.Array)
+	}
+
+	location := gl.GetUniformLocation(units.Program, gl.Str("textureSet\x00"))
+	if location < 0 {
+		return fmt.Errorf("texture: textureSet is not in program %d", units.Program)
+	}
+	gl.ProgramUniformHandleui64vARB(units.Program, location, int32(len(s.handles)), &s.handles[0])
+	return nil
+}
+
+// Delete makes the handles non-resident and frees the textures.
+func (s *TextureSet) Delete() {
+	for _, h := range s.handles {
+		gl.MakeTextureHandleNonResidentARB(h)
+	}
+	s.handles = nil
+	for _, t := range s.Textures {
+		t.Delete()
+	}
+	s.Textures = nil
+	if s.Array != nil {
+		s.Array.Delete()
+		s.Array = nil
+	}
+}
@@ -0,0 +1,538 @@
+package texture
+
+import (
+	"encoding/binary"
+)
+
+// BPTC (BC6H and BC7) blocks are 128-bit strings of fields of any width,
+// read from the least significant bit of the first byte.
+type blockBits struct {
+	lo, hi uint64
+	pos    uint
+}
+
+func newBlockBits(block []byte) *blockBits {
+	return &blockBits{
+		lo: binary.LittleEndian.Uint64(block),
+		hi: binary.LittleEndian.Uint64(block[8:]),
+	}
+}
+
+// read returns the next n bits, n at most 32.
+func (b *blockBits) read(n uint) uint32 {
+	var v uint64
+	if b.pos < 64 {
+		v = b.lo>>b.pos | b.hi<<(64-b.pos)
+	} else {
+		v = b.hi >> (b.pos - 64)
+	}
+	b.pos += n
+	return uint32(v & (1<<n - 1))
+}
+
+// Interpolation weights for 2, 3 and 4-bit indices, out of 64.
+var bptcWeights = [...][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// partitions2 gives the subset of each pixel for the 64 two-subset
+// partitions, bit i set for pixel i in subset 1.
+var partitions2 = [64]uint16{
+	0xCCCC, 0x8888, 0xEEEE, 0xECC8, 0xC880, 0xFEEC, 0xFEC8, 0xEC80,
+	0xC800, 0xFFEC, 0xFE80, 0xE800, 0xFFE8, 0xFF00, 0xFFF0, 0xF000,
+	0xF710, 0x008E, 0x7100, 0x08CE, 0x008C, 0x7310, 0x3100, 0x8CCE,
+	0x088C, 0x3110, 0x6666, 0x366C, 0x17E8, 0x0FF0, 0x718E, 0x399C,
+	0xAAAA, 0xF0F0, 0x5A5A, 0x33CC, 0x3C3C, 0x55AA, 0x9696, 0xA55A,
+	0x73CE, 0x13C8, 0x324C, 0x3BDC, 0x6996, 0xC33C, 0x9966, 0x0660,
+	0x0272, 0x04E4, 0x4E40, 0x2720, 0xC936, 0x936C, 0x39C6, 0x639C,
+	0x9336, 0x9CC6, 0x817E, 0xE718, 0xCCF0, 0x0FCC, 0x7744, 0xEE22,
+}
+
+// partitions3 gives the subset of each pixel for the 64 three-subset
+// partitions.
+var partitions3 = [64][16]byte{
+	{0, 0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 1, 2, 2, 2, 2},
+	{0, 0, 0, 1, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 2, 0, 0, 1, 2, 2, 1, 1, 2, 2, 1, 1},
+	{0, 2, 2, 2, 0, 0, 2, 2, 0, 0, 1, 1, 0, 1, 1, 1},
+	{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2},
+	{0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 2, 2, 0, 0, 2, 2},
+	{0, 0, 2, 2, 0, 0, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1},
+	{0, 0, 1, 1, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1},
+	{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2},
+	{0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2},
+	{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2},
+	{0, 1, 1, 2, 0, 1, 1, 2, 0, 1, 1, 2, 0, 1, 1, 2},
+	{0, 1, 2, 2, 0, 1, 2, 2, 0, 1, 2, 2, 0, 1, 2, 2},
+	{0, 0, 1, 1, 0, 1, 1, 2, 1, 1, 2, 2, 1, 2, 2, 2},
+	{0, 0, 1, 1, 2, 0, 0, 1, 2, 2, 0, 0, 2, 2, 2, 0},
+	{0, 0, 0, 1, 0, 0, 1, 1, 0, 1, 1, 2, 1, 1, 2, 2},
+	{0, 1, 1, 1, 0, 0, 1, 1, 2, 0, 0, 1, 2, 2, 0, 0},
+	{0, 0, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1, 2, 2},
+	{0, 0, 2, 2, 0, 0, 2, 2, 0, 0, 2, 2, 1, 1, 1, 1},
+	{0, 1, 1, 1, 0, 1, 1, 1, 0, 2, 2, 2, 0, 2, 2, 2},
+	{0, 0, 0, 1, 0, 0, 0, 1, 2, 2, 2, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 0, 0, 1, 1, 0, 1, 2, 2, 0, 1, 2, 2},
+	{0, 0, 0, 0, 1, 1, 0, 0, 2, 2, 1, 0, 2, 2, 1, 0},
+	{0, 1, 2, 2, 0, 1, 2, 2, 0, 0, 1, 1, 0, 0, 0, 0},
+	{0, 0, 1, 2, 0, 0, 1, 2, 1, 1, 2, 2, 2, 2, 2, 2},
+	{0, 1, 1, 0, 1, 2, 2, 1, 1, 2, 2, 1, 0, 1, 1, 0},
+	{0, 0, 0, 0, 0, 1, 1, 0, 1, 2, 2, 1, 1, 2, 2, 1},
+	{0, 0, 2, 2, 1, 1, 0, 2, 1, 1, 0, 2, 0, 0, 2, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 2, 0, 0, 2, 2, 2, 2, 2},
+	{0, 0, 1, 1, 0, 1, 2, 2, 0, 1, 2, 2, 0, 0, 1, 1},
+	{0, 0, 0, 0, 2, 0, 0, 0, 2, 2, 1, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 2, 2, 2},
+	{0, 2, 2, 2, 0, 0, 2, 2, 0, 0, 1, 2, 0, 0, 1, 1},
+	{0, 0, 1, 1, 0, 0, 1, 2, 0, 0, 2, 2, 0, 2, 2, 2},
+	{0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0},
+	{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 0, 0, 0, 0},
+	{0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2, 0},
+	{0, 1, 2, 0, 2, 0, 1, 2, 1, 2, 0, 1, 0, 1, 2, 0},
+	{0, 0, 1, 1, 2, 2, 0, 0, 1, 1, 2, 2, 0, 0, 1, 1},
+	{0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 0, 0, 0, 0, 1, 1},
+	{0, 1, 0, 1, 0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 2, 1, 2, 1, 2, 1},
+	{0, 0, 2, 2, 1, 1, 2, 2, 0, 0, 2, 2, 1, 1, 2, 2},
+	{0, 0, 2, 2, 0, 0, 1, 1, 0, 0, 2, 2, 0, 0, 1, 1},
+	{0, 2, 2, 0, 1, 2, 2, 1, 0, 2, 2, 0, 1, 2, 2, 1},
+	{0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 0, 1, 0, 1},
+	{0, 0, 0, 0, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1},
+	{0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 2, 2, 2, 2},
+	{0, 2, 2, 2, 0, 1, 1, 1, 0, 2, 2, 2, 0, 1, 1, 1},
+	{0, 0, 0, 2, 1, 1, 1, 2, 0, 0, 0, 2, 1, 1, 1, 2},
+	{0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1, 2},
+	{0, 2, 2, 2, 0, 1, 1, 1, 0, 1, 1, 1, 0, 2, 2, 2},
+	{0, 0, 0, 2, 1, 1, 1, 2, 1, 1, 1, 2, 0, 0, 0, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 1, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 2, 2, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 2, 2},
+	{0, 0, 2, 2, 1, 1, 2, 2, 1, 1, 2, 2, 0, 0, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2},
+	{0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 1},
+	{0, 2, 2, 2, 1, 2, 2, 2, 0, 2, 2, 2, 1, 2, 2, 2},
+	{0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 1, 1, 1, 2, 0, 1, 1, 2, 2, 0, 1, 2, 2, 2, 0},
+}
+
+// Anchor pixels, whose index has its top bit left out because it is always
+// zero: pixel 0 for the first subset, and these for the others.
+var (
+	anchors2 = [64]byte{
+		15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+		15, 2, 8, 2, 2, 8, 8, 15, 2, 8, 2, 2, 8, 8, 2, 2,
+		15, 15, 6, 8, 2, 8, 15, 15, 2, 8, 2, 2, 2, 15, 15, 6,
+		6, 2, 6, 8, 15, 15, 2, 2, 15, 15, 15, 15, 15, 2, 2, 15,
+	}
+	anchors3Second = [64]byte{
+		3, 3, 15, 15, 8, 3, 15, 15, 8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10, 5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15, 15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10, 5, 10, 8, 13, 15, 12, 3, 3,
+	}
+	anchors3Third = [64]byte{
+		15, 8, 8, 3, 15, 15, 3, 8, 15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8, 3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10, 6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3, 15, 15, 8,
+	}
+)
+
+// subsetOf returns the subset pixel i belongs to and whether it is that
+// subset's anchor.
+func subsetOf(subsets int, partition uint32, i int) (subset int, anchor bool) {
+	switch subsets {
+	case 2:
+		subset = int(partitions2[partition] >> i & 1)
+		if subset == 1 {
+			return 1, i == int(anchors2[partition])
+		}
+	case 3:
+		subset = int(partitions3[partition][i])
+		switch subset {
+		case 1:
+			return 1, i == int(anchors3Second[partition])
+		case 2:
+			return 2, i == int(anchors3Third[partition])
+		}
+	}
+	return 0, i == 0
+}
+
+// bc7Modes describes the eight BC7 block layouts.
+var bc7Modes = [8]struct {
+	subsets                  int
+	partitionBits            uint
+	rotationBits, selectBits uint
+	colorBits, alphaBits     uint
+	endpointPBit, sharedPBit bool
+	indexBits, secondaryBits uint
+}{
+	{3, 4, 0, 0, 4, 0, true, false, 3, 0},
+	{2, 6, 0, 0, 6, 0, false, true, 3, 0},
+	{3, 6, 0, 0, 5, 0, false, false, 2, 0},
+	{2, 6, 0, 0, 7, 0, true, false, 2, 0},
+	{1, 0, 2, 1, 5, 6, false, false, 2, 3},
+	{1, 0, 2, 0, 7, 8, false, false, 2, 2},
+	{1, 0, 0, 0, 7, 7, true, false, 4, 0},
+	{2, 6, 0, 0, 5, 5, true, false, 2, 0},
+}
+
+func decodeBC7(block []byte, out *[16][4]byte) {
+	b := newBlockBits(block)
+
+	// The mode is the number of zero bits before the first one.
+	mode := 0
+	for mode < 8 && b.read(1) == 0 {
+		mode++
+	}
+	if mode == 8 {
+		*out = [16][4]byte{}
+		return
+	}
+	m := bc7Modes[mode]
+
+	partition := b.read(m.partitionBits)
+	rotation := b.read(m.rotationBits)
+	selector := b.read(m.selectBits)
+
+	// Endpoints are stored channel by channel, then their P bits, which
+	// add a shared lowest bit to every channel.
+	var endpoints [6][4]uint32
+	n := 2 * m.subsets
+	for c := 0; c < 3; c++ {
+		for e := 0; e < n; e++ {
+			endpoints[e][c] = b.read(m.colorBits)
+		}
+	}
+	for e := 0; e < n && m.alphaBits > 0; e++ {
+		endpoints[e][3] = b.read(m.alphaBits)
+	}
+
+	colorBits, alphaBits := m.colorBits, m.alphaBits
+	if m.endpointPBit || m.sharedPBit {
+		for e := 0; e < n; e++ {
+			if m.endpointPBit || e%2 == 0 {
+				p := b.read(1)
+				for c := 0; c < 4; c++ {
+					endpoints[e][c] = endpoints[e][c]<<1 | p
+					if m.sharedPBit {
+						endpoints[e+1][c] = endpoints[e+1][c]<<1 | p
+					}
+				}
+			}
+		}
+		colorBits++
+		if alphaBits > 0 {
+			alphaBits++
+		}
+	}
+
+	var colors [6][4]int
+	for e := 0; e < n; e++ {
+		for c := 0; c < 3; c++ {
+			colors[e][c] = int(expandBits(endpoints[e][c], colorBits))
+		}
+		colors[e][3] = 255
+		if alphaBits > 0 {
+			colors[e][3] = int(expandBits(endpoints[e][3], alphaBits))
+		}
+	}
+
+	var indices, secondary [16]uint32
+	for i := range indices {
+		bits := m.indexBits
+		if _, anchor := subsetOf(m.subsets, partition, i); anchor {
+			bits--
+		}
+		indices[i] = b.read(bits)
+	}
+	for i := 0; i < 16 && m.secondaryBits > 0; i++ {
+		bits := m.secondaryBits
+		if i == 0 {
+			bits--
+		}
+		secondary[i] = b.read(bits)
+	}
+
+	for i := range out {
+		subset, _ := subsetOf(m.subsets, partition, i)
+		e0, e1 := colors[2*subset], colors[2*subset+1]
+
+		colorIndex, colorWeights := indices[i], bptcWeights[m.indexBits]
+		alphaIndex, alphaWeights := indices[i], bptcWeights[m.indexBits]
+		if m.secondaryBits > 0 {
+			alphaIndex, alphaWeights = secondary[i], bptcWeights[m.secondaryBits]
+			if selector == 1 {
+				colorIndex, alphaIndex = alphaIndex, colorIndex
+				colorWeights, alphaWeights = alphaWeights, colorWeights
+			}
+		}
+
+		var p [4]byte
+		for c := 0; c < 3; c++ {
+			p[c] = byte(bptcInterpolate(e0[c], e1[c], colorWeights[colorIndex]))
+		}
+		p[3] = byte(bptcInterpolate(e0[3], e1[3], alphaWeights[alphaIndex]))
+
+		// Rotation swaps alpha with one of the colour channels, so that
+		// the channel with its own indices can be any of them.
+		if rotation > 0 {
+			p[3], p[rotation-1] = p[rotation-1], p[3]
+		}
+		out[i] = p
+	}
+}
+
+// expandBits widens an n-bit value to 8 bits by repeating its top bits.
+func expandBits(x uint32, n uint) uint32 {
+	x <<= 8 - n
+	return x | x>>n
+}
+
+func bptcInterpolate(a, b, weight int) int {
+	return ((64-weight)*a + weight*b + 32) >> 6
+}
+
+// BC6H endpoint fields, numbered endpoint by endpoint: w, x, y and z are
+// the two endpoints of the first subset and then of the second.
+const (
+	rw = iota
+	gw
+	bw
+	rx
+	gx
+	bx
+	ry
+	gy
+	by
+	rz
+	gz
+	bz
+	partitionField
+)
+
+// bc6hSegment is a run of count bits in a block, stored into a field from
+// bit shift up.
+type bc6hSegment struct {
+	field, shift, count uint8
+}
+
+// bc6hMode is one of the 14 BC6H block layouts.
+type bc6hMode struct {
+	// endpointBits is the precision of the first endpoint and deltaBits
+	// that of the others, per channel.
+	endpointBits uint
+	deltaBits    [3]uint
+	// transformed endpoints are stored as signed differences from the
+	// first.
+	transformed bool
+	subsets     int
+	layout      []bc6hSegment
+}
+
+// bc6hModes is indexed by the 2-bit mode, or the 5-bit mode when the low
+// two bits are 2 or 3. Reserved modes are left empty.
+var bc6hModes = map[uint32]bc6hMode{
+	0x00: {10, [3]uint{5, 5, 5}, true, 2, []bc6hSegment{
+		{gy, 4, 1}, {by, 4, 1}, {bz, 4, 1}, {rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10},
+		{rx, 0, 5}, {gz, 4, 1}, {gy, 0, 4}, {gx, 0, 5}, {bz, 0, 1}, {gz, 0, 4},
+		{bx, 0, 5}, {bz, 1, 1}, {by, 0, 4}, {ry, 0, 5}, {bz, 2, 1}, {rz, 0, 5},
+		{bz, 3, 1}, {partitionField, 0, 5},
+	}},
+	0x01: {7, [3]uint{6, 6, 6}, true, 2, []bc6hSegment{
+		{gy, 5, 1}, {gz, 4, 1}, {gz, 5, 1}, {rw, 0, 7}, {bz, 0, 1}, {bz, 1, 1},
+		{by, 4, 1}, {gw, 0, 7}, {by, 5, 1}, {bz, 2, 1}, {gy, 4, 1}, {bw, 0, 7},
+		{bz, 3, 1}, {bz, 5, 1}, {bz, 4, 1}, {rx, 0, 6}, {gy, 0, 4}, {gx, 0, 6},
+		{gz, 0, 4}, {bx, 0, 6}, {by, 0, 4}, {ry, 0, 6}, {rz, 0, 6},
+		{partitionField, 0, 5},
+	}},
+	0x02: {11, [3]uint{5, 4, 4}, true, 2, []bc6hSegment{
+		{rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10}, {rx, 0, 5}, {rw, 10, 1},
+		{gy, 0, 4}, {gx, 0, 4}, {gw, 10, 1}, {bz, 0, 1}, {gz, 0, 4}, {bx, 0, 4},
+		{bw, 10, 1}, {bz, 1, 1}, {by, 0, 4}, {ry, 0, 5}, {bz, 2, 1}, {rz, 0, 5},
+		{bz, 3, 1}, {partitionField, 0, 5},
+	}},
+	0x06: {11, [3]uint{4, 5, 4}, true, 2, []bc6hSegment{
+		{rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10}, {rx, 0, 4}, {rw, 10, 1},
+		{gz, 4, 1}, {gy, 0, 4}, {gx, 0, 5}, {gw, 10, 1}, {gz, 0, 4}, {bx, 0, 4},
+		{bw, 10, 1}, {bz, 1, 1}, {by, 0, 4}, {ry, 0, 4}, {bz, 0, 1}, {bz, 2, 1},
+		{rz, 0, 4}, {gy, 4, 1}, {bz, 3, 1}, {partitionField, 0, 5},
+	}},
+	0x0A: {11, [3]uint{4, 4, 5}, true, 2, []bc6hSegment{
+		{rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10}, {rx, 0, 4}, {rw, 10, 1},
+		{by, 4, 1}, {gy, 0, 4}, {gx, 0, 4}, {gw, 10, 1}, {bz, 0, 1}, {gz, 0, 4},
+		{bx, 0, 5}, {bw, 10, 1}, {by, 0, 4}, {ry, 0, 4}, {bz, 1, 1}, {bz, 2, 1},
+		{rz, 0, 4}, {bz, 4, 1}, {bz, 3, 1}, {partitionField, 0, 5},
+	}},
+	0x0E: {9, [3]uint{5, 5, 5}, true, 2, []bc6hSegment{
+		{rw, 0, 9}, {by, 4, 1}, {gw, 0, 9}, {gy, 4, 1}, {bw, 0, 9}, {bz, 4, 1},
+		{rx, 0, 5}, {gz, 4, 1}, {gy, 0, 4}, {gx, 0, 5}, {bz, 0, 1}, {gz, 0, 4},
+		{bx, 0, 5}, {bz, 1, 1}, {by, 0, 4}, {ry, 0, 5}, {bz, 2, 1}, {rz, 0, 5},
+		{bz, 3, 1}, {partitionField, 0, 5},
+	}},
+	0x12: {8, [3]uint{6, 5, 5}, true, 2, []bc6hSegment{
+		{rw, 0, 8}, {gz, 4, 1}, {by, 4, 1}, {gw, 0, 8}, {bz, 2, 1}, {gy, 4, 1},
+		{bw, 0, 8}, {bz, 3, 1}, {bz, 4, 1}, {rx, 0, 6}, {gy, 0, 4}, {gx, 0, 5},
+		{bz, 0, 1}, {gz, 0, 4}, {bx, 0, 5}, {bz, 1, 1}, {by, 0, 4}, {ry, 0, 6},
+		{rz, 0, 6}, {partitionField, 0, 5},
+	}},
+	0x16: {8, [3]uint{5, 6, 5}, true, 2, []bc6hSegment{
+		{rw, 0, 8}, {bz, 0, 1}, {by, 4, 1}, {gw, 0, 8}, {gy, 5, 1}, {gy, 4, 1},
+		{bw, 0, 8}, {gz, 5, 1}, {bz, 4, 1}, {rx, 0, 5}, {gz, 4, 1}, {gy, 0, 4},
+		{gx, 0, 6}, {gz, 0, 4}, {bx, 0, 5}, {bz, 1, 1}, {by, 0, 4}, {ry, 0, 5},
+		{bz, 2, 1}, {rz, 0, 5}, {bz, 3, 1}, {partitionField, 0, 5},
+	}},
+	0x1A: {8, [3]uint{5, 5, 6}, true, 2, []bc6hSegment{
+		{rw, 0, 8}, {bz, 1, 1}, {by, 4, 1}, {gw, 0, 8}, {by, 5, 1}, {gy, 4, 1},
+		{bw, 0, 8}, {bz, 5, 1}, {bz, 4, 1}, {rx, 0, 5}, {gz, 4, 1}, {gy, 0, 4},
+		{gx, 0, 5}, {bz, 0, 1}, {gz, 0, 4}, {bx, 0, 6}, {by, 0, 4}, {ry, 0, 5},
+		{bz, 2, 1}, {rz, 0, 5}, {bz, 3, 1}, {partitionField, 0, 5},
+	}},
+	0x1E: {6, [3]uint{6, 6, 6}, false, 2, []bc6hSegment{
+		{rw, 0, 6}, {gz, 4, 1}, {bz, 0, 1}, {bz, 1, 1}, {by, 4, 1}, {gw, 0, 6},
+		{gy, 5, 1}, {by, 5, 1}, {bz, 2, 1}, {gy, 4, 1}, {bw, 0, 6}, {gz, 5, 1},
+		{bz, 3, 1}, {bz, 5, 1}, {bz, 4, 1}, {rx, 0, 6}, {gy, 0, 4}, {gx, 0, 6},
+		{gz, 0, 4}, {bx, 0, 6}, {by, 0, 4}, {ry, 0, 6}, {rz, 0, 6},
+		{partitionField, 0, 5},
+	}},
+	0x03: {10, [3]uint{10, 10, 10}, false, 1, []bc6hSegment{
+		{rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10}, {rx, 0, 10}, {gx, 0, 10}, {bx, 0, 10},
+	}},
+	0x07: {11, [3]uint{9, 9, 9}, true, 1, []bc6hSegment{
+		{rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10}, {rx, 0, 9}, {rw, 10, 1},
+		{gx, 0, 9}, {gw, 10, 1}, {bx, 0, 9}, {bw, 10, 1},
+	}},
+	// The top bits of the first endpoint are stored in reverse order.
+	0x0B: {12, [3]uint{8, 8, 8}, true, 1, []bc6hSegment{
+		{rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10},
+		{rx, 0, 8}, {rw, 11, 1}, {rw, 10, 1},
+		{gx, 0, 8}, {gw, 11, 1}, {gw, 10, 1},
+		{bx, 0, 8}, {bw, 11, 1}, {bw, 10, 1},
+	}},
+	0x0F: {16, [3]uint{4, 4, 4}, true, 1, []bc6hSegment{
+		{rw, 0, 10}, {gw, 0, 10}, {bw, 0, 10},
+		{rx, 0, 4}, {rw, 15, 1}, {rw, 14, 1}, {rw, 13, 1}, {rw, 12, 1}, {rw, 11, 1}, {rw, 10, 1},
+		{gx, 0, 4}, {gw, 15, 1}, {gw, 14, 1}, {gw, 13, 1}, {gw, 12, 1}, {gw, 11, 1}, {gw, 10, 1},
+		{bx, 0, 4}, {bw, 15, 1}, {bw, 14, 1}, {bw, 13, 1}, {bw, 12, 1}, {bw, 11, 1}, {bw, 10, 1},
+	}},
+}
+
+// decodeBC6H decodes a BC6H block to RGB half floats, row by row.
+func decodeBC6H(block []byte, signed bool, out *[16][3]uint16) {
+	b := newBlockBits(block)
+
+	mode := b.read(2)
+	if mode >= 2 {
+		mode |= b.read(3) << 2
+	}
+	m, ok := bc6hModes[mode]
+	if !ok {
+		*out = [16][3]uint16{}
+		return
+	}
+
+	var fields [partitionField + 1]int32
+	for _, s := range m.layout {
+		fields[s.field] |= int32(b.read(uint(s.count))) << s.shift
+	}
+	partition := uint32(fields[partitionField])
+
+	// Sign extend, undo the delta transform and widen the endpoints to
+	// 16 bits.
+	var endpoints [4][3]int32
+	for e := 0; e < 2*m.subsets; e++ {
+		for c := 0; c < 3; c++ {
+			v := fields[3*e+c]
+			switch {
+			case e == 0:
+				if signed {
+					v = signExtend(v, m.endpointBits)
+				}
+			case m.transformed:
+				v = signExtend(v, m.deltaBits[c])
+				v = (fields[c] + v) & (1<<m.endpointBits - 1)
+				if signed {
+					v = signExtend(v, m.endpointBits)
+				}
+			case signed:
+				v = signExtend(v, m.deltaBits[c])
+			}
+			endpoints[e][c] = bc6hUnquantize(v, m.endpointBits, signed)
+		}
+	}
+
+	indexBits := uint(3)
+	if m.subsets == 1 {
+		indexBits = 4
+	}
+	weights := bptcWeights[indexBits]
+
+	for i := range out {
+		subset, anchor := subsetOf(m.subsets, partition, i)
+		bits := indexBits
+		if anchor {
+			bits--
+		}
+		w := weights[b.read(bits)]
+
+		e0, e1 := endpoints[2*subset], endpoints[2*subset+1]
+		for c := 0; c < 3; c++ {
+			v := int32(bptcInterpolate(int(e0[c]), int(e1[c]), w))
+			out[i][c] = bc6hFinish(v, signed)
+		}
+	}
+}
+
+func signExtend(v int32, bits uint) int32 {
+	shift := 32 - bits
+	return v << shift >> shift
+}
+
+// bc6hUnquantize scales an endpoint of the given precision to the full
+// 16-bit range, ready to interpolate.
+func bc6hUnquantize(v int32, bits uint, signed bool) int32 {
+	if !signed {
+		switch {
+		case bits >= 15, v == 0:
+			return v
+		case v == 1<<bits-1:
+			return 0xFFFF
+		}
+		return (v<<16 + 0x8000) >> bits
+	}
+
+	if bits >= 16 {
+		return v
+	}
+	negative := v < 0
+	if negative {
+		v = -v
+	}
+	switch {
+	case v == 0:
+	case v >= 1<<(bits-1)-1:
+		v = 0x7FFF
+	default:
+		v = (v<<15 + 0x4000) >> (bits - 1)
+	}
+	if negative {
+		v = -v
+	}
+	return v
+}
+
+// bc6hFinish scales an interpolated value to the bits of a half float.
+func bc6hFinish(v int32, signed bool) uint16 {
+	if !signed {
+		return uint16(v * 31 >> 6)
+	}
+	if v < 0 {
+		return 0x8000 | uint16(-v*31>>5)
+	}
+	return uint16(v * 31 >> 5)
+}
@@ -0,0 +1,452 @@
+package texture
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Format is a block compression format. Every format stores 4x4 pixel
+// blocks in a fixed number of bytes.
+type Format int
+
+const (
+	// BC1 (DXT1) is RGB with optional 1-bit alpha in 8 bytes.
+	BC1 Format = iota + 1
+	// BC2 (DXT3) adds explicit 4-bit alpha to BC1 colour.
+	BC2
+	// BC3 (DXT5) adds interpolated alpha to BC1 colour.
+	BC3
+	// BC4 is a single channel, such as a height or roughness map.
+	BC4
+	// BC5 is two channels, usually a normal map's x and y.
+	BC5
+	// BC6H is unsigned HDR RGB in half floats.
+	BC6H
+	// BC6HSigned is BC6H allowing negative values.
+	BC6HSigned
+	// BC7 is high quality RGBA.
+	BC7
+	// ETC2RGB is the ETC2 RGB format, a superset of ETC1.
+	ETC2RGB
+	// ETC2RGBA1 is ETC2 RGB with punch-through (1-bit) alpha.
+	ETC2RGBA1
+	// ETC2RGBA is ETC2 RGB with EAC alpha.
+	ETC2RGBA
+)
+
+// Compressed sRGB formats from EXT_texture_sRGB, which the core profile
+// bindings do not define.
+const (
+	compressedSRGBS3TCDXT1      = 0x8C4C
+	compressedSRGBAlphaS3TCDXT1 = 0x8C4D
+	compressedSRGBAlphaS3TCDXT3 = 0x8C4E
+	compressedSRGBAlphaS3TCDXT5 = 0x8C4F
+)
+
+var formats = [...]struct {
+	name      string
+	blockSize int
+	// internal is the GL internal format and srgb its sRGB variant, 0 if
+	// there is none.
+	internal, srgb uint32
+}{
+	BC1:        {"BC1", 8, gl.COMPRESSED_RGBA_S3TC_DXT1_EXT, compressedSRGBAlphaS3TCDXT1},
+	BC2:        {"BC2", 16, gl.COMPRESSED_RGBA_S3TC_DXT3_EXT, compressedSRGBAlphaS3TCDXT3},
+	BC3:        {"BC3", 16, gl.COMPRESSED_RGBA_S3TC_DXT5_EXT, compressedSRGBAlphaS3TCDXT5},
+	BC4:        {"BC4", 8, gl.COMPRESSED_RED_RGTC1, 0},
+	BC5:        {"BC5", 16, gl.COMPRESSED_RG_RGTC2, 0},
+	BC6H:       {"BC6H", 16, gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT, 0},
+	BC6HSigned: {"BC6H signed", 16, gl.COMPRESSED_RGB_BPTC_SIGNED_FLOAT, 0},
+	BC7:        {"BC7", 16, gl.COMPRESSED_RGBA_BPTC_UNORM, gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM},
+	ETC2RGB:    {"ETC2 RGB", 8, gl.COMPRESSED_RGB8_ETC2, gl.COMPRESSED_SRGB8_ETC2},
+	ETC2RGBA1:  {"ETC2 RGB A1", 8, gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2},
+	ETC2RGBA:   {"ETC2 RGBA", 16, gl.COMPRESSED_RGBA8_ETC2_EAC, gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC},
+}
+
+// known reports whether f is one of the formats above.
+func (f Format) known() bool {
+	return f > 0 && int(f) < len(formats)
+}
+
+func (f Format) String() string {
+	if !f.known() {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formats[f].name
+}
+
+// BlockSize returns the number of bytes in a 4x4 block, or 0 for an unknown
+// format.
+func (f Format) BlockSize() int {
+	if !f.known() {
+		return 0
+	}
+	return formats[f].blockSize
+}
+
+// LevelSize returns the number of bytes in an image of the given size.
+func (f Format) LevelSize(width, height int) int {
+	return ((width + 3) / 4) * ((height + 3) / 4) * f.BlockSize()
+}
+
+// InternalFormat returns the GL internal format, using the sRGB variant if
+// srgb is set and there is one, or 0 for an unknown format.
+func (f Format) InternalFormat(srgb bool) uint32 {
+	if !f.known() {
+		return 0
+	}
+	if srgb && formats[f].srgb != 0 {
+		return formats[f].srgb
+	}
+	return formats[f].internal
+}
+
+// HDR reports whether f holds floating point colour.
+func (f Format) HDR() bool {
+	return f == BC6H || f == BC6HSigned
+}
+
+// formatFromGL looks up a GL internal format, as stored in KTX files.
+func formatFromGL(internal uint32) (f Format, srgb bool, ok bool) {
+	switch internal {
+	case gl.COMPRESSED_RGB_S3TC_DXT1_EXT:
+		return BC1, false, true
+	case compressedSRGBS3TCDXT1:
+		return BC1, true, true
+	}
+	for f := BC1; int(f) < len(formats); f++ {
+		switch internal {
+		case formats[f].internal:
+			return f, false, true
+		case formats[f].srgb:
+			return f, true, true
+		}
+	}
+	return 0, false, false
+}
+
+// CompressedImage is block compressed image data with its mipmaps, as read
+// from a DDS or KTX file.
+type CompressedImage struct {
+	Format        Format
+	SRGB          bool
+	Width, Height int
+	// Levels holds each mip level, starting with the full size image.
+	Levels [][]byte
+}
+
+// LevelSize returns the width and height of a mip level.
+func (c *CompressedImage) LevelSize(level int) (width, height int) {
+	width, height = c.Width>>level, c.Height>>level
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return width, height
+}
+
+// readLevels slices levels mip levels out of data, which holds them one
+// after the other.
+func (c *CompressedImage) readLevels(data []byte, levels int) error {
+	for l := 0; l < levels; l++ {
+		w, h := c.LevelSize(l)
+		size := c.Format.LevelSize(w, h)
+		if len(data) < size {
+			return fmt.Errorf("mip level %d is truncated", l)
+		}
+		c.Levels = append(c.Levels, data[:size])
+		data = data[size:]
+		if w == 1 && h == 1 {
+			break
+		}
+	}
+	return nil
+}
+
+// isContainer reports whether header starts a DDS or KTX file.
+func isContainer(header []byte) bool {
+	return bytes.HasPrefix(header, ddsMagic) ||
+		bytes.HasPrefix(header, ktxMagic) ||
+		bytes.HasPrefix(header, ktx2Magic)
+}
+
+// DecodeCompressed reads a DDS, KTX or KTX2 file from r, telling them apart
+// by their first bytes.
+func DecodeCompressed(r io.Reader) (*CompressedImage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, ddsMagic):
+		return decodeDDS(data)
+	case bytes.HasPrefix(data, ktxMagic):
+		return decodeKTX(data)
+	case bytes.HasPrefix(data, ktx2Magic):
+		return decodeKTX2(data)
+	}
+	return nil, fmt.Errorf("not a DDS or KTX file")
+}
+
+// LoadCompressed reads the DDS, KTX or KTX2 file at path.
+func LoadCompressed(path string) (*CompressedImage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := DecodeCompressed(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// supported holds the compressed internal formats the driver accepts, once
+// Supported has asked it.
+var supported map[uint32]bool
+
+// Supported reports whether the driver can sample textures of format f. It
+// needs a current GL context.
+func Supported(f Format, srgb bool) bool {
+	if supported == nil {
+		supported = driverFormats()
+	}
+	return supported[f.InternalFormat(srgb)]
+}
+
+func driverFormats() map[uint32]bool {
+	formats := make(map[uint32]bool)
+	add := func(first, last uint32) {
+		for f := first; f <= last; f++ {
+			formats[f] = true
+		}
+	}
+
+	var n int32
+	gl.GetIntegerv(gl.NUM_COMPRESSED_TEXTURE_FORMATS, &n)
+	if n > 0 {
+		list := make([]int32, n)
+		gl.GetIntegerv(gl.COMPRESSED_TEXTURE_FORMATS, &list[0])
+		for _, f := range list {
+			formats[uint32(f)] = true
+		}
+	}
+
+	// Core profiles need not list every format they support, so go by the
+	// version and extensions as well.
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	version := major*10 + minor
+	if version >= 30 {
+		add(gl.COMPRESSED_RED_RGTC1, gl.COMPRESSED_SIGNED_RG_RGTC2)
+	}
+	if version >= 42 {
+		add(gl.COMPRESSED_RGBA_BPTC_UNORM, gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT)
+	}
+	if version >= 43 {
+		add(gl.COMPRESSED_R11_EAC, gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC)
+	}
+
+	var extensions int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &extensions)
+	for i := int32(0); i < extensions; i++ {
+		switch gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))) {
+		case "GL_EXT_texture_compression_s3tc":
+			add(gl.COMPRESSED_RGB_S3TC_DXT1_EXT, gl.COMPRESSED_RGBA_S3TC_DXT5_EXT)
+		case "GL_EXT_texture_sRGB", "GL_EXT_texture_compression_s3tc_srgb":
+			add(compressedSRGBS3TCDXT1, compressedSRGBAlphaS3TCDXT5)
+		case "GL_ARB_texture_compression_rgtc":
+			add(gl.COMPRESSED_RED_RGTC1, gl.COMPRESSED_SIGNED_RG_RGTC2)
+		case "GL_ARB_texture_compression_bptc":
+			add(gl.COMPRESSED_RGBA_BPTC_UNORM, gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT)
+		case "GL_ARB_ES3_compatibility":
+			add(gl.COMPRESSED_R11_EAC, gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC)
+		}
+	}
+
+	return formats
+}
+
+// NewCompressedTexture2D uploads c with its mipmaps as they are. If the
+// driver does not support the format, the levels are decompressed on the
+// CPU and uploaded as RGBA8, or RGBA16F for BC6H, instead.
+//
+// opts.SRGB picks the sRGB variant of the format even if the file does not
+// ask for it. Mipmaps, FlipY and Channels are ignored: compressed images
+// bring their own mipmaps and cannot be rearranged without decoding them.
+// It needs a current GL context.
+func NewCompressedTexture2D(c *CompressedImage, opts Options) (*Texture2D, error) {
+	if len(c.Levels) == 0 || c.Width == 0 || c.Height == 0 {
+		return nil, fmt.Errorf("texture: image is empty")
+	}
+	if !c.Format.known() {
+		return nil, fmt.Errorf("texture: unknown compressed format %v", c.Format)
+	}
+	srgb := c.SRGB || opts.SRGB
+	if !Supported(c.Format, srgb) {
+		return newDecompressedTexture2D(c, srgb, opts)
+	}
+
+	internal := c.Format.InternalFormat(srgb)
+	t := &Texture2D{Width: c.Width, Height: c.Height, InternalFormat: internal, Levels: int32(len(c.Levels))}
+
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	if opts.Immutable {
+		gl.TexStorage2D(gl.TEXTURE_2D, t.Levels, internal, int32(c.Width), int32(c.Height))
+	}
+	for l, data := range c.Levels {
+		w, h := c.LevelSize(l)
+		if opts.Immutable {
+			gl.CompressedTexSubImage2D(gl.TEXTURE_2D, int32(l), 0, 0, int32(w), int32(h), internal, int32(len(data)), gl.Ptr(data))
+		} else {
+			gl.CompressedTexImage2D(gl.TEXTURE_2D, int32(l), internal, int32(w), int32(h), 0, int32(len(data)), gl.Ptr(data))
+		}
+	}
+	if !opts.Immutable {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, t.Levels-1)
+	}
+
+	setParameters(gl.TEXTURE_2D, opts, t.Levels > 1)
+	return t, nil
+}
+
+// newDecompressedTexture2D is NewCompressedTexture2D's fallback.
+func newDecompressedTexture2D(c *CompressedImage, srgb bool, opts Options) (*Texture2D, error) {
+	var internal, typ uint32 = gl.RGBA8, gl.UNSIGNED_BYTE
+	switch {
+	case c.Format.HDR():
+		internal, typ = gl.RGBA16F, gl.HALF_FLOAT
+	case srgb:
+		internal = gl.SRGB8_ALPHA8
+	}
+
+	// Decode every level before creating the texture, so that a bad block
+	// does not leave half a texture behind.
+	pixels := make([]interface{}, len(c.Levels))
+	for l := range c.Levels {
+		var err error
+		if c.Format.HDR() {
+			pixels[l], err = c.DecompressHalf(l)
+		} else {
+			var img *image.NRGBA
+			img, err = c.Decompress(l)
+			if img != nil {
+				pixels[l] = img.Pix
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t := &Texture2D{Width: c.Width, Height: c.Height, InternalFormat: internal, Levels: int32(len(c.Levels))}
+
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	if opts.Immutable {
+		gl.TexStorage2D(gl.TEXTURE_2D, t.Levels, internal, int32(c.Width), int32(c.Height))
+	}
+	for l, pix := range pixels {
+		w, h := c.LevelSize(l)
+		if opts.Immutable {
+			gl.TexSubImage2D(gl.TEXTURE_2D, int32(l), 0, 0, int32(w), int32(h), gl.RGBA, typ, gl.Ptr(pix))
+		} else {
+			gl.TexImage2D(gl.TEXTURE_2D, int32(l), int32(internal), int32(w), int32(h), 0, gl.RGBA, typ, gl.Ptr(pix))
+		}
+	}
+	if !opts.Immutable {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, t.Levels-1)
+	}
+
+	setParameters(gl.TEXTURE_2D, opts, t.Levels > 1)
+	return t, nil
+}
+
+// Decompress decodes a mip level to 8-bit RGBA, as the GPU would sample it:
+// BC4 gives red only and BC5 red and green, with the other channels 0 and
+// alpha 1. BC6H holds HDR colour and has to use DecompressHalf.
+func (c *CompressedImage) Decompress(level int) (*image.NRGBA, error) {
+	if c.Format.HDR() {
+		return nil, fmt.Errorf("texture: %v is HDR, use DecompressHalf", c.Format)
+	}
+	data, w, h, err := c.level(level)
+	if err != nil {
+		return nil, err
+	}
+
+	decode := blockDecoders[c.Format]
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	var block [16][4]byte
+	size := c.Format.BlockSize()
+	for by := 0; by < h; by += 4 {
+		for bx := 0; bx < w; bx += 4 {
+			decode(data[:size], &block)
+			data = data[size:]
+			for y := 0; y < 4 && by+y < h; y++ {
+				for x := 0; x < 4 && bx+x < w; x++ {
+					copy(img.Pix[img.PixOffset(bx+x, by+y):], block[4*y+x][:])
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// DecompressHalf decodes a mip level of BC6H to RGBA half floats, four per
+// pixel with alpha 1, ready to upload as HALF_FLOAT.
+func (c *CompressedImage) DecompressHalf(level int) ([]uint16, error) {
+	if !c.Format.HDR() {
+		return nil, fmt.Errorf("texture: %v is not HDR, use Decompress", c.Format)
+	}
+	data, w, h, err := c.level(level)
+	if err != nil {
+		return nil, err
+	}
+
+	const one = 0x3C00 // 1.0 as a half float
+	pix := make([]uint16, 4*w*h)
+	var block [16][3]uint16
+	for by := 0; by < h; by += 4 {
+		for bx := 0; bx < w; bx += 4 {
+			decodeBC6H(data[:16], c.Format == BC6HSigned, &block)
+			data = data[16:]
+			for y := 0; y < 4 && by+y < h; y++ {
+				for x := 0; x < 4 && bx+x < w; x++ {
+					p := pix[4*((by+y)*w+bx+x):]
+					copy(p, block[4*y+x][:])
+					p[3] = one
+				}
+			}
+		}
+	}
+	return pix, nil
+}
+
+// level returns a mip level's data and size, checking that the format is
+// known and the level is all there.
+func (c *CompressedImage) level(level int) (data []byte, width, height int, err error) {
+	if !c.Format.known() {
+		return nil, 0, 0, fmt.Errorf("texture: unknown compressed format %v", c.Format)
+	}
+	if level < 0 || level >= len(c.Levels) {
+		return nil, 0, 0, fmt.Errorf("texture: no mip level %d", level)
+	}
+	width, height = c.LevelSize(level)
+	data = c.Levels[level]
+	if len(data) < c.Format.LevelSize(width, height) {
+		return nil, 0, 0, fmt.Errorf("texture: mip level %d is truncated", level)
+	}
+	return data, width, height, nil
+}
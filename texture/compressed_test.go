@@ -0,0 +1,135 @@
+package texture
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestDecompressFixtures(t *testing.T) {
+	tests := []struct {
+		file   string
+		format Format
+		srgb   bool
+		// The checker alternates 4x4 squares of a and b, starting with a,
+		// and the last mip level averages them to mean.
+		a, b, mean color.NRGBA
+	}{
+		{"testdata/checker-bc1.dds", BC1, false,
+			color.NRGBA{231, 121, 24, 255}, color.NRGBA{24, 89, 206, 255}, color.NRGBA{132, 105, 115, 255}},
+		{"testdata/checker-bc7.ktx2", BC7, true,
+			color.NRGBA{231, 121, 31, 255}, color.NRGBA{31, 91, 201, 255}, color.NRGBA{131, 105, 115, 255}},
+		{"testdata/checker-etc2.ktx", ETC2RGB, false,
+			color.NRGBA{238, 119, 34, 255}, color.NRGBA{34, 85, 204, 255}, color.NRGBA{136, 102, 119, 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			c, err := LoadCompressed(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Format != tt.format || c.SRGB != tt.srgb || c.Width != 16 || c.Height != 16 {
+				t.Fatalf("got %v (sRGB %v) %dx%d, want %v (sRGB %v) 16x16", c.Format, c.SRGB, c.Width, c.Height, tt.format, tt.srgb)
+			}
+			// 16, 8, 4, 2 and 1 pixels across.
+			if len(c.Levels) != 5 {
+				t.Fatalf("got %d mip levels, want 5", len(c.Levels))
+			}
+
+			img, err := c.Decompress(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					want := tt.a
+					if (x/4+y/4)%2 == 1 {
+						want = tt.b
+					}
+					if got := img.NRGBAAt(x, y); got != want {
+						t.Fatalf("pixel (%d, %d) is %v, want %v", x, y, got, want)
+					}
+				}
+			}
+
+			last, err := c.Decompress(len(c.Levels) - 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := last.Bounds(); b.Dx() != 1 || b.Dy() != 1 {
+				t.Fatalf("last level is %v, want 1x1", b)
+			}
+			if got := last.NRGBAAt(0, 0); got != tt.mean {
+				t.Errorf("last level is %v, want %v", got, tt.mean)
+			}
+
+			if _, err := c.Decompress(len(c.Levels)); err == nil {
+				t.Error("no error decompressing a level past the last")
+			}
+			if _, err := c.DecompressHalf(0); err == nil {
+				t.Error("no error decompressing LDR to half floats")
+			}
+		})
+	}
+}
+
+// bc7Mode6 builds a mode 6 block of one colour, with every channel of both
+// endpoints set to the 7-bit value v and both P bits to p.
+func bc7Mode6(v, p uint64) []byte {
+	var bits [2]uint64
+	pos := uint(0)
+	put := func(x uint64, n uint) {
+		for i := uint(0); i < n; i, pos = i+1, pos+1 {
+			bits[pos/64] |= (x >> i & 1) << (pos % 64)
+		}
+	}
+	put(1<<6, 7)
+	for i := 0; i < 8; i++ {
+		put(v, 7)
+	}
+	put(p, 1)
+	put(p, 1)
+
+	block := make([]byte, 16)
+	for i := range block {
+		block[i] = byte(bits[i/8] >> (8 * (i % 8)))
+	}
+	return block
+}
+
+func TestBC7PBits(t *testing.T) {
+	// The P bit is each endpoint's lowest bit, so 127 stored with P of 0
+	// is 254, not 255.
+	for _, tt := range []struct {
+		v, p uint64
+		want byte
+	}{
+		{127, 1, 255},
+		{127, 0, 254},
+		{0, 0, 0},
+		{0, 1, 1},
+		{64, 1, 129},
+	} {
+		var out [16][4]byte
+		decodeBC7(bc7Mode6(tt.v, tt.p), &out)
+		for i, px := range out {
+			if px != [4]byte{tt.want, tt.want, tt.want, tt.want} {
+				t.Fatalf("value %d with P %d: pixel %d is %v, want %d", tt.v, tt.p, i, px, tt.want)
+			}
+		}
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	for _, f := range []Format{0, -1, ETC2RGBA + 1} {
+		if f.BlockSize() != 0 || f.InternalFormat(false) != 0 {
+			t.Errorf("%v: block size %d, internal format %#x, want 0", f, f.BlockSize(), f.InternalFormat(false))
+		}
+		c := &CompressedImage{Format: f, Width: 4, Height: 4, Levels: [][]byte{make([]byte, 16)}}
+		if _, err := c.Decompress(0); err == nil {
+			t.Errorf("%v: no error decompressing", f)
+		}
+		if _, err := c.DecompressHalf(0); err == nil {
+			t.Errorf("%v: no error decompressing to half floats", f)
+		}
+	}
+}
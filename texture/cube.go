@@ -0,0 +1,181 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Cube map faces, in the order GL numbers them.
+const (
+	PositiveX = iota
+	NegativeX
+	PositiveY
+	NegativeY
+	PositiveZ
+	NegativeZ
+)
+
+// TextureCube is a cube map texture: six square faces sampled by direction.
+type TextureCube struct {
+	ID uint32
+	// Size is the width and height of each face.
+	Size           int
+	InternalFormat uint32
+	// Levels is the number of mipmap levels.
+	Levels int32
+}
+
+// cubeOptions fills in CLAMP_TO_EDGE wrapping, which hides the seams
+// between faces, unless opts asks for something else.
+func cubeOptions(opts Options) Options {
+	if opts.WrapS == 0 {
+		opts.WrapS = gl.CLAMP_TO_EDGE
+	}
+	if opts.WrapT == 0 {
+		opts.WrapT = gl.CLAMP_TO_EDGE
+	}
+	return opts
+}
+
+// NewTextureCube uploads six face images, indexed by PositiveX to
+// NegativeZ. The faces must be square and the same size. Images are used as
+// they are, top row first, which is the orientation cube maps expect. It
+// needs a current GL context.
+func NewTextureCube(faces [6]image.Image, opts Options) (*TextureCube, error) {
+	internal, format, err := opts.formats()
+	if err != nil {
+		return nil, err
+	}
+
+	channels := opts.Channels
+	if channels == 0 {
+		channels = 4
+	}
+
+	var pix [6][]byte
+	size := 0
+	for i, img := range faces {
+		if img == nil {
+			return nil, fmt.Errorf("texture: cube face %d is missing", i)
+		}
+		p, w, h := pixels(img, channels, opts.FlipY)
+		if w != h {
+			return nil, fmt.Errorf("texture: cube face %d is %dx%d, not square", i, w, h)
+		}
+		if i == 0 {
+			size = w
+		} else if w != size {
+			return nil, fmt.Errorf("texture: cube face %d is %dx%d, not %dx%d", i, w, h, size, size)
+		}
+		pix[i] = p
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("texture: cube faces are empty")
+	}
+
+	t := &TextureCube{Size: size, InternalFormat: internal, Levels: 1}
+	if opts.Mipmaps {
+		t.Levels = mipLevels(size, size)
+	}
+
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, t.ID)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	if opts.Immutable {
+		gl.TexStorage2D(gl.TEXTURE_CUBE_MAP, t.Levels, internal, int32(size), int32(size))
+	}
+	for i := range pix {
+		target := uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X + i)
+		if opts.Immutable {
+			gl.TexSubImage2D(target, 0, 0, 0, int32(size), int32(size), format, gl.UNSIGNED_BYTE, gl.Ptr(pix[i]))
+		} else {
+			gl.TexImage2D(target, 0, int32(internal), int32(size), int32(size), 0, format, gl.UNSIGNED_BYTE, gl.Ptr(pix[i]))
+		}
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	if !opts.Immutable {
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAX_LEVEL, t.Levels-1)
+	}
+
+	if opts.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+
+	t.setParameters(opts)
+	return t, nil
+}
+
+// setParameters applies opts to the bound cube map, wrapping R as S.
+func (t *TextureCube) setParameters(opts Options) {
+	opts = cubeOptions(opts)
+	setParameters(gl.TEXTURE_CUBE_MAP, opts, t.Levels > 1)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, opts.WrapS)
+}
+
+// LoadTextureCube reads six face image files, indexed by PositiveX to
+// NegativeZ, and uploads them.
+func LoadTextureCube(paths [6]string, opts Options) (*TextureCube, error) {
+	var faces [6]image.Image
+	for i, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("texture %q not found on disk: %v", path, err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: texture: %v", path, err)
+		}
+		faces[i] = img
+	}
+	return NewTextureCube(faces, opts)
+}
+
+// LoadTextureCubeFS reads six face images in fsys, such as an embed.FS, and
+// uploads them.
+func LoadTextureCubeFS(fsys fs.FS, paths [6]string, opts Options) (*TextureCube, error) {
+	var faces [6]image.Image
+	for i, path := range paths {
+		f, err := fsys.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: texture: %v", path, err)
+		}
+		faces[i] = img
+	}
+	return NewTextureCube(faces, opts)
+}
+
+// Bind makes t the cube map of texture unit unit (0 for TEXTURE0).
+func (t *TextureCube) Bind(unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, t.ID)
+}
+
+// SetFilter sets the minifying and magnifying filters.
+func (t *TextureCube) SetFilter(min, mag int32) {
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, t.ID)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, min)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, mag)
+}
+
+// GenerateMipmaps rebuilds the mipmaps of every face from level 0.
+func (t *TextureCube) GenerateMipmaps() {
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, t.ID)
+	gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+}
+
+// Delete frees the texture.
+func (t *TextureCube) Delete() {
+	gl.DeleteTextures(1, &t.ID)
+	t.ID = 0
+}
@@ -0,0 +1,88 @@
+package texture
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var ddsMagic = []byte("DDS ")
+
+const (
+	ddsHeaderSize = 128 // magic and DDS_HEADER
+	ddsDX10Size   = 20  // DDS_HEADER_DXT10
+
+	ddsPixelFormatFourCC = 0x4
+)
+
+// DXGI formats of the block compressed images a DX10 header can describe,
+// with whether they are sRGB.
+var dxgiFormats = map[uint32]struct {
+	format Format
+	srgb   bool
+}{
+	71: {BC1, false},
+	72: {BC1, true},
+	74: {BC2, false},
+	75: {BC2, true},
+	77: {BC3, false},
+	78: {BC3, true},
+	80: {BC4, false},
+	83: {BC5, false},
+	95: {BC6H, false},
+	96: {BC6HSigned, false},
+	98: {BC7, false},
+	99: {BC7, true},
+}
+
+// decodeDDS reads a DirectDraw Surface. Only block compressed 2D images are
+// supported; of a cube map or array, the first image is read.
+func decodeDDS(data []byte) (*CompressedImage, error) {
+	if len(data) < ddsHeaderSize {
+		return nil, fmt.Errorf("DDS header is truncated")
+	}
+	le := binary.LittleEndian
+
+	c := &CompressedImage{
+		Height: int(le.Uint32(data[12:])),
+		Width:  int(le.Uint32(data[16:])),
+	}
+	levels := int(le.Uint32(data[28:]))
+	if levels == 0 {
+		levels = 1
+	}
+
+	if le.Uint32(data[80:])&ddsPixelFormatFourCC == 0 {
+		return nil, fmt.Errorf("uncompressed DDS files are not supported")
+	}
+	offset := ddsHeaderSize
+	switch fourCC := string(data[84:88]); fourCC {
+	case "DXT1":
+		c.Format = BC1
+	case "DXT2", "DXT3":
+		c.Format = BC2
+	case "DXT4", "DXT5":
+		c.Format = BC3
+	case "ATI1", "BC4U":
+		c.Format = BC4
+	case "ATI2", "BC5U":
+		c.Format = BC5
+	case "DX10":
+		if len(data) < ddsHeaderSize+ddsDX10Size {
+			return nil, fmt.Errorf("DDS DX10 header is truncated")
+		}
+		dxgi := le.Uint32(data[ddsHeaderSize:])
+		f, ok := dxgiFormats[dxgi]
+		if !ok {
+			return nil, fmt.Errorf("DXGI format %d is not supported", dxgi)
+		}
+		c.Format, c.SRGB = f.format, f.srgb
+		offset += ddsDX10Size
+	default:
+		return nil, fmt.Errorf("DDS format %q is not supported", fourCC)
+	}
+
+	if err := c.readLevels(data[offset:], levels); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
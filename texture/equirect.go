@@ -0,0 +1,156 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/purelazy/GopenGL/shader"
+)
+
+// The equirectangular conversion draws one full-screen triangle per face,
+// with no vertex data, and looks up the panorama in the direction of each
+// texel.
+var equirectVertexShader = `
+#version 330
+
+void main() {
+    vec2 p = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    gl_Position = vec4(p * 2.0 - 1.0, 0, 1);
+}
+` + "\x00"
+
+var equirectFragmentShader = `
+#version 330
+
+const float PI = 3.14159265358979;
+
+uniform sampler2D pano;
+uniform int face;
+uniform float size;
+
+out vec4 outputColor;
+
+void main() {
+    // Face coordinates (s, t) mapped to [-1, 1].
+    vec2 st = gl_FragCoord.xy / size * 2.0 - 1.0;
+    float u = st.x, v = st.y;
+
+    vec3 d;
+    if (face == 0) d = vec3(1, -v, -u);
+    else if (face == 1) d = vec3(-1, -v, u);
+    else if (face == 2) d = vec3(u, 1, v);
+    else if (face == 3) d = vec3(u, -1, -v);
+    else if (face == 4) d = vec3(u, -v, 1);
+    else d = vec3(-u, -v, -1);
+    d = normalize(d);
+
+    // The panorama's top row (t = 0, as uploaded without FlipY) is straight
+    // up, and its centre column looks along +X.
+    vec2 uv = vec2(0.5 + atan(d.z, d.x) / (2.0 * PI), 0.5 - asin(d.y) / PI);
+    outputColor = vec4(textureLod(pano, uv, 0).rgb, 1);
+}
+` + "\x00"
+
+// NewTextureCubeFromEquirect renders an equirectangular (latitude-longitude)
+// panorama into the faces of a new size x size cube map. The cube map is
+// RGBA16F so that HDR panoramas keep their range. Of opts, Mipmaps and the
+// filter and wrap settings are used. The GL state it changes is restored.
+func NewTextureCubeFromEquirect(pano *Texture2D, size int, opts Options) (*TextureCube, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("texture: cube size %d is not positive", size)
+	}
+
+	program, err := shader.NewProgram(equirectVertexShader, equirectFragmentShader)
+	if err != nil {
+		return nil, fmt.Errorf("texture: %v", err)
+	}
+	defer gl.DeleteProgram(program)
+
+	t := &TextureCube{Size: size, InternalFormat: gl.RGBA16F, Levels: 1}
+	if opts.Mipmaps {
+		t.Levels = mipLevels(size, size)
+	}
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, t.ID)
+	gl.TexStorage2D(gl.TEXTURE_CUBE_MAP, t.Levels, t.InternalFormat, int32(size), int32(size))
+
+	// Save the state drawing the faces changes.
+	var framebuffer, program0, vao0 int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &framebuffer)
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program0)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &vao0)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	depthTest := gl.IsEnabled(gl.DEPTH_TEST)
+	blend := gl.IsEnabled(gl.BLEND)
+	cullFace := gl.IsEnabled(gl.CULL_FACE)
+
+	var fbo, vao uint32
+	gl.GenFramebuffers(1, &fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, fbo)
+	gl.GenVertexArrays(1, &vao)
+	gl.BindVertexArray(vao)
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.CULL_FACE)
+	gl.Viewport(0, 0, int32(size), int32(size))
+
+	gl.UseProgram(program)
+	pano.Bind(0)
+	gl.Uniform1i(shader.Uniform(program, "pano"), 0)
+	gl.Uniform1f(shader.Uniform(program, "size"), float32(size))
+	faceUniform := shader.Uniform(program, "face")
+
+	for face := 0; face < 6; face++ {
+		gl.FramebufferTexture2D(gl.DRAW_FRAMEBUFFER, gl.COLOR_ATTACHMENT0, uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+face), t.ID, 0)
+		if status := gl.CheckFramebufferStatus(gl.DRAW_FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+			err = fmt.Errorf("texture: cube face framebuffer is incomplete (0x%X)", status)
+			break
+		}
+		gl.Uniform1i(faceUniform, int32(face))
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	}
+
+	// Put everything back.
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(framebuffer))
+	gl.DeleteFramebuffers(1, &fbo)
+	gl.BindVertexArray(uint32(vao0))
+	gl.DeleteVertexArrays(1, &vao)
+	gl.UseProgram(uint32(program0))
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+	if depthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	}
+	if blend {
+		gl.Enable(gl.BLEND)
+	}
+	if cullFace {
+		gl.Enable(gl.CULL_FACE)
+	}
+
+	if err != nil {
+		t.Delete()
+		return nil, err
+	}
+
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, t.ID)
+	if opts.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+	t.setParameters(opts)
+	return t, nil
+}
+
+// LoadTextureCubeEquirect reads an equirectangular panorama, in any format
+// Load accepts, and converts it to a size x size cube map. HDR panoramas
+// (.hdr or .exr) keep their full range.
+func LoadTextureCubeEquirect(path string, size int, opts Options) (*TextureCube, error) {
+	pano, err := Load(path, Options{WrapT: gl.CLAMP_TO_EDGE})
+	if err != nil {
+		return nil, err
+	}
+	defer pano.Delete()
+
+	return NewTextureCubeFromEquirect(pano, size, opts)
+}
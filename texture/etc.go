@@ -0,0 +1,246 @@
+package texture
+
+import (
+	"encoding/binary"
+)
+
+// etcModifiers are the ETC1 intensity tables, in the order the pixel
+// indices pick them.
+var etcModifiers = [8][4]int{
+	{2, 8, -2, -8},
+	{5, 17, -5, -17},
+	{9, 29, -9, -29},
+	{13, 42, -13, -42},
+	{18, 60, -18, -60},
+	{24, 80, -24, -80},
+	{33, 106, -33, -106},
+	{47, 183, -47, -183},
+}
+
+// etcDistances are the distances between paint colours in T and H modes.
+var etcDistances = [8]int{3, 6, 11, 16, 23, 32, 41, 64}
+
+// eacModifiers are the EAC alpha tables.
+var eacModifiers = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+func clampByte(v int) byte {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return byte(v)
+}
+
+func extend4(v uint32) int { return int(v<<4 | v) }
+func extend5(v uint32) int { return int(v<<3 | v>>2) }
+func extend6(v uint32) int { return int(v<<2 | v>>4) }
+func extend7(v uint32) int { return int(v<<1 | v>>6) }
+
+// decodeETC2 decodes an ETC2 RGB block. With punchThrough the differential
+// bit instead says whether the block is opaque; if it is not, index 2 is
+// transparent black.
+//
+// ETC blocks are big-endian and number their pixels down each column, so
+// pixel (x, y) is index 4x+y.
+func decodeETC2(block []byte, out *[16][4]byte, punchThrough bool) {
+	hi := binary.BigEndian.Uint32(block)
+	lo := binary.BigEndian.Uint32(block[4:])
+
+	differential := hi>>1&1 != 0
+	opaque := true
+	if punchThrough {
+		opaque, differential = differential, true
+	}
+
+	if differential {
+		r, g, b := int(hi>>27&31), int(hi>>19&31), int(hi>>11&31)
+		dr := int(signExtend(int32(hi>>24&7), 3))
+		dg := int(signExtend(int32(hi>>16&7), 3))
+		db := int(signExtend(int32(hi>>8&7), 3))
+
+		// ETC2 modes are blocks whose second colour would overflow.
+		switch {
+		case r+dr < 0 || r+dr > 31:
+			etcPaint(hi, lo, out, opaque, false)
+			return
+		case g+dg < 0 || g+dg > 31:
+			etcPaint(hi, lo, out, opaque, true)
+			return
+		case b+db < 0 || b+db > 31:
+			etcPlanar(hi, lo, out)
+			return
+		}
+
+		c1 := [3]int{extend5(uint32(r)), extend5(uint32(g)), extend5(uint32(b))}
+		c2 := [3]int{extend5(uint32(r + dr)), extend5(uint32(g + dg)), extend5(uint32(b + db))}
+		etcSubblocks(hi, lo, c1, c2, opaque, out)
+		return
+	}
+
+	c1 := [3]int{extend4(hi >> 28 & 15), extend4(hi >> 20 & 15), extend4(hi >> 12 & 15)}
+	c2 := [3]int{extend4(hi >> 24 & 15), extend4(hi >> 16 & 15), extend4(hi >> 8 & 15)}
+	etcSubblocks(hi, lo, c1, c2, opaque, out)
+}
+
+// etcIndex returns the 2-bit index of pixel (x, y), whose high and low bits
+// are stored in separate halves of lo.
+func etcIndex(lo uint32, x, y int) int {
+	i := 4*x + y
+	return int(lo>>(i+16)&1)<<1 | int(lo>>i&1)
+}
+
+// etcSubblocks fills the two halves of an ETC1-style block, side by side or
+// one above the other, from their base colours.
+func etcSubblocks(hi, lo uint32, c1, c2 [3]int, opaque bool, out *[16][4]byte) {
+	flip := hi&1 != 0
+	tables := [2]uint32{hi >> 5 & 7, hi >> 2 & 7}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			sub := x / 2
+			if flip {
+				sub = y / 2
+			}
+			base := c1
+			if sub == 1 {
+				base = c2
+			}
+
+			i := etcIndex(lo, x, y)
+			modifier := etcModifiers[tables[sub]][i]
+			if !opaque {
+				// Punch-through blocks drop the small modifiers to make
+				// room for transparency.
+				switch i {
+				case 0:
+					modifier = 0
+				case 2:
+					out[4*y+x] = [4]byte{}
+					continue
+				}
+			}
+			out[4*y+x] = [4]byte{clampByte(base[0] + modifier), clampByte(base[1] + modifier), clampByte(base[2] + modifier), 255}
+		}
+	}
+}
+
+// etcPaint decodes the T and H modes, which pick each pixel from four
+// paint colours made from two base colours and a distance.
+func etcPaint(hi, lo uint32, out *[16][4]byte, opaque, hMode bool) {
+	var c1, c2 [3]int
+	var d int
+	if hMode {
+		r1, r2 := hi>>27&15, hi>>11&15
+		g1, g2 := hi>>24&7<<1|hi>>20&1, hi>>7&15
+		b1, b2 := hi>>19&1<<3|hi>>15&7, hi>>3&15
+		c1 = [3]int{extend4(r1), extend4(g1), extend4(b1)}
+		c2 = [3]int{extend4(r2), extend4(g2), extend4(b2)}
+
+		// The lowest bit of the distance is whether the first colour is
+		// the greater.
+		di := hi>>2&1<<2 | hi&1<<1
+		if r1<<8|g1<<4|b1 >= r2<<8|g2<<4|b2 {
+			di |= 1
+		}
+		d = etcDistances[di]
+	} else {
+		r1 := hi>>27&3<<2 | hi>>24&3
+		c1 = [3]int{extend4(r1), extend4(hi >> 20 & 15), extend4(hi >> 16 & 15)}
+		c2 = [3]int{extend4(hi >> 12 & 15), extend4(hi >> 8 & 15), extend4(hi >> 4 & 15)}
+		d = etcDistances[hi>>2&3<<1|hi&1]
+	}
+
+	add := func(c [3]int, d int) [3]int {
+		return [3]int{c[0] + d, c[1] + d, c[2] + d}
+	}
+	var paint [4][3]int
+	if hMode {
+		paint = [4][3]int{add(c1, d), add(c1, -d), add(c2, d), add(c2, -d)}
+	} else {
+		paint = [4][3]int{c1, add(c2, d), c2, add(c2, -d)}
+	}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			p := etcIndex(lo, x, y)
+			if !opaque && p == 2 {
+				out[4*y+x] = [4]byte{}
+				continue
+			}
+			c := paint[p]
+			out[4*y+x] = [4]byte{clampByte(c[0]), clampByte(c[1]), clampByte(c[2]), 255}
+		}
+	}
+}
+
+// etcPlanar decodes the planar mode, a colour gradient given by the colours
+// at the origin and the right and bottom edges.
+func etcPlanar(hi, lo uint32, out *[16][4]byte) {
+	o := [3]int{
+		extend6(hi >> 25 & 63),
+		extend7(hi>>24&1<<6 | hi>>17&63),
+		extend6(hi>>16&1<<5 | hi>>11&3<<3 | hi>>7&7),
+	}
+	h := [3]int{
+		extend6(hi>>2&31<<1 | hi&1),
+		extend7(lo >> 25 & 127),
+		extend6(lo >> 19 & 63),
+	}
+	v := [3]int{
+		extend6(lo >> 13 & 63),
+		extend7(lo >> 6 & 127),
+		extend6(lo & 63),
+	}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			p := &out[4*y+x]
+			for c := 0; c < 3; c++ {
+				p[c] = clampByte((x*(h[c]-o[c]) + y*(v[c]-o[c]) + 4*o[c] + 2) >> 2)
+			}
+			p[3] = 255
+		}
+	}
+}
+
+// decodeEAC decodes an 8-byte EAC alpha block, giving values row by row.
+func decodeEAC(block []byte) (out [16]byte) {
+	v := binary.BigEndian.Uint64(block)
+	base := int(v >> 56)
+	multiplier := int(v >> 52 & 15)
+	table := eacModifiers[v>>48&15]
+
+	for i := 0; i < 16; i++ {
+		index := v >> (45 - 3*uint(i)) & 7
+		x, y := i/4, i%4
+		out[4*y+x] = clampByte(base + table[index]*multiplier)
+	}
+	return out
+}
+
+func decodeETC2RGBA(block []byte, out *[16][4]byte) {
+	decodeETC2(block[8:], out, false)
+	alpha := decodeEAC(block)
+	for i := range out {
+		out[i][3] = alpha[i]
+	}
+}
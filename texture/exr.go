@@ -0,0 +1,343 @@
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+var exrMagic = []byte{0x76, 0x2F, 0x31, 0x01}
+
+// OpenEXR version flags.
+const (
+	exrTiled     = 0x200
+	exrDeep      = 0x800
+	exrMultipart = 0x1000
+)
+
+// OpenEXR compression methods. Only the first three are supported.
+const (
+	exrNoCompression   = 0
+	exrZIPSCompression = 2 // zlib, one scanline per block
+	exrZIPCompression  = 3 // zlib, 16 scanlines per block
+)
+
+// OpenEXR pixel types.
+const (
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+type exrChannel struct {
+	name      string
+	pixelType int32
+}
+
+func (c exrChannel) size() int {
+	if c.pixelType == exrHalf {
+		return 2
+	}
+	return 4
+}
+
+// decodeEXR reads a single part, scanline OpenEXR image, uncompressed or
+// ZIP compressed. Channels R, G, B and A fill the image's components; a
+// lone Y (luminance) channel is copied to R, G and B. Others are skipped.
+func decodeEXR(data []byte) (*FloatImage, error) {
+	le := binary.LittleEndian
+	if len(data) < 8 {
+		return nil, fmt.Errorf("EXR header is truncated")
+	}
+	version := le.Uint32(data[4:])
+	if version&0xFF != 2 {
+		return nil, fmt.Errorf("EXR version %d is not supported", version&0xFF)
+	}
+	if version&(exrTiled|exrDeep|exrMultipart) != 0 {
+		return nil, fmt.Errorf("tiled, deep and multipart EXR files are not supported")
+	}
+
+	// The header is a list of attributes: name, type name, size and value,
+	// ending with an empty name.
+	var (
+		channels               []exrChannel
+		compression            = -1
+		xMin, yMin, xMax, yMax int32
+		haveWindow             bool
+	)
+	p := 8
+	cstring := func() (string, error) {
+		n := bytes.IndexByte(data[p:], 0)
+		if n < 0 {
+			return "", fmt.Errorf("EXR header is truncated")
+		}
+		s := string(data[p : p+n])
+		p += n + 1
+		return s, nil
+	}
+	for {
+		name, err := cstring()
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			break
+		}
+		typeName, err := cstring()
+		if err != nil {
+			return nil, err
+		}
+		if p+4 > len(data) {
+			return nil, fmt.Errorf("EXR header is truncated")
+		}
+		size := int(le.Uint32(data[p:]))
+		p += 4
+		if size < 0 || p+size > len(data) {
+			return nil, fmt.Errorf("EXR attribute %q is truncated", name)
+		}
+		value := data[p : p+size]
+		p += size
+
+		switch {
+		case name == "channels" && typeName == "chlist":
+			channels, err = readEXRChannels(value)
+			if err != nil {
+				return nil, err
+			}
+		case name == "compression" && typeName == "compression" && size == 1:
+			compression = int(value[0])
+		case name == "dataWindow" && typeName == "box2i" && size == 16:
+			xMin, yMin = int32(le.Uint32(value)), int32(le.Uint32(value[4:]))
+			xMax, yMax = int32(le.Uint32(value[8:])), int32(le.Uint32(value[12:]))
+			haveWindow = true
+		}
+	}
+	if channels == nil || compression < 0 || !haveWindow {
+		return nil, fmt.Errorf("EXR header lacks channels, compression or dataWindow")
+	}
+
+	linesPerBlock := 1
+	switch compression {
+	case exrNoCompression, exrZIPSCompression:
+	case exrZIPCompression:
+		linesPerBlock = 16
+	default:
+		return nil, fmt.Errorf("EXR compression %d is not supported", compression)
+	}
+
+	// Work in 64 bits, as the window's corners can be anywhere in 32.
+	if xMin > xMax || yMin > yMax {
+		return nil, fmt.Errorf("EXR data window is empty")
+	}
+	w, h := int64(xMax)-int64(xMin)+1, int64(yMax)-int64(yMin)+1
+	if err := checkFloatSize(w, h); err != nil {
+		return nil, fmt.Errorf("EXR data window: %v", err)
+	}
+	width, height := int(w), int(h)
+
+	pixelSize := 0
+	for _, c := range channels {
+		pixelSize += c.size()
+	}
+	rowSize := pixelSize * width
+
+	// The file must hold every block's offset, and every row: as it is, or
+	// deflated, which shrinks data at most about 1032 times.
+	blocks := (height + linesPerBlock - 1) / linesPerBlock
+	if p+8*blocks > len(data) {
+		return nil, fmt.Errorf("EXR offset table is truncated")
+	}
+	maxRaw := int64(len(data))
+	if compression != exrNoCompression {
+		maxRaw *= 1032
+	}
+	if int64(rowSize)*h > maxRaw {
+		return nil, fmt.Errorf("EXR data window is larger than the file holds")
+	}
+	img := NewFloatImage(width, height)
+
+	// Where each channel goes in a pixel, -1 to skip it. Alpha defaults to
+	// 1; a luminance image, without R, G or B, fills all three from Y.
+	targets := make([]int, len(channels))
+	haveAlpha, luminance := false, true
+	for _, c := range channels {
+		switch c.name {
+		case "R", "G", "B":
+			luminance = false
+		case "A":
+			haveAlpha = true
+		}
+	}
+	for i, c := range channels {
+		targets[i] = -1
+		switch c.name {
+		case "R":
+			targets[i] = 0
+		case "G":
+			targets[i] = 1
+		case "B":
+			targets[i] = 2
+		case "A":
+			targets[i] = 3
+		case "Y":
+			if luminance {
+				targets[i] = 0
+			}
+		}
+	}
+	for i := 3; !haveAlpha && i < len(img.Pix); i += 4 {
+		img.Pix[i] = 1
+	}
+
+	// The offset table follows the header, one entry per block.
+	for b := 0; b < blocks; b++ {
+		offset := le.Uint64(data[p+8*b:])
+		if offset+8 > uint64(len(data)) {
+			return nil, fmt.Errorf("EXR block %d is truncated", b)
+		}
+		chunk := data[offset:]
+		y := int(int32(le.Uint32(chunk))) - int(yMin)
+		size := int(le.Uint32(chunk[4:]))
+		if size < 0 || 8+size > len(chunk) || y < 0 || y >= height {
+			return nil, fmt.Errorf("EXR block %d is corrupt", b)
+		}
+		packed := chunk[8 : 8+size]
+
+		lines := linesPerBlock
+		if y+lines > height {
+			lines = height - y
+		}
+		raw := packed
+		// Blocks that would not shrink are stored as they are.
+		if compression != exrNoCompression && size < lines*rowSize {
+			var err error
+			if raw, err = unzipEXR(packed, lines*rowSize); err != nil {
+				return nil, fmt.Errorf("EXR block %d: %v", b, err)
+			}
+		}
+		if len(raw) < lines*rowSize {
+			return nil, fmt.Errorf("EXR block %d is truncated", b)
+		}
+
+		// Each line holds every sample of the first channel, then every
+		// sample of the next, and so on.
+		for l := 0; l < lines; l++ {
+			dst := img.Pix[4*(y+l)*width : 4*(y+l+1)*width]
+			line := raw[l*rowSize:]
+			for i, c := range channels {
+				n := c.size()
+				if t := targets[i]; t >= 0 {
+					for x := 0; x < width; x++ {
+						v := exrSample(line[n*x:], c.pixelType)
+						dst[4*x+t] = v
+						if t == 0 && luminance {
+							dst[4*x+1], dst[4*x+2] = v, v
+						}
+					}
+				}
+				line = line[n*width:]
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// readEXRChannels reads a chlist attribute. Subsampled channels are not
+// supported.
+func readEXRChannels(value []byte) ([]exrChannel, error) {
+	le := binary.LittleEndian
+	var channels []exrChannel
+	for len(value) > 0 && value[0] != 0 {
+		n := bytes.IndexByte(value, 0)
+		if n < 0 || n+17 > len(value) {
+			return nil, fmt.Errorf("EXR channel list is truncated")
+		}
+		c := exrChannel{name: string(value[:n]), pixelType: int32(le.Uint32(value[n+1:]))}
+		if c.pixelType < exrUint || c.pixelType > exrFloat {
+			return nil, fmt.Errorf("EXR channel %q has unknown pixel type %d", c.name, c.pixelType)
+		}
+		// pLinear and 3 reserved bytes come before the sampling.
+		if xs, ys := le.Uint32(value[n+9:]), le.Uint32(value[n+13:]); xs != 1 || ys != 1 {
+			return nil, fmt.Errorf("EXR channel %q is subsampled", c.name)
+		}
+		channels = append(channels, c)
+		value = value[n+17:]
+	}
+
+	// Files list channels sorted by name, which is also the order of the
+	// pixel data, but sort anyway rather than trust the writer.
+	sort.Slice(channels, func(i, j int) bool { return channels[i].name < channels[j].name })
+	return channels, nil
+}
+
+// unzipEXR inflates a ZIP block and undoes the byte predictor and the
+// splitting of every value's bytes into two halves.
+func unzipEXR(packed []byte, size int) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	tmp := make([]byte, size)
+	if _, err := io.ReadFull(zr, tmp); err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(tmp); i++ {
+		tmp[i] = tmp[i-1] + tmp[i] - 128
+	}
+
+	raw := make([]byte, size)
+	half := (size + 1) / 2
+	for i := range raw {
+		if i%2 == 0 {
+			raw[i] = tmp[i/2]
+		} else {
+			raw[i] = tmp[half+i/2]
+		}
+	}
+	return raw, nil
+}
+
+// exrSample reads one little-endian sample of the given pixel type.
+func exrSample(b []byte, pixelType int32) float32 {
+	switch pixelType {
+	case exrHalf:
+		return halfToFloat(binary.LittleEndian.Uint16(b))
+	case exrFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	return float32(binary.LittleEndian.Uint32(b))
+}
+
+// halfToFloat converts an IEEE 754 half precision float.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1F
+	mant := uint32(h & 0x3FF)
+
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Subnormal: renormalise into a float's wider exponent.
+		exp = 127 - 15 + 1
+		for mant&0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+		mant &= 0x3FF
+	case exp == 0x1F:
+		// Infinity or NaN.
+		return math.Float32frombits(sign | 0xFF<<23 | mant<<13)
+	default:
+		exp += 127 - 15
+	}
+	return math.Float32frombits(sign | exp<<23 | mant<<13)
+}
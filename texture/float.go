@@ -0,0 +1,169 @@
+package texture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// FloatImage is a floating point RGBA image, as read from a Radiance .hdr or
+// OpenEXR file. Values are linear and may exceed 1.
+type FloatImage struct {
+	Width, Height int
+	// Pix holds four values (R, G, B, A) per pixel, row by row from the top.
+	Pix []float32
+}
+
+// The largest width or height, and area, that the decoders accept, so that
+// a corrupt header cannot ask for more memory than a real image needs. A
+// 16K by 8K panorama has 1<<27 pixels.
+const (
+	maxFloatSize   = 1 << 16
+	maxFloatPixels = 1 << 27
+)
+
+// checkFloatSize returns an error if a width x height image is too large
+// to decode.
+func checkFloatSize(width, height int64) error {
+	if width > maxFloatSize || height > maxFloatSize || width*height > maxFloatPixels {
+		return fmt.Errorf("%dx%d is too large", width, height)
+	}
+	return nil
+}
+
+// NewFloatImage returns a black, transparent width x height image.
+func NewFloatImage(width, height int) *FloatImage {
+	return &FloatImage{Width: width, Height: height, Pix: make([]float32, 4*width*height)}
+}
+
+// isFloatImage reports whether header starts a Radiance or OpenEXR file.
+func isFloatImage(header []byte) bool {
+	return bytes.HasPrefix(header, hdrMagic) || bytes.HasPrefix(header, exrMagic)
+}
+
+// DecodeFloat reads a Radiance .hdr or OpenEXR file from r, telling them
+// apart by their first bytes.
+func DecodeFloat(r io.Reader) (*FloatImage, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(exrMagic))
+	switch {
+	case bytes.HasPrefix(header, hdrMagic):
+		return decodeHDR(br)
+	case bytes.HasPrefix(header, exrMagic):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return decodeEXR(data)
+	}
+	return nil, fmt.Errorf("not a Radiance HDR or OpenEXR file")
+}
+
+// LoadFloat reads the Radiance .hdr or OpenEXR file at path.
+func LoadFloat(path string) (*FloatImage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := DecodeFloat(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return img, nil
+}
+
+// floatFormats returns the internal format and pixel format for a channel
+// count, with 16 or 32-bit floats.
+func (o *Options) floatFormats() (internal, format uint32, err error) {
+	switch o.Channels {
+	case 1:
+		internal, format = gl.R16F, gl.RED
+		if o.Float32 {
+			internal = gl.R32F
+		}
+	case 2:
+		internal, format = gl.RG16F, gl.RG
+		if o.Float32 {
+			internal = gl.RG32F
+		}
+	case 3:
+		internal, format = gl.RGB16F, gl.RGB
+		if o.Float32 {
+			internal = gl.RGB32F
+		}
+	case 0, 4:
+		internal, format = gl.RGBA16F, gl.RGBA
+		if o.Float32 {
+			internal = gl.RGBA32F
+		}
+	default:
+		return 0, 0, fmt.Errorf("texture: %d channels are not supported", o.Channels)
+	}
+	return internal, format, nil
+}
+
+// floatPixels returns the first channels values of each pixel of img, upside
+// down if flip is set.
+func floatPixels(img *FloatImage, channels int, flip bool) []float32 {
+	pix := make([]float32, 0, channels*img.Width*img.Height)
+	for y := 0; y < img.Height; y++ {
+		row := y
+		if flip {
+			row = img.Height - 1 - y
+		}
+		src := img.Pix[4*row*img.Width : 4*(row+1)*img.Width]
+		for x := 0; x < img.Width; x++ {
+			pix = append(pix, src[4*x:4*x+channels]...)
+		}
+	}
+	return pix
+}
+
+// NewFloatTexture2D uploads a floating point image as RGBA16F, or RGBA32F if
+// opts.Float32 is set. Fewer channels give R, RG or RGB formats. SRGB is
+// ignored, as the values are already linear. It needs a current GL context.
+func NewFloatTexture2D(img *FloatImage, opts Options) (*Texture2D, error) {
+	internal, format, err := opts.floatFormats()
+	if err != nil {
+		return nil, err
+	}
+	if img.Width == 0 || img.Height == 0 {
+		return nil, fmt.Errorf("texture: image is empty")
+	}
+
+	channels := opts.Channels
+	if channels == 0 {
+		channels = 4
+	}
+	pix := floatPixels(img, channels, opts.FlipY)
+	width, height := int32(img.Width), int32(img.Height)
+
+	t := &Texture2D{Width: img.Width, Height: img.Height, InternalFormat: internal, Levels: 1}
+	if opts.Mipmaps {
+		t.Levels = mipLevels(img.Width, img.Height)
+	}
+
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+
+	if opts.Immutable {
+		gl.TexStorage2D(gl.TEXTURE_2D, t.Levels, internal, width, height)
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, width, height, format, gl.FLOAT, gl.Ptr(pix))
+	} else {
+		gl.TexImage2D(gl.TEXTURE_2D, 0, int32(internal), width, height, 0, format, gl.FLOAT, gl.Ptr(pix))
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, t.Levels-1)
+	}
+
+	if opts.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+
+	setParameters(gl.TEXTURE_2D, opts, opts.Mipmaps)
+	return t, nil
+}
@@ -0,0 +1,127 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestDecodeFloat(t *testing.T) {
+	// Every file holds the same 16x20 gradient: red is x/4, green y/2 and
+	// blue 8+x, so that the blue channel sets the shared RGBE exponent.
+	// The ZIP compressed EXR also has alpha (y+5)/20 in half floats.
+	tests := []struct {
+		file  string
+		alpha func(y int) float64
+	}{
+		{"testdata/gradient.hdr", nil},
+		{"testdata/gradient.exr", nil},
+		{"testdata/gradient-zip.exr", func(y int) float64 { return float64(y+5) / 20 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			img, err := LoadFloat(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Width != 16 || img.Height != 20 || len(img.Pix) != 4*16*20 {
+				t.Fatalf("got %dx%d with %d values, want 16x20", img.Width, img.Height, len(img.Pix))
+			}
+			for y := 0; y < img.Height; y++ {
+				for x := 0; x < img.Width; x++ {
+					want := [4]float64{float64(x) / 4, float64(y) / 2, float64(8 + x), 1}
+					if tt.alpha != nil {
+						want[3] = tt.alpha(y)
+					}
+					got := img.Pix[4*(y*img.Width+x):][:4]
+					for c := range want {
+						// Half floats keep 11 significant bits.
+						if math.Abs(float64(got[c])-want[c]) > 1e-3*math.Max(1, want[c]) {
+							t.Fatalf("pixel (%d, %d) is %v, want %v", x, y, got, want)
+						}
+					}
+				}
+			}
+
+			// Truncated files are an error, not a panic.
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range []int{10, len(data) / 2, len(data) - 1} {
+				if _, err := DecodeFloat(bytes.NewReader(data[:n])); err == nil {
+					t.Errorf("no error decoding the first %d of %d bytes", n, len(data))
+				}
+			}
+		})
+	}
+
+	if _, err := DecodeFloat(bytes.NewReader([]byte("P6 16 16 255\n"))); err == nil {
+		t.Error("no error decoding a file that is neither")
+	}
+}
+
+func TestDecodeFloatHugeHeader(t *testing.T) {
+	exr, err := os.ReadFile("testdata/gradient.exr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	window := bytes.Index(exr, []byte("dataWindow\x00box2i\x00")) + len("dataWindow\x00box2i\x00") + 4
+	setWindow := func(xMin, yMin, xMax, yMax int32) []byte {
+		data := bytes.Clone(exr)
+		for i, v := range []int32{xMin, yMin, xMax, yMax} {
+			binary.LittleEndian.PutUint32(data[window+4*i:], uint32(v))
+		}
+		return data
+	}
+	// One corrupt byte, the top of yMax, asks for 2 billion rows.
+	highY := bytes.Clone(exr)
+	highY[window+15] = 0x7f
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"EXR with yMax's top byte set", highY},
+		{"EXR wider than the limit", setWindow(0, 0, maxFloatSize, 19)},
+		{"EXR window over the whole int32 range", setWindow(math.MinInt32, 0, math.MaxInt32, 19)},
+		{"EXR window inside out", setWindow(15, 0, 0, 19)},
+		{"EXR rows past the offset table", setWindow(0, 0, 15, 1<<20)},
+		{"EXR rows past the data", setWindow(0, 0, 4095, 4095)},
+		{"HDR over the pixel limit", []byte("#?RADIANCE\n\n-Y 60000 +X 60000\n")},
+		{"HDR with no scanlines", []byte("#?RADIANCE\n\n-Y 8000 +X 8000\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeFloat(bytes.NewReader(tt.data)); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestDecodeHDRBottomUp(t *testing.T) {
+	data, err := os.ReadFile("testdata/gradient.hdr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, err := DecodeFloat(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same scanlines listed from the bottom give the image upside down.
+	bottom, err := DecodeFloat(bytes.NewReader(bytes.Replace(data, []byte("\n-Y "), []byte("\n+Y "), 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := 4 * top.Width
+	for y := 0; y < top.Height; y++ {
+		flipped := top.Height - 1 - y
+		if !slices.Equal(top.Pix[y*row:(y+1)*row], bottom.Pix[flipped*row:(flipped+1)*row]) {
+			t.Fatalf("row %d read from the bottom is not row %d read from the top", flipped, y)
+		}
+	}
+}
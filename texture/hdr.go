@@ -0,0 +1,191 @@
+package texture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// hdrMagic starts both "#?RADIANCE" and "#?RGBE" files.
+var hdrMagic = []byte("#?")
+
+// decodeHDR reads a Radiance RGBE (.hdr) image, with flat, old style RLE or
+// new style (per component) RLE scanlines.
+func decodeHDR(r *bufio.Reader) (*FloatImage, error) {
+	// The header is text lines up to an empty one. Only the format matters;
+	// EXPOSURE and the like describe how the image was captured.
+	for first := true; ; first = false {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("HDR header is truncated")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if first {
+			continue // the #? line
+		}
+		if line == "" {
+			break
+		}
+		if format := strings.TrimPrefix(line, "FORMAT="); format != line && format != "32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("HDR format %q is not supported", format)
+		}
+	}
+
+	// The resolution line gives the row order and size. Only rows along X are
+	// supported, which is what everything writes.
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("HDR resolution is missing")
+	}
+	var ySign, xSign rune
+	var width, height int
+	if _, err := fmt.Sscanf(line, "%cY %d %cX %d", &ySign, &height, &xSign, &width); err != nil {
+		return nil, fmt.Errorf("HDR resolution %q is not supported", strings.TrimSpace(line))
+	}
+	if width <= 0 || height <= 0 || xSign != '+' || (ySign != '-' && ySign != '+') {
+		return nil, fmt.Errorf("HDR resolution %q is not supported", strings.TrimSpace(line))
+	}
+
+	if err := checkFloatSize(int64(width), int64(height)); err != nil {
+		return nil, fmt.Errorf("HDR resolution: %v", err)
+	}
+
+	// Rows are added as they are read, so that a file that stops short of
+	// its resolution costs no more memory than it holds.
+	img := &FloatImage{Width: width, Height: height}
+	scanline := make([]byte, 4*width)
+	for y := 0; y < height; y++ {
+		if err := readHDRScanline(r, scanline); err != nil {
+			return nil, fmt.Errorf("HDR scanline %d: %v", y, err)
+		}
+		for x := 0; x < width; x++ {
+			var rgba [4]float32
+			rgbeToFloat(scanline[4*x:4*x+4], rgba[:])
+			img.Pix = append(img.Pix, rgba[:]...)
+		}
+	}
+
+	// -Y lists rows from the top, +Y from the bottom.
+	if ySign == '+' {
+		row := 4 * width
+		for top, bottom := 0, height-1; top < bottom; top, bottom = top+1, bottom-1 {
+			a, b := img.Pix[top*row:(top+1)*row], img.Pix[bottom*row:(bottom+1)*row]
+			for i := range a {
+				a[i], b[i] = b[i], a[i]
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// readHDRScanline reads one scanline of RGBE pixels into scanline.
+func readHDRScanline(r *bufio.Reader, scanline []byte) error {
+	width := len(scanline) / 4
+	var head [4]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return err
+	}
+
+	// New style RLE stores each component in turn, and starts with 2, 2 and
+	// the width. It is only used for widths from 8 to 32767.
+	if width < 8 || width > 0x7FFF || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		return readHDRFlat(r, scanline, head)
+	}
+	if int(head[2])<<8|int(head[3]) != width {
+		return fmt.Errorf("RLE width does not match")
+	}
+
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				// A run of one value.
+				n := int(count) - 128
+				if x+n > width {
+					return fmt.Errorf("RLE run overflows the scanline")
+				}
+				v, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				for ; n > 0; n-- {
+					scanline[4*x+c] = v
+					x++
+				}
+			} else {
+				// count literal values.
+				n := int(count)
+				if n == 0 || x+n > width {
+					return fmt.Errorf("bad RLE count")
+				}
+				for ; n > 0; n-- {
+					v, err := r.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[4*x+c] = v
+					x++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readHDRFlat reads a scanline of whole pixels, the first of which is
+// already in first. A pixel of 1, 1, 1, n repeats the one before it n times,
+// shifted left 8 bits for every such pixel in a row.
+func readHDRFlat(r *bufio.Reader, scanline []byte, first [4]byte) error {
+	width := len(scanline) / 4
+	pixel, have := first, true
+	shift := 0
+	for x := 0; x < width; {
+		if !have {
+			if _, err := io.ReadFull(r, pixel[:]); err != nil {
+				return err
+			}
+		}
+		have = false
+
+		if pixel[0] == 1 && pixel[1] == 1 && pixel[2] == 1 {
+			if x == 0 {
+				return fmt.Errorf("RLE repeat has no pixel to repeat")
+			}
+			n := int(pixel[3]) << shift
+			if x+n > width {
+				return fmt.Errorf("RLE run overflows the scanline")
+			}
+			for ; n > 0; n-- {
+				copy(scanline[4*x:4*x+4], scanline[4*x-4:4*x])
+				x++
+			}
+			shift += 8
+			continue
+		}
+
+		copy(scanline[4*x:4*x+4], pixel[:])
+		x++
+		shift = 0
+	}
+	return nil
+}
+
+// rgbeToFloat converts a shared exponent RGBE pixel to RGBA floats with
+// alpha 1.
+func rgbeToFloat(rgbe []byte, dst []float32) {
+	if rgbe[3] == 0 {
+		dst[0], dst[1], dst[2] = 0, 0, 0
+	} else {
+		f := float32(math.Ldexp(1, int(rgbe[3])-(128+8)))
+		dst[0] = float32(rgbe[0]) * f
+		dst[1] = float32(rgbe[1]) * f
+		dst[2] = float32(rgbe[2]) * f
+	}
+	dst[3] = 1
+}
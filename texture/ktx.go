@@ -0,0 +1,149 @@
+package texture
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var (
+	ktxMagic  = []byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+	ktx2Magic = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+)
+
+const (
+	ktxHeaderSize  = 64
+	ktx2HeaderSize = 80 // identifier, header and index, before the levels
+)
+
+// decodeKTX reads a KTX 1 file. Only block compressed 2D images are
+// supported; of a cube map or array, the first image is read.
+func decodeKTX(data []byte) (*CompressedImage, error) {
+	if len(data) < ktxHeaderSize {
+		return nil, fmt.Errorf("KTX header is truncated")
+	}
+
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(data[12:]) {
+	case 0x04030201:
+		order = binary.LittleEndian
+	case 0x01020304:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("bad KTX endianness")
+	}
+	field := func(i int) int { return int(order.Uint32(data[12+4*i:])) }
+
+	internal := uint32(field(4))
+	f, srgb, ok := formatFromGL(internal)
+	if !ok {
+		return nil, fmt.Errorf("KTX internal format 0x%X is not supported", internal)
+	}
+	c := &CompressedImage{Format: f, SRGB: srgb, Width: field(6), Height: field(7)}
+	if depth := field(8); depth > 1 {
+		return nil, fmt.Errorf("3D textures are not supported")
+	}
+	arrayElements, faces, levels := field(9), field(10), field(11)
+	if levels == 0 {
+		levels = 1
+	}
+
+	offset := ktxHeaderSize + field(12)
+	for l := 0; l < levels; l++ {
+		if offset+4 > len(data) {
+			return nil, fmt.Errorf("mip level %d is truncated", l)
+		}
+		imageSize := int(order.Uint32(data[offset:]))
+		offset += 4
+
+		w, h := c.LevelSize(l)
+		size := f.LevelSize(w, h)
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("mip level %d is truncated", l)
+		}
+		c.Levels = append(c.Levels, data[offset:offset+size])
+
+		// imageSize covers one face of a cube map that is not an array,
+		// and the whole level otherwise. Everything is 4-byte aligned.
+		padded := (imageSize + 3) &^ 3
+		if faces == 6 && arrayElements == 0 {
+			padded *= 6
+		}
+		offset += padded
+	}
+
+	return c, nil
+}
+
+// Vulkan formats of the block compressed images a KTX2 file can hold.
+var vkFormats = map[uint32]struct {
+	format Format
+	srgb   bool
+}{
+	131: {BC1, false}, // VK_FORMAT_BC1_RGB_UNORM_BLOCK
+	132: {BC1, true},
+	133: {BC1, false}, // VK_FORMAT_BC1_RGBA_UNORM_BLOCK
+	134: {BC1, true},
+	135: {BC2, false},
+	136: {BC2, true},
+	137: {BC3, false},
+	138: {BC3, true},
+	139: {BC4, false},
+	141: {BC5, false},
+	143: {BC6H, false},
+	144: {BC6HSigned, false},
+	145: {BC7, false},
+	146: {BC7, true},
+	147: {ETC2RGB, false},
+	148: {ETC2RGB, true},
+	149: {ETC2RGBA1, false},
+	150: {ETC2RGBA1, true},
+	151: {ETC2RGBA, false},
+	152: {ETC2RGBA, true},
+}
+
+// decodeKTX2 reads a KTX 2 file. Only block compressed 2D images without
+// supercompression are supported; of a cube map or array, the first image
+// is read.
+func decodeKTX2(data []byte) (*CompressedImage, error) {
+	if len(data) < ktx2HeaderSize {
+		return nil, fmt.Errorf("KTX2 header is truncated")
+	}
+	le := binary.LittleEndian
+	field := func(i int) uint32 { return le.Uint32(data[12+4*i:]) }
+
+	vkFormat := field(0)
+	f, ok := vkFormats[vkFormat]
+	if !ok {
+		return nil, fmt.Errorf("KTX2 Vulkan format %d is not supported", vkFormat)
+	}
+	if scheme := field(8); scheme != 0 {
+		return nil, fmt.Errorf("supercompressed KTX2 files (scheme %d) are not supported", scheme)
+	}
+	c := &CompressedImage{Format: f.format, SRGB: f.srgb, Width: int(field(2)), Height: int(field(3))}
+	if depth := field(4); depth > 1 {
+		return nil, fmt.Errorf("3D textures are not supported")
+	}
+	levels := int(field(7))
+	if levels == 0 {
+		levels = 1
+	}
+
+	// The level index lists the largest level first, though the data is
+	// stored smallest first.
+	if len(data) < ktx2HeaderSize+24*levels {
+		return nil, fmt.Errorf("KTX2 level index is truncated")
+	}
+	for l := 0; l < levels; l++ {
+		entry := data[ktx2HeaderSize+24*l:]
+		offset, length := le.Uint64(entry), le.Uint64(entry[8:])
+
+		w, h := c.LevelSize(l)
+		size := uint64(c.Format.LevelSize(w, h))
+		if length < size || offset+size > uint64(len(data)) {
+			return nil, fmt.Errorf("mip level %d is truncated", l)
+		}
+		c.Levels = append(c.Levels, data[offset:offset+size])
+	}
+
+	return c, nil
+}
@@ -0,0 +1,54 @@
+package texture
+
+import (
+	"image"
+	"image/draw"
+)
+
+// pixels converts img to tightly packed rows of 8-bit channels, the top row
+// first unless flip is set. One channel is luminance and two are luminance
+// and alpha. Colour is not premultiplied by alpha.
+func pixels(img image.Image, channels int, flip bool) (pix []byte, width, height int) {
+	b := img.Bounds()
+	width, height = b.Dx(), b.Dy()
+
+	// Any stride or pixel format is handled by drawing into a fresh NRGBA
+	// image first, unless img already is one.
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	}
+	origin := nrgba.PixOffset(nrgba.Rect.Min.X, nrgba.Rect.Min.Y)
+	if ok {
+		origin = nrgba.PixOffset(b.Min.X, b.Min.Y)
+	}
+
+	pix = make([]byte, 0, width*height*channels)
+	for y := 0; y < height; y++ {
+		row := y
+		if flip {
+			row = height - 1 - y
+		}
+		src := nrgba.Pix[origin+row*nrgba.Stride:]
+		for x := 0; x < width; x++ {
+			p := src[4*x : 4*x+4]
+			switch channels {
+			case 1:
+				pix = append(pix, luminance(p))
+			case 2:
+				pix = append(pix, luminance(p), p[3])
+			case 3:
+				pix = append(pix, p[0], p[1], p[2])
+			default:
+				pix = append(pix, p...)
+			}
+		}
+	}
+	return pix, width, height
+}
+
+// luminance weights an RGB pixel as color.GrayModel does.
+func luminance(p []byte) byte {
+	return byte((19595*uint32(p[0]) + 38470*uint32(p[1]) + 7471*uint32(p[2]) + 1<<15) >> 16)
+}
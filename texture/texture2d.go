@@ -0,0 +1,306 @@
+// Package texture creates OpenGL textures from images.
+//
+// Texture2D uploads any image.Image, whether it comes from memory, an
+// io.Reader, a file or an embed.FS, with control over mipmaps, filtering,
+// wrapping, sRGB decoding, orientation, channel count and storage.
+//
+// Block compressed images (BC1 to BC7 and ETC2) in DDS, KTX and KTX2 files
+// are uploaded as they are, with their own mipmaps, saving memory and
+// bandwidth. Formats the driver lacks are decompressed on the CPU.
+//
+// Radiance .hdr and OpenEXR images become RGBA16F or RGBA32F textures, for
+// image based lighting and tone mapping.
+//
+// TextureCube holds cube maps, built from six face images or rendered from
+// an equirectangular panorama, and Texture2DArray stacks of same-sized
+// images. TextureSet lets shaders index images, through bindless handles
+// where the driver has them. Units gives each sampler of a program its own
+// texture unit.
+package texture
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register decoders for image.Decode
+	_ "image/png"
+	"io"
+	"io/fs"
+	"math/bits"
+	"os"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Options control how an image becomes a texture. The zero value gives a
+// mutable RGBA8 texture with bilinear filtering, REPEAT wrapping and no
+// mipmaps, uploaded as it is stored: the image's top row at t = 0.
+type Options struct {
+	// Mipmaps generates the full mipmap chain. MinFilter then defaults to
+	// LINEAR_MIPMAP_LINEAR (trilinear).
+	Mipmaps bool
+
+	// MinFilter and MagFilter are GL filter enums; 0 picks the default.
+	MinFilter, MagFilter int32
+	// WrapS and WrapT are GL wrap enums (REPEAT, CLAMP_TO_EDGE,
+	// MIRRORED_REPEAT, ...); 0 means REPEAT.
+	WrapS, WrapT int32
+
+	// Anisotropy above 1 enables anisotropic filtering, clamped to what the
+	// driver supports.
+	Anisotropy float32
+
+	// SRGB stores colour in an sRGB format, so that sampling returns linear
+	// values. It needs 3 or 4 channels.
+	SRGB bool
+
+	// FlipY turns the image upside down. Images are stored top row first
+	// but GL puts t = 0 at the bottom, so set this when texture coordinates
+	// have t increasing upwards.
+	FlipY bool
+
+	// Channels is 1 (R, luminance), 2 (RG, luminance and alpha), 3 (RGB) or
+	// 4 (RGBA). 0 means 4.
+	Channels int
+
+	// Immutable allocates storage once with TexStorage2D. The size and format
+	// are then fixed, but the driver can skip completeness checks.
+	Immutable bool
+
+	// Float32 stores floating point images (Radiance .hdr and OpenEXR) with
+	// 32-bit floats rather than 16-bit halves.
+	Float32 bool
+}
+
+// Texture2D is a 2D texture.
+type Texture2D struct {
+	ID             uint32
+	Width, Height  int
+	InternalFormat uint32
+	// Levels is the number of mipmap levels.
+	Levels int32
+}
+
+// formats returns the internal format and pixel format for a channel count.
+func (o *Options) formats() (internal, format uint32, err error) {
+	switch o.Channels {
+	case 1:
+		internal, format = gl.R8, gl.RED
+	case 2:
+		internal, format = gl.RG8, gl.RG
+	case 3:
+		internal, format = gl.RGB8, gl.RGB
+		if o.SRGB {
+			internal = gl.SRGB8
+		}
+	case 0, 4:
+		internal, format = gl.RGBA8, gl.RGBA
+		if o.SRGB {
+			internal = gl.SRGB8_ALPHA8
+		}
+	default:
+		return 0, 0, fmt.Errorf("texture: %d channels are not supported", o.Channels)
+	}
+
+	if o.SRGB && o.Channels != 0 && o.Channels < 3 {
+		return 0, 0, fmt.Errorf("texture: sRGB needs 3 or 4 channels, not %d", o.Channels)
+	}
+
+	return internal, format, nil
+}
+
+// mipLevels returns the length of the full mipmap chain for a size.
+func mipLevels(width, height int) int32 {
+	if height > width {
+		width = height
+	}
+	return int32(bits.Len(uint(width)))
+}
+
+// NewTexture2D uploads img. It needs a current GL context.
+func NewTexture2D(img image.Image, opts Options) (*Texture2D, error) {
+	internal, format, err := opts.formats()
+	if err != nil {
+		return nil, err
+	}
+
+	channels := opts.Channels
+	if channels == 0 {
+		channels = 4
+	}
+	pix, width, height := pixels(img, channels, opts.FlipY)
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("texture: image is empty")
+	}
+
+	t := &Texture2D{Width: width, Height: height, InternalFormat: internal, Levels: 1}
+	if opts.Mipmaps {
+		t.Levels = mipLevels(width, height)
+	}
+
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+
+	// Rows of 1, 2 or 3 byte pixels need not be 4-byte aligned.
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	if opts.Immutable {
+		gl.TexStorage2D(gl.TEXTURE_2D, t.Levels, internal, int32(width), int32(height))
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(width), int32(height), format, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+	} else {
+		gl.TexImage2D(gl.TEXTURE_2D, 0, int32(internal), int32(width), int32(height), 0, format, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, t.Levels-1)
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	if opts.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+
+	setParameters(gl.TEXTURE_2D, opts, opts.Mipmaps)
+	return t, nil
+}
+
+// setParameters applies the filtering and wrapping options to the texture
+// bound to target, defaulting to trilinear filtering if it has mipmaps.
+func setParameters(target uint32, opts Options, mipmapped bool) {
+	minFilter, magFilter := opts.MinFilter, opts.MagFilter
+	if minFilter == 0 {
+		minFilter = gl.LINEAR
+		if mipmapped {
+			minFilter = gl.LINEAR_MIPMAP_LINEAR
+		}
+	}
+	if magFilter == 0 {
+		magFilter = gl.LINEAR
+	}
+	gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(target, gl.TEXTURE_MAG_FILTER, magFilter)
+
+	wrapS, wrapT := opts.WrapS, opts.WrapT
+	if wrapS == 0 {
+		wrapS = gl.REPEAT
+	}
+	if wrapT == 0 {
+		wrapT = gl.REPEAT
+	}
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_S, wrapS)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_T, wrapT)
+
+	if opts.Anisotropy > 1 {
+		setAnisotropy(target, opts.Anisotropy)
+	}
+}
+
+// setAnisotropy sets the maximum anisotropy of the texture bound to target,
+// clamped to the driver's limit.
+func setAnisotropy(target uint32, a float32) {
+	var max float32
+	gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &max)
+	if a > max {
+		a = max
+	}
+	if a < 1 {
+		a = 1
+	}
+	gl.TexParameterf(target, gl.TEXTURE_MAX_ANISOTROPY, a)
+}
+
+// Decode reads an image (PNG or JPEG, or any format registered with the
+// image package) from r and uploads it. DDS and KTX files are recognised
+// and uploaded compressed with NewCompressedTexture2D, and Radiance .hdr
+// and OpenEXR files as floating point with NewFloatTexture2D.
+func Decode(r io.Reader, opts Options) (*Texture2D, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(ktxMagic))
+	switch {
+	case isContainer(header):
+		c, err := DecodeCompressed(br)
+		if err != nil {
+			return nil, fmt.Errorf("texture: %v", err)
+		}
+		return NewCompressedTexture2D(c, opts)
+	case isFloatImage(header):
+		img, err := DecodeFloat(br)
+		if err != nil {
+			return nil, fmt.Errorf("texture: %v", err)
+		}
+		return NewFloatTexture2D(img, opts)
+	}
+
+	img, _, err := image.Decode(br)
+	if err != nil {
+		return nil, fmt.Errorf("texture: %v", err)
+	}
+	return NewTexture2D(img, opts)
+}
+
+// Load reads the image file at path and uploads it.
+func Load(path string, opts Options) (*Texture2D, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("texture %q not found on disk: %v", path, err)
+	}
+	defer f.Close()
+
+	t, err := Decode(f, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, nil
+}
+
+// LoadFS reads the image at path in fsys, such as an embed.FS, and uploads
+// it.
+func LoadFS(fsys fs.FS, path string, opts Options) (*Texture2D, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := Decode(f, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, nil
+}
+
+// Bind makes t the 2D texture of texture unit unit (0 for TEXTURE0).
+func (t *Texture2D) Bind(unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+}
+
+// SetFilter sets the minifying and magnifying filters.
+func (t *Texture2D) SetFilter(min, mag int32) {
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, min)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, mag)
+}
+
+// SetWrap sets how coordinates outside [0, 1] are treated.
+func (t *Texture2D) SetWrap(s, tWrap int32) {
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, s)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, tWrap)
+}
+
+// SetAnisotropy sets the maximum anisotropy, clamped to the driver's limit.
+// 1 turns anisotropic filtering off.
+func (t *Texture2D) SetAnisotropy(a float32) {
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	setAnisotropy(gl.TEXTURE_2D, a)
+}
+
+// GenerateMipmaps rebuilds the mipmaps from level 0, after it has been
+// changed.
+func (t *Texture2D) GenerateMipmaps() {
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+}
+
+// Delete frees the texture.
+func (t *Texture2D) Delete() {
+	gl.DeleteTextures(1, &t.ID)
+	t.ID = 0
+}
@@ -0,0 +1,71 @@
+package texture
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Binder is a texture that can be bound to a texture unit: Texture2D,
+// TextureCube and Texture2DArray.
+type Binder interface {
+	Bind(unit uint32)
+}
+
+// Units hands out texture units to the samplers of a program. The first
+// sampler asked for gets unit 0, the next unit 1 and so on; each keeps its
+// unit, and the program's sampler uniform is set to it once.
+type Units struct {
+	Program uint32
+
+	units map[string]uint32
+	max   uint32
+}
+
+// NewUnits returns an allocator for program's samplers. It needs a current
+// GL context.
+func NewUnits(program uint32) *Units {
+	var max int32
+	gl.GetIntegerv(gl.MAX_COMBINED_TEXTURE_IMAGE_UNITS, &max)
+	return &Units{Program: program, units: make(map[string]uint32), max: uint32(max)}
+}
+
+// Unit returns the texture unit of the named sampler uniform, allocating
+// one and pointing the sampler at it the first time.
+func (u *Units) Unit(sampler string) (uint32, error) {
+	if unit, ok := u.units[sampler]; ok {
+		return unit, nil
+	}
+
+	location := gl.GetUniformLocation(u.Program, gl.Str(sampler+"\x00"))
+	if location < 0 {
+		return 0, fmt.Errorf("texture: sampler %q is not in program %d", sampler, u.Program)
+	}
+	unit := uint32(len(u.units))
+	if unit >= u.max {
+		return 0, fmt.Errorf("texture: no texture unit left for sampler %q (there are %d)", sampler, u.max)
+	}
+
+	gl.ProgramUniform1i(u.Program, location, int32(unit))
+	u.units[sampler] = unit
+	return unit, nil
+}
+
+// Bind binds t to the unit of the named sampler.
+func (u *Units) Bind(sampler string, t Binder) error {
+	unit, err := u.Unit(sampler)
+	if err != nil {
+		return err
+	}
+	t.Bind(unit)
+	return nil
+}
+
+// Samplers returns the samplers given units so far, and their units.
+func (u *Units) Samplers() map[string]uint32 {
+	samplers := make(map[string]uint32, len(u.units))
+	for name, unit := range u.units {
+		samplers[name] = unit
+	}
+	return samplers
+}